@@ -0,0 +1,98 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("station approval", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agentScript := writeMockAgentScript(dir, "unique-agent.sh", `#!/bin/bash
+PROMPT="${@: -1}"
+NAME=$(echo "$PROMPT" | tail -1 | tr ' ' '-')
+echo "$PROMPT" > "$NAME.txt"
+`)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "review"
+    approval: manual
+  - name: docs
+    prompt: "docs"
+`)
+		writeFile(dir, ".gitignore", "/.line/\nunique-agent.sh\nline.yaml\n")
+		git(dir, "add", ".gitignore")
+		git(dir, "commit", "-m", "ignore line files")
+	})
+
+	It("holds a manual station's commit until line approve, then continues downstream [RUN-22, STAT-15]", func() {
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station review: awaiting approval (1 file changed"))
+		Expect(out).To(ContainSubstring("line approve review or line reject review"))
+
+		// The station branch does not move and the line stops
+		Expect(git(dir, "rev-parse", "line/stn/review")).To(Equal(git(dir, "rev-parse", "master")))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/docs"))
+
+		status := lineOK(dir, "status")
+		Expect(status).To(ContainSubstring("awaiting approval"))
+		Expect(status).To(ContainSubstring("line approve review / line reject review"))
+
+		// Later runs leave the held station alone
+		Expect(lineOK(dir, "run")).To(ContainSubstring("station review: awaiting approval, skipping"))
+
+		out = lineOK(dir, "approve", "review")
+		Expect(out).To(ContainSubstring("Approved review: 1 file changed"))
+		Expect(git(dir, "show", "line/stn/review:review.txt")).To(ContainSubstring("review"))
+		Expect(git(dir, "show", "line/stn/docs:review.txt")).To(ContainSubstring("review"))
+		Expect(git(dir, "show", "line/stn/docs:docs.txt")).To(ContainSubstring("docs"))
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("awaiting approval"))
+	})
+
+	It("discards the held commit on line reject [RUN-22]", func() {
+		lineOK(dir, "run")
+
+		out := lineOK(dir, "reject", "review")
+		Expect(out).To(ContainSubstring("Rejected review: discarded 1 file changed"))
+		_, err := gitMay(dir, "show", "line/stn/review:review.txt")
+		Expect(err).To(HaveOccurred())
+		_, err = gitMay(dir, "rev-parse", "--verify", "refs/line/approval/review")
+		Expect(err).To(HaveOccurred())
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/docs"))
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("awaiting approval"))
+	})
+
+	It("refuses stations that are not awaiting approval [RUN-22]", func() {
+		out, err := line(dir, "approve", "docs")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("station docs is not awaiting approval"))
+
+		_, err = line(dir, "reject", "nope")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects an unknown approval mode [VAL-1, CFG-STN-8]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "review"
+    approval: sometimes
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].approval: "sometimes" is not one of auto, manual`))
+	})
+})
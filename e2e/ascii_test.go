@@ -0,0 +1,62 @@
+package e2e_test
+
+import (
+	"fmt"
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var nonASCII = regexp.MustCompile(`[^\x00-\x7f]`)
+
+var _ = Describe("ASCII output", func() {
+	var dir string
+
+	config := `agent:
+  command: "true"
+settings:
+  watches: master
+%s
+stations:
+  - name: review
+  - name: docs
+    command: "false"
+`
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, fmt.Sprintf(config, ""))
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add config")
+		line(dir, "run")
+	})
+
+	// ASCII-1: --ascii replaces symbols in status, show, digest and statusline
+	It("prints only ASCII with --ascii [ASCII-1]", func() {
+		out := lineOK(dir, "status")
+		Expect(out).To(ContainSubstring("✗ docs"))
+
+		out = lineOK(dir, "--ascii", "status")
+		Expect(nonASCII.FindString(out)).To(BeEmpty(), out)
+		Expect(out).To(ContainSubstring("||"))
+		Expect(out).To(ContainSubstring("+ review"))
+		Expect(out).To(ContainSubstring("x docs"))
+
+		for _, args := range [][]string{{"show", "docs"}, {"digest"}, {"statusline"}, {"statusline", "--format", "prompt"}} {
+			out = lineOK(dir, append([]string{"--ascii"}, args...)...)
+			Expect(nonASCII.FindString(out)).To(BeEmpty(), out)
+		}
+		Expect(lineOK(dir, "--ascii", "statusline", "--compact", "--no-color")).To(Equal("|| master > review+ > docsx"))
+		Expect(lineOK(dir, "--ascii", "statusline", "--format", "prompt", "--no-color")).To(Equal("|| +x"))
+	})
+
+	// ASCII-1: settings.ascii does the same from the config
+	It("prints only ASCII when settings.ascii is set [ASCII-1]", func() {
+		writeConfig(dir, fmt.Sprintf(config, "  ascii: true\n  statusline:\n    max_width: 10"))
+		Expect(lineOK(dir, "statusline", "--no-color")).To(Equal("|| + re..."))
+		out := lineOK(dir, "status")
+		Expect(nonASCII.FindString(out)).To(BeEmpty(), out)
+	})
+})
@@ -0,0 +1,72 @@
+package e2e_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line attribution", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agent := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
+	})
+
+	It("needs the terminal branch to exist [ATTR-1]", func() {
+		out, err := line(dir, "attribution")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("line/stn/cleanup does not exist yet"))
+	})
+
+	It("attributes the terminal branch's lines to stations and humans [ATTR-1]", func() {
+		writeFile(dir, "code.go", "package main\n\nfunc main() {}\n")
+		git(dir, "add", "code.go")
+		git(dir, "commit", "-m", "add code")
+		lineOK(dir, "run")
+
+		var report struct {
+			Branch string
+			Files  []struct {
+				Path  string
+				Lines int
+				By    map[string]int
+			}
+			Total struct {
+				Lines int
+				By    map[string]int
+			}
+		}
+		Expect(json.Unmarshal([]byte(lineOK(dir, "attribution", "--json")), &report)).To(Succeed())
+		Expect(report.Branch).To(Equal("line/stn/cleanup"))
+
+		by := map[string]map[string]int{}
+		for _, f := range report.Files {
+			by[f.Path] = f.By
+		}
+		Expect(by["code.go"]).To(Equal(map[string]int{"human": 3}))
+		Expect(by["agent-output.txt"]).To(HaveKey("review"))
+		Expect(by["agent-output.txt"]).To(HaveKey("cleanup"))
+		Expect(by["agent-output.txt"]).NotTo(HaveKey("human"))
+
+		table := lineOK(dir, "attribution")
+		Expect(table).To(MatchRegexp(`File\s+Lines\s+human\s+review\s+cleanup`))
+		Expect(table).To(MatchRegexp(`code\.go\s+3\s+100%\s+-\s+-`))
+		Expect(table).To(ContainSubstring("Total"))
+	})
+})
@@ -103,6 +103,17 @@ stations:
 		Expect(out).To(BeEmpty())
 	})
 
+	It("exits silently when the pipeline is disabled [HOOK-3, RUN-17]", func() {
+		writeAutoRebaseConfig(dir, true)
+		setupStationBranches(dir)
+		writeFile(dir, ".line/disabled", "")
+		headBefore := git(dir, "rev-parse", "HEAD")
+
+		out := lineOK(dir, "auto-rebase-hook")
+		Expect(out).To(BeEmpty())
+		Expect(git(dir, "rev-parse", "HEAD")).To(Equal(headBefore))
+	})
+
 	It("exits silently when no changes to pick up [HOOK-3]", func() {
 		writeAutoRebaseConfig(dir, true)
 		// No station branches created — nothing to rebase
@@ -0,0 +1,81 @@
+package e2e_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line ci", func() {
+	var dir, remote, ciDir, summary string
+
+	// checkout clones the remote like a CI job does: detached at the
+	// pushed commit.
+	checkout := func() string {
+		git(dir, "push", "--quiet", "origin", "master")
+		git(dir, "clone", "--quiet", remote, ciDir)
+		git(ciDir, "config", "user.email", "ci@test.com")
+		git(ciDir, "config", "user.name", "CI")
+		git(ciDir, "checkout", "--quiet", "--detach")
+		return git(ciDir, "rev-parse", "HEAD")
+	}
+
+	withAgent := func(agent string) {
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", "line.yaml", "code.go")
+		git(dir, "commit", "-m", "add code")
+	}
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		tmp, err := os.MkdirTemp("", "line-ci-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(tmp) })
+		remote = filepath.Join(tmp, "remote.git")
+		ciDir = filepath.Join(tmp, "checkout")
+		summary = filepath.Join(tmp, "summary.md")
+		git(tmp, "init", "--quiet", "--bare", remote)
+		git(dir, "remote", "add", "origin", remote)
+	})
+
+	It("runs the stations for the CI commit on a detached HEAD and pushes their branches [CI-1]", func() {
+		withAgent(writeMockAgent(dir))
+		sha := checkout()
+
+		out, err := lineWithEnv(ciDir, []string{"GITHUB_SHA=" + sha, "GITHUB_STEP_SUMMARY=" + summary}, "ci", "--push")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("Pushed line/stn/review to origin"))
+
+		Expect(git(dir, "--git-dir", remote, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("Review code"))
+		Expect(git(dir, "--git-dir", remote, "rev-parse", "line/stn/review^")).To(Equal(sha))
+		Expect(readFile(filepath.Dir(summary), "summary.md")).To(ContainSubstring("| review | ok |"))
+		// No local runner machinery is left behind
+		Expect(fileExists(ciDir, ".line/run.pid")).To(BeFalse())
+	})
+
+	It("exits 2 when a station fails [CI-1]", func() {
+		withAgent(writeFailingMockAgent(dir))
+		sha := checkout()
+
+		out, err := lineWithEnv(ciDir, []string{"GITHUB_SHA=" + sha, "GITHUB_STEP_SUMMARY=" + summary}, "ci")
+		var exitErr *exec.ExitError
+		Expect(err).To(BeAssignableToTypeOf(exitErr))
+		Expect(err.(*exec.ExitError).ExitCode()).To(Equal(2))
+		Expect(out).To(ContainSubstring("station review failed (--fail-on station-failure)"))
+		Expect(readFile(filepath.Dir(summary), "summary.md")).To(ContainSubstring("| review | failed: agent failed"))
+	})
+})
@@ -0,0 +1,64 @@
+package e2e_test
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ansiColor = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+var _ = Describe("color output", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `agent:
+  command: sh
+  args: ["-c", "printf '\\033[32mlooks good\\033[0m\\n'"]
+settings:
+  watches: master
+stations:
+  - name: review
+  - name: docs
+    command: "false"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add config")
+	})
+
+	// COLOR-1: no colors where output is not a terminal
+	It("prints no colors into pipes unless CLICOLOR_FORCE is set [COLOR-1]", func() {
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("looks good"))
+		Expect(ansiColor.FindString(out)).To(BeEmpty(), out)
+
+		for _, args := range [][]string{{"status"}, {"show", "docs"}, {"digest"}} {
+			out = lineOK(dir, args...)
+			Expect(ansiColor.FindString(out)).To(BeEmpty(), out)
+		}
+		Expect(lineOK(dir, "status")).To(ContainSubstring("✗ docs"))
+
+		Expect(lineColorOK(dir, "status")).To(ContainSubstring("\033[31m  ✗ docs"))
+		Expect(lineColorOK(dir, "show", "docs")).To(ContainSubstring("\033[31m✗ failed"))
+		// The statusline is read through a pipe, so it keeps its colors.
+		Expect(ansiColor.FindString(lineOK(dir, "statusline"))).NotTo(BeEmpty())
+	})
+
+	// COLOR-1: --no-color and NO_COLOR win over CLICOLOR_FORCE
+	It("drops colors with --no-color or NO_COLOR [COLOR-1]", func() {
+		line(dir, "run")
+		out, err := lineWithEnv(dir, []string{"CLICOLOR_FORCE=1"}, "--no-color", "status")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("✗ docs"))
+		Expect(ansiColor.FindString(out)).To(BeEmpty(), out)
+
+		out, err = lineWithEnv(dir, []string{"CLICOLOR_FORCE=1", "NO_COLOR=1"}, "status")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ansiColor.FindString(out)).To(BeEmpty(), out)
+
+		Expect(ansiColor.FindString(lineOK(dir, "--no-color", "statusline"))).To(BeEmpty())
+	})
+})
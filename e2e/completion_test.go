@@ -0,0 +1,53 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("shell completion", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: refactor
+    prompt: "Refactor"
+  - name: docs
+    prompt: "Docs"
+`)
+	})
+
+	// CMP-1: Completion scripts for common shells
+	It("generates completion scripts for bash, zsh and fish [CMP-1]", func() {
+		for _, shell := range []string{"bash", "zsh", "fish"} {
+			out := lineOK(dir, "completion", shell)
+			Expect(out).To(ContainSubstring("line"), shell)
+		}
+	})
+
+	// CMP-2: Station arguments complete from the config
+	It("completes station names for line show [CMP-2]", func() {
+		out := lineOK(dir, "__complete", "show", "re")
+		Expect(out).To(ContainSubstring("review"))
+		Expect(out).To(ContainSubstring("refactor"))
+		Expect(out).NotTo(ContainSubstring("docs"))
+	})
+
+	// CMP-2: line resolve only offers conflicted stations
+	It("completes only conflicted stations for line resolve [CMP-2]", func() {
+		writeFile(dir, ".line/stations/docs.conflict", "README.md\n")
+
+		out := lineOK(dir, "__complete", "resolve", "")
+		Expect(out).To(ContainSubstring("docs"))
+		Expect(out).NotTo(ContainSubstring("review"))
+	})
+})
@@ -0,0 +1,84 @@
+package e2e_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line config", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `# The review line.
+agent:
+  command: echo # stand-in agent
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: release-notes
+    watches: "tag:v*"
+    prompt: "Write release notes"
+  - name: docs
+    prompt: "Update docs"
+`)
+	})
+
+	// CONFIG-1: line config view prints the effective config
+	It("prints the config with the global defaults merged in [CONFIG-1]", func() {
+		configHome, err := os.MkdirTemp("", "line-xdg-config-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(configHome) })
+		Expect(os.MkdirAll(filepath.Join(configHome, "line"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(configHome, "line", "config.yaml"), []byte("settings:\n  commit_author: \"Line Bot <line@example.com>\"\n"), 0o644)).To(Succeed())
+
+		out, err := lineWithEnv(dir, []string{"XDG_CONFIG_HOME=" + configHome}, "config", "view")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("  watches: master\n"))
+		Expect(out).To(ContainSubstring(`commit_author: Line Bot <line@example.com>`))
+		// Stations keep the config file's order, ref stations included.
+		Expect(out).To(MatchRegexp(`(?s)name: review.*name: release-notes\n[^-]*watches: tag:v\*.*name: docs`))
+	})
+
+	// CONFIG-2: line config set edits line.yaml in place
+	It("sets keys keeping comments and layout [CONFIG-2]", func() {
+		Expect(lineOK(dir, "config", "set", "settings.verify", "true")).To(Equal("set settings.verify in line.yaml"))
+		lineOK(dir, "config", "set", "stations.docs.prompt", "Update the docs")
+		lineOK(dir, "config", "set", "stations.0.approval", "manual")
+		lineOK(dir, "config", "set", "settings.worktree.copy", "[.env]")
+
+		content := readFile(dir, "line.yaml")
+		Expect(content).To(HavePrefix("# The review line.\n"))
+		Expect(content).To(ContainSubstring("command: echo # stand-in agent\n"))
+		Expect(content).To(ContainSubstring("  watches: master\n  verify: true\n  worktree:\n    copy: [.env]\n"))
+		Expect(content).To(ContainSubstring("  - name: review\n    prompt: \"Review code\"\n    approval: manual\n"))
+		Expect(content).To(ContainSubstring("  - name: docs\n    prompt: Update the docs\n"))
+		Expect(lineOK(dir, "validate")).To(Equal("valid"))
+	})
+
+	// CONFIG-2: unknown keys and invalid values are refused
+	It("refuses keys line does not know and invalid values [CONFIG-2]", func() {
+		before := readFile(dir, "line.yaml")
+
+		out, err := line(dir, "config", "set", "settings.poll_interval", "10s")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("field poll_interval not found"))
+
+		out, err = line(dir, "config", "set", "stations.review.approval", "maybe")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].approval: "maybe" is not one of auto, manual`))
+
+		out, err = line(dir, "config", "set", "stations.lint.prompt", "x")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations.lint.prompt: no entry named or numbered "lint"`))
+
+		Expect(readFile(dir, "line.yaml")).To(Equal(before))
+	})
+})
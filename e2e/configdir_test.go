@@ -0,0 +1,98 @@
+package e2e_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line.d config files", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		Expect(os.MkdirAll(filepath.Join(dir, "line.d"), 0o755)).To(Succeed())
+	})
+
+	It("merges the stations and gates of each file into one line [CFG-18, CFG-STN-17]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+gates:
+  - name: lint
+    run: "true"
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "line.d/docs.yaml", `stations:
+  - name: docs
+    prompt: "Write docs"
+    follows: tests
+`)
+		writeFile(dir, "line.d/tests.yaml", `gates:
+  - name: fmt
+    run: "true"
+stations:
+  - name: tests
+    prompt: "Write tests"
+    follows: review
+`)
+		Expect(lineOK(dir, "validate")).To(Equal("valid"))
+		Expect(lineOK(dir, "config", "view")).To(MatchRegexp(`(?s)gates:.*name: lint.*name: fmt`))
+		Expect(lineOK(dir, "status")).To(MatchRegexp(`(?s)review .*tests .*docs `))
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		Expect(lineOK(dir, "run")).To(MatchRegexp(`(?s)running station review.*running station tests.*running station docs`))
+		Expect(git(dir, "show", "line/stn/docs:agent-output.txt")).To(MatchRegexp(`(?s)Review code.*Write tests.*Write docs`))
+	})
+
+	It("rejects duplicate names across files and stations following each other in a cycle [CFG-18, CFG-STN-17]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+settings:
+  watches: master
+gates:
+  - name: lint
+    run: "true"
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "line.d/a.yaml", `gates:
+  - name: lint
+    run: "golangci-lint run"
+stations:
+  - name: review
+    prompt: "Review it again"
+  - name: docs
+    prompt: "Write docs"
+    follows: tests
+`)
+		writeFile(dir, "line.d/b.yaml", `stations:
+  - name: tests
+    prompt: "Write tests"
+    follows: docs
+  - name: release
+    prompt: "Release"
+    follows: deploy
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`line.d/a.yaml: stations[0].name: duplicate station name "review", also defined in stations[0]`))
+		Expect(out).To(ContainSubstring(`line.d/a.yaml: gates[0].name: duplicate gate name "lint", also defined in gates[0]`))
+		Expect(out).To(ContainSubstring(`line.d/a.yaml: stations[1].follows: stations follow each other in a cycle (docs -> tests -> docs)`))
+		Expect(out).NotTo(ContainSubstring(`line.d/b.yaml: stations[0].follows`))
+		Expect(out).To(ContainSubstring(`line.d/b.yaml: stations[1].follows: "deploy" is not a station of the line`))
+
+		writeFile(dir, "line.d/c.yaml", "settings:\n  verify: true\n")
+		out, err = line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("parsing line.d/c.yaml (only gates, push_gates and stations are allowed)"))
+	})
+})
@@ -0,0 +1,75 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line context", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `agent:
+  command: claude
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: |
+      Review the latest changes.
+
+      Fix any bugs you find.
+`)
+		gitCommit(dir, "add line config")
+		writeFile(dir, "main.go", "package main\n")
+		gitCommit(dir, "add main")
+	})
+
+	// CTX-1: Dumps the assembled context deterministically
+	It("prints the same context on every run [CTX-1]", func() {
+		first := lineOK(dir, "context", "review")
+		Expect(first).To(HavePrefix("Station:  review\nCommand:  claude -p <prompt>\nReviews:  1 commit"))
+		Expect(first).To(ContainSubstring("add main"))
+		Expect(first).NotTo(ContainSubstring("add line config"))
+		Expect(first).To(ContainSubstring("  IMPORTANT: Do NOT commit any changes."))
+		Expect(first).To(ContainSubstring("  Review the latest changes.\n\n  Fix any bugs you find."))
+		Expect(lineOK(dir, "context", "review")).To(Equal(first))
+	})
+
+	// CTX-1: --range and --out write a snapshot that changes with the prompt
+	It("writes the context for a range to a file [CTX-1]", func() {
+		lineOK(dir, "context", "review", "--range", "HEAD~2..HEAD", "--out", "review.ctx")
+		snapshot := readFile(dir, "review.ctx")
+		Expect(snapshot).To(ContainSubstring("Reviews:  2 commits"))
+		Expect(snapshot).To(ContainSubstring("add line config"))
+		Expect(snapshot).To(ContainSubstring("  Fix any bugs you find.\n"))
+
+		lineOK(dir, "context", "review", "--range", "HEAD~2..HEAD", "--out", "again.ctx")
+		Expect(readFile(dir, "again.ctx")).To(Equal(snapshot))
+
+		writeConfig(dir, `agent:
+  command: claude
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes."
+`)
+		lineOK(dir, "context", "review", "--range", "HEAD~2..HEAD", "--out", "changed.ctx")
+		Expect(readFile(dir, "changed.ctx")).NotTo(Equal(snapshot))
+	})
+
+	// CTX-1: Malformed ranges and unknown stations are rejected
+	It("rejects malformed ranges and unknown stations [CTX-1]", func() {
+		out, err := line(dir, "context", "review", "--range", "HEAD")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`--range: "HEAD" is not of the form <from>..<to>`))
+
+		out, err = line(dir, "context", "nope")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown station "nope"`))
+	})
+})
@@ -0,0 +1,66 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line digest", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agent := writeMockAgent(dir)
+		failing := writeFailingMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    command: `+failing+`
+    prompt: "Clean up code"
+`)
+		for _, msg := range []string{"first", "second"} {
+			writeFile(dir, msg+".go", "package main\n")
+			git(dir, "add", msg+".go")
+			git(dir, "commit", "-m", msg)
+			lineOK(dir, "run")
+		}
+	})
+
+	It("summarises commits reviewed, changes and failures per station [DIG-1]", func() {
+		out := lineOK(dir, "digest")
+		Expect(out).To(ContainSubstring("Last 24h:"))
+		Expect(out).To(MatchRegexp(`review\s+2\s+2 commits \(\+\d+ -0 in 1 file\)\s+0`))
+		Expect(out).To(MatchRegexp(`cleanup\s+2\s+no changes\s+2`))
+		Expect(out).To(ContainSubstring("Failures:"))
+		Expect(out).To(ContainSubstring("cleanup"))
+		Expect(out).To(ContainSubstring("agent failed"))
+	})
+
+	It("prints Markdown for pasting into chat [DIG-1]", func() {
+		out := lineOK(dir, "digest", "--format", "markdown")
+		Expect(out).To(ContainSubstring("**line digest**, last 24h"))
+		Expect(out).To(MatchRegexp(`- \*\*review\*\*: 2 commits reviewed, 2 commits \(\+\d+ -0 in 1 file\), 0 failures`))
+		Expect(out).To(ContainSubstring("- **cleanup**: 2 commits reviewed, no changes, 2 failures"))
+		Expect(out).To(ContainSubstring("`agent failed"))
+	})
+
+	It("only counts runs and commits inside the --since window [DIG-1]", func() {
+		out := lineOK(dir, "digest", "--since", "0s")
+		Expect(out).To(MatchRegexp(`review\s+0\s+no changes\s+0`))
+		Expect(out).NotTo(ContainSubstring("Failures:"))
+
+		Expect(lineOK(dir, "digest", "--since", "2000-01-01")).To(ContainSubstring("Since 2000-01-01:"))
+
+		out, err := line(dir, "digest", "--since", "yesterday")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`--since: "yesterday" is neither a duration`))
+	})
+})
@@ -0,0 +1,174 @@
+package e2e_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// smtpServer is a minimal SMTP server that records the messages it
+// receives.
+type smtpServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	msgs []string
+}
+
+func startSMTPServer() *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	s := &smtpServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	DeferCleanup(ln.Close)
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 localhost ready")
+	for {
+		cmd, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch verb := strings.ToUpper(strings.Fields(cmd + " x")[0]); verb {
+		case "EHLO", "HELO", "MAIL", "RCPT":
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				msg.WriteString(line)
+			}
+			s.mu.Lock()
+			s.msgs = append(s.msgs, msg.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+var _ = Describe("email notifications", func() {
+	var (
+		dir    string
+		server *smtpServer
+	)
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		server = startSMTPServer()
+	})
+
+	emailConfig := func(agent, extra string) string {
+		return `agent:
+  command: ` + agent + `
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+` + extra + `
+
+notifications:
+  email:
+    smtp: "${SMTP_ADDR}"
+    from: "Line Bot <line@example.com>"
+    to: ["team@example.com"]
+    failures: 2
+`
+	}
+
+	run := func(msg string) {
+		writeFile(dir, msg+".go", "package main\n")
+		git(dir, "add", msg+".go")
+		git(dir, "commit", "-m", msg)
+		out, err := lineWithEnv(dir, []string{"SMTP_ADDR=" + server.ln.Addr().String()}, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).NotTo(ContainSubstring("notification failed"))
+	}
+
+	It("mails the diffstat when a run leaves changes on the terminal branch [NOTIFY-2]", func() {
+		writeConfig(dir, emailConfig(writeMockAgent(dir), ""))
+		run("first")
+
+		msgs := server.messages()
+		Expect(msgs).To(HaveLen(1))
+		Expect(msgs[0]).To(ContainSubstring("From: \"Line Bot\" <line@example.com>"))
+		Expect(msgs[0]).To(ContainSubstring("To: team@example.com"))
+		Expect(msgs[0]).To(MatchRegexp(`Subject: line: changes ready for master \([0-9a-f]{7}\)`))
+		Expect(msgs[0]).To(ContainSubstring("first with changes on line/stn/review"))
+		Expect(msgs[0]).To(ContainSubstring("agent-output.txt |"))
+		Expect(msgs[0]).To(ContainSubstring("line rebase"))
+	})
+
+	It("mails a failing station once it has failed the configured number of times, with its log [NOTIFY-2]", func() {
+		// verify output is written to the station log before the station
+		// finishes, unlike agent output streamed through tmux.
+		writeConfig(dir, emailConfig(writeMockAgent(dir), `    verify: "echo tests are broken; exit 1"`))
+		run("first")
+		Expect(server.messages()).To(BeEmpty())
+
+		run("second")
+		msgs := server.messages()
+		Expect(msgs).To(HaveLen(1))
+		Expect(msgs[0]).To(ContainSubstring("Subject: line: station review failed 2 times in a row"))
+		Expect(msgs[0]).To(ContainSubstring("exit status 1"))
+		Expect(msgs[0]).To(MatchRegexp(`Its log ends with:[\s\S]*tests are broken`))
+	})
+
+	It("validates the email settings [NOTIFY-2]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+
+notifications:
+  email:
+    smtp: "smtp.example.com"
+    from: "not an address"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`notifications.email.smtp: "smtp.example.com" must be host:port`))
+		Expect(out).To(ContainSubstring(`notifications.email.from: "not an address" is not an email address`))
+		Expect(out).To(ContainSubstring("notifications.email.to: required field is empty"))
+	})
+})
@@ -0,0 +1,71 @@
+package e2e_test
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line run --fail-on", func() {
+	var dir string
+
+	exitCode := func(err error) int {
+		var exitErr *exec.ExitError
+		ExpectWithOffset(1, err).To(BeAssignableToTypeOf(exitErr))
+		return err.(*exec.ExitError).ExitCode()
+	}
+
+	configWith := func(agent string) {
+		writeConfig(dir, `agent:
+  command: `+agent+`
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+	}
+
+	BeforeEach(func() {
+		dir = tempRepo()
+	})
+
+	It("exits 0 by default when a station fails [RUN-28]", func() {
+		configWith(writeFailingMockAgent(dir))
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station review failed"))
+	})
+
+	It("exits 2 when a station fails with --fail-on station-failure [RUN-28]", func() {
+		configWith(writeFailingMockAgent(dir))
+		out, err := line(dir, "run", "--fail-on", "station-failure")
+		Expect(exitCode(err)).To(Equal(2))
+		Expect(out).To(ContainSubstring("station review failed (--fail-on station-failure)"))
+		Expect(out).NotTo(ContainSubstring("Usage:"))
+	})
+
+	It("exits 0 with --fail-on station-failure when every station succeeds [RUN-28]", func() {
+		configWith(writeMockAgent(dir))
+		lineOK(dir, "run", "--fail-on", "station-failure")
+	})
+
+	It("exits 3 for a skipped run only with --fail-on skip [RUN-28]", func() {
+		configWith(writeMockAgent(dir))
+		git(dir, "commit", "--allow-empty", "-m", "nothing to see [skip ci]")
+
+		lineOK(dir, "run", "--fail-on", "station-failure")
+		out, err := line(dir, "run", "--fail-on", "skip")
+		Expect(exitCode(err)).To(Equal(3))
+		Expect(out).To(ContainSubstring("run skipped (--fail-on skip)"))
+	})
+
+	It("rejects an unknown --fail-on value [RUN-28]", func() {
+		configWith(writeMockAgent(dir))
+		out, err := line(dir, "run", "--fail-on", "always")
+		Expect(exitCode(err)).To(Equal(1))
+		Expect(out).To(ContainSubstring(`--fail-on: "always" is not one of station-failure, skip, none`))
+	})
+})
@@ -1,6 +1,11 @@
 package e2e_test
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -55,4 +60,233 @@ gates:
 		Expect(log).To(Equal("pass\n"))
 		Expect(log).NotTo(ContainSubstring("never"))
 	})
+	It("shows gate output only on failure and reports the last gate run [CFG-GATE-3]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+gates:
+  - name: noisy
+    run: "echo all good"
+  - name: broken
+    run: "echo 'main.go:3: undefined: x' >&2; exit 3"
+  - name: never
+    run: "echo never"
+`)
+		out, err := line(dir, "gate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).NotTo(ContainSubstring("all good"))
+		Expect(out).To(ContainSubstring("main.go:3: undefined: x"))
+		Expect(out).NotTo(ContainSubstring("never"))
+
+		var report struct {
+			Hook   string `json:"hook"`
+			OK     bool   `json:"ok"`
+			Failed string `json:"failed"`
+			Gates  []struct {
+				Name     string `json:"name"`
+				OK       bool   `json:"ok"`
+				ExitCode int    `json:"exit_code"`
+				Output   string `json:"output"`
+			} `json:"gates"`
+		}
+		Expect(json.Unmarshal([]byte(readFile(dir, ".line/gate-last.json")), &report)).To(Succeed())
+		Expect(report.Hook).To(Equal("pre-commit"))
+		Expect(report.OK).To(BeFalse())
+		Expect(report.Failed).To(Equal("broken"))
+		Expect(report.Gates).To(HaveLen(2))
+		Expect(report.Gates[0].OK).To(BeTrue())
+		Expect(report.Gates[0].Output).To(Equal("all good\n"))
+		Expect(report.Gates[1].ExitCode).To(Equal(3))
+		Expect(report.Gates[1].Output).To(Equal("main.go:3: undefined: x\n"))
+
+		// --verbose shows the output of passing gates too.
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+gates:
+  - name: noisy
+    run: "echo all good"
+`)
+		Expect(lineOK(dir, "gate", "--verbose")).To(ContainSubstring("all good"))
+		Expect(readFile(dir, ".line/gate-last.json")).To(ContainSubstring(`"ok": true`))
+	})
+
+	It("runs commit-msg gates on the commit message [CFG-GATE-4]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+gates:
+  - name: lint
+    run: "echo lint >> gate-log.txt"
+  - name: commitlint
+    hook: commit-msg
+    run: "grep -qE '^(feat|fix|chore)(\\(.+\\))?: ' {msg_file} && test \"$LINE_MSG_FILE\" = .git/COMMIT_EDITMSG"
+`)
+		writeFile(dir, ".gitignore", "/.line/\ngate-log.txt\n")
+		installHooksForTest(dir)
+
+		writeFile(dir, "a.txt", "a\n")
+		git(dir, "add", ".")
+		out, err := gitMay(dir, "commit", "-m", "add a")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`gates failed: gate "commitlint" failed`))
+		Expect(readFile(dir, ".line/gate-last.json")).To(ContainSubstring(`"hook": "commit-msg"`))
+
+		git(dir, "commit", "-m", "chore: add a")
+		Expect(git(dir, "log", "-1", "--format=%s")).To(Equal("chore: add a"))
+		// The pre-commit gates ran for both attempts; commit-msg gates only from their hook.
+		Expect(readFile(dir, "gate-log.txt")).To(Equal("lint\nlint\n"))
+	})
+
+	It("runs the gates against the staged files with gate_staged [CFG-GATE-5]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  gate_staged: true
+
+gates:
+  - name: no-todo
+    run: "! grep -q TODO code.txt other.txt"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		installHooksForTest(dir)
+		writeFile(dir, "code.txt", "a\n")
+		writeFile(dir, "other.txt", "x\n")
+		gitCommit(dir, "add code")
+
+		// The unstaged TODO is not part of the commit.
+		writeFile(dir, "code.txt", "a\nb\n")
+		git(dir, "add", "code.txt")
+		writeFile(dir, "code.txt", "a\nb\nTODO\n")
+		out := git(dir, "commit", "-m", "add b")
+		Expect(out).To(ContainSubstring("checking the staged files only"))
+		Expect(git(dir, "show", "HEAD:code.txt")).To(Equal("a\nb"))
+
+		// Nor does an unstaged fix make a staged TODO pass.
+		git(dir, "add", "code.txt")
+		writeFile(dir, "code.txt", "a\nb\nc\n")
+		out, err := gitMay(dir, "commit", "-m", "add TODO")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`gates failed: gate "no-todo" failed`))
+
+		// git commit <paths> commits those paths from a temporary index,
+		// leaving out what else is staged.
+		git(dir, "reset", "-q")
+		writeFile(dir, "other.txt", "TODO\n")
+		git(dir, "add", "other.txt")
+		git(dir, "commit", "-m", "add c", "code.txt")
+		Expect(git(dir, "show", "HEAD:code.txt")).To(Equal("a\nb\nc"))
+		Expect(git(dir, "worktree", "list")).NotTo(ContainSubstring("staged-"))
+	})
+
+	It("exports the gates as a pre-commit or lefthook config [CFG-GATE-6]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+gates:
+  - name: lint
+    run: "echo it's fine"
+  - name: commitlint
+    hook: commit-msg
+    run: "npx commitlint --edit {msg_file}"
+
+push_gates:
+  - name: test
+    run: "go test ./..."
+`)
+		out := lineOK(dir, "gate", "export", "--format", "pre-commit")
+		Expect(out).To(ContainSubstring("default_install_hook_types: [pre-commit, commit-msg, pre-push]"))
+		Expect(out).To(ContainSubstring("fail_fast: true"))
+		Expect(out).To(ContainSubstring(`entry: sh -c 'echo it'\''s fine' --`))
+		Expect(out).To(ContainSubstring(`entry: sh -c 'npx commitlint --edit "$1"' --`))
+		Expect(out).To(ContainSubstring("stages: [pre-push]"))
+
+		out = lineOK(dir, "gate", "export", "--format", "lefthook")
+		Expect(out).To(ContainSubstring("commit-msg:\n  piped: true\n  commands:\n    commitlint:\n      priority: 1\n      run: npx commitlint --edit {1}\n"))
+		Expect(out).To(ContainSubstring("pre-push:\n  piped: true\n  commands:\n    test:\n"))
+
+		out, err := line(dir, "gate", "export", "--format", "husky")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`--format: "husky" is not one of pre-commit, lefthook`))
+	})
+
+	It("rejects unknown gate hooks [CFG-GATE-4]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+gates:
+  - name: lint
+    hook: post-commit
+    run: "true"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`gates[0].hook: "post-commit" is not one of pre-commit, commit-msg`))
+	})
+
+	It("runs the push gates against the commits being pushed [CFG-GATE-2]", func() {
+		remote, err := os.MkdirTemp("", "line-remote-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(remote) })
+		git(remote, "init", "--bare")
+		git(dir, "remote", "add", "origin", remote)
+		pushLog := filepath.Join(remote, "push-log.txt")
+
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+push_gates:
+  - name: test
+    run: 'echo "$LINE_PUSH_REMOTE $LINE_PUSH_REF from=$LINE_PUSH_FROM to=$LINE_PUSH_TO" >> `+pushLog+` && test ! -f broken.txt'
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add config")
+		lineOK(dir, "init", "--pre-push")
+		Expect(readFile(dir, ".git/hooks/pre-push")).To(ContainSubstring(`line gate --hook pre-push "$@"`))
+		patchHook(dir, "pre-push", "line gate", binaryPath+" gate")
+		patchHook(dir, "pre-commit", "line gate", binaryPath+" gate")
+		patchHook(dir, "commit-msg", "line gate", binaryPath+" gate")
+
+		master := strings.TrimSpace(git(dir, "rev-parse", "HEAD"))
+		git(dir, "push", "origin", "master")
+		Expect(readFile(remote, "push-log.txt")).To(Equal("origin refs/heads/master from= to=" + master + "\n"))
+
+		// A branch that is not checked out is checked in its own worktree.
+		git(dir, "checkout", "-b", "feature")
+		writeFile(dir, "broken.txt", "oops\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "break it")
+		feature := strings.TrimSpace(git(dir, "rev-parse", "HEAD"))
+		git(dir, "checkout", "master")
+		out, err := gitMay(dir, "push", "origin", "feature")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`push gates failed: refs/heads/feature: gate "test" failed`))
+		Expect(readFile(remote, "push-log.txt")).To(ContainSubstring("refs/heads/feature from= to=" + feature))
+		Expect(git(remote, "branch")).NotTo(ContainSubstring("feature"))
+
+		// line remove takes the hook out again.
+		lineOK(dir, "remove")
+		Expect(readFile(dir, ".git/hooks/pre-push")).NotTo(ContainSubstring("line gate"))
+	})
 })
@@ -0,0 +1,95 @@
+package e2e_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("global config", func() {
+	var dir, configHome string
+	var env []string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		var err error
+		configHome, err = os.MkdirTemp("", "line-xdg-config-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(configHome) })
+		env = []string{"XDG_CONFIG_HOME=" + configHome}
+	})
+
+	writeGlobalConfig := func(content string) {
+		Expect(os.MkdirAll(filepath.Join(configHome, "line"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(configHome, "line", "config.yaml"), []byte(content), 0o644)).To(Succeed())
+	}
+
+	It("uses the global agent when line.yaml does not set one [CFG-9]", func() {
+		agentScript := writeMockAgent(dir)
+		writeGlobalConfig(`agent:
+  command: ` + agentScript + `
+  args: ["-p"]
+`)
+		writeConfig(dir, `settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+
+		_, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred(), "without the global config there is no agent")
+
+		out, err := lineWithEnv(dir, env, "validate")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(Equal("valid"))
+
+		out, err = lineWithEnv(dir, env, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("Review code"))
+	})
+
+	It("lets line.yaml override global settings key by key [CFG-9]", func() {
+		writeGlobalConfig(`agent:
+  command: global-agent
+  args: ["--global"]
+settings:
+  watches: main
+  on_conflict: bogus
+`)
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+
+		out, err := lineWithEnv(dir, env, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.on_conflict: "bogus"`))
+
+		out, err = lineWithEnv(dir, env, "show", "review")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("master"))
+	})
+
+	It("rejects gates and stations in the global config [CFG-9]", func() {
+		writeGlobalConfig(`stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeDefaultConfig(dir)
+
+		out, err := lineWithEnv(dir, env, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(filepath.Join(configHome, "line", "config.yaml")))
+		Expect(out).To(ContainSubstring("stations"))
+	})
+})
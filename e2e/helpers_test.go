@@ -49,13 +49,48 @@ func git(dir string, args ...string) string {
 
 // line runs the line binary in the given directory and returns stdout.
 func line(dir string, args ...string) (string, error) {
+	return lineWithEnv(dir, nil, args...)
+}
+
+// lineWithEnv is like line but appends extra KEY=value environment entries.
+func lineWithEnv(dir string, env []string, args ...string) (string, error) {
 	cmd := exec.Command(binaryPath, args...)
 	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	// COLUMNS would make line statusline truncate to the developer's terminal
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "COLUMNS="), env...)
 	out, err := cmd.CombinedOutput()
 	return strings.TrimSpace(string(out)), err
 }
 
+// withoutTmux returns an environment entry for lineWithEnv with a PATH on
+// which tmux is not found, so agents run directly and their output reaches
+// line's own output as it does on machines without tmux.
+func withoutTmux() []string {
+	tmuxPath, err := exec.LookPath("tmux")
+	if err != nil {
+		return nil
+	}
+	tmuxDir := filepath.Dir(tmuxPath)
+	shadow := GinkgoT().TempDir()
+	entries, err := os.ReadDir(tmuxDir)
+	Expect(err).NotTo(HaveOccurred())
+	for _, e := range entries {
+		if e.Name() != "tmux" {
+			Expect(os.Symlink(filepath.Join(tmuxDir, e.Name()), filepath.Join(shadow, e.Name()))).To(Succeed())
+		}
+	}
+	var dirs []string
+	for _, d := range filepath.SplitList(os.Getenv("PATH")) {
+		if d == tmuxDir {
+			d = shadow
+		} else if _, err := os.Stat(filepath.Join(d, "tmux")); err == nil {
+			continue
+		}
+		dirs = append(dirs, d)
+	}
+	return []string{"PATH=" + strings.Join(dirs, string(os.PathListSeparator))}
+}
+
 // lineOK runs the line binary and expects success.
 func lineOK(dir string, args ...string) string {
 	out, err := line(dir, args...)
@@ -63,6 +98,14 @@ func lineOK(dir string, args ...string) string {
 	return out
 }
 
+// lineColorOK is lineOK with colors forced on (CLICOLOR_FORCE), as if
+// line were writing to a terminal.
+func lineColorOK(dir string, args ...string) string {
+	out, err := lineWithEnv(dir, []string{"CLICOLOR_FORCE=1"}, args...)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred(), "line %s failed: %s", strings.Join(args, " "), out)
+	return out
+}
+
 // writeFile creates a file with the given content, creating parent dirs as needed.
 func writeFile(dir, name, content string) {
 	p := filepath.Join(dir, name)
@@ -165,6 +208,7 @@ func installHooksForTest(dir string) {
 	lineOK(dir, "init")
 	patchHook(dir, "post-commit", "line run &", binaryPath+" run")
 	patchHook(dir, "pre-commit", "line gate", binaryPath+" gate")
+	patchHook(dir, "commit-msg", "line gate", binaryPath+" gate")
 }
 
 // installHooksForTestBg is like installHooksForTest but keeps the post-commit
@@ -180,6 +224,7 @@ func installHooksForTestBg(dir string) {
 	logFile := filepath.Join(logDir, "run.log")
 	patchHook(dir, "post-commit", "line run &", binaryPath+" run >"+logFile+" 2>&1 &")
 	patchHook(dir, "pre-commit", "line gate", binaryPath+" gate")
+	patchHook(dir, "commit-msg", "line gate", binaryPath+" gate")
 }
 
 // patchHook replaces old with new in the named hook file.
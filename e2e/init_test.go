@@ -33,6 +33,74 @@ var _ = Describe("line init", func() {
 		Expect(postCommit).To(ContainSubstring("# <<< assembly-line <<<"))
 	})
 
+	// INIT-9: post-merge and post-rewrite hooks catch commits that arrive
+	// without firing post-commit
+	It("installs post-merge and post-rewrite hooks invoking line run [INIT-9]", func() {
+		lineOK(dir, "init")
+
+		postMerge := readFile(dir, ".git/hooks/post-merge")
+		Expect(postMerge).To(HavePrefix("#!/bin/sh"))
+		Expect(postMerge).To(ContainSubstring("line run"))
+		Expect(postMerge).To(ContainSubstring("# >>> assembly-line >>>"))
+
+		postRewrite := readFile(dir, ".git/hooks/post-rewrite")
+		Expect(postRewrite).To(ContainSubstring(`if [ "$1" = rebase ]`))
+		Expect(postRewrite).To(ContainSubstring("line run"))
+
+		refTx := readFile(dir, ".git/hooks/reference-transaction")
+		Expect(refTx).To(ContainSubstring(`if [ "$1" = committed ]`))
+		Expect(refTx).To(ContainSubstring("line run --refs"))
+	})
+
+	It("installs a commit-msg hook running the commit-msg gates [INIT-12]", func() {
+		lineOK(dir, "init")
+		commitMsg := readFile(dir, ".git/hooks/commit-msg")
+		Expect(commitMsg).To(HavePrefix("#!/bin/sh"))
+		Expect(commitMsg).To(ContainSubstring(`line gate --hook commit-msg "$1"`))
+	})
+
+	It("runs the line for commits arriving by merge or rebase [INIT-9]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\nmock-agent.sh\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add config")
+
+		git(dir, "checkout", "-b", "feature")
+		writeFile(dir, "feature.txt", "feature\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "feature work")
+		git(dir, "checkout", "master")
+
+		installHooksForTest(dir)
+		patchHook(dir, "post-merge", "line run &", binaryPath+" run")
+		patchHook(dir, "post-rewrite", "line run &", binaryPath+" run")
+
+		// A fast-forward merge fires post-merge, not post-commit
+		git(dir, "merge", "--ff-only", "feature")
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("Review code"))
+		git(dir, "branch", "-D", "line/stn/review")
+
+		// A rebase fires post-rewrite
+		git(dir, "checkout", "-b", "upstream", "HEAD~1")
+		writeFile(dir, "upstream.txt", "upstream\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "upstream work [skip line]")
+		git(dir, "checkout", "master")
+		git(dir, "rebase", "upstream")
+		Expect(git(dir, "show", "line/stn/review:upstream.txt")).To(Equal("upstream"))
+	})
+
 	// INIT-2: Preserves any existing Git pre-commit hooks
 	It("preserves existing hook content [INIT-2]", func() {
 		hooksDir := filepath.Join(dir, ".git", "hooks")
@@ -133,4 +201,37 @@ var _ = Describe("line init", func() {
 		Expect(skillFile).To(ContainSubstring("Procedure"))
 		Expect(skillFile).To(ContainSubstring("line rebase"))
 	})
+
+	// INIT-10: --preset writes line.yaml from a built-in preset
+	It("writes line.yaml from a preset [INIT-10]", func() {
+		for _, name := range []string{"go-backend", "ts-frontend"} {
+			os.Remove(filepath.Join(dir, "line.yaml"))
+			out := lineOK(dir, "init", "--preset", name)
+			Expect(out).To(ContainSubstring("wrote line.yaml from the " + name + " preset (watching master)"))
+			Expect(out).To(ContainSubstring("assembly-line initialized"))
+
+			config := readFile(dir, "line.yaml")
+			Expect(config).To(ContainSubstring("watches: master"))
+			for _, station := range []string{"security", "tests", "docs", "changelog", "dependencies"} {
+				Expect(config).To(ContainSubstring("- name: " + station))
+			}
+			Expect(lineOK(dir, "validate")).To(ContainSubstring("valid"))
+			Expect(lineOK(dir, "lint-prompts")).To(Equal("no problems found"))
+		}
+	})
+
+	// INIT-10: Existing config is never overwritten; unknown presets are named
+	It("refuses to overwrite line.yaml or use an unknown preset [INIT-10]", func() {
+		writeFile(dir, "line.yaml", "# mine\n")
+		out, err := line(dir, "init", "--preset", "go-backend")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("line.yaml already exists; remove it to start from the go-backend preset"))
+		Expect(readFile(dir, "line.yaml")).To(Equal("# mine\n"))
+		Expect(fileExists(dir, ".git/hooks/post-commit")).To(BeFalse())
+
+		os.Remove(filepath.Join(dir, "line.yaml"))
+		out, err = line(dir, "init", "--preset", "rust")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown preset "rust" (available: go-backend, ts-frontend)`))
+	})
 })
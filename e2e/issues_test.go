@@ -0,0 +1,175 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("issue context providers", func() {
+	var (
+		dir    string
+		server *httptest.Server
+		auth   []string
+	)
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		auth = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = append(auth, r.Header.Get("Authorization"))
+			var issue any
+			switch r.URL.Path {
+			case "/rest/api/2/issue/PROJ-12":
+				issue = map[string]any{"fields": map[string]any{"summary": "Export invoices as CSV", "description": "Finance needs a CSV export."}}
+			case "/repos/acme/shop/issues/34":
+				issue = map[string]any{"title": "Totals are off by one cent", "body": "Rounding happens per line."}
+			default:
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(issue)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	env := func() []string {
+		return []string{"JIRA_URL=" + server.URL, "JIRA_API_TOKEN=secret", "GITHUB_API_URL=" + server.URL, "GITHUB_TOKEN=gh-token"}
+	}
+
+	// RUN-31: Issues referenced in the reviewed commits are appended to the prompt
+	It("appends referenced Jira and GitHub issues to the context [RUN-31]", func() {
+		git(dir, "remote", "add", "origin", "git@github.com:acme/shop.git")
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes."
+    context: ["jira:PROJ", "github-issues"]
+`)
+		writeFile(dir, "invoice.go", "package main\n")
+		gitCommit(dir, "PROJ-12: export invoices (fixes #34, see #99)")
+
+		out, err := lineWithEnv(dir, env(), "context", "review")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("  Review the latest changes.\n\n  Issues referenced by the commits under review:"))
+		Expect(out).To(ContainSubstring("  ## PROJ-12: Export invoices as CSV\n\n  Finance needs a CSV export."))
+		Expect(out).To(ContainSubstring("  ## #34: Totals are off by one cent\n\n  Rounding happens per line."))
+		Expect(out).To(ContainSubstring("warning: context: github acme/shop#99: tracker returned 404 Not Found"))
+		Expect(auth).To(ContainElements("Bearer secret", "Bearer gh-token"))
+	})
+
+	// RUN-31: Station runs give the agent the issues too
+	It("passes the issues to the agent when the station runs [RUN-31]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes."
+    context: ["github-issues:acme/shop"]
+`)
+		gitCommit(dir, "add line config")
+		writeFile(dir, "total.go", "package main\n")
+		gitCommit(dir, "Fix rounding (#34)")
+
+		out, err := lineWithEnv(dir, env(), "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		output := git(dir, "show", "line/stn/review:agent-output.txt")
+		Expect(output).To(ContainSubstring("## #34: Totals are off by one cent"))
+	})
+
+	// CFG-STN-11: Unknown providers are rejected by validate
+	It("rejects unknown providers [CFG-STN-11]", func() {
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review"
+    context: ["jira:proj", "linear", "github-issues:acme", "beads:../issues.jsonl"]
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].context[0]: "jira:proj": jira needs a project key, e.g. jira:PROJ`))
+		Expect(out).To(ContainSubstring(`stations[0].context[1]: "linear" is not one of jira:<PROJECT>, github-issues[:<owner>/<repo>], beads[:<path>]`))
+		Expect(out).To(ContainSubstring(`stations[0].context[2]: "github-issues:acme": repository must be <owner>/<repo>`))
+		Expect(out).To(ContainSubstring(`stations[0].context[3]: "beads:../issues.jsonl": "../issues.jsonl" must be a relative path inside the repository`))
+		Expect(strings.Count(out, "context[")).To(Equal(4))
+	})
+
+	// RUN-32: Open beads items that mention the changed files are added to the prompt
+	It("adds open beads items that mention the changed files [RUN-32]", func() {
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes."
+    context: ["beads"]
+`)
+		writeFile(dir, ".beads/issues.jsonl", `{"id":"bd-1","title":"Invoice totals","description":"Rounding in invoice.go is wrong.","status":"open"}
+{"id":"bd-2","title":"Cart cleanup","description":"Tidy cart.go.","status":"open"}
+{"id":"bd-3","title":"Old invoice bug","description":"invoice.go crashed.","status":"closed"}
+`)
+		gitCommit(dir, "add line config and beads")
+		writeFile(dir, "billing/invoice.go", "package billing\n")
+		gitCommit(dir, "add invoices")
+
+		out := lineOK(dir, "context", "review")
+		Expect(out).To(ContainSubstring("  Open items in .beads/issues.jsonl that mention the changed files:\n\n  ## bd-1: Invoice totals\n\n  Rounding in invoice.go is wrong."))
+		Expect(out).To(ContainSubstring("write .line-result.json (do not edit .beads/issues.jsonl yourself)"))
+		Expect(out).NotTo(ContainSubstring("bd-2"))
+		Expect(out).NotTo(ContainSubstring("bd-3"))
+	})
+
+	// RUN-32: The agent's result closes and annotates items in the station's commit
+	It("closes and annotates items as the agent's result says [RUN-32]", func() {
+		agent := writeMockAgentScript(dir, "beads-agent.sh", `#!/bin/bash
+echo "${@: -1}" > agent-output.txt
+echo '{"close": [{"id": "bd-1", "reason": "Rounded per invoice"}], "annotate": [{"id": "bd-2", "note": "Seen while fixing bd-1"}, {"id": "bd-9", "note": "?"}]}' > .line-result.json
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes."
+    context: ["beads"]
+`)
+		writeFile(dir, ".beads/issues.jsonl", `{"id":"bd-1","title":"Invoice totals","description":"Rounding in invoice.go is wrong.","status":"open"}
+{"id":"bd-2","title":"Cart cleanup","description":"Tidy cart.go.","status":"open"}
+`)
+		gitCommit(dir, "add line config and beads")
+		writeFile(dir, "invoice.go", "package main\n")
+		gitCommit(dir, "add invoices")
+
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("no items bd-9 in .beads/issues.jsonl"))
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("## bd-1: Invoice totals"))
+
+		store := strings.Split(git(dir, "show", "line/stn/review:.beads/issues.jsonl"), "\n")
+		Expect(store).To(HaveLen(2))
+		var closed, annotated map[string]any
+		Expect(json.Unmarshal([]byte(store[0]), &closed)).To(Succeed())
+		Expect(json.Unmarshal([]byte(store[1]), &annotated)).To(Succeed())
+		Expect(closed).To(HaveKeyWithValue("status", "closed"))
+		Expect(closed).To(HaveKeyWithValue("close_reason", "Rounded per invoice"))
+		Expect(closed).To(HaveKey("closed_at"))
+		Expect(annotated).To(HaveKeyWithValue("status", "open"))
+		Expect(annotated).To(HaveKeyWithValue("notes", "Seen while fixing bd-1"))
+
+		_, err := gitMay(dir, "show", "line/stn/review:.line-result.json")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,52 @@
+package e2e_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line lint-prompts", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+	})
+
+	// LINT-1: Clean prompts pass
+	It("reports no problems for clean prompts [LINT-1]", func() {
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes and fix the TODO comments. Do not commit or push your changes."
+`)
+		Expect(lineOK(dir, "lint-prompts")).To(Equal("no problems found"))
+	})
+
+	// LINT-1: Conflicting directives, excessive length and placeholders are reported
+	It("reports prompt problems and exits non-zero [LINT-1]", func() {
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Fix the tests. Then commit your changes and open a pull request."
+  - name: docs
+    prompt: "Update the docs for {{.Feature}} in <INSERT PATH>."
+  - name: style
+    prompt: "`+strings.Repeat("Fix style. ", 800)+`"
+`)
+		out, err := line(dir, "lint-prompts")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].prompt (review): "commit your changes" conflicts with the preamble`))
+		Expect(out).To(ContainSubstring(`stations[0].prompt (review): "open a pull request" conflicts with the preamble`))
+		Expect(out).To(ContainSubstring(`stations[1].prompt (docs): unresolved placeholder "{{.Feature}}"`))
+		Expect(out).To(ContainSubstring(`stations[1].prompt (docs): unresolved placeholder "<INSERT PATH>"`))
+		Expect(out).To(ContainSubstring("stations[2].prompt (style): 8800 characters is over 8000"))
+	})
+})
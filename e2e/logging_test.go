@@ -0,0 +1,76 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("run logging", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+	})
+
+	It("hides progress with --quiet and adds routine steps with --verbose [LOG-1]", func() {
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("assembly-line: running station review"))
+		Expect(out).NotTo(ContainSubstring("station review: committing"))
+
+		// Agents under tmux write to the station log only.
+		out, err := lineWithEnv(dir, withoutTmux(), "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("mock-agent ran"))
+		out, err = lineWithEnv(dir, withoutTmux(), "run", "--quiet")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).NotTo(ContainSubstring("running station"))
+		Expect(out).NotTo(ContainSubstring("mock-agent ran"))
+
+		out = lineOK(dir, "run", "--verbose")
+		Expect(out).To(ContainSubstring("station review: committing"))
+		Expect(out).To(ContainSubstring("station review: station done"))
+
+		_, err = line(dir, "run", "--verbose", "--quiet")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("writes one JSON record per event with --log-format json [LOG-2]", func() {
+		// Agents under tmux write to the station log, not agent_output.
+		out, err := lineWithEnv(dir, withoutTmux(), "run", "--log-format", "json")
+		Expect(err).NotTo(HaveOccurred(), out)
+
+		var events []string
+		for _, l := range strings.Split(out, "\n") {
+			var rec map[string]any
+			Expect(json.Unmarshal([]byte(l), &rec)).To(Succeed(), "not JSON: %s", l)
+			Expect(rec).To(HaveKey("time"))
+			Expect(rec).To(HaveKey("level"))
+			Expect(rec).To(HaveKey("msg"))
+			events = append(events, rec["event"].(string))
+			if rec["event"] == "station_started" {
+				Expect(rec["station"]).To(Equal("review"))
+			}
+		}
+		Expect(events).To(ContainElement("station_started"))
+		Expect(events).To(ContainElement("agent_output"))
+
+		out, err = line(dir, "run", "--log-format", "xml")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`--log-format: "xml" is not one of text, json`))
+	})
+})
@@ -0,0 +1,166 @@
+package e2e_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+var _ = Describe("line logs", func() {
+	var dir string
+
+	// writeLog replaces a station's log, last written at t.
+	writeLog := func(name, content string, t time.Time) {
+		path := state.StationLogPath(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+		Expect(os.Chtimes(path, t, t)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `agent:
+  command: echo
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: docs
+    prompt: "Write docs"
+  - name: tests
+    prompt: "Write tests"
+`)
+	})
+
+	It("prints the end of one station's log [LOGS-1]", func() {
+		writeLog("review", "one\ntwo\nthree\n", time.Now())
+		Expect(lineOK(dir, "logs", "review", "-n", "2")).To(Equal("two\nthree"))
+
+		out, err := line(dir, "logs")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("name one station, or use --merge to interleave several"))
+
+		out, err = line(dir, "logs", "deploy")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown station "deploy"`))
+	})
+
+	It("interleaves the logs of every station, ordered by when they were written [LOGS-2]", func() {
+		now := time.Now()
+		writeLog("review", "review done\n", now.Add(-time.Minute))
+		writeLog("docs", "docs started\n\x1b[32mdocs done\x1b[0m\n", now)
+		writeLog("tests", "tests done\n", now.Add(-2*time.Minute))
+
+		out := lineOK(dir, "logs", "--merge")
+		Expect(out).To(MatchRegexp(`^\d\d:\d\d:\d\d tests  │ tests done\n\d\d:\d\d:\d\d review │ review done\n\d\d:\d\d:\d\d docs   │ docs started\n\d\d:\d\d:\d\d docs   │ docs done$`))
+
+		out = lineOK(dir, "logs", "--merge", "--ascii", "docs", "review", "-n", "1")
+		Expect(out).To(MatchRegexp(`^\d\d:\d\d:\d\d review \| review done\n\d\d:\d\d:\d\d docs   \| docs done$`))
+	})
+
+	It("prints what one run wrote to the log with --run [LOGS-3]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+    verify: "cat code.go; exit 1"
+`)
+		writeFile(dir, "code.go", "package one\n")
+		gitCommit(dir, "add code")
+		lineOK(dir, "run")
+		writeFile(dir, "code.go", "package two\n")
+		gitCommit(dir, "change code")
+		lineOK(dir, "run")
+
+		var ids []string
+		for _, r := range state.ReadStationRuns(dir, "review") {
+			ids = append(ids, r.ID)
+		}
+		Expect(ids).To(HaveLen(2))
+		first := lineOK(dir, "logs", "--run", ids[0])
+		Expect(first).To(ContainSubstring("package one"))
+		Expect(first).NotTo(ContainSubstring("package two"))
+		second := lineOK(dir, "logs", "review", "--run", ids[1])
+		Expect(second).To(ContainSubstring("package two"))
+		Expect(second).NotTo(ContainSubstring("package one"))
+
+		out, err := line(dir, "logs", "--run", "20260102T150405Z-000000")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("no run 20260102T150405Z-000000 in the history of review"))
+	})
+
+	It("stores agent output without escape sequences with settings.log_filter: strip-ansi [CFG-19, LOGS-4]", func() {
+		agent := writeMockAgentScript(dir, "color-agent.sh", `#!/bin/sh
+sleep 1
+printf '\033[31mred\033[0m alert\r\n'
+`)
+		config := func(filter string) string {
+			return `agent:
+  command: ` + agent + `
+settings:
+  watches: master
+  log_filter: ` + filter + `
+stations:
+  - name: review
+    prompt: "Review code"
+`
+		}
+		writeConfig(dir, config("keep"))
+		writeFile(dir, "code.go", "package one\n")
+		gitCommit(dir, "add code")
+		lineOK(dir, "run")
+		Expect(os.ReadFile(state.StationLogPath(dir, "review"))).To(ContainSubstring("\x1b[31mred"))
+		Expect(lineOK(dir, "logs", "review")).To(ContainSubstring("red alert"))
+		Expect(lineOK(dir, "logs", "review", "--raw")).To(ContainSubstring("\x1b[31mred\x1b[0m alert"))
+
+		lineOK(dir, "clear", "--force")
+		writeConfig(dir, config("strip-ansi"))
+		writeFile(dir, "code.go", "package two\n")
+		gitCommit(dir, "change code")
+		lineOK(dir, "run")
+		log, err := os.ReadFile(state.StationLogPath(dir, "review"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(log)).To(ContainSubstring("red alert\n"))
+		Expect(string(log)).NotTo(ContainSubstring("\x1b"))
+		Expect(string(log)).NotTo(ContainSubstring("\r"))
+
+		writeConfig(dir, config("strip"))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.log_filter: "strip" is not one of keep, strip-ansi`))
+	})
+
+	It("keeps printing lines as the stations write them with -f [LOGS-2]", func() {
+		writeLog("review", "review started\n", time.Now())
+
+		out := gbytes.NewBuffer()
+		cmd := exec.Command(binaryPath, "logs", "--merge", "-f")
+		cmd.Dir = dir
+		cmd.Stdout = out
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		Expect(cmd.Start()).To(Succeed())
+		defer func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}()
+		Eventually(out, 5*time.Second).Should(gbytes.Say(`review │ review started\n`))
+
+		Expect(state.AppendStationLog(dir, "tests", "tests passed\n")).To(Succeed())
+		Eventually(out, 5*time.Second).Should(gbytes.Say(`tests  │ tests passed\n`))
+		Expect(state.AppendStationLog(dir, "review", "review ")).To(Succeed())
+		Expect(state.AppendStationLog(dir, "review", "done\n")).To(Succeed())
+		Eventually(out, 5*time.Second).Should(gbytes.Say(`review │ review done\n`))
+	})
+})
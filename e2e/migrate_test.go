@@ -0,0 +1,94 @@
+package e2e_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/re-cinq/assembly-line/internal/state"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line migrate-station", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+	})
+
+	writeStationConfig := func(agent, name string) {
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: `+name+`
+    prompt: "Review code"
+`)
+	}
+
+	It("warns about a renamed station's leftovers and carries them over [MIG-1, MIG-2]", func() {
+		agent := writeMockAgent(dir)
+		writeStationConfig(agent, "review")
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+		lineOK(dir, "run")
+		head := git(dir, "rev-parse", "line/stn/review")
+		Expect(os.MkdirAll(filepath.Dir(state.StationLogPath(dir, "review")), 0o755)).To(Succeed())
+		Expect(os.WriteFile(state.StationLogPath(dir, "review"), []byte("reviewed\n"), 0o644)).To(Succeed())
+
+		writeStationConfig(agent, "audit")
+		Expect(lineOK(dir, "status")).To(ContainSubstring(`⚠ branch or state of unknown station "review" — renamed? line migrate-station review <new name>`))
+
+		out, err := line(dir, "migrate-station", "audit", "review")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`station "audit" is still in the config; rename it there first`))
+
+		Expect(lineOK(dir, "migrate-station", "review", "audit")).To(ContainSubstring("Moved review's branch and state to audit."))
+		Expect(git(dir, "rev-parse", "line/stn/audit")).To(Equal(head))
+		Expect(git(dir, "branch", "--list", "line/stn/review")).To(BeEmpty())
+		Expect(fileExists(dir, ".line/stations/audit.last-run")).To(BeTrue())
+		Expect(fileExists(dir, ".line/stations/review.last-run")).To(BeFalse())
+		Expect(os.ReadFile(state.StationLogPath(dir, "audit"))).To(Equal([]byte("reviewed\n")))
+
+		status := lineOK(dir, "status")
+		Expect(status).NotTo(ContainSubstring("unknown station"))
+		Expect(status).To(MatchRegexp(`audit .*\[up to date\]`))
+	})
+
+	It("refuses to replace the new name's own state without --force [MIG-2]", func() {
+		agent := writeMockAgent(dir)
+		writeStationConfig(agent, "review")
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+		lineOK(dir, "run")
+
+		writeStationConfig(agent, "audit")
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		out, _ := line(dir, "run")
+		Expect(out).To(ContainSubstring(`branch or state of unknown station "review"`))
+
+		out, err := line(dir, "migrate-station", "review", "audit")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("station audit already has a branch or state of its own; --force replaces it with review's"))
+
+		head := git(dir, "rev-parse", "line/stn/review")
+		lineOK(dir, "migrate-station", "review", "audit", "--force")
+		Expect(git(dir, "rev-parse", "line/stn/audit")).To(Equal(head))
+	})
+
+	It("drops the leftovers of removed stations with line clear [CLEAR-1]", func() {
+		agent := writeMockAgent(dir)
+		writeStationConfig(agent, "review")
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+		lineOK(dir, "run")
+
+		writeStationConfig(agent, "audit")
+		lineOK(dir, "clear", "--force")
+		Expect(git(dir, "branch", "--list", "line/stn/review")).To(BeEmpty())
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("unknown station"))
+	})
+})
@@ -0,0 +1,151 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("notifications", func() {
+	var (
+		dir      string
+		server   *httptest.Server
+		mu       sync.Mutex
+		received map[string][]map[string]any
+	)
+
+	// messages returns the JSON payloads posted to a webhook path.
+	messages := func(path string) []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return received[path]
+	}
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		received = map[string][]map[string]any{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var payload map[string]any
+			_ = json.Unmarshal(body, &payload)
+			mu.Lock()
+			received[r.URL.Path] = append(received[r.URL.Path], payload)
+			mu.Unlock()
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	commit := func() {
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", "code.go")
+		git(dir, "commit", "-m", "add code")
+	}
+
+	It("posts station failures to Slack and Teams [NOTIFY-1]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeFailingMockAgent(dir)+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+
+notifications:
+  slack:
+    webhook_url: "${HOOK_URL}/slack"
+    template: "{{.Station}} broke on {{.Branch}}: {{.Result}}"
+  teams:
+    webhook_url: `+server.URL+`/teams
+`)
+		commit()
+		out, err := lineWithEnv(dir, []string{"HOOK_URL=" + server.URL}, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+
+		Expect(messages("/slack")).To(HaveLen(1))
+		Expect(messages("/slack")[0]["text"]).To(Equal("review broke on line/stn/review: agent failed: exit status 1"))
+
+		Expect(messages("/teams")).To(HaveLen(1))
+		card, _ := json.Marshal(messages("/teams")[0])
+		Expect(string(card)).To(ContainSubstring(`"AdaptiveCard"`))
+		Expect(string(card)).To(MatchRegexp(`line: station review failed on [0-9a-f]{7}: agent failed: exit status 1`))
+	})
+
+	It("does not post when stations succeed [NOTIFY-1]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+
+notifications:
+  slack:
+    webhook_url: `+server.URL+`/slack
+`)
+		commit()
+		lineOK(dir, "run")
+		Expect(messages("/slack")).To(BeEmpty())
+	})
+
+	It("posts the digest with line digest --notify [NOTIFY-1]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+
+notifications:
+  slack:
+    webhook_url: `+server.URL+`/slack
+`)
+		commit()
+		lineOK(dir, "run")
+
+		Expect(lineOK(dir, "digest", "--notify")).To(ContainSubstring("Posted digest to slack"))
+		Expect(messages("/slack")).To(HaveLen(1))
+		Expect(messages("/slack")[0]["text"]).To(ContainSubstring("*line digest*, last 24h"))
+		Expect(messages("/slack")[0]["text"]).To(ContainSubstring("- *review*: 1 commit reviewed"))
+	})
+
+	It("validates webhook URLs and templates [NOTIFY-1]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+
+notifications:
+  slack:
+    webhook_url: "hooks.slack.com/services/x"
+  teams:
+    webhook_url: "${TEAMS_URL}"
+    template: "{{.Station"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("notifications.slack.webhook_url: must be an http(s) URL"))
+		Expect(out).To(ContainSubstring("notifications.teams.template:"))
+		Expect(out).NotTo(ContainSubstring("notifications.teams.webhook_url"))
+	})
+})
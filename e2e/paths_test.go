@@ -0,0 +1,89 @@
+package e2e_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line paths", func() {
+	var dir, xdg string
+	var env []string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		var err error
+		xdg, err = os.MkdirTemp("", "line-xdg-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(xdg) })
+		env = []string{
+			"XDG_CONFIG_HOME=" + filepath.Join(xdg, "config"),
+			"XDG_STATE_HOME=" + filepath.Join(xdg, "state"),
+			"XDG_CACHE_HOME=" + filepath.Join(xdg, "cache"),
+		}
+	})
+
+	pathOf := func(name string) string {
+		out, err := lineWithEnv(dir, env, "paths", name)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred(), out)
+		return out
+	}
+
+	It("lists every location, namespaced per repo under the XDG dirs [PATH-1, PATH-2]", func() {
+		out, err := lineWithEnv(dir, env, "paths")
+		Expect(err).NotTo(HaveOccurred(), out)
+		for _, name := range []string{"config", "global", "state", "logs", "worktrees"} {
+			Expect(out).To(MatchRegexp(`(?m)^` + name + ` +/`))
+		}
+
+		realDir, err := filepath.EvalSymlinks(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pathOf("config")).To(HaveSuffix("line.yaml"))
+		Expect(pathOf("global")).To(Equal(filepath.Join(xdg, "config", "line", "config.yaml")))
+		Expect(pathOf("state")).To(HaveSuffix(".line"))
+
+		ns := filepath.Base(realDir) + "-"
+		Expect(pathOf("logs")).To(HavePrefix(filepath.Join(xdg, "state", "line", ns)))
+		Expect(pathOf("logs")).To(HaveSuffix("logs"))
+		Expect(pathOf("worktrees")).To(HavePrefix(filepath.Join(xdg, "cache", "line", ns)))
+
+		other := tempRepo()
+		otherLogs, err := lineWithEnv(other, env, "paths", "logs")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otherLogs).NotTo(Equal(pathOf("logs")))
+	})
+
+	It("rejects unknown names [PATH-2]", func() {
+		_, err := lineWithEnv(dir, env, "paths", "bogus")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("writes station logs to the logs dir, not .line [PATH-1]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    verify: "echo verify-output-here; false"
+`)
+		out, _ := lineWithEnv(dir, env, "run")
+		Expect(out).To(ContainSubstring("verify failed"))
+
+		logs := pathOf("logs")
+		Expect(readFile(logs, "review.log")).To(ContainSubstring("verify-output-here"))
+		Expect(fileExists(dir, ".line/stations/review.log")).To(BeFalse())
+
+		// line clear removes the logs along with the rest of the state
+		out, err := lineWithEnv(dir, env, "clear", "--force")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(logs).NotTo(BeADirectory())
+	})
+})
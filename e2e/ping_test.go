@@ -0,0 +1,101 @@
+package e2e_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line ping", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		lineOK(dir, "run")
+		lineOK(dir, "init")
+	})
+
+	It("is healthy when the hook is installed and the head has finished a cycle [PING-1]", func() {
+		out := lineOK(dir, "ping")
+		Expect(out).To(MatchRegexp(`✓\S* trigger\s+post-commit hook installed`))
+		Expect(out).To(MatchRegexp(`✓\S* runner\s+idle`))
+		Expect(out).To(MatchRegexp(`✓\S* cycle\s+[0-9a-f]{7} finished \d+s ago`))
+	})
+
+	It("accepts skipped and recent commits, and flags commits older than --max-age [PING-1]", func() {
+		writeFile(dir, "notes.md", "notes\n")
+		git(dir, "add", "notes.md")
+		git(dir, "commit", "--no-verify", "-m", "notes [skip line]")
+		lineOK(dir, "run")
+		Expect(lineOK(dir, "ping")).To(MatchRegexp(`✓\S* cycle\s+[0-9a-f]{7} skipped \(\[skip line\]\)`))
+
+		writeFile(dir, "more.go", "package main\n")
+		git(dir, "add", "more.go")
+		git(dir, "commit", "--no-verify", "-m", "more")
+		Expect(lineOK(dir, "ping")).To(MatchRegexp(`✓\S* cycle\s+[0-9a-f]{7} not run, committed \d+s ago`))
+
+		out, err := line(dir, "ping", "--max-age", "-1s")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(MatchRegexp(`✗\S* cycle\s+[0-9a-f]{7} not run, committed \d+s ago \(max age -1s\)`))
+		Expect(out).To(ContainSubstring("unhealthy: 1 check failed"))
+	})
+
+	It("accepts a skipped commit triggered while another branch is checked out [PING-1, RUN-49]", func() {
+		writeFile(dir, "notes.md", "notes\n")
+		git(dir, "add", "notes.md")
+		git(dir, "commit", "--no-verify", "-m", "notes [skip line]")
+		git(dir, "checkout", "-b", "feature")
+		writeFile(dir, "feature.go", "package main\n")
+		git(dir, "add", "feature.go")
+		git(dir, "commit", "--no-verify", "-m", "feature")
+
+		Expect(lineOK(dir, "trigger", "--commit", "master")).To(ContainSubstring("skipping (commit contains [skip line])"))
+		Expect(lineOK(dir, "ping")).To(MatchRegexp(`✓\S* cycle\s+[0-9a-f]{7} skipped \(\[skip line\]\)`))
+	})
+
+	It("fails without the hook, when disabled or when the runner died [PING-1]", func() {
+		writeFile(dir, ".line/disabled", "")
+		writeFile(dir, ".line/run.pid", "999999")
+		out, err := line(dir, "ping")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(MatchRegexp(`✗\S* trigger\s+pipeline disabled`))
+		Expect(out).To(MatchRegexp(`✗\S* runner\s+pid 999999 exited without finishing its run`))
+		Expect(out).To(ContainSubstring("unhealthy: 2 checks failed"))
+
+		lineOK(dir, "remove")
+		out, err = line(dir, "ping")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(MatchRegexp(`✗\S* trigger\s+post-commit hook missing or not executable; run line init`))
+	})
+
+	It("prints JSON for monitoring systems [PING-1]", func() {
+		var report struct {
+			Healthy bool `json:"healthy"`
+			Checks  []struct {
+				Name   string `json:"name"`
+				OK     bool   `json:"ok"`
+				Detail string `json:"detail"`
+			} `json:"checks"`
+		}
+		Expect(json.Unmarshal([]byte(lineOK(dir, "ping", "--json")), &report)).To(Succeed())
+		Expect(report.Healthy).To(BeTrue())
+		Expect(report.Checks).To(HaveLen(3))
+		Expect(report.Checks[0].Name).To(Equal("trigger"))
+		Expect(report.Checks[2].Name).To(Equal("cycle"))
+		Expect(report.Checks[2].OK).To(BeTrue())
+	})
+})
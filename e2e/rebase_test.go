@@ -342,6 +342,86 @@ stations:
 		Expect(out).To(ContainSubstring("rebase already in progress"))
 	})
 
+	// writeGatedConfig replaces the config with one whose gate fails when the
+	// terminal station's cleanup.txt is present, and commits it.
+	writeGatedConfig := func(dir string) {
+		writeConfig(dir, `agent:
+  command: echo
+  args: ["hello"]
+
+settings:
+  watches: master
+
+gates:
+  - name: no-cleanup
+    run: "test ! -f cleanup.txt"
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
+		git(dir, "add", "line.yaml")
+		git(dir, "commit", "-m", "add gate")
+	}
+
+	It("refuses to rebase when gates fail against the terminal branch [REB-5]", func() {
+		setupStationBranches(dir)
+		writeGatedConfig(dir)
+		headBefore := git(dir, "rev-parse", "HEAD")
+
+		out, err := line(dir, "rebase")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("gate: running no-cleanup"))
+		Expect(out).To(ContainSubstring("Refusing to rebase"))
+
+		// Watched branch untouched and no gate worktree left registered.
+		Expect(git(dir, "rev-parse", "HEAD")).To(Equal(headBefore))
+		Expect(git(dir, "worktree", "list")).NotTo(ContainSubstring("land-"))
+		noRebaseInProgress(dir)
+	})
+
+	It("requires a reason when forcing past failing gates [REB-5]", func() {
+		setupStationBranches(dir)
+		writeGatedConfig(dir)
+
+		out, err := line(dir, "rebase", "--force")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("--reason"))
+	})
+
+	It("lands with --force and records the justification trailer [REB-5]", func() {
+		setupStationBranches(dir)
+		writeGatedConfig(dir)
+
+		out := lineOK(dir, "rebase", "--force", "--reason", "hotfix approved by alice")
+		Expect(out).To(ContainSubstring("Rebased onto line/stn/cleanup"))
+		Expect(out).To(ContainSubstring("override recorded"))
+
+		terminalHead := git(dir, "rev-parse", lineGit.StationBranchName("cleanup"))
+		Expect(lineGit.IsAncestor(dir, terminalHead, "HEAD")).To(BeTrue())
+
+		trailer := git(dir, "log", "-1", "--format=%(trailers:key=Gate-Override,valueonly)")
+		Expect(trailer).To(Equal("hotfix approved by alice"))
+		Expect(git(dir, "log", "-1", "--format=%s")).To(ContainSubstring("[skip line]"))
+	})
+
+	It("rebases normally when gates pass against the terminal branch [REB-5]", func() {
+		setupStationBranches(dir)
+		writeConfig(dir, readFile(dir, "line.yaml")+`
+gates:
+  - name: has-review
+    run: "test -f review.txt"
+`)
+		git(dir, "add", "line.yaml")
+		git(dir, "commit", "-m", "add gate")
+
+		out := lineOK(dir, "rebase")
+		Expect(out).To(ContainSubstring("Rebased onto line/stn/cleanup"))
+		Expect(git(dir, "log", "-1", "--format=%B")).NotTo(ContainSubstring("Gate-Override"))
+	})
+
 	It("refuses to run when not on the watched branch [REB-1]", func() {
 		setupStationBranches(dir)
 
@@ -0,0 +1,78 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("changelog stations and line release", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agent := writeMockAgentScript(dir, "changelog-agent.sh", `#!/bin/bash
+printf '# Changelog\n\n## [Unreleased]\n\n- Added CSV export\n' > CHANGELOG.md
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+settings:
+  watches: master
+stations:
+  - name: changelog
+    changelog: CHANGELOG.md
+`)
+		gitCommit(dir, "chore: set up line")
+		git(dir, "tag", "v0.1.0")
+
+		writeFile(dir, "export.go", "package main\n")
+		gitCommit(dir, "feat(export): add CSV export")
+		writeFile(dir, "export.go", "package main\n\n// quoted\n")
+		gitCommit(dir, "fix: quote fields")
+		writeFile(dir, "api.go", "package main\n")
+		gitCommit(dir, "feat!: drop the v1 API")
+		writeFile(dir, "README.md", "# test\n\nMore.\n")
+		gitCommit(dir, "update readme")
+	})
+
+	// RUN-35: A changelog station's prompt lists the commits by type
+	It("gives a changelog station the commits grouped by type [RUN-35]", func() {
+		out := lineOK(dir, "context", "changelog", "--range", "v0.1.0..HEAD")
+		Expect(out).To(ContainSubstring("  Update CHANGELOG.md for the commits listed below"))
+		Expect(out).To(MatchRegexp(`  Commits to record in CHANGELOG.md:\n\n  Breaking changes:\n  - drop the v1 API \([0-9a-f]{7}\)\n\n  Features:\n  - export: add CSV export \([0-9a-f]{7}\)\n\n  Fixes:\n  - quote fields \([0-9a-f]{7}\)\n\n  Other:\n  - update readme \([0-9a-f]{7}\)`))
+		Expect(lineOK(dir, "validate")).To(ContainSubstring("valid"))
+	})
+
+	// REL-1: line release tags the watched branch with collated notes
+	It("tags a release with the changelog entries, commits and station notes [REL-1]", func() {
+		lineOK(dir, "run")
+		git(dir, "merge", "--ff-only", "line/stn/changelog")
+
+		out := lineOK(dir, "release", "v0.2.0", "--dry-run")
+		Expect(out).To(HavePrefix("v0.2.0\n\nChangelog (CHANGELOG.md):\n- Added CSV export\n\nChanges:\n\nBreaking changes:\n- drop the v1 API"))
+		Expect(out).To(ContainSubstring("Features:\n- export: add CSV export"))
+		Expect(out).NotTo(ContainSubstring("set up line"))
+		Expect(out).To(HaveSuffix("Station notes:\n- changelog: 1 commit, CHANGELOG.md"))
+		Expect(git(dir, "tag", "--list", "v0.2.0")).To(BeEmpty())
+
+		out = lineOK(dir, "release", "v0.2.0")
+		Expect(out).To(MatchRegexp(`tagged v0.2.0 at [0-9a-f]{7} \(master\)`))
+		Expect(git(dir, "tag", "--list", "--format=%(contents)", "v0.2.0")).To(ContainSubstring("- Added CSV export"))
+
+		// The next release only covers what came after v0.2.0.
+		writeFile(dir, "more.go", "package main\n")
+		gitCommit(dir, "perf: faster export")
+		out = lineOK(dir, "release", "v0.3.0", "--dry-run")
+		Expect(out).To(Equal("v0.3.0\n\nChanges:\n\nPerformance:\n- faster export (" + git(dir, "rev-parse", "--short", "HEAD") + ")"))
+	})
+
+	// REL-1: Existing tags and invalid names are rejected
+	It("rejects existing tags and invalid names [REL-1]", func() {
+		out, err := line(dir, "release", "v0.1.0")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("tag v0.1.0 already exists"))
+
+		out, err = line(dir, "release", "v 1")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`"v 1" is not a valid tag name`))
+	})
+})
@@ -38,6 +38,12 @@ var _ = Describe("line remove", func() {
 		Expect(postCommit).NotTo(ContainSubstring("line run"))
 		Expect(postCommit).NotTo(ContainSubstring("# >>> assembly-line >>>"))
 		Expect(postCommit).NotTo(ContainSubstring("# <<< assembly-line <<<"))
+
+		for _, hook := range []string{"commit-msg", "post-merge", "post-rewrite", "reference-transaction"} {
+			content := readFile(dir, ".git/hooks/"+hook)
+			Expect(content).NotTo(ContainSubstring("line run"), hook)
+			Expect(content).NotTo(ContainSubstring("# >>> assembly-line >>>"), hook)
+		}
 	})
 
 	It("preserves other hook content when removing [RMV-1]", func() {
@@ -0,0 +1,85 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line resolve", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  on_conflict: keep
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n*.sh\n")
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+
+		writeFile(dir, "agent-output.txt", "conflicting content from master\n")
+		gitCommit(dir, "conflicting change")
+	})
+
+	// STAT-12: Conflicted stations are a distinct state listing their files
+	It("shows the conflict state with the conflicting files [STAT-12, RUN-18]", func() {
+		out := lineColorOK(dir, "status")
+		Expect(out).To(ContainSubstring("\033[35m  ⚠ review"))
+		Expect(out).To(ContainSubstring("[conflict] agent-output.txt — line resolve review"))
+		Expect(out).NotTo(ContainSubstring("[failed]"))
+
+		sl := lineOK(dir, "statusline")
+		Expect(sl).To(ContainSubstring("⚠ review (agent-output.txt)"))
+	})
+
+	// RSV-1: resolve replays the rebase and hands the worktree to the user's shell
+	It("rebases the station onto its predecessor once the user fixes the conflict [RSV-1]", func() {
+		shell := writeMockAgentScript(dir, "fix-shell.sh", `#!/bin/bash
+echo "resolved by hand" > agent-output.txt
+`)
+		out, err := lineWithEnv(dir, []string{"SHELL=" + shell}, "resolve", "review")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("agent-output.txt"))
+		Expect(out).To(ContainSubstring("Resolved"))
+
+		Expect(git(dir, "merge-base", "--is-ancestor", "master", "line/stn/review")).To(BeEmpty())
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(Equal("resolved by hand"))
+		Expect(fileExists(dir, ".line/stations/review.conflict")).To(BeFalse())
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("conflict"))
+	})
+
+	// RSV-2: leaving conflict markers behind aborts and keeps the branch
+	It("leaves the branch unchanged when conflicts are not resolved [RSV-2]", func() {
+		before := git(dir, "rev-parse", "line/stn/review")
+		shell := writeMockAgentScript(dir, "noop-shell.sh", "#!/bin/bash\nexit 0\n")
+
+		out, err := lineWithEnv(dir, []string{"SHELL=" + shell}, "resolve", "review")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("unchanged"))
+		Expect(git(dir, "rev-parse", "line/stn/review")).To(Equal(before))
+		Expect(fileExists(dir, ".line/stations/review.conflict")).To(BeTrue())
+	})
+
+	// RSV-2: only stations with a recorded conflict can be resolved
+	It("refuses stations without a recorded conflict [RSV-2]", func() {
+		_, err := line(dir, "resolve", "nope")
+		Expect(err).To(HaveOccurred())
+
+		lineOK(dir, "clear", "--force")
+		out, err := line(dir, "resolve", "review")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("no recorded conflict"))
+	})
+})
@@ -0,0 +1,71 @@
+package e2e_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("station quarantine", func() {
+	var dir, calls string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		calls = filepath.Join(dir, ".line", "agent-calls")
+		Expect(os.MkdirAll(filepath.Dir(calls), 0o755)).To(Succeed())
+		agent := writeMockAgentScript(dir, "flaky-agent.sh", "#!/bin/bash\necho call >> "+calls+"\nexit 1\n")
+		writeConfig(dir, `agent:
+  command: `+agent+`
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: docs
+    prompt: "Update docs"
+`)
+	})
+
+	callCount := func() int {
+		data, _ := os.ReadFile(calls)
+		return len(data)
+	}
+
+	It("quarantines a station after repeated failures until line retry [RUN-21, STAT-14]", func() {
+		out := lineOK(dir, "run")
+		Expect(out).NotTo(ContainSubstring("quarantined"))
+
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station review: failed 2 times in a row, quarantined until"))
+
+		status := lineOK(dir, "status")
+		Expect(status).To(ContainSubstring("quarantined"))
+		Expect(status).To(ContainSubstring("after 2 failures — line retry review"))
+
+		// While quarantined the agent is not started and the line stops there
+		before := callCount()
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station review: quarantined until"))
+		Expect(callCount()).To(Equal(before))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/docs"))
+
+		Expect(lineOK(dir, "retry", "review")).To(ContainSubstring("Cleared review"))
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("quarantined"))
+
+		lineOK(dir, "run")
+		Expect(callCount()).To(BeNumerically(">", before))
+	})
+
+	It("refuses to retry stations that have not failed [RUN-21]", func() {
+		out, err := line(dir, "retry", "docs")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("station docs has not failed"))
+
+		_, err = line(dir, "retry", "nope")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,191 @@
+package e2e_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// rpcClient drives line rpc over its stdin/stdout.
+type rpcClient struct {
+	stdin  io.WriteCloser
+	msgs   chan map[string]any
+	nextID int
+}
+
+func startRPC(dir string) *rpcClient {
+	cmd := exec.Command(binaryPath, "rpc")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	stdin, err := cmd.StdinPipe()
+	Expect(err).NotTo(HaveOccurred())
+	stdout, err := cmd.StdoutPipe()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cmd.Start()).To(Succeed())
+	DeferCleanup(func() {
+		stdin.Close()
+		_ = cmd.Wait()
+	})
+
+	c := &rpcClient{stdin: stdin, msgs: make(chan map[string]any, 100)}
+	go func() {
+		r := bufio.NewReader(stdout)
+		for {
+			header, err := textproto.NewReader(r).ReadMIMEHeader()
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			n, _ := strconv.Atoi(header.Get("Content-Length"))
+			body := make([]byte, n)
+			if _, err := io.ReadFull(r, body); err != nil {
+				close(c.msgs)
+				return
+			}
+			var msg map[string]any
+			_ = json.Unmarshal(body, &msg)
+			c.msgs <- msg
+		}
+	}()
+	return c
+}
+
+func (c *rpcClient) send(msg map[string]any) {
+	msg["jsonrpc"] = "2.0"
+	data, _ := json.Marshal(msg)
+	_, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// call sends a request and waits for its response, returning the result or
+// the error message.
+func (c *rpcClient) call(method string, params any) (result any, errMsg string) {
+	c.nextID++
+	id := float64(c.nextID)
+	c.send(map[string]any{"id": id, "method": method, "params": params})
+	for {
+		var msg map[string]any
+		Eventually(c.msgs, 60*time.Second).Should(Receive(&msg))
+		if msg["id"] != id {
+			continue
+		}
+		if e, ok := msg["error"].(map[string]any); ok {
+			return nil, e["message"].(string)
+		}
+		return msg["result"], ""
+	}
+}
+
+// notification waits for the next notification of the given method.
+func (c *rpcClient) notification(method string) map[string]any {
+	for {
+		var msg map[string]any
+		Eventually(c.msgs, 10*time.Second).Should(Receive(&msg))
+		if msg["method"] == method {
+			return msg["params"].(map[string]any)
+		}
+	}
+}
+
+var _ = Describe("line rpc", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", "code.go")
+		git(dir, "commit", "-m", "add code")
+	})
+
+	stations := func(status any) []any {
+		return status.(map[string]any)["stations"].([]any)
+	}
+
+	It("reports its protocol and serves status snapshots [RPC-1]", func() {
+		c := startRPC(dir)
+		init, errMsg := c.call("initialize", nil)
+		Expect(errMsg).To(BeEmpty())
+		Expect(init.(map[string]any)["protocolVersion"]).To(BeEquivalentTo(1))
+		Expect(init.(map[string]any)["methods"]).To(ContainElements("status", "trigger", "approve"))
+
+		status, _ := c.call("status", nil)
+		Expect(status.(map[string]any)["watches"]).To(Equal("master"))
+		Expect(stations(status)[0].(map[string]any)["state"]).To(Equal("pending"))
+
+		result, errMsg := c.call("trigger", nil)
+		Expect(errMsg).To(BeEmpty())
+		Expect(result.(map[string]any)).To(HaveKey("output"))
+
+		status, _ = c.call("status", nil)
+		review := stations(status)[0].(map[string]any)
+		Expect(review["state"]).To(Equal("up to date"))
+		Expect(review["branch"]).To(Equal("line/stn/review"))
+		Expect(review["ahead"]).To(BeEquivalentTo(1))
+	})
+
+	It("runs commands and reports their failures as errors [RPC-1]", func() {
+		c := startRPC(dir)
+		_, errMsg := c.call("retry", map[string]any{"station": "review"})
+		Expect(errMsg).To(ContainSubstring("station review has not failed"))
+
+		_, errMsg = c.call("approve", map[string]any{"station": "nope"})
+		Expect(errMsg).To(ContainSubstring(`unknown station "nope"`))
+
+		_, errMsg = c.call("approve", nil)
+		Expect(errMsg).To(ContainSubstring("approve: station is required"))
+
+		_, errMsg = c.call("frobnicate", nil)
+		Expect(errMsg).To(ContainSubstring(`unknown method "frobnicate"`))
+	})
+
+	It("streams status changes and log output to subscribers [RPC-1]", func() {
+		c := startRPC(dir)
+		_, errMsg := c.call("subscribe", nil)
+		Expect(errMsg).To(BeEmpty())
+		first := c.notification("status")
+		Expect(stations(first)[0].(map[string]any)["state"]).To(Equal("pending"))
+
+		_, errMsg = c.call("subscribe", map[string]any{"station": "review"})
+		Expect(errMsg).To(BeEmpty())
+		logPath := filepath.Join(strings.TrimSpace(lineOK(dir, "paths", "logs")), "review.log")
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0o755)).To(Succeed())
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		Expect(err).NotTo(HaveOccurred())
+		fmt.Fprint(f, "\x1b[32mthinking hard\x1b[0m\n")
+		f.Close()
+
+		log := c.notification("log")
+		Expect(log["station"]).To(Equal("review"))
+		Expect(log["text"]).To(Equal("thinking hard\n"))
+
+		lineOK(dir, "run")
+		Eventually(func() any {
+			return stations(c.notification("status"))[0].(map[string]any)["state"]
+		}, 30*time.Second).Should(Equal("up to date"))
+
+		tail, _ := c.call("log", map[string]any{"station": "review", "lines": 1})
+		Expect(tail.(map[string]any)["lines"]).To(HaveLen(1))
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"syscall"
 	"time"
@@ -64,6 +65,24 @@ stations:
 		Expect(readFile(dir, "agent-output.txt")).To(ContainSubstring("Clean up code"))
 	})
 
+	// RUN-30: Station commits carry trailers naming the trigger, branch and station
+	It("records the trigger and station in commit trailers [RUN-30]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		watched := git(dir, "rev-parse", "HEAD")
+		lineOK(dir, "run")
+
+		trailer := func(branch, key string) string {
+			return git(dir, "log", "-1", "--format=%(trailers:key="+key+",valueonly)", branch)
+		}
+		Expect(trailer("line/stn/review", "Triggered-By")).To(Equal(watched))
+		Expect(trailer("line/stn/review", "Triggered-Branch")).To(Equal("master"))
+		Expect(trailer("line/stn/review", "Line-Station")).To(Equal("review"))
+		Expect(trailer("line/stn/cleanup", "Line-Station")).To(Equal("cleanup"))
+		Expect(git(dir, "log", "-1", "--format=%s", "line/stn/cleanup")).To(Equal("assembly-line: station cleanup [skip line]"))
+	})
+
 	// RUN-3: Stations must not operate on any other branches
 	It("does not modify the watched branch during station execution [RUN-3]", func() {
 		writeRunConfig(dir, agentScript)
@@ -169,117 +188,1764 @@ stations:
 		Expect(content).To(ContainSubstring("agent was here"))
 	})
 
-	// RUN-7, RUN-8: .lineignore
-	It("skips when all changed files match .lineignore [RUN-7, RUN-8]", func() {
-		writeRunConfig(dir, agentScript)
-		writeFile(dir, ".lineignore", "*.log\n")
+	// CFG-STN-6: squash keeps one unpicked commit per station
+	It("folds each run into a single station commit with squash: true [CFG-STN-6]", func() {
+		// Each station writes its own file so downstream rebases stay clean.
+		uniqueAgent := writeMockAgentScript(dir, "unique-agent.sh", `#!/bin/bash
+PROMPT="${@: -1}"
+FNAME="$(echo "$PROMPT" | tail -1 | tr ' ' '-').txt"
+echo "agent was here" >> "$FNAME"
+`)
+		writeConfig(dir, `agent:
+  command: `+uniqueAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    squash: true
+  - name: cleanup
+    prompt: "Clean up code"
+`)
 		installHooksForTest(dir)
 
-		gitCommit(dir, "add config and lineignore")
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+		writeFile(dir, "extra.go", "package main\n")
+		gitCommit(dir, "second change")
 
-		writeFile(dir, "debug.log", "log stuff\n")
-		out := gitCommit(dir, "add log file")
-		Expect(out).To(ContainSubstring("skipping"))
+		Expect(git(dir, "rev-list", "--count", "master..line/stn/review")).To(Equal("1"))
+		Expect(git(dir, "log", "-1", "--format=%s", "line/stn/review")).To(Equal("assembly-line: station review [skip line]"))
+		Expect(git(dir, "show", "line/stn/review:Review-code.txt")).To(ContainSubstring("agent was here"))
+
+		// Stations without squash keep one commit per run
+		Expect(git(dir, "rev-list", "--count", "line/stn/review..line/stn/cleanup")).To(Equal("2"))
 	})
 
-	// RUN-8: .lineignore supports directory patterns
-	It("supports directory patterns in .lineignore [RUN-8]", func() {
-		writeRunConfig(dir, agentScript)
-		writeFile(dir, ".lineignore", "docs/\n")
+	// CFG-6: Station commits use settings.commit_author
+	It("authors and commits station changes as settings.commit_author [CFG-6, RUN-5]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  commit_author: "Line Bot <line@example.com>"
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
 		installHooksForTest(dir)
 
-		gitCommit(dir, "add config and lineignore")
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
 
-		writeFile(dir, "docs/readme.md", "documentation\n")
-		out := gitCommit(dir, "add docs")
-		Expect(out).To(ContainSubstring("skipping"))
+		Expect(git(dir, "log", "-1", "--format=%an <%ae>|%cn <%ce>", "line/stn/review")).
+			To(Equal("Line Bot <line@example.com>|Line Bot <line@example.com>"))
+		Expect(git(dir, "log", "-1", "--format=%an", "master")).NotTo(Equal("Line Bot"))
 	})
 
-	// RUN-8: .lineignore supports negation patterns
-	It("supports negation patterns in .lineignore [RUN-8]", func() {
-		writeRunConfig(dir, agentScript)
-		writeFile(dir, ".lineignore", "*.log\n!important.log\n")
+	// CFG-7: Station commits are signed with settings.commit_signing
+	It("signs station commits with settings.commit_signing [CFG-7]", func() {
+		if _, err := exec.LookPath("ssh-keygen"); err != nil {
+			Skip("ssh-keygen not available")
+		}
+		keyPath := filepath.Join(GinkgoT().TempDir(), "line_ed25519")
+		out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", keyPath).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  commit_signing:
+    format: ssh
+    key: `+keyPath+`.pub
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
 		installHooksForTest(dir)
 
-		gitCommit(dir, "add config and lineignore")
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
 
-		// A file that matches the negation should NOT be ignored
-		writeFile(dir, "important.log", "important stuff\n")
-		out := gitCommit(dir, "add important log")
+		Expect(git(dir, "cat-file", "commit", "line/stn/review")).To(ContainSubstring("-----BEGIN SSH SIGNATURE-----"))
+		Expect(git(dir, "cat-file", "commit", "master")).NotTo(ContainSubstring("SIGNATURE"))
+	})
 
-		// Should NOT skip because important.log is negated
-		Expect(out).NotTo(ContainSubstring("all changed files are ignored"))
+	// CFG-6, CFG-7: the station's earlier commits are replayed onto each new
+	// watched commit with the same committer and signature.
+	It("keeps commit_author and commit_signing on station commits replayed by later runs [CFG-6, CFG-7]", func() {
+		if _, err := exec.LookPath("ssh-keygen"); err != nil {
+			Skip("ssh-keygen not available")
+		}
+		keyPath := filepath.Join(GinkgoT().TempDir(), "line_ed25519")
+		out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", keyPath).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  commit_author: "Line Bot <line@example.com>"
+  commit_signing:
+    format: ssh
+    key: `+keyPath+`.pub
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		first := git(dir, "rev-parse", "line/stn/review")
+		writeFile(dir, "more.go", "package main\n")
+		gitCommit(dir, "add more")
+
+		commits := strings.Fields(git(dir, "rev-list", "master..line/stn/review"))
+		Expect(commits).NotTo(BeEmpty())
+		Expect(commits).NotTo(ContainElement(first), "the first station commit was not replayed")
+		for _, c := range commits {
+			Expect(git(dir, "log", "-1", "--format=%an <%ae>|%cn <%ce>", c)).
+				To(Equal("Line Bot <line@example.com>|Line Bot <line@example.com>"))
+			Expect(git(dir, "cat-file", "commit", c)).To(ContainSubstring("-----BEGIN SSH SIGNATURE-----"))
+		}
 	})
 
-	// RUN-8: .lineignore supports double-star patterns
-	It("supports double-star glob patterns in .lineignore [RUN-8]", func() {
-		writeRunConfig(dir, agentScript)
-		writeFile(dir, ".lineignore", "**/generated/**\n")
+	// RUN-19: settings.verify re-runs gates on station output
+	It("marks a station failed verification when its changes fail the gates [RUN-19, CFG-8, STAT-13]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  verify: true
+
+gates:
+  - name: no-agent-output
+    run: "test ! -f agent-output.txt || { echo 'agent-output.txt is not allowed'; exit 1; }"
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
 		installHooksForTest(dir)
 
-		gitCommit(dir, "add config and lineignore")
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code")
+		Expect(out).To(ContainSubstring("station review: verification failed"))
+
+		// The failing changes are never committed, so they reach no
+		// downstream station
+		Expect(git(dir, "rev-list", "--count", "master..line/stn/review")).To(Equal("0"))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/cleanup"))
+		Expect(readFile(lineOK(dir, "paths", "logs"), "review.log")).To(ContainSubstring("agent-output.txt is not allowed"))
+
+		status := lineOK(dir, "status")
+		Expect(status).To(ContainSubstring(`[failed verification] gate "no-agent-output" failed`))
+
+		// ...not even when the station is passed through next time
+		writeFile(dir, "more.go", "package main\n")
+		out = gitCommit(dir, "add more [skip line:review]")
+		Expect(out).To(ContainSubstring("station review: skipped by commit message"))
+		Expect(out).To(ContainSubstring("station cleanup: verification failed"))
+		Expect(git(dir, "log", "--format=%s", "master..line/stn/cleanup")).To(BeEmpty())
+	})
 
-		writeFile(dir, "src/generated/code.go", "package gen\n")
-		out := gitCommit(dir, "add generated code")
-		Expect(out).To(ContainSubstring("skipping"))
+	// RUN-20: station verify gates the commit
+	It("does not commit station changes that fail verify [RUN-20, CFG-STN-7, STAT-13]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    verify: "grep -q fixed agent-output.txt"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code")
+		Expect(out).To(ContainSubstring("station review: verify failed"))
+
+		Expect(git(dir, "rev-list", "--count", "master..line/stn/review")).To(Equal("0"))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/cleanup"))
+		Expect(lineOK(dir, "status")).To(ContainSubstring(`[failed verification] verify "grep -q fixed agent-output.txt" failed`))
 	})
 
-	// RUN-8: .lineignore supports comments
-	It("treats lines starting with # as comments in .lineignore [RUN-8]", func() {
-		writeRunConfig(dir, agentScript)
-		writeFile(dir, ".lineignore", "# This is a comment\n*.log\n")
+	// RUN-20: on_verify_failure: repair re-runs the agent until verify passes
+	It("asks the agent to repair a failed verify before committing [RUN-20, CFG-STN-7]", func() {
+		repairingAgent := writeMockAgentScript(dir, "repairing-agent.sh", `#!/bin/bash
+PROMPT="${@: -1}"
+if [[ "$PROMPT" == *"failed verification"* ]]; then
+  echo "$PROMPT" > repair-prompt.txt
+  echo "fixed" >> agent-output.txt
+  exit 0
+fi
+echo "agent was here" >> agent-output.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+repairingAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    verify: "grep -q fixed agent-output.txt || { echo 'expected fixed in agent-output.txt'; exit 1; }"
+    on_verify_failure: repair
+    max_repair_attempts: 1
+`)
 		installHooksForTest(dir)
 
-		gitCommit(dir, "add config and lineignore")
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code")
+		Expect(out).To(ContainSubstring("asking agent to repair (attempt 1/1)"))
+
+		Expect(git(dir, "rev-list", "--count", "master..line/stn/review")).To(Equal("1"))
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("fixed"))
+		// The failing verify output was handed back to the agent
+		repair := git(dir, "show", "line/stn/review:repair-prompt.txt")
+		Expect(repair).To(ContainSubstring("Review code"))
+		Expect(repair).To(ContainSubstring("expected fixed in agent-output.txt"))
+		Expect(fileExists(dir, ".line/stations/review.failed")).To(BeFalse())
+	})
 
-		writeFile(dir, "debug.log", "log stuff\n")
-		out := gitCommit(dir, "add log file")
-		Expect(out).To(ContainSubstring("skipping"))
+	It("repairs with the prompt the agent ran with, root instruction included [RUN-20, RUN-34]", func() {
+		repairingAgent := writeMockAgentScript(dir, "repairing-agent.sh", `#!/bin/bash
+PROMPT="${@: -1}"
+if [[ "$PROMPT" == *"failed verification"* ]]; then
+  echo "$PROMPT" > repair-prompt.txt
+  echo "fixed" >> out.txt
+  exit 0
+fi
+echo "agent was here" >> out.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+repairingAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    root: src
+    verify: "grep -q fixed src/out.txt || { echo 'expected fixed in src/out.txt'; exit 1; }"
+    on_verify_failure: repair
+    max_repair_attempts: 1
+`)
+		writeFile(dir, "src/code.go", "package main\n")
+		gitCommit(dir, "add code")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("asking agent to repair (attempt 1/1)"))
+
+		repair := git(dir, "show", "line/stn/review:src/repair-prompt.txt")
+		Expect(repair).To(ContainSubstring("Review code"))
+		Expect(repair).To(ContainSubstring("Work only within src/"))
+		Expect(repair).To(ContainSubstring("expected fixed in src/out.txt"))
 	})
 
-	// RUN-9: Skip markers in commit message
-	It("skips commits with [skip ci] marker [RUN-9]", func() {
-		writeRunConfig(dir, agentScript)
+	// RUN-23: after post-processes the agent's changes; on_success runs in the repo root
+	It("runs after in the worktree and on_success in the repo root [RUN-23, CFG-STN-9]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    after: "echo formatted >> agent-output.txt"
+    on_success: 'echo "$LINE_STATION $LINE_BRANCH $LINE_RESULT" > .git/on-success.txt'
+  - name: cleanup
+    prompt: "Clean up code"
+`)
 		installHooksForTest(dir)
 
 		writeFile(dir, "code.go", "package main\n")
-		out := gitCommit(dir, "add code [skip ci]")
-		Expect(out).To(ContainSubstring("skipping"))
+		gitCommit(dir, "add code")
+
+		// The after changes land in the station's single commit
+		Expect(git(dir, "rev-list", "--count", "master..line/stn/review")).To(Equal("1"))
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("formatted"))
+		Expect(readFile(dir, ".git/on-success.txt")).To(Equal("review line/stn/review ok\n"))
+		Expect(git(dir, "branch")).To(ContainSubstring("line/stn/cleanup"))
 	})
 
-	It("skips commits with [ci skip] marker [RUN-9]", func() {
-		writeRunConfig(dir, agentScript)
+	// RUN-23: a failing after fails the station and runs on_failure
+	It("fails the station when after fails and runs on_failure [RUN-23, CFG-STN-9]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    after: "echo cannot format; exit 3"
+    on_success: "touch .git/on-success.txt"
+    on_failure: 'echo "$LINE_RESULT" > .git/on-failure.txt'
+  - name: cleanup
+    prompt: "Clean up code"
+`)
 		installHooksForTest(dir)
 
 		writeFile(dir, "code.go", "package main\n")
-		out := gitCommit(dir, "add code [ci skip]")
-		Expect(out).To(ContainSubstring("skipping"))
+		out := gitCommit(dir, "add code")
+		Expect(out).To(ContainSubstring("station review: after failed"))
+		Expect(out).To(ContainSubstring("cannot format"))
+
+		Expect(git(dir, "rev-list", "--count", "master..line/stn/review")).To(Equal("0"))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/cleanup"))
+		Expect(readFile(dir, ".git/on-failure.txt")).To(ContainSubstring(`after "echo cannot format; exit 3" failed`))
+		Expect(fileExists(dir, ".git/on-success.txt")).To(BeFalse())
+		Expect(lineOK(dir, "show", "review")).To(ContainSubstring(`after "echo cannot format; exit 3" failed`))
 	})
 
-	It("skips commits with [line skip] marker [RUN-9]", func() {
-		writeRunConfig(dir, agentScript)
+	// RUN-23: outcome hooks never change the station's outcome
+	It("only warns when on_success fails [RUN-23]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    on_success: "exit 1"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
 		installHooksForTest(dir)
 
 		writeFile(dir, "code.go", "package main\n")
-		out := gitCommit(dir, "add code [line skip]")
-		Expect(out).To(ContainSubstring("skipping"))
+		out := gitCommit(dir, "add code")
+		Expect(out).To(ContainSubstring(`station review: on_success "exit 1" failed`))
+		Expect(git(dir, "branch")).To(ContainSubstring("line/stn/cleanup"))
 	})
 
-	// RUN-10: Line runs should be independent of rebases on the watched branch
-	It("works correctly after a rebase on the watched branch [RUN-10]", func() {
-		writeRunConfig(dir, agentScript)
+	// RUN-26: settings.worktree brings untracked local files into station worktrees
+	It("copies and symlinks settings.worktree files without committing them [RUN-26, CFG-11]", func() {
+		seeingAgent := writeMockAgentScript(dir, "seeing-agent.sh", `#!/bin/bash
+cat .env node_modules/pkg.txt > seen.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+seeingAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  worktree:
+    copy: [".env", ".tool-versions"]
+    symlink: ["node_modules"]
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
 		installHooksForTest(dir)
+		writeFile(dir, ".env", "SECRET=1\n")
+		writeFile(dir, "node_modules/pkg.txt", "pkg contents\n")
 
-		// First commit and run (via hook)
 		writeFile(dir, "code.go", "package main\n")
-		gitCommit(dir, "first change")
+		git(dir, "add", "line.yaml", "code.go")
+		git(dir, "commit", "-m", "add code")
+
+		seen := git(dir, "show", "line/stn/review:seen.txt")
+		Expect(seen).To(ContainSubstring("SECRET=1"))
+		Expect(seen).To(ContainSubstring("pkg contents"))
+		files := git(dir, "ls-tree", "-r", "--name-only", "line/stn/review")
+		Expect(files).NotTo(ContainSubstring(".env"))
+		Expect(files).NotTo(ContainSubstring("node_modules"))
+		// Removing the worktree leaves the linked directory alone
+		Expect(readFile(dir, "node_modules/pkg.txt")).To(Equal("pkg contents\n"))
+	})
 
-		// Simulate a rebase by amending the last commit (triggers hooks again)
-		writeFile(dir, "code.go", "package main\n\nfunc main() {}\n")
-		git(dir, "add", ".")
-		git(dir, "commit", "--amend", "-m", "first change amended")
+	// RUN-27: stations[].cache keeps build directories between runs
+	It("keeps a station's cache directories from one run to the next [RUN-27, CFG-STN-10]", func() {
+		buildingAgent := writeMockAgentScript(dir, "building-agent.sh", `#!/bin/bash
+mkdir -p target
+echo run >> target/build.txt
+echo "builds: $(wc -l < target/build.txt | tr -d ' ')" >> agent-output.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+buildingAgent+`
+  args: ["-p"]
 
-		Expect(currentBranch(dir)).To(Equal("master"))
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    cache: ["target/"]
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(Equal("builds: 1"))
+
+		writeFile(dir, "more.go", "package main\n")
+		gitCommit(dir, "add more")
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("builds: 2"))
+		Expect(git(dir, "ls-tree", "-r", "--name-only", "line/stn/review")).NotTo(ContainSubstring("target/"))
+	})
+
+	// RUN-18: on_conflict: keep leaves the station branch alone and blocks the line
+	It("keeps the station branch and records the conflict with on_conflict: keep [RUN-18, CFG-5]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  on_conflict: keep
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+		stationHead := git(dir, "rev-parse", "line/stn/review")
+		cleanupHead := git(dir, "rev-parse", "line/stn/cleanup")
+
+		writeFile(dir, "agent-output.txt", "conflicting content from master\n")
+		out := gitCommit(dir, "conflicting change")
+		Expect(out).To(ContainSubstring("keeping branch for manual resolution"))
+
+		Expect(git(dir, "rev-parse", "line/stn/review")).To(Equal(stationHead))
+		Expect(git(dir, "rev-parse", "line/stn/cleanup")).To(Equal(cleanupHead), "a conflicted station blocks the line")
+		Expect(readFile(dir, ".line/stations/review.conflict")).To(ContainSubstring("agent-output.txt"))
+	})
+
+	// RUN-18: on_conflict: agent hands the conflict markers to the agent
+	It("lets the agent resolve rebase conflicts with on_conflict: agent [RUN-18, CFG-5]", func() {
+		resolvingAgent := writeMockAgentScript(dir, "resolving-agent.sh", `#!/bin/bash
+PROMPT="${@: -1}"
+if [[ "$PROMPT" == *"merge conflicts"* ]]; then
+  echo "resolved" > agent-output.txt
+  exit 0
+fi
+echo "agent was here: $PROMPT" >> agent-output.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+resolvingAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  on_conflict: agent
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+		stationHead := git(dir, "rev-parse", "line/stn/review")
+
+		writeFile(dir, "agent-output.txt", "conflicting content from master\n")
+		out := gitCommit(dir, "conflicting change")
+		Expect(out).To(ContainSubstring("agent resolved rebase conflict"))
+
+		// The original station commit survives, rebased onto master
+		Expect(git(dir, "log", "--format=%s", "master..line/stn/review")).To(ContainSubstring("assembly-line: station review"))
+		Expect(git(dir, "merge-base", "--is-ancestor", "master", "line/stn/review")).To(BeEmpty())
+		Expect(git(dir, "rev-parse", "line/stn/review")).NotTo(Equal(stationHead))
+		content := git(dir, "show", "line/stn/review:agent-output.txt")
+		Expect(content).To(HavePrefix("resolved"))
+		Expect(content).NotTo(ContainSubstring("<<<<<<<"))
+		Expect(fileExists(dir, ".line/stations/review.conflict")).To(BeFalse())
+	})
+
+	// RUN-7, RUN-8: .lineignore
+	It("skips when all changed files match .lineignore [RUN-7, RUN-8]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineignore", "*.log\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config and lineignore")
+
+		writeFile(dir, "debug.log", "log stuff\n")
+		out := gitCommit(dir, "add log file")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	// RUN-8: .lineignore supports directory patterns
+	It("supports directory patterns in .lineignore [RUN-8]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineignore", "docs/\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config and lineignore")
+
+		writeFile(dir, "docs/readme.md", "documentation\n")
+		out := gitCommit(dir, "add docs")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	// RUN-8: .lineignore supports negation patterns
+	It("supports negation patterns in .lineignore [RUN-8]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineignore", "*.log\n!important.log\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config and lineignore")
+
+		// A file that matches the negation should NOT be ignored
+		writeFile(dir, "important.log", "important stuff\n")
+		out := gitCommit(dir, "add important log")
+
+		// Should NOT skip because important.log is negated
+		Expect(out).NotTo(ContainSubstring("all changed files are ignored"))
+	})
+
+	// RUN-8: .lineignore supports double-star patterns
+	It("supports double-star glob patterns in .lineignore [RUN-8]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineignore", "**/generated/**\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config and lineignore")
+
+		writeFile(dir, "src/generated/code.go", "package gen\n")
+		out := gitCommit(dir, "add generated code")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	// RUN-8: .lineignore supports comments
+	It("treats lines starting with # as comments in .lineignore [RUN-8]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineignore", "# This is a comment\n*.log\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config and lineignore")
+
+		writeFile(dir, "debug.log", "log stuff\n")
+		out := gitCommit(dir, "add log file")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	// RUN-48: .lineonly lists the only paths that trigger the line
+	It("only triggers for changes matching .lineonly, minus .lineignore [RUN-48]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineonly", "src/\napi/*.go\n")
+		writeFile(dir, ".lineignore", "src/generated/\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config, lineonly and lineignore")
+
+		writeFile(dir, "docs/readme.md", "documentation\n")
+		writeFile(dir, "api/openapi.yaml", "openapi: 3.1.0\n")
+		out := gitCommit(dir, "add docs and spec")
+		Expect(out).To(ContainSubstring("all changed files are ignored"))
+
+		writeFile(dir, "src/generated/code.go", "package gen\n")
+		out = gitCommit(dir, "add generated code")
+		Expect(out).To(ContainSubstring("all changed files are ignored"))
+
+		writeFile(dir, "api/server.go", "package api\n")
+		writeFile(dir, "docs/api.md", "api docs\n")
+		out = gitCommit(dir, "add server")
+		Expect(out).NotTo(ContainSubstring("all changed files are ignored"))
+	})
+
+	// RUN-47: .lineignore follows core.ignoreCase
+	It("matches .lineignore patterns regardless of case when core.ignoreCase is set [RUN-8, RUN-47]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, ".lineignore", "Docs/\n")
+		installHooksForTest(dir)
+
+		gitCommit(dir, "add config and lineignore")
+
+		writeFile(dir, "docs/readme.md", "documentation\n")
+		out := gitCommit(dir, "add docs")
+		Expect(out).NotTo(ContainSubstring("all changed files are ignored"))
+
+		git(dir, "config", "core.ignoreCase", "true")
+		writeFile(dir, "docs/guide.md", "more documentation\n")
+		out = gitCommit(dir, "add guide")
+		Expect(out).To(ContainSubstring("all changed files are ignored"))
+	})
+
+	// RUN-9: Skip markers in commit message
+	It("skips commits with [skip ci] marker [RUN-9]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code [skip ci]")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	It("skips commits with [ci skip] marker [RUN-9]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code [ci skip]")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	It("runs a squash merge listing skip-marker commits in its body [RUN-9, STAT-8]", func() {
+		writeRunConfig(dir, agentScript)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		lineOK(dir, "run")
+
+		writeFile(dir, "feature.go", "package main\n\nfunc feature() {}\n")
+		gitCommit(dir, "add feature (#12)\n\n* add feature\n* fix typo [skip ci]")
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("up to date"))
+		out := lineOK(dir, "run")
+		Expect(out).NotTo(ContainSubstring("skipping"))
+		Expect(out).To(ContainSubstring("running station review"))
+	})
+
+	It("skips commits from configured machine authors and trailers [RUN-9, CFG-10]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  machine_commits:
+    authors: ["^Renovate Bot <"]
+    trailers: ["triggered-by"]
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "deps.txt", "v2\n")
+		git(dir, "add", ".")
+		out := git(dir, "-c", "user.name=Renovate Bot", "-c", "user.email=bot@renovate.test", "commit", "-m", "bump deps")
+		Expect(out).To(ContainSubstring("skipping (machine commit: author Renovate Bot <bot@renovate.test>)"))
+
+		writeFile(dir, "deps.txt", "v3\n")
+		out = gitCommit(dir, "bump deps again\n\nTriggered-By: ci-bot")
+		Expect(out).To(ContainSubstring("skipping (machine commit: triggered-by trailer)"))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/review"))
+		Expect(lineOK(dir, "status")).To(ContainSubstring("skipped 2 commits since the last run (2 machine commits)"))
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		Expect(git(dir, "branch")).To(ContainSubstring("line/stn/review"))
+	})
+
+	It("passes changes through stations named in [skip line:<station>] [RUN-24]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code [skip line:review]")
+		Expect(out).To(ContainSubstring("station review: skipped by commit message ([skip line:review]), passing changes through"))
+
+		// review caught up without running its agent; cleanup still ran on top
+		Expect(git(dir, "show", "line/stn/review:code.go")).To(Equal("package main"))
+		_, err := gitMay(dir, "show", "line/stn/review:agent-output.txt")
+		Expect(err).To(HaveOccurred())
+		cleanup := git(dir, "show", "line/stn/cleanup:agent-output.txt")
+		Expect(cleanup).To(ContainSubstring("Clean up code"))
+		Expect(cleanup).NotTo(ContainSubstring("Review code"))
+	})
+
+	It("reads [skip line:<station>] in the commit body too [RUN-24]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code\n\nNothing to review here. [skip line:review]")
+		Expect(out).To(ContainSubstring("station review: skipped by commit message ([skip line:review]), passing changes through"))
+		_, err := gitMay(dir, "show", "line/stn/review:agent-output.txt")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("only runs the stations named in [line only:<station>] [RUN-24]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code [line only:review]")
+		Expect(out).To(ContainSubstring("station cleanup: skipped by commit message (not in [line only:...])"))
+
+		cleanup := git(dir, "show", "line/stn/cleanup:agent-output.txt")
+		Expect(cleanup).To(ContainSubstring("Review code"))
+		Expect(cleanup).NotTo(ContainSubstring("Clean up code"))
+		Expect(lineOK(dir, "status")).To(ContainSubstring("up to date"))
+	})
+
+	It("routes Conventional Commits types to stations per commit [RUN-33]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+settings:
+  watches: master
+  routes:
+    feat: [docs, tests]
+    fix: [regression]
+    chore: []
+stations:
+  - name: docs
+    prompt: "Update docs"
+  - name: tests
+    prompt: "Write tests"
+  - name: regression
+    prompt: "Add a regression test"
+`)
+		gitCommit(dir, "add line config")
+		lineOK(dir, "run")
+
+		writeFile(dir, "export.go", "package main\n")
+		gitCommit(dir, "feat(export): add CSV export")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station regression: skipped by settings.routes (feat), passing changes through"))
+		Expect(out).NotTo(ContainSubstring("station docs: skipped"))
+		Expect(out).NotTo(ContainSubstring("station tests: skipped"))
+		Expect(git(dir, "show", "line/stn/regression:export.go")).To(Equal("package main"))
+
+		writeFile(dir, "deps.txt", "v2\n")
+		gitCommit(dir, "chore: bump deps")
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station docs: skipped by settings.routes (chore)"))
+		Expect(out).To(ContainSubstring("station tests: skipped by settings.routes (chore)"))
+		Expect(out).To(ContainSubstring("station regression: skipped by settings.routes (chore, feat)"))
+
+		// regression still has the feat and chore commits to review; the fix
+		// among them routes to it.
+		writeFile(dir, "export.go", "package main\n\n// fixed\n")
+		gitCommit(dir, "fix!: quote CSV fields")
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station docs: skipped by settings.routes (fix, chore)"))
+		Expect(out).NotTo(ContainSubstring("station regression: skipped"))
+		Expect(git(dir, "show", "line/stn/regression:agent-output.txt")).To(ContainSubstring("Add a regression test"))
+	})
+
+	It("routes a first run by its commit and leaves station commits out [RUN-33]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+settings:
+  watches: master
+  routes:
+    feat: [docs]
+    chore: []
+stations:
+  - name: docs
+    prompt: "Update docs"
+  - name: regression
+    prompt: "Add a regression test"
+`)
+		gitCommit(dir, "add line config")
+
+		// Without a previous run only the triggered commit counts, not the
+		// history before it.
+		writeFile(dir, "export.go", "package main\n")
+		gitCommit(dir, "feat: add CSV export")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station regression: skipped by settings.routes (feat), passing changes through"))
+		Expect(out).NotTo(ContainSubstring("station docs: skipped"))
+
+		writeFile(dir, "docs.md", "# Export\n")
+		gitCommit(dir, "assembly-line: station docs [skip line]")
+		writeFile(dir, "deps.txt", "v2\n")
+		gitCommit(dir, "chore: bump deps")
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station docs: skipped by settings.routes (chore)"))
+	})
+
+	It("rejects routes to unknown stations [RUN-33]", func() {
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+  routes:
+    feat: [docs]
+    Fix: []
+stations:
+  - name: review
+    prompt: "Review"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.routes.Fix: not a commit type"))
+		Expect(out).To(ContainSubstring(`settings.routes.feat[0]: "docs" is not a station`))
+	})
+
+	It("scopes stations to a monorepo package with root [RUN-34]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+settings:
+  watches: master
+stations:
+  - name: api
+    root: services/api/
+    prompt: "Review the API"
+  - name: web
+    root: services/web
+    prompt: "Review the web app"
+`)
+		writeFile(dir, ".lineignore", "*.md\n")
+		writeFile(dir, "services/api/go.mod", "module api\n")
+		writeFile(dir, "services/web/package.json", "{}\n")
+		gitCommit(dir, "add services")
+		lineOK(dir, "run")
+
+		writeFile(dir, "services/api/main.go", "package main\n")
+		gitCommit(dir, "api: add main")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station web: skipped with no changes under services/web, passing changes through"))
+		Expect(out).NotTo(ContainSubstring("station api: skipped"))
+
+		// The agent ran in the root and was told to stay there.
+		output := git(dir, "show", "line/stn/api:services/api/agent-output.txt")
+		Expect(output).To(ContainSubstring("Work only within services/api/"))
+		Expect(git(dir, "show", "line/stn/web:services/api/agent-output.txt")).To(Equal(output))
+
+		ctx := lineOK(dir, "context", "api", "--range", "HEAD~2..HEAD")
+		Expect(ctx).To(ContainSubstring("Root:     services/api\nReviews:  2 commits"))
+		Expect(ctx).To(ContainSubstring(", 2 under services/api"))
+
+		writeFile(dir, "services/api/README.md", "# API\n")
+		gitCommit(dir, "api: docs")
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station api: skipped with no changes under services/api"))
+	})
+
+	It("fails a station that changes files outside its root [RUN-34]", func() {
+		stray := writeMockAgentScript(dir, "stray-agent.sh", `#!/bin/bash
+echo fixed > ../shared.txt
+echo ok > mine.txt
+`)
+		writeConfig(dir, `settings:
+  watches: master
+stations:
+  - name: api
+    command: `+stray+`
+    root: services/api
+    prompt: "Review the API"
+`)
+		writeFile(dir, "services/api/main.go", "package main\n")
+		gitCommit(dir, "add api")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("changed files outside root services/api: services/shared.txt"))
+		Expect(lineOK(dir, "status")).To(ContainSubstring("failed"))
+		_, err := gitMay(dir, "show", "line/stn/api:services/shared.txt")
+		Expect(err).To(HaveOccurred())
+
+		out, err = line(dir, "validate")
+		Expect(err).NotTo(HaveOccurred(), out)
+		writeConfig(dir, `agent:
+  command: claude
+settings:
+  watches: master
+stations:
+  - name: api
+    root: ../api
+    prompt: "Review"
+`)
+		out, err = line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].root: "../api" must be a relative path inside the repository`))
+	})
+
+	It("does not run again for a rewritten commit with an unchanged tree [RUN-36]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		first := strings.TrimSpace(git(dir, "rev-parse", "--short", "HEAD"))
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("running station review"))
+
+		git(dir, "commit", "--amend", "-m", "add code, better message")
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("skipping (tree unchanged since " + first + ")"))
+		Expect(out).NotTo(ContainSubstring("running station review"))
+		status := lineOK(dir, "status")
+		Expect(status).To(ContainSubstring("skipped 1 commit since the last run (1 unchanged tree)"))
+		Expect(status).To(ContainSubstring("[up to date]"))
+		Expect(status).NotTo(ContainSubstring("[pending]"))
+		lineOK(dir, "clear", "--force")
+		Expect(lineOK(dir, "run")).To(ContainSubstring("running station review"))
+
+		// Rerunning the same commit is not a rewrite, and changed content runs.
+		writeFile(dir, "code.go", "package main\n\nfunc main() {}\n")
+		git(dir, "commit", "-a", "--amend", "-m", "add main")
+		Expect(lineOK(dir, "run")).To(ContainSubstring("running station review"))
+		Expect(lineOK(dir, "run")).To(ContainSubstring("running station review"))
+	})
+
+	It("runs a rewritten commit again when its last cycle failed [RUN-36]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeFailingMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		lineOK(dir, "run")
+
+		git(dir, "commit", "--amend", "-m", "add code again")
+		out := lineOK(dir, "run")
+		Expect(out).NotTo(ContainSubstring("tree unchanged"))
+		Expect(out).To(ContainSubstring("running station review"))
+	})
+
+	It("runs a station with watches for new tags only [RUN-37]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: release
+    watches: "tag:v*"
+    prompt: "Write release notes"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		git(dir, "tag", "v0.1.0")
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+		b := strings.TrimSpace(git(dir, "rev-parse", "HEAD"))
+		git(dir, "tag", "-a", "v0.2.0", "-m", "release 0.2.0")
+
+		// The first time, only the newest tag runs, from its commit.
+		out := lineOK(dir, "run", "--refs")
+		Expect(out).NotTo(ContainSubstring("station review"))
+		Expect(git(dir, "show", "line/stn/release:agent-output.txt")).To(ContainSubstring("Write release notes\n\nTriggered by tag v0.2.0."))
+		Expect(git(dir, "log", "-1", "--format=%(trailers:key=Triggered-Ref,valueonly)", "line/stn/release")).To(ContainSubstring("refs/tags/v0.2.0"))
+		_, err := gitMay(dir, "merge-base", "--is-ancestor", b, "line/stn/release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readFile(dir, ".line/stations/release.refs")).To(ContainSubstring("refs/tags/v0.1.0"))
+		Expect(git(dir, "branch", "--list", "line/stn/review")).To(BeEmpty())
+
+		// Seen tags don't run again; a new one does, after the line.
+		before := git(dir, "rev-parse", "line/stn/release")
+		lineOK(dir, "run", "--refs")
+		Expect(git(dir, "rev-parse", "line/stn/release")).To(Equal(before))
+
+		writeFile(dir, "c.go", "package main\n")
+		gitCommit(dir, "add c")
+		git(dir, "tag", "v0.3.0")
+		out = lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("station review"))
+		Expect(git(dir, "show", "line/stn/release:agent-output.txt")).To(ContainSubstring("Triggered by tag v0.3.0."))
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).NotTo(ContainSubstring("Triggered by"))
+		Expect(lineOK(dir, "status")).To(MatchRegexp(`release\s.*\[done\] tag v0\.3\.0`))
+	})
+
+	It("runs a station with watches when a tag is created [RUN-37]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: release
+    watches: "tag:v*"
+    prompt: "Write release notes"
+`)
+		installHooksForTest(dir)
+		patchHook(dir, "reference-transaction", "line run --refs >/dev/null 2>&1 &", binaryPath+" run --refs >/dev/null 2>&1")
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		Expect(git(dir, "branch", "--list", "line/stn/release")).To(BeEmpty())
+
+		git(dir, "tag", "v1.0.0")
+		Expect(git(dir, "show", "line/stn/release:agent-output.txt")).To(ContainSubstring("Triggered by tag v1.0.0."))
+	})
+
+	It("leaves a station with watches running when a commit fires the ref hook [RUN-37, RUN-11]", func() {
+		agent := writeMockAgentScript(dir, "slow-agent.sh", `#!/bin/bash
+sleep 3
+echo "release notes" >> agent-output.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: release
+    watches: "tag:v*"
+    prompt: "Write release notes"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+		lineOK(dir, "init")
+		hookLog := filepath.Join(GinkgoT().TempDir(), "ref-hook.log")
+		patchHook(dir, "reference-transaction", "line run --refs >/dev/null 2>&1 &", binaryPath+" run --refs >>"+hookLog+" 2>&1 &")
+		patchHook(dir, "pre-commit", "line gate", binaryPath+" gate")
+		patchHook(dir, "commit-msg", "line gate", binaryPath+" gate")
+		git(dir, "tag", "v1.0.0")
+		Eventually(func() bool { return fileExists(dir, ".line/stations/release.in-progress") }, 10*time.Second, 50*time.Millisecond).Should(BeTrue())
+
+		// The commit's own ref update finds the tag's run in progress.
+		writeFile(dir, "a.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "--no-verify", "-m", "add a")
+		Eventually(func() string { return readFile(hookLog, "") }, 5*time.Second, 50*time.Millisecond).
+			Should(ContainSubstring("skipping (a run is in progress; it checks the watched refs when it finishes)"))
+
+		Eventually(func() string {
+			out, _ := gitMay(dir, "show", "line/stn/release:agent-output.txt")
+			return out
+		}, 15*time.Second, 200*time.Millisecond).Should(ContainSubstring("release notes"))
+		Expect(readFile(hookLog, "")).NotTo(ContainSubstring("terminating previous run"))
+	})
+
+	It("runs a station with trigger: manual only on demand [RUN-38]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: refactor
+    trigger: manual
+    prompt: "Big refactor"
+  - name: docs
+    prompt: "Write docs"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+
+		// Runs pass changes through it to the stations after it.
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("manual trigger, passing changes through (line run --station refactor)"))
+		Expect(git(dir, "rev-parse", "line/stn/refactor")).To(Equal(git(dir, "rev-parse", "line/stn/review")))
+		Expect(git(dir, "show", "line/stn/docs:agent-output.txt")).To(ContainSubstring("Write docs"))
+		Expect(lineOK(dir, "status")).To(MatchRegexp(`refactor\s.*\[up to date\] manual`))
+
+		out = lineOK(dir, "run", "--station", "refactor", "--range", "HEAD~1..HEAD")
+		Expect(out).To(ContainSubstring("station refactor"))
+		Expect(git(dir, "show", "line/stn/refactor:agent-output.txt")).To(ContainSubstring("Big refactor"))
+		Expect(git(dir, "log", "--format=%s", "line/stn/docs")).To(ContainSubstring("station refactor"))
+		Expect(lineOK(dir, "status")).To(MatchRegexp(`refactor\s.*manual reviewed 1 commit`))
+	})
+
+	It("coalesces commits arriving while a run settles into one cycle [RUN-39]", func() {
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+settings:
+  watches: master
+  debounce: 3s
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+
+		var first strings.Builder
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		cmd.Stdout, cmd.Stderr = &first, &first
+		Expect(cmd.Start()).To(Succeed())
+		Eventually(func() bool { return fileExists(dir, ".line/run.settling") }, 5*time.Second, 50*time.Millisecond).Should(BeTrue())
+
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+		head := git(dir, "rev-parse", "HEAD")
+		for range 2 {
+			Expect(lineOK(dir, "run")).To(ContainSubstring(fmt.Sprintf("run PID %d is about to start and will pick this commit up", cmd.Process.Pid)))
+		}
+
+		Expect(cmd.Wait()).To(Succeed(), first.String())
+		Expect(first.String()).To(ContainSubstring("waiting 3s for further commits (settings.debounce)"))
+		Expect(fileExists(dir, ".line/run.settling")).To(BeFalse())
+		Expect(strings.Split(strings.TrimSpace(readFile(dir, ".line/stations/review.history")), "\n")).To(HaveLen(1))
+		Expect(readFile(dir, ".line/stations/review.last-run")).To(ContainSubstring(`"range_to":"` + head + `"`))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), "debounce: 3s", "debounce: nope", 1))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.debounce: "nope" is not a duration (e.g. 2s)`))
+	})
+
+	// interruptedRun starts a line run whose agent writes part of its work
+	// and hangs, then kills the runner and the agent. The agent logs each of
+	// its runs to .line/agent-runs.
+	interruptedRun := func(onInterrupt string) {
+		runs := filepath.Join(dir, ".line", "agent-runs")
+		agent := writeMockAgentScript(dir, "interrupted-agent.sh", `#!/bin/bash
+PROMPT="${@: -1}"
+if [ ! -e "`+runs+`" ]; then
+  echo "half done" > notes.txt
+  echo "first run" > "`+runs+`"
+  sleep 30
+fi
+echo "$PROMPT" >> "`+runs+`"
+case "$PROMPT" in *"continue where it left off"*) grep -qx finished notes.txt || echo "finished" >> notes.txt ;; esac
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+    on_interrupt: `+onInterrupt+`
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		Expect(cmd.Start()).To(Succeed())
+		Eventually(func() bool { return fileExists(dir, ".line/agent-runs") }, 10*time.Second, 50*time.Millisecond).Should(BeTrue())
+		killBackground(dir, "review")
+		_ = cmd.Wait()
+	}
+
+	It("commits the changes of an interrupted agent with on_interrupt: commit [RUN-40]", func() {
+		interruptedRun("commit")
+
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("kept the changes of the run interrupted since"))
+		Expect(out).To(ContainSubstring("restored the changes of the interrupted run (on_interrupt: commit)"))
+		Expect(git(dir, "show", "line/stn/review:notes.txt")).To(Equal("half done"))
+		// The agent did not run again.
+		Expect(readFile(dir, ".line/agent-runs")).To(Equal("first run\n"))
+		_, err := gitMay(dir, "rev-parse", "--verify", "--quiet", lineGit.InterruptedRefName("review"))
+		Expect(err).To(HaveOccurred())
+		Expect(fileExists(dir, ".line/stations/review.in-progress")).To(BeFalse())
+	})
+
+	It("resumes an interrupted agent with on_interrupt: resume [RUN-40]", func() {
+		interruptedRun("resume")
+
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("restored the changes of the interrupted run (on_interrupt: resume)"))
+		Expect(git(dir, "show", "line/stn/review:notes.txt")).To(Equal("half done\nfinished"))
+		Expect(readFile(dir, ".line/agent-runs")).To(ContainSubstring("continue where it left off"))
+	})
+
+	It("starts an interrupted station again by default [RUN-40]", func() {
+		interruptedRun("discard")
+
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("discarding the changes of the run interrupted since"))
+		_, err := gitMay(dir, "show", "line/stn/review:notes.txt")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects bad line run --station invocations [RUN-38]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: refactor
+    trigger: manual
+    prompt: "Big refactor"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+
+		out, err := line(dir, "run", "--range", "HEAD~1..HEAD")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("--range needs --station"))
+		out, err = line(dir, "run", "--station", "nope")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown station "nope"`))
+		out, err = line(dir, "run", "--station", "refactor")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("station review has not run yet; run the line first"))
+		out, err = line(dir, "run", "--station", "review", "--range", "HEAD")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`range "HEAD" is not <from>..<to>`))
+	})
+
+	It("runs the line for a given commit whatever is checked out [RUN-49]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: docs
+    prompt: "Write docs"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		first := git(dir, "rev-parse", "HEAD")
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+		git(dir, "checkout", "-b", "feature")
+		writeFile(dir, "c.go", "package main\n")
+		gitCommit(dir, "add c")
+
+		out, err := line(dir, "trigger", "--commit", "HEAD")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("is not on master"))
+		out, err = line(dir, "trigger", "--commit", "nope")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown commit "nope"`))
+
+		// Only the review station, for the commits up to the first.
+		lineOK(dir, "trigger", "--commit", first, "--station", "review")
+		Expect(git(dir, "log", "--format=%(trailers:key=Triggered-By,valueonly)", "-1", "line/stn/review")).To(ContainSubstring(first))
+
+		lineOK(dir, "trigger", "--commit", "master")
+		Expect(git(dir, "show", "line/stn/docs:agent-output.txt")).To(ContainSubstring("Write docs"))
+		Expect(git(dir, "rev-parse", "--abbrev-ref", "HEAD")).To(Equal("feature"))
+
+		out = lineOK(dir, "trigger", "--commit", first)
+		Expect(out).To(ContainSubstring("was reviewed in the cycle for"))
+	})
+
+	It("runs an agent that hit its provider's rate limit again [RUN-50]", func() {
+		attempts := filepath.Join(dir, "attempts")
+		agent := writeMockAgentScript(dir, "limited-agent.sh", `#!/bin/bash
+sleep 1
+echo x >> `+attempts+`
+if [ "$(wc -l < `+attempts+`)" -lt 2 ]; then
+  echo "API Error: 429 Too Many Requests"
+  exit 1
+fi
+echo "agent was here" >> agent-output.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("agent hit its provider's rate limit, running it again in 10s (1/3)"))
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("agent was here"))
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("failed"))
+	})
+
+	It("does not run again an agent whose failure only mentions 429 or rate limits [RUN-50]", func() {
+		attempts := filepath.Join(dir, "attempts")
+		agent := writeMockAgentScript(dir, "failing-agent.sh", `#!/bin/bash
+sleep 1
+echo x >> `+attempts+`
+echo "ok   pkg/api  429 tests passed"
+echo "--- FAIL: TestRateLimit (0.01s)"
+echo "main.go:12: overloaded method Parse"
+echo "internal/ratelimit/ratelimit.go:12: undefined: x"
+echo "add a rate limiter to the client"
+exit 1
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+
+		out, _ := line(dir, "run")
+		Expect(out).NotTo(ContainSubstring("rate limit, running it again"))
+		Expect(readFile(dir, "attempts")).To(Equal("x\n"))
+		Expect(lineOK(dir, "status")).To(ContainSubstring("failed"))
+	})
+
+	It("does not run again an agent whose rate-limit error is not at the end of its output [RUN-50]", func() {
+		attempts := filepath.Join(dir, "attempts")
+		agent := writeMockAgentScript(dir, "failing-agent.sh", `#!/bin/bash
+sleep 1
+echo x >> `+attempts+`
+echo "API Error: 429 rate_limit_error, retrying"
+for i in 1 2 3 4 5 6; do echo "step $i done"; done
+echo "go build: internal/ratelimit/ratelimit.go:12: undefined: x"
+exit 1
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+
+		out, _ := line(dir, "run")
+		Expect(out).NotTo(ContainSubstring("rate limit, running it again"))
+		Expect(readFile(dir, "attempts")).To(Equal("x\n"))
+	})
+
+	It("paces agent starts with settings.agent_rate [RUN-50, CFG-20]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+  agent_rate: 60/min
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		// Two starts are owed already: the next waits about 3s.
+		writeFile(dir, ".line/agent-rate", fmt.Sprintf(`{"tokens": -2, "updated": %q}`, time.Now().Format(time.RFC3339Nano)))
+
+		out := lineOK(dir, "run")
+		Expect(out).To(MatchRegexp(`waiting [1-3]s for settings.agent_rate \(60/min\)`))
+		Expect(git(dir, "show", "line/stn/review:agent-output.txt")).To(ContainSubstring("Review code"))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), "agent_rate: 60/min", "agent_rate: 60/fortnight", 1))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.agent_rate: "60/fortnight" is not <n>/<s|min|hour> (e.g. 2/min)`))
+	})
+
+	It("rejects malformed watches [CFG-STN-14]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: release
+    watches: "v*"
+    prompt: "Write release notes"
+  - name: audit
+    watches: "branch:release/*"
+    approval: manual
+    prompt: "Audit"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[1].watches: "v*" must be tag:<pattern> or branch:<pattern>`))
+		Expect(out).To(ContainSubstring("stations[2].approval: manual is not supported for a station with watches"))
+	})
+
+	It("rejects unknown triggers [CFG-STN-15]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+settings:
+  watches: master
+stations:
+  - name: review
+    trigger: sometimes
+    prompt: "Review code"
+  - name: release
+    watches: "tag:v*"
+    trigger: manual
+    prompt: "Write release notes"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].trigger: "sometimes" is not one of auto, manual`))
+		Expect(out).To(ContainSubstring("stations[1].trigger: manual is not supported for a station with watches"))
+	})
+
+	It("records how a failed agent exited and the end of its output [RUN-41, STAT-18]", func() {
+		agent := writeMockAgentScript(dir, "killed-agent.sh", `#!/bin/bash
+sleep 1
+for i in $(seq 1 60); do echo "working on step $i"; done
+kill -9 $$
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+
+		out, _ := line(dir, "run")
+		Expect(out).To(ContainSubstring("agent exited with error: exit status 137 (killed)"))
+
+		Expect(lineOK(dir, "status")).To(MatchRegexp(`review .*\[failed\] agent exited 137 \(killed\)`))
+		show := lineOK(dir, "show", "review", "-n", "3")
+		Expect(show).To(ContainSubstring("Agent output (last 3 lines):\n  working on step 58\n  working on step 59\n  working on step 60"))
+
+		exit := readFile(dir, ".line/stations/review.agent-exit")
+		Expect(exit).To(ContainSubstring(`"code":137,"signal":"killed"`))
+		// At most 50 lines are kept.
+		Expect(exit).To(ContainSubstring(`"working on step 60"]`))
+		Expect(exit).NotTo(ContainSubstring(`"working on step 10"`))
+
+		out, _ = line(dir, "run", "--log-format", "json")
+		Expect(out).To(MatchRegexp(`"event":"station_done".*"exit_code":137,"signal":"killed"`))
+	})
+
+	It("flags running agents that have written nothing for settings.stall_after [RUN-42]", func() {
+		agent := writeMockAgentScript(dir, "quiet-agent.sh", `#!/bin/bash
+sleep 1
+echo "thinking"
+sleep 30
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+  stall_after: 2s
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		Expect(cmd.Start()).To(Succeed())
+		DeferCleanup(func() {
+			killBackground(dir, "review")
+			_ = cmd.Wait()
+		})
+
+		Eventually(func() string {
+			data, _ := os.ReadFile(filepath.Join(dir, ".line", "stations", "review.heartbeat"))
+			return string(data)
+		}, 15*time.Second, 200*time.Millisecond).Should(MatchRegexp(`"heartbeat_at":".*","output_bytes":[1-9]`))
+		Eventually(func() string { return lineOK(dir, "status") }, 15*time.Second, 500*time.Millisecond).
+			Should(MatchRegexp(`review .*\[agent running\] \(\d+s\) no output for \d+s`))
+		Expect(lineOK(dir, "show", "review")).To(MatchRegexp(`Status: +● agent running: no output for \d+s`))
+		Expect(lineOK(dir, "statusline")).To(MatchRegexp(`review \(no output for \d+s\)`))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), "stall_after: 2s", "stall_after: soon", 1))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.stall_after: "soon" is not a duration (e.g. 10m)`))
+	})
+
+	It("kills agents that write nothing for settings.stall_timeout [RUN-43]", func() {
+		agent := writeMockAgentScript(dir, "stuck-agent.sh", `#!/bin/bash
+sleep 1
+echo "thinking"
+sleep 60
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+  stall_timeout: 3s
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+
+		start := time.Now()
+		out, _ := line(dir, "run")
+		Expect(time.Since(start)).To(BeNumerically("<", 30*time.Second))
+		Expect(out).To(ContainSubstring("agent wrote no output for 3s (settings.stall_timeout), killed it"))
+		Expect(out).To(ContainSubstring("station review failed: agent failed: stalled: no output for 3s"))
+		Expect(lineOK(dir, "status")).To(MatchRegexp(`review .*\[failed\] stalled: no output for 3s`))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), "stall_timeout: 3s", "stall_timeout: 3", 1))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.stall_timeout: "3" is not a duration (e.g. 20m)`))
+	})
+
+	It("replays only the station's own commits after a force-push [RUN-44, CFG-16]", func() {
+		agent := writeMockAgentScript(dir, "station-agent.sh", `#!/bin/bash
+# Once per run, even if the agent is started again
+[ -z "$(git status --porcelain prompts.txt)" ] || exit 0
+echo "${@: -1}" | grep -o "Review code\|Write docs" >> prompts.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+		dropped := strings.TrimSpace(git(dir, "rev-parse", "--short", "HEAD"))
+		lineOK(dir, "run")
+
+		git(dir, "reset", "--hard", "HEAD~1")
+		writeFile(dir, "c.go", "package main\n")
+		gitCommit(dir, "add c")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("master was force-pushed, replaying the station's own commits since " + dropped + " (settings.on_force_push: rebase)"))
+
+		files := git(dir, "ls-tree", "--name-only", "line/stn/review")
+		Expect(files).To(ContainSubstring("c.go"))
+		Expect(files).NotTo(ContainSubstring("b.go"))
+		Expect(git(dir, "log", "--format=%s", "master..line/stn/review")).NotTo(ContainSubstring("add b"))
+		Expect(git(dir, "show", "line/stn/review:prompts.txt")).To(Equal("Review code\nReview code"))
+	})
+
+	It("starts the stations again from a force-pushed branch with on_force_push: reset [RUN-44, CFG-16]", func() {
+		agent := writeMockAgentScript(dir, "station-agent.sh", `#!/bin/bash
+# Once per run, even if the agent is started again
+[ -z "$(git status --porcelain prompts.txt)" ] || exit 0
+echo "${@: -1}" | grep -o "Review code\|Write docs" >> prompts.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+  on_force_push: reset
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: docs
+    prompt: "Write docs"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+		lineOK(dir, "run")
+
+		git(dir, "reset", "--hard", "HEAD~1")
+		writeFile(dir, "c.go", "package main\n")
+		gitCommit(dir, "add c")
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("master was force-pushed, discarding the station's commits (settings.on_force_push: reset)"))
+
+		for _, branch := range []string{"line/stn/review", "line/stn/docs"} {
+			Expect(git(dir, "ls-tree", "--name-only", branch)).NotTo(ContainSubstring("b.go"))
+		}
+		Expect(git(dir, "show", "line/stn/docs:prompts.txt")).To(Equal("Review code\nWrite docs"))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), "on_force_push: reset", "on_force_push: merge", 1))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.on_force_push: "merge" is not one of rebase, reset`))
+	})
+
+	It("runs settings.hooks once per cycle and aborts the cycle when one fails [RUN-45, CFG-17, STAT-19]", func() {
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+settings:
+  watches: master
+  hooks:
+    before_all: echo "fetching $LINE_WATCHES" && echo before >> .line/hooks.log
+    after_all: echo "after $LINE_RESULT $LINE_COMMIT" >> .line/hooks.log
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+		head := strings.TrimSpace(git(dir, "rev-parse", "HEAD"))
+
+		out := lineOK(dir, "run")
+		Expect(out).To(ContainSubstring("ran settings.hooks.before_all\nfetching master\n"))
+		Expect(out).To(ContainSubstring("ran settings.hooks.after_all"))
+		Expect(readFile(dir, ".line/hooks.log")).To(Equal("before\nafter ok " + head + "\n"))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), `echo "fetching $LINE_WATCHES"`, `echo "no network" && exit 3`, 1))
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		out, err := line(dir, "run")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.hooks.before_all failed, aborting the cycle: exit status 3\nno network\n"))
+		Expect(out).NotTo(ContainSubstring("running station review"))
+		Expect(readFile(dir, ".line/hooks.log")).To(Equal("before\nafter ok " + head + "\n"))
+		Expect(lineOK(dir, "status")).To(ContainSubstring("✗ last cycle aborted: settings.hooks.before_all failed: exit status 3"))
+
+		writeConfig(dir, strings.Replace(readFile(dir, "line.yaml"), `echo "no network" && exit 3`, "true", 1))
+		Expect(lineOK(dir, "run")).To(ContainSubstring("running station review"))
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("aborted"))
+	})
+
+	It("describes the run to the agent in LINE_* environment variables [RUN-46]", func() {
+		agent := writeMockAgentScript(dir, "env-agent.sh", `#!/bin/bash
+env | grep -E '^LINE_(RUN_ID|STATION|TRIGGER_COMMIT|RANGE)=' | sort > line-env.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "a.go", "package main\n")
+		gitCommit(dir, "add a")
+		first := strings.TrimSpace(git(dir, "rev-parse", "HEAD"))
+		lineOK(dir, "run")
+
+		env := git(dir, "show", "line/stn/review:line-env.txt")
+		Expect(env).To(ContainSubstring("LINE_RANGE=" + first + "\n"))
+		Expect(env).To(ContainSubstring("LINE_STATION=review\n"))
+		Expect(env).To(ContainSubstring("LINE_TRIGGER_COMMIT=" + first))
+		id := regexp.MustCompile(`LINE_RUN_ID=(\d{8}T\d{6}Z-[0-9a-f]{6})\n`).FindStringSubmatch(env)
+		Expect(id).NotTo(BeNil(), env)
+		Expect(lineOK(dir, "show", "review")).To(ContainSubstring("Run ID:    " + id[1]))
+		Expect(readFile(dir, ".line/stations/review.history")).To(ContainSubstring(`"id":"` + id[1] + `"`))
+
+		writeFile(dir, "b.go", "package main\n")
+		gitCommit(dir, "add b")
+		second := strings.TrimSpace(git(dir, "rev-parse", "HEAD"))
+		lineOK(dir, "run")
+		env = git(dir, "show", "line/stn/review:line-env.txt")
+		Expect(env).To(ContainSubstring("LINE_RANGE=" + first + ".." + second + "\n"))
+		Expect(env).NotTo(ContainSubstring(id[1]))
+	})
+
+	It("rejects unknown on_interrupt values [CFG-STN-16]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+settings:
+  watches: master
+stations:
+  - name: review
+    on_interrupt: retry
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].on_interrupt: "retry" is not one of discard, commit, resume`))
+	})
+
+	It("skips commits with [line skip] marker [RUN-9]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code [line skip]")
+		Expect(out).To(ContainSubstring("skipping"))
+	})
+
+	// RUN-10: Line runs should be independent of rebases on the watched branch
+	It("works correctly after a rebase on the watched branch [RUN-10]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		// First commit and run (via hook)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+
+		// Simulate a rebase by amending the last commit (triggers hooks again)
+		writeFile(dir, "code.go", "package main\n\nfunc main() {}\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "--amend", "-m", "first change amended")
+
+		Expect(currentBranch(dir)).To(Equal("master"))
+	})
+
+	// RUN-17: Kill switch via .line/disabled
+	It("does nothing while .line/disabled exists [RUN-17]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+		writeFile(dir, ".line/disabled", "incident in progress\n")
+
+		writeFile(dir, "code.go", "package main\n")
+		out := gitCommit(dir, "add code")
+		Expect(out).To(ContainSubstring("pipeline disabled"))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/"))
+
+		// Removing the file re-enables the line
+		Expect(os.Remove(filepath.Join(dir, ".line", "disabled"))).To(Succeed())
+		writeFile(dir, "more.go", "package main\n")
+		gitCommit(dir, "add more code")
+		Expect(git(dir, "branch")).To(ContainSubstring("line/stn/review"))
+	})
+
+	// RUN-17: Kill switch via LINE_DISABLED=1
+	It("does nothing when LINE_DISABLED=1 is set [RUN-17]", func() {
+		writeRunConfig(dir, agentScript)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		cmd := exec.Command("git", "commit", "-m", "add code")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LINE_DISABLED=1")
+		out, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("pipeline disabled"))
+		Expect(git(dir, "branch")).NotTo(ContainSubstring("line/stn/"))
 	})
 
 	// RUN-11: New run terminates previous run
@@ -541,7 +2207,7 @@ stations:
 		writeFile(dir, "code.go", "package main\n")
 		gitCommit(dir, "add code")
 
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("\033[31m"))
 		Expect(out).To(ContainSubstring("failed"))
 	})
@@ -625,7 +2291,7 @@ stations:
 		git(dir, "add", "user-work.txt")
 		git(dir, "commit", "-m", "user commit while line runs [skip line]")
 
-		// A worktree dir should exist under /tmp/line-*/review
+		// A worktree dir should exist under the cache dir (PATH-1)
 		baseDir, err := lineGit.WorktreeBaseDir(dir)
 		Expect(err).NotTo(HaveOccurred())
 		wtPath := filepath.Join(baseDir, "review")
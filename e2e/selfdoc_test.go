@@ -95,6 +95,106 @@ stations:
 		Expect(err).To(HaveOccurred())
 		Expect(out).To(ContainSubstring("no resolvable command"))
 	})
+
+	It("reports malformed commit authorship and signing settings [VAL-1, CFG-6, CFG-7]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  commit_author: line@example.com
+  commit_signing:
+    format: pgp
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.commit_author"))
+		Expect(out).To(ContainSubstring("settings.commit_signing.key"))
+		Expect(out).To(ContainSubstring("settings.commit_signing.format"))
+	})
+
+	It("reports invalid station verify and cache settings [VAL-1, CFG-STN-7, CFG-STN-10]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    on_verify_failure: retry
+    max_repair_attempts: -1
+    cache: ["../target"]
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("stations[0].on_verify_failure"))
+		Expect(out).To(ContainSubstring("stations[0].max_repair_attempts"))
+		Expect(out).To(ContainSubstring("no effect without verify"))
+		Expect(out).To(ContainSubstring(`stations[0].cache[0]: "../target" must be a relative path inside the repository`))
+	})
+
+	It("reports an unknown on_conflict strategy [VAL-1, CFG-5]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  on_conflict: merge
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.on_conflict"))
+	})
+
+	It("reports settings.worktree paths outside the repository [VAL-1, CFG-11]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  worktree:
+    copy: ["../secrets.env"]
+    symlink: ["/opt/node_modules"]
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.worktree.copy[0]: "../secrets.env" must be a relative path inside the repository`))
+		Expect(out).To(ContainSubstring(`settings.worktree.symlink[0]: "/opt/node_modules" must be a relative path inside the repository`))
+	})
+
+	It("reports invalid machine_commits patterns [VAL-1, CFG-10]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  machine_commits:
+    authors: ["renovate[bot"]
+    trailers: ["Triggered-By:"]
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.machine_commits.authors[0]: invalid regular expression"))
+		Expect(out).To(ContainSubstring(`settings.machine_commits.trailers[0]: "Triggered-By:" is not a trailer key`))
+	})
 })
 
 var _ = Describe("line explain", func() {
@@ -0,0 +1,123 @@
+package e2e_test
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line serve", func() {
+	var upstream, host string
+
+	BeforeEach(func() {
+		upstream = tempRepo()
+		parent, err := os.MkdirTemp("", "line-host-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(parent) })
+		git(parent, "clone", "--quiet", upstream, "host")
+		host = filepath.Join(parent, "host")
+		git(host, "config", "user.email", "test@test.com")
+		git(host, "config", "user.name", "Test")
+		git(host, "config", "commit.gpgsign", "false")
+		writeConfig(host, `agent:
+  command: `+writeMockAgent(host)+`
+  args: ["-p"]
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+	})
+
+	// startServe starts line serve --github-webhook in the host clone and
+	// returns the URL it listens on.
+	startServe := func() string {
+		cmd := exec.Command(binaryPath, "serve", "--github-webhook", "--addr", "127.0.0.1:0")
+		cmd.Dir = host
+		cmd.Env = append(os.Environ(), "LINE_WEBHOOK_SECRET=s3cret")
+		stdout, err := cmd.StdoutPipe()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmd.Start()).To(Succeed())
+		DeferCleanup(func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			killBackground(host, "review")
+		})
+		first, err := bufio.NewReader(stdout).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		url, ok := strings.CutPrefix(strings.TrimSpace(first), "Listening for GitHub push events on ")
+		Expect(ok).To(BeTrue(), first)
+		return url
+	}
+
+	// deliver posts a webhook delivery signed with secret and returns the
+	// response status and body.
+	deliver := func(url, event, body, secret string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("X-GitHub-Event", event)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+
+	It("fetches pushes to the watched branch and runs the line for them [SERVE-1] [SERVE-2]", func() {
+		git(host, "switch", "--quiet", "--detach")
+		url := startServe()
+
+		writeFile(upstream, "a.go", "package main\n")
+		gitCommit(upstream, "add a")
+		sha := git(upstream, "rev-parse", "HEAD")
+		push := fmt.Sprintf(`{"ref": "refs/heads/master", "after": %q}`, sha)
+
+		status, _ := deliver(url, "push", push, "wrong")
+		Expect(status).To(Equal(http.StatusUnauthorized))
+		status, body := deliver(url, "ping", `{"zen": "Keep it logically awesome."}`, "s3cret")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body).To(ContainSubstring("pong"))
+		status, body = deliver(url, "push", fmt.Sprintf(`{"ref": "refs/heads/feature", "after": %q}`, sha), "s3cret")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(body).To(ContainSubstring("ignored: nothing watches refs/heads/feature"))
+
+		status, body = deliver(url, "push", push, "s3cret")
+		Expect(status).To(Equal(http.StatusAccepted), body)
+		Expect(body).To(ContainSubstring("line trigger --commit " + sha))
+		Expect(git(host, "rev-parse", "master")).To(Equal(sha))
+		Eventually(func() string {
+			out, _ := gitMay(host, "log", "-1", "--format=%(trailers:key=Triggered-By,valueonly)", "line/stn/review")
+			return out
+		}, 20*time.Second, 200*time.Millisecond).Should(ContainSubstring(sha))
+	})
+
+	It("refuses to start without a secret or with the watched branch checked out [SERVE-1]", func() {
+		out, err := line(host, "serve", "--github-webhook")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("LINE_WEBHOOK_SECRET is not set"))
+
+		out, err = lineWithEnv(host, []string{"LINE_WEBHOOK_SECRET=s3cret"}, "serve", "--github-webhook")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("master is checked out here"))
+
+		out, err = line(host, "serve")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("line serve needs a mode: --github-webhook"))
+	})
+})
@@ -0,0 +1,89 @@
+package e2e_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line show", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+	})
+
+	// SHOW-1: One-screen summary of a station after a run
+	It("summarises status, last run, commit, watched head and pending counts [SHOW-1]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		lineOK(dir, "run")
+
+		out := lineOK(dir, "show", "review")
+		Expect(out).To(ContainSubstring("Station:   review (line/stn/review)"))
+		Expect(out).To(ContainSubstring("✓ up to date"))
+		Expect(out).To(MatchRegexp(`Last run:  \d{4}-\d\d-\d\d \d\d:\d\d:\d\d, took \d+s — ok`))
+		Expect(out).To(ContainSubstring("assembly-line: station review [skip line]"))
+		Expect(out).To(ContainSubstring("Watched:   master @ " + shortRef(dir) + " add code"))
+		Expect(out).To(ContainSubstring("Pending:   0 behind master, 1 unpicked"))
+	})
+
+	// SHOW-1: Stations that have never run
+	It("reports a station that has never run [SHOW-1]", func() {
+		writeDefaultConfig(dir)
+
+		out := lineOK(dir, "show", "review")
+		Expect(out).To(ContainSubstring("○ pending"))
+		Expect(out).To(ContainSubstring("Last run:  never"))
+		Expect(out).To(ContainSubstring("Commit:    -"))
+	})
+
+	// SHOW-2: Log tail, including verification output
+	It("shows the tail of the station log [SHOW-2]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    verify: "echo 'verify says no'; exit 1"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		lineOK(dir, "run")
+
+		out := lineOK(dir, "show", "review", "-n", "3")
+		Expect(out).To(ContainSubstring("failed verification"))
+		Expect(out).To(ContainSubstring("Log (last"))
+		Expect(out).To(ContainSubstring("verify says no"))
+	})
+
+	// SHOW-1: Unknown stations are an error
+	It("errors for an unknown station [SHOW-1]", func() {
+		writeDefaultConfig(dir)
+
+		out, err := line(dir, "show", "nope")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown station "nope"`))
+	})
+})
@@ -0,0 +1,83 @@
+package e2e_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line simulate", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agent := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: "`+agent+`"
+settings:
+  watches: master
+stations:
+  - name: review
+    prompt: "Review the latest changes"
+`)
+		git(dir, "add", "line.yaml")
+		gitCommit(dir, "add line config")
+		writeFile(dir, "main.go", "package main\n")
+		git(dir, "add", "main.go")
+		gitCommit(dir, "add main")
+	})
+
+	// SIM-1: Runs the station in a throwaway worktree and shows the context and diff
+	It("prints the context, runs the agent and shows its changes without touching the line [SIM-1]", func() {
+		head := git(dir, "rev-parse", "--short", "HEAD")
+		out := lineOK(dir, "simulate", "review")
+		Expect(out).To(ContainSubstring("Station:  review"))
+		Expect(out).To(ContainSubstring("detached at " + head))
+		Expect(out).To(ContainSubstring("Reviews:  1 commit"))
+		Expect(out).To(ContainSubstring("add main"))
+		Expect(out).To(ContainSubstring("  IMPORTANT: Do NOT commit any changes."))
+		Expect(out).To(ContainSubstring("  Review the latest changes"))
+		Expect(out).To(ContainSubstring("mock-agent ran with prompt"))
+		Expect(out).To(ContainSubstring("--- changes ---"))
+		Expect(out).To(ContainSubstring("+agent was here"))
+
+		Expect(git(dir, "branch", "--list", "line/*")).To(BeEmpty())
+		Expect(fileExists(dir, ".line")).To(BeFalse())
+		Expect(fileExists(dir, "agent-output.txt")).To(BeFalse())
+		Expect(git(dir, "worktree", "list")).NotTo(ContainSubstring("line-simulate-"))
+		Expect(git(dir, "rev-parse", "--short", "HEAD")).To(Equal(head))
+	})
+
+	// SIM-1: --range reviews a chosen range; --dry-run stops before the agent
+	It("prints the context for a commit range without running the agent [SIM-1]", func() {
+		out := lineOK(dir, "simulate", "review", "--range", "HEAD~2..HEAD", "--dry-run")
+		Expect(out).To(ContainSubstring("Reviews:  2 commits"))
+		Expect(out).To(ContainSubstring("add line config"))
+		Expect(out).To(ContainSubstring("add main"))
+		Expect(out).NotTo(ContainSubstring("mock-agent ran"))
+		Expect(out).NotTo(ContainSubstring("--- changes ---"))
+	})
+
+	// SIM-1: --patch reviews a synthetic commit
+	It("reviews a patch as a synthetic commit [SIM-1]", func() {
+		writeFile(dir, "main.go", "package main\n\nfunc main() {}\n")
+		patch := git(dir, "diff")
+		git(dir, "checkout", "main.go")
+		patchFile := filepath.Join(GinkgoT().TempDir(), "sim-change.patch")
+		writeFile(filepath.Dir(patchFile), filepath.Base(patchFile), patch+"\n")
+
+		out := lineOK(dir, "simulate", "review", "--patch", patchFile, "--dry-run")
+		Expect(out).To(ContainSubstring("Reviews:  1 commit"))
+		Expect(out).To(ContainSubstring("line simulate: sim-change.patch"))
+		Expect(git(dir, "log", "--oneline")).NotTo(ContainSubstring("line simulate"))
+		Expect(git(dir, "status", "--porcelain")).To(BeEmpty())
+	})
+
+	// SIM-1: Unknown stations are rejected
+	It("rejects an unknown station [SIM-1]", func() {
+		out, err := line(dir, "simulate", "nope")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown station "nope"`))
+	})
+})
@@ -0,0 +1,95 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line stats", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		agent := writeMockAgent(dir)
+		noop := writeMockAgentScript(dir, "noop-agent.sh", "#!/bin/sh\nexit 0\n")
+		writeConfig(dir, `agent:
+  command: `+agent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: lint
+    command: `+noop+`
+    prompt: "Lint code"
+`)
+		for _, msg := range []string{"first", "second"} {
+			writeFile(dir, msg+".go", "package main\n")
+			gitCommit(dir, msg)
+			lineOK(dir, "run")
+		}
+	})
+
+	It("reports reviews, noop ratio, latency and lines per station [STATS-1]", func() {
+		out := lineOK(dir, "stats")
+		Expect(out).To(MatchRegexp(`Station\s+Reviewed\s+Modified\s+Noop\s+Noop ratio\s+Avg latency\s+Commits\s+Added\s+Deleted`))
+		Expect(out).To(MatchRegexp(`review\s+2\s+2\s+0\s+-\s+\d+s\s+2\s+\+\d+\s+-0`))
+		Expect(out).To(MatchRegexp(`lint\s+2\s+0\s+2\s+100%\s+-\s+0\s+\+0\s+-0`))
+		Expect(out).To(ContainSubstring("Lines changed by week:"))
+		Expect(out).To(MatchRegexp(`\d{4}-\d{2}-\d{2}\s+review\s+2\s+\+\d+\s+-0`))
+
+		Expect(lineOK(dir, "stats", "--by", "day")).To(ContainSubstring("Lines changed by day:"))
+	})
+
+	It("prints JSON for dashboards [STATS-1]", func() {
+		var report struct {
+			By       string `json:"by"`
+			Stations []struct {
+				Station    string   `json:"station"`
+				Reviewed   int      `json:"reviewed"`
+				Modified   int      `json:"modified"`
+				Noop       int      `json:"noop"`
+				Commits    int      `json:"commits"`
+				Added      int      `json:"added"`
+				AvgLatency *float64 `json:"avg_latency_seconds"`
+				Periods    []struct {
+					Start   string `json:"start"`
+					Commits int    `json:"commits"`
+				} `json:"periods"`
+			} `json:"stations"`
+		}
+		Expect(json.Unmarshal([]byte(lineOK(dir, "stats", "--json", "--by", "day")), &report)).To(Succeed())
+		Expect(report.By).To(Equal("day"))
+		Expect(report.Stations).To(HaveLen(2))
+
+		review := report.Stations[0]
+		Expect(review.Station).To(Equal("review"))
+		Expect([]int{review.Reviewed, review.Modified, review.Noop, review.Commits}).To(Equal([]int{2, 2, 0, 2}))
+		Expect(review.Added).To(BeNumerically(">", 0))
+		Expect(review.AvgLatency).NotTo(BeNil())
+		Expect(review.Periods).To(HaveLen(1))
+		Expect(review.Periods[0].Start).To(Equal(time.Now().Format(time.DateOnly)))
+		Expect(review.Periods[0].Commits).To(Equal(2))
+
+		lint := report.Stations[1]
+		Expect([]int{lint.Reviewed, lint.Modified, lint.Noop, lint.Commits}).To(Equal([]int{2, 0, 2, 0}))
+		Expect(lint.AvgLatency).To(BeNil())
+		Expect(lint.Periods).To(BeEmpty())
+	})
+
+	It("only counts runs and commits inside the --since window [STATS-1]", func() {
+		out := lineOK(dir, "stats", "--since", "0s")
+		Expect(out).To(MatchRegexp(`review\s+0\s+0\s+0\s+-\s+-\s+0`))
+		Expect(out).NotTo(ContainSubstring("Lines changed"))
+
+		out, err := line(dir, "stats", "--by", "month")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`--by: "month" is not one of day, week`))
+	})
+})
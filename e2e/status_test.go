@@ -2,6 +2,7 @@ package e2e_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -46,9 +47,69 @@ var _ = Describe("line status", func() {
 		Expect(out).To(ContainSubstring("pending"))
 	})
 
+	// STAT-16: Commits skipped since the last run are summarised with reasons
+	It("summarises commits skipped since the last run [STAT-16, RUN-25]", func() {
+		writeFile(dir, ".lineignore", "*.log\n")
+		installHooksForTest(dir)
+		gitCommit(dir, "add config and lineignore")
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("skipped"))
+
+		writeFile(dir, "debug.log", "log stuff\n")
+		gitCommit(dir, "add log file")
+		writeFile(dir, "a.txt", "a\n")
+		gitCommit(dir, "add a [skip ci]")
+		writeFile(dir, "b.txt", "b\n")
+		gitCommit(dir, "add b [skip ci]")
+
+		Expect(lineOK(dir, "status")).To(ContainSubstring("skipped 3 commits since the last run (2 [skip ci], 1 ignored paths)"))
+
+		// The next run starts a fresh tally
+		writeFile(dir, "c.txt", "c\n")
+		gitCommit(dir, "add c")
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("skipped"))
+	})
+
+	// STAT-17: Each station shows the watched-branch range its last run reviewed
+	It("shows the commit range the last run reviewed [STAT-17, RUN-29]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, ".gitignore", "/.line/\n")
+		gitCommit(dir, "add config")
+		first := git(dir, "rev-parse", "HEAD")
+		lineOK(dir, "run")
+		Expect(lineOK(dir, "status")).To(ContainSubstring("reviewed 2 commits (up to " + first[:7] + ")"))
+
+		writeFile(dir, "a.txt", "a\n")
+		gitCommit(dir, "add a")
+		writeFile(dir, "b.txt", "b\n")
+		gitCommit(dir, "add b")
+		last := git(dir, "rev-parse", "HEAD")
+		lineOK(dir, "run")
+
+		reviewed := "reviewed 2 commits (" + first[:7] + ".." + last[:7] + ")"
+		Expect(lineOK(dir, "status")).To(ContainSubstring(reviewed))
+		Expect(lineOK(dir, "show", "review")).To(MatchRegexp(`Reviewed:\s+2 commits \(` + first[:7] + `\.\.` + last[:7] + `\)`))
+		Expect(git(dir, "log", "-1", "--format=%b", "line/stn/review")).To(HavePrefix("Reviewed 2 commits (" + first[:7] + ".." + last[:7] + ")."))
+
+		var run map[string]any
+		Expect(json.Unmarshal([]byte(readFile(dir, ".line/stations/review.last-run")), &run)).To(Succeed())
+		Expect(run["range_from"]).To(Equal(first))
+		Expect(run["range_to"]).To(Equal(last))
+	})
+
 	// STAT-2: Pending status is colour-coded yellow
 	It("colour-codes pending status as yellow [STAT-2]", func() {
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("\033[93m"))
 		Expect(out).To(ContainSubstring("pending"))
 	})
@@ -73,7 +134,7 @@ stations:
 
 		lineOK(dir, "run")
 
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("up to date"))
 		// STAT-2: up to date is green
 		Expect(out).To(ContainSubstring("\033[32m"))
@@ -82,7 +143,7 @@ stations:
 
 	// STAT-3: Line runner indicator at the top with ⏸/▶ symbols
 	It("shows ⏸ indicator and config filename when no runner is active [STAT-3]", func() {
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("\033[90m⏸\033[0m"))
 		Expect(out).To(ContainSubstring("line.yaml"))
 	})
@@ -123,7 +184,7 @@ stations:
 		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
 
 		// Query status while agent is running
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 
 		// Should show orange-coloured "agent running"
 		Expect(out).To(ContainSubstring("\033[33m"))
@@ -168,7 +229,7 @@ stations:
 			return fileExists(dir, ".line/run.pid")
 		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
 
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("\033[32m▶\033[0m"))
 		Expect(out).To(ContainSubstring("line.yaml"))
 	})
@@ -201,7 +262,7 @@ stations:
 		os.RemoveAll(filepath.Join(dir, ".line"))
 
 		// Status should STILL show "up to date" because it's computed from git
-		out = lineOK(dir, "status")
+		out = lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("up to date"))
 
 		// And line should show inactive (no PID file, derived on-demand)
@@ -239,7 +300,7 @@ stations:
 
 		lineOK(dir, "run")
 
-		out := lineOK(dir, "status")
+		out := lineColorOK(dir, "status")
 		Expect(out).To(ContainSubstring("✓"))
 		Expect(out).To(ContainSubstring("\033[32m"))
 		Expect(out).To(ContainSubstring("✓"))
@@ -396,6 +457,7 @@ stations:
     prompt: "dry"
 `)
 		writeFile(dir, "code.go", "package main\n")
+		writeFile(dir, ".gitignore", "/.line/\n")
 		git(dir, "add", ".")
 		git(dir, "commit", "-m", "add code")
 
@@ -418,4 +480,18 @@ stations:
 		Expect(out).NotTo(ContainSubstring("pending"),
 			"no station should be pending after line-rebase picks up all station work")
 	})
+
+	// STAT-11: Kill switch banner in status and statusline
+	It("shows a disabled banner while the kill switch is engaged [STAT-11, RUN-17]", func() {
+		Expect(lineOK(dir, "status")).NotTo(ContainSubstring("PIPELINE DISABLED"))
+
+		writeFile(dir, ".line/disabled", "")
+
+		out := lineColorOK(dir, "status")
+		Expect(out).To(ContainSubstring("\033[31mPIPELINE DISABLED"))
+
+		sl := lineOK(dir, "statusline")
+		Expect(sl).To(ContainSubstring("disabled"))
+		Expect(sl).To(ContainSubstring("○ review"))
+	})
 })
@@ -2,8 +2,12 @@ package e2e_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -96,6 +100,209 @@ stations:
 		out := lineOK(dir, "statusline")
 		Expect(out).NotTo(ContainSubstring("/line-rebase"))
 	})
+
+	// SL-4: Rendered statusline is cached, keyed on branch heads and state
+	It("caches the rendered statusline and invalidates it when refs move [SL-4]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		lineOK(dir, "run")
+
+		first := lineOK(dir, "statusline")
+		Expect(first).To(ContainSubstring("/line-rebase"))
+		Expect(fileExists(dir, ".line/statusline-cache")).To(BeTrue())
+		Expect(lineOK(dir, "statusline")).To(Equal(first))
+
+		// Picking up the station commits moves master, so the cached
+		// line must not be reused.
+		lineOK(dir, "rebase")
+		Expect(lineOK(dir, "statusline")).NotTo(ContainSubstring("/line-rebase"))
+	})
+
+	// SL-5: Rendering variants for shell prompts, limited fonts and no color
+	It("renders plain, prompt and colorless variants [SL-5]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+		lineOK(dir, "run")
+
+		Expect(lineOK(dir, "statusline", "--format", "plain", "--no-color")).To(Equal(
+			"|| + review + cleanup | line changes available - /line-preview or /line-rebase"))
+		Expect(lineOK(dir, "statusline", "--format", "prompt", "--no-color")).To(Equal("⏸ ✓✓ ↑"))
+
+		colored := lineOK(dir, "statusline", "--format", "prompt")
+		Expect(colored).To(ContainSubstring("\033["))
+		Expect(regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(colored, "")).To(Equal("⏸ ✓✓ ↑"))
+		out, err := lineWithEnv(dir, []string{"NO_COLOR=1"}, "statusline")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).NotTo(ContainSubstring("\033["))
+		Expect(out).To(ContainSubstring("✓ review ✓ cleanup"))
+
+		// Each variant is cached separately, so they don't evict each other
+		Expect(fileExists(dir, ".line/statusline-cache-prompt-nocolor")).To(BeTrue())
+		Expect(fileExists(dir, ".line/statusline-cache-plain-nocolor")).To(BeTrue())
+
+		out, err = line(dir, "statusline", "--format", "fancy")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`--format: "fancy" is not one of claude, plain, prompt`))
+	})
+
+	// SL-6: settings.statusline themes symbols and colors and caps the width
+	It("applies the statusline theme from settings [SL-6]", func() {
+		config := `settings:
+  watches: master
+  statusline:
+    symbols: {pending: "..", idle: "zz"}
+    colors: {pending: "208", idle: none}
+%s
+stations:
+  - name: review
+  - name: documentation
+`
+		writeConfig(dir, fmt.Sprintf(config, ""))
+
+		Expect(lineOK(dir, "statusline")).To(Equal("zz \033[38;5;208m.. review\033[0m \033[38;5;208m.. documentation\033[0m"))
+		// --format plain keeps its ASCII symbols
+		Expect(lineOK(dir, "statusline", "--format", "plain", "--no-color")).To(Equal("|| - review - documentation"))
+
+		writeConfig(dir, fmt.Sprintf(config, "    max_width: 20"))
+		Expect(lineOK(dir, "statusline", "--no-color")).To(Equal("zz .. review .. doc…"))
+
+		writeConfig(dir, fmt.Sprintf(config, "    max_width: 24\n    truncate: names"))
+		Expect(lineOK(dir, "statusline", "--no-color")).To(Equal("zz .. review .. documen…"))
+		writeConfig(dir, fmt.Sprintf(config, "    max_width: 16\n    truncate: names"))
+		Expect(lineOK(dir, "statusline", "--no-color")).To(Equal("zz .. re… .. do…"))
+
+		writeConfig(dir, `settings:
+  watches: master
+  statusline:
+    colors: {pending: mauve, waiting: red}
+    max_width: -1
+    truncate: wrap
+stations:
+  - name: review
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.statusline.colors.pending: "mauve" is not a color name or a number from 0 to 255`))
+		Expect(out).To(ContainSubstring("settings.statusline.colors.waiting: not a state"))
+		Expect(out).To(ContainSubstring("settings.statusline.max_width: must not be negative"))
+		Expect(out).To(ContainSubstring(`settings.statusline.truncate: "wrap" is not one of end, names, middle, active`))
+	})
+
+	// SL-7: The statusline fits the terminal width from stdin JSON or COLUMNS
+	It("truncates to the terminal width [SL-7]", func() {
+		config := `agent:
+  command: "true"
+settings:
+  watches: master
+%s
+stations:
+  - name: review
+  - name: lint
+  - name: docs
+    command: "false"
+`
+		writeConfig(dir, fmt.Sprintf(config, ""))
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add config")
+		line(dir, "run")
+
+		out, err := lineWithEnv(dir, []string{"COLUMNS=100"}, "statusline", "--no-color")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("⏸ ✓ review ✓ lint ✗ docs"))
+		out, err = lineWithEnv(dir, []string{"COLUMNS=10"}, "statusline", "--no-color")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("⏸ ✓ revie…"))
+
+		// Middle truncation keeps both ends; the width comes from stdin JSON
+		writeConfig(dir, fmt.Sprintf(config, "  statusline:\n    truncate: middle"))
+		cmd := exec.Command(binaryPath, "statusline", "--no-color")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "COLUMNS=")
+		cmd.Stdin = strings.NewReader(`{"columns": 12, "model": {"id": "x"}}`)
+		stdout, err := cmd.Output()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(stdout)).To(Equal("⏸ ✓ re… docs"))
+
+		// Abbreviated mode hides the up-to-date stations
+		writeConfig(dir, fmt.Sprintf(config, "  statusline:\n    truncate: active"))
+		out, err = lineWithEnv(dir, []string{"COLUMNS=20"}, "statusline", "--no-color")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("⏸ ✗ docs +2 ✓"))
+	})
+
+	// SL-8: A compact chain layout for hosts with little room
+	It("renders the compact layout [SL-8]", func() {
+		config := `agent:
+  command: "true"
+settings:
+  watches: master
+%s
+stations:
+  - name: review
+  - name: lint
+  - name: docs
+    command: "false"
+`
+		writeConfig(dir, fmt.Sprintf(config, ""))
+		writeFile(dir, ".gitignore", "/.line/\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add config")
+		line(dir, "run")
+
+		Expect(lineOK(dir, "statusline", "--compact", "--no-color")).To(Equal("⏸ master ▸ review✓ ▸ lint✓ ▸ docs✗"))
+		Expect(lineOK(dir, "statusline", "--compact", "--format", "plain", "--no-color")).To(Equal("|| master > review+ > lint+ > docsx"))
+		Expect(fileExists(dir, ".line/statusline-cache-compact-nocolor")).To(BeTrue())
+
+		writeConfig(dir, fmt.Sprintf(config, "  statusline:\n    layout: compact"))
+		Expect(lineOK(dir, "statusline", "--no-color")).To(Equal("⏸ master ▸ review✓ ▸ lint✓ ▸ docs✗"))
+		// --format prompt keeps its own layout
+		Expect(lineOK(dir, "statusline", "--format", "prompt", "--no-color")).To(Equal("⏸ ✓✓✗"))
+
+		writeConfig(dir, fmt.Sprintf(config, "  statusline:\n    layout: tree"))
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`settings.statusline.layout: "tree" is not one of full, compact`))
+	})
+
+	// SL-4: The statusline never creates state in a repo the line has not run in
+	It("does not create the .line directory [SL-4]", func() {
+		writeDefaultConfig(dir)
+
+		lineOK(dir, "statusline")
+		Expect(fileExists(dir, ".line")).To(BeFalse())
+	})
 })
 
 var _ = Describe("line init statusline", func() {
@@ -23,6 +23,12 @@ var _ = BeforeSuite(func() {
 
 	binaryPath = filepath.Join(tmpDir, "line")
 
+	// Keep the developer's global line config (CFG-9), logs and worktrees
+	// (PATH-1) out of the specs.
+	Expect(os.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))).To(Succeed())
+	Expect(os.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))).To(Succeed())
+	Expect(os.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))).To(Succeed())
+
 	// Find the module root (where go.mod lives)
 	modRoot, err := filepath.Abs(filepath.Join("..", "."))
 	Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,203 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// otlpSpan is the part of an OTLP/JSON span the tests look at.
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s otlpSpan) attr(key string) string {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value.StringValue
+		}
+	}
+	return ""
+}
+
+var _ = Describe("tracing", func() {
+	var (
+		dir      string
+		server   *httptest.Server
+		mu       sync.Mutex
+		paths    []string
+		headers  []http.Header
+		service  []string
+		spans    []otlpSpan
+		respCode int
+	)
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		paths, headers, service, spans, respCode = nil, nil, nil, nil, http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req struct {
+				ResourceSpans []struct {
+					Resource struct {
+						Attributes []struct {
+							Key   string `json:"key"`
+							Value struct {
+								StringValue string `json:"stringValue"`
+							} `json:"value"`
+						} `json:"attributes"`
+					} `json:"resource"`
+					ScopeSpans []struct {
+						Spans []otlpSpan `json:"spans"`
+					} `json:"scopeSpans"`
+				} `json:"resourceSpans"`
+			}
+			_ = json.Unmarshal(body, &req)
+			mu.Lock()
+			defer mu.Unlock()
+			paths = append(paths, r.URL.Path)
+			headers = append(headers, r.Header)
+			for _, rs := range req.ResourceSpans {
+				for _, a := range rs.Resource.Attributes {
+					if a.Key == "service.name" {
+						service = append(service, a.Value.StringValue)
+					}
+				}
+				for _, ss := range rs.ScopeSpans {
+					spans = append(spans, ss.Spans...)
+				}
+			}
+			w.WriteHeader(respCode)
+		}))
+		DeferCleanup(server.Close)
+
+		writeConfig(dir, `agent:
+  command: `+writeMockAgent(dir)+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    command: `+writeFailingMockAgent(dir)+`
+    prompt: "Clean up code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+	})
+
+	named := func(name string) otlpSpan {
+		for _, s := range spans {
+			if s.Name == name {
+				return s
+			}
+		}
+		Fail("no span named " + name)
+		return otlpSpan{}
+	}
+
+	It("exports run, station, agent and git spans over OTLP/HTTP [TRACE-1]", func() {
+		out, err := lineWithEnv(dir, []string{
+			"OTEL_EXPORTER_OTLP_ENDPOINT=" + server.URL,
+			"OTEL_EXPORTER_OTLP_HEADERS=x-api-key=secret%20key",
+			"OTEL_SERVICE_NAME=my-line",
+		}, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).NotTo(ContainSubstring("tracing:"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(paths).To(Equal([]string{"/v1/traces"}))
+		Expect(headers[0].Get("Content-Type")).To(Equal("application/json"))
+		Expect(headers[0].Get("X-Api-Key")).To(Equal("secret key"))
+		Expect(service).To(Equal([]string{"my-line"}))
+
+		run := named("line run")
+		Expect(run.ParentSpanID).To(BeEmpty())
+		Expect(run.attr("line.watches")).To(Equal("master"))
+		Expect(run.attr("line.commit")).To(HaveLen(40))
+
+		review := named("station review")
+		Expect(review.TraceID).To(Equal(run.TraceID))
+		Expect(review.ParentSpanID).To(Equal(run.SpanID))
+		Expect(review.attr("line.station")).To(Equal("review"))
+		Expect(review.Status.Code).To(Equal(0))
+
+		agent := named("agent review")
+		Expect(agent.ParentSpanID).To(Equal(review.SpanID))
+		Expect(agent.attr("process.command")).To(ContainSubstring("mock-agent"))
+
+		commit := named("git commit")
+		Expect(commit.ParentSpanID).To(Equal(review.SpanID))
+		Expect(commit.attr("git.args")).To(ContainSubstring("commit"))
+		for _, s := range spans {
+			Expect(s.TraceID).To(Equal(run.TraceID))
+		}
+
+		cleanup := named("station cleanup")
+		Expect(cleanup.Status.Code).To(Equal(2))
+		Expect(cleanup.Status.Message).To(ContainSubstring("agent failed"))
+		Expect(named("agent cleanup").ParentSpanID).To(Equal(cleanup.SpanID))
+	})
+
+	It("prefers the traces endpoint and warns when the export fails [TRACE-1]", func() {
+		respCode = http.StatusServiceUnavailable
+		out, err := lineWithEnv(dir, []string{
+			"OTEL_EXPORTER_OTLP_ENDPOINT=http://127.0.0.1:1",
+			"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT=" + server.URL + "/custom",
+		}, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("tracing: exporting spans to " + server.URL + "/custom: 503"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(paths).To(Equal([]string{"/custom"}))
+		Expect(service).To(Equal([]string{"line"}))
+	})
+
+	It("exports nothing without an endpoint or with OTEL_SDK_DISABLED [TRACE-1]", func() {
+		lineOK(dir, "run")
+		writeFile(dir, "more.go", "package main\n")
+		gitCommit(dir, "add more")
+		out, err := lineWithEnv(dir, []string{"OTEL_EXPORTER_OTLP_ENDPOINT=" + server.URL, "OTEL_SDK_DISABLED=true"}, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(paths).To(BeEmpty())
+	})
+
+	It("warns about protocols other than http/json [TRACE-1]", func() {
+		out, err := lineWithEnv(dir, []string{
+			"OTEL_EXPORTER_OTLP_ENDPOINT=" + server.URL,
+			"OTEL_EXPORTER_OTLP_PROTOCOL=grpc",
+		}, "run")
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("tracing: OTLP protocol grpc is not supported, only http/json"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(paths).To(BeEmpty())
+	})
+})
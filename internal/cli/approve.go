@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
+	"github.com/spf13/cobra"
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <station>",
+	Short: "Commit a station's held changes and continue the line",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		if err := approveStation(".", cfg, args[0]); err != nil {
+			return err
+		}
+		return runner.Continue(".", cfg, args[0], runner.Options{Events: runEvents(cfg)})
+	},
+}
+
+var rejectCmd = &cobra.Command{
+	Use:   "reject <station>",
+	Short: "Discard a station's held changes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		return rejectStation(".", cfg, args[0])
+	},
+}
+
+// pendingApproval returns the commit a station is waiting on, refusing
+// unknown stations, stations not awaiting approval and a running line.
+func pendingApproval(dir string, cfg *config.Config, name string) (state.StationApproval, error) {
+	if _, ok := stationPredecessor(cfg, name); !ok {
+		return state.StationApproval{}, fmt.Errorf("unknown station %q", name)
+	}
+	a, ok := state.ReadStationApproval(dir, name)
+	if !ok {
+		return a, fmt.Errorf("station %s is not awaiting approval", name)
+	}
+	if statusdata.RunnerActive(dir) {
+		return a, fmt.Errorf("a line run is in progress; wait for it to finish or run line clear")
+	}
+	return a, nil
+}
+
+// approveStation moves the station branch onto the held commit.
+func approveStation(dir string, cfg *config.Config, name string) error {
+	a, err := pendingApproval(dir, cfg, name)
+	if err != nil {
+		return err
+	}
+	if err := git.UpdateRef(dir, "refs/heads/"+git.StationBranchName(name), a.Commit, a.Base); err != nil {
+		return fmt.Errorf("%s moved since the change was made; line reject %s and run the line again", git.StationBranchName(name), name)
+	}
+	_ = git.DeleteRef(dir, git.ApprovalRefName(name))
+	if err := state.RemoveStationApproval(dir, name); err != nil {
+		return err
+	}
+	fmt.Printf("Approved %s: %s\n", name, a.Summary)
+	return nil
+}
+
+// rejectStation drops the held commit, leaving the station branch as it was.
+func rejectStation(dir string, cfg *config.Config, name string) error {
+	a, err := pendingApproval(dir, cfg, name)
+	if err != nil {
+		return err
+	}
+	if err := git.DeleteRef(dir, git.ApprovalRefName(name)); err != nil {
+		return err
+	}
+	if err := state.RemoveStationApproval(dir, name); err != nil {
+		return err
+	}
+	fmt.Printf("Rejected %s: discarded %s\n", name, a.Summary)
+	return nil
+}
+
+// awaitingApproval reports whether a station holds a commit for approval.
+func awaitingApproval(name string) bool {
+	_, ok := state.ReadStationApproval(".", name)
+	return ok
+}
+
+func init() {
+	approveCmd.ValidArgsFunction = completeStations(awaitingApproval)
+	rejectCmd.ValidArgsFunction = completeStations(awaitingApproval)
+	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(rejectCmd)
+}
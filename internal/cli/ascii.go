@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+)
+
+// asciiFlag is set by --ascii.
+var asciiFlag bool
+
+// asciiSymbols replaces the status symbols in ASCII output (--ascii,
+// settings.ascii, and line statusline --format plain).
+var asciiSymbols = map[string]string{
+	"▶": ">", "⏸": "||", "✓": "+", "●": "*", "○": "-", "⚠": "!", "✗": "x", "◇": "?",
+}
+
+// asciiReplacer rewrites the symbols and punctuation line prints into ASCII.
+var asciiReplacer = func() *strings.Replacer {
+	var pairs []string
+	for sym, ascii := range asciiSymbols {
+		pairs = append(pairs, sym, ascii)
+	}
+	pairs = append(pairs, "—", "-", "…", "...", "↑", "^", "▸", ">", "─", "-", "│", "|")
+	return strings.NewReplacer(pairs...)
+}()
+
+// asciiOutput reports whether output should be ASCII only, for terminals
+// and CI logs that mangle Unicode: --ascii, or settings.ascii.
+func asciiOutput(cfg *config.Config) bool {
+	return asciiFlag || cfg != nil && cfg.Settings.ASCII
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&asciiFlag, "ascii", false, "print ASCII instead of Unicode symbols (also settings.ascii)")
+}
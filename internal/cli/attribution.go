@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+// humanAuthor attributes lines from commits not made by a station.
+const humanAuthor = "human"
+
+var attributionJSON bool
+
+var attributionCmd = &cobra.Command{
+	Use:   "attribution",
+	Short: "Report how many lines on the terminal branch each station wrote",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		return printAttribution(".", cfg)
+	},
+}
+
+// fileAttribution counts a file's lines by who last changed them: a station
+// name or "human".
+type fileAttribution struct {
+	Path  string         `json:"path"`
+	Lines int            `json:"lines"`
+	By    map[string]int `json:"by"`
+}
+
+// attributionReport is the JSON form of line attribution.
+type attributionReport struct {
+	Branch string            `json:"branch"`
+	Files  []fileAttribution `json:"files"`
+	Total  fileAttribution   `json:"total"`
+}
+
+// printAttribution blames every text file on the terminal station branch and
+// prints the share of lines each station and humans introduced.
+func printAttribution(dir string, cfg *config.Config) error {
+	if len(cfg.Stations) == 0 {
+		return fmt.Errorf("no stations configured")
+	}
+	branch := git.StationBranchName(cfg.Stations[len(cfg.Stations)-1].Name)
+	if !git.BranchExists(dir, branch) {
+		return fmt.Errorf("%s does not exist yet; run the line first", branch)
+	}
+
+	report, err := attribute(dir, branch)
+	if err != nil {
+		return err
+	}
+	if attributionJSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	// Columns: humans, then stations in line order, then any station that
+	// wrote lines but is no longer configured.
+	authors := []string{humanAuthor}
+	for _, s := range cfg.Stations {
+		authors = append(authors, s.Name)
+	}
+	var retired []string
+	for name := range report.Total.By {
+		if !slices.Contains(authors, name) {
+			retired = append(retired, name)
+		}
+	}
+	slices.Sort(retired)
+	authors = append(authors, retired...)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "File\tLines\t%s\t\n", strings.Join(authors, "\t"))
+	for _, f := range append(report.Files, report.Total) {
+		cells := make([]string, len(authors))
+		for i, a := range authors {
+			cells[i] = percent(f.By[a], f.Lines)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t\n", f.Path, f.Lines, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// attribute blames each text file on branch, attributing every line to the
+// station whose commit last touched it, or to humans.
+func attribute(dir, branch string) (attributionReport, error) {
+	report := attributionReport{Branch: branch, Total: fileAttribution{Path: "Total", By: map[string]int{}}}
+	// git grep -I skips binary files; the empty pattern matches any file
+	// with at least one line. It exits 1 when there are none.
+	listed, _ := git.Run(dir, "grep", "-I", "--name-only", "-e", "", branch, "--")
+	for _, entry := range strings.Split(listed, "\n") {
+		path := strings.TrimPrefix(entry, branch+":")
+		if path == "" {
+			continue
+		}
+		blame, err := git.Run(dir, "blame", "--line-porcelain", branch, "--", path)
+		if err != nil {
+			return report, fmt.Errorf("blaming %s: %w", path, err)
+		}
+		f := fileAttribution{Path: path, By: map[string]int{}}
+		for _, line := range strings.Split(blame, "\n") {
+			subject, ok := strings.CutPrefix(line, "summary ")
+			if !ok {
+				continue
+			}
+			author := humanAuthor
+			if name, ok := runner.CommitStation(subject); ok {
+				author = name
+			}
+			f.Lines++
+			f.By[author]++
+			report.Total.Lines++
+			report.Total.By[author]++
+		}
+		report.Files = append(report.Files, f)
+	}
+	return report, nil
+}
+
+// percent formats n of total as a whole percentage, or "-" for none.
+func percent(n, total int) string {
+	if n == 0 || total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", (n*100+total/2)/total)
+}
+
+func init() {
+	attributionCmd.Flags().BoolVar(&attributionJSON, "json", false, "print the line counts as JSON")
+	rootCmd.AddCommand(attributionCmd)
+}
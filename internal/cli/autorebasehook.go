@@ -22,7 +22,7 @@ var autoRebaseHookCmd = &cobra.Command{
 			return nil // no config or invalid config — exit silently
 		}
 
-		if !cfg.Settings.AutoRebase {
+		if !cfg.Settings.AutoRebase || state.Disabled(".") {
 			return nil
 		}
 
@@ -79,6 +79,13 @@ var autoRebaseHookCmd = &cobra.Command{
 			return printBlockJSON(msg)
 		}
 
+		if r.GateFailed {
+			return printBlockJSON(fmt.Sprintf(
+				"Auto-rebase onto %s blocked: %v. Fix the station output, or run `line rebase --force --reason \"...\"` to land anyway.",
+				terminalBranch, r.GateError,
+			))
+		}
+
 		if r.Conflict {
 			if cfg.Settings.AutoResolve {
 				return printConflictBlockJSON(r, terminalBranch)
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciPush   bool
+	ciRemote string
+	ciFailOn string
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Run the line once for the commit a CI job checked out",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFailOn(ciFailOn); err != nil {
+			return err
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return runCI(".", cfg)
+	},
+}
+
+// ciCommit returns the commit of the current CI event: $GITHUB_SHA on
+// GitHub Actions, $CI_COMMIT_SHA on GitLab CI, otherwise HEAD.
+func ciCommit(dir string) (string, error) {
+	for _, env := range []string{"GITHUB_SHA", "CI_COMMIT_SHA"} {
+		if sha := os.Getenv(env); sha != "" {
+			return git.Run(dir, "rev-parse", "--verify", sha+"^{commit}")
+		}
+	}
+	return git.Run(dir, "rev-parse", "HEAD")
+}
+
+// runCI points the watched branch at the CI commit, runs the stations
+// without the local runner's PID and takeover machinery, and optionally
+// pushes the station branches back to the remote.
+func runCI(dir string, cfg *config.Config) error {
+	commit, err := ciCommit(dir)
+	if err != nil {
+		return fmt.Errorf("resolving CI commit: %w", err)
+	}
+	// CI checkouts are usually detached; the stations chain off the
+	// watched branch, so make it the event's commit.
+	if current, _ := git.CurrentBranch(dir); current != cfg.Settings.Watches {
+		if _, err := git.Run(dir, "branch", "--force", cfg.Settings.Watches, commit); err != nil {
+			return fmt.Errorf("pointing %s at %s: %w", cfg.Settings.Watches, git.ShortHash(commit), err)
+		}
+	}
+
+	branches := make([]string, len(cfg.Stations))
+	for i, s := range cfg.Stations {
+		branches[i] = git.StationBranchName(s.Name)
+	}
+	if ciPush {
+		// Pick up where the previous CI run left off; a first run has
+		// nothing to fetch.
+		for _, b := range branches {
+			_, _ = git.Run(dir, "fetch", "--quiet", ciRemote, "+refs/heads/"+b+":refs/heads/"+b)
+		}
+	}
+
+	var outcome runOutcome
+	if err := runner.Run(dir, cfg, runner.Options{Events: outcome.sink(runEvents(cfg)), CI: true}); err != nil {
+		return err
+	}
+
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendStepSummary(path, git.ShortHash(commit), outcome.results); err != nil {
+			fmt.Fprintf(os.Stderr, "assembly-line: writing step summary: %v\n", err)
+		}
+	}
+
+	if ciPush {
+		var existing []string
+		for _, b := range branches {
+			if git.BranchExists(dir, b) {
+				existing = append(existing, b)
+			}
+		}
+		if len(existing) > 0 {
+			// Station branches are rewritten (squash, reset on conflict), so
+			// the push has to be forced.
+			args := append([]string{"push", "--quiet", "--force", ciRemote}, existing...)
+			if _, err := git.Run(dir, args...); err != nil {
+				return fmt.Errorf("pushing station branches to %s: %w", ciRemote, err)
+			}
+			fmt.Printf("Pushed %s to %s\n", strings.Join(existing, ", "), ciRemote)
+		}
+	}
+	return outcome.err(ciFailOn)
+}
+
+// appendStepSummary adds a table of station results to the GitHub Actions
+// job summary.
+func appendStepSummary(path, commit string, results []stationResult) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### line: %s\n\n", commit)
+	if len(results) == 0 {
+		b.WriteString("No stations ran.\n")
+	} else {
+		b.WriteString("| Station | Result |\n|---|---|\n")
+		for _, r := range results {
+			fmt.Fprintf(&b, "| %s | %s |\n", r.station, strings.ReplaceAll(r.result, "|", "\\|"))
+		}
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(b.String() + "\n")
+	return err
+}
+
+func init() {
+	ciCmd.Flags().BoolVar(&ciPush, "push", false, "fetch station branches from the remote before the run and force-push them after")
+	ciCmd.Flags().StringVar(&ciRemote, "remote", "origin", "remote for --push")
+	ciCmd.Flags().StringVar(&ciFailOn, "fail-on", failOnStationFailure, "exit non-zero when a station fails (station-failure), or also when skipped (skip); none always exits 0")
+	rootCmd.AddCommand(ciCmd)
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// completeStations completes the first argument with station names from the
+// config, optionally narrowed by keep. Cobra provides `line completion
+// bash|zsh|fish|powershell` to install the scripts that call this.
+func completeStations(keep func(name string) bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, s := range cfg.Stations {
+			if strings.HasPrefix(s.Name, toComplete) && (keep == nil || keep(s.Name)) {
+				names = append(names, s.Name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// conflictedStation reports whether a station has a recorded rebase conflict.
+func conflictedStation(name string) bool {
+	_, ok := state.ReadStationConflict(".", name)
+	return ok
+}
+
+func init() {
+	showCmd.ValidArgsFunction = completeStations(nil)
+	resolveCmd.ValidArgsFunction = completeStations(conflictedStation)
+}
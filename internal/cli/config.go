@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print or edit the line config",
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the effective config, with the global config's defaults merged in",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		// Stations with watches go back where the config file lists them.
+		cfg.Stations, cfg.RefStations = cfg.AllStations(), nil
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			return err
+		}
+		return enc.Close()
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a key in line.yaml, e.g. settings.verify true or stations.review.prompt \"...\"",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if err := config.Set(configPath, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("set %s in %s\n", args[0], configPath)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configViewCmd, configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
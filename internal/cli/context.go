@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextRange string
+	contextOut   string
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context <station>",
+	Short: "Print the exact context a station's agent is given, for snapshot tests",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		var station *config.Station
+		for i := range cfg.Stations {
+			if cfg.Stations[i].Name == args[0] {
+				station = &cfg.Stations[i]
+			}
+		}
+		if station == nil {
+			return fmt.Errorf("unknown station %q", args[0])
+		}
+
+		var from, to string
+		if contextRange != "" {
+			var ok bool
+			if from, to, ok = strings.Cut(contextRange, ".."); !ok || from == "" || to == "" {
+				return fmt.Errorf("--range: %q is not of the form <from>..<to>", contextRange)
+			}
+		}
+		from, to, err = runner.ResolveRange(".", cfg.Settings.Watches, from, to)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := runner.WriteContext(&buf, ".", cfg.ResolveStation(*station), from, to); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: context: %v\n", err)
+		}
+		if contextOut == "" {
+			_, err = os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		return os.WriteFile(contextOut, buf.Bytes(), 0o644)
+	},
+}
+
+func init() {
+	contextCmd.Flags().StringVar(&contextRange, "range", "", "commits to review, as <from>..<to> (default: the watched branch head)")
+	contextCmd.Flags().StringVarP(&contextOut, "out", "o", "", "write the context to this file instead of stdout")
+	contextCmd.ValidArgsFunction = completeStations(nil)
+	rootCmd.AddCommand(contextCmd)
+}
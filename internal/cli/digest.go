@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/notify"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestSince  string
+	digestFormat string
+	digestNotify bool
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarise what the stations did recently, e.g. for a standup",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if digestFormat != "text" && digestFormat != "markdown" {
+			return fmt.Errorf("--format: %q is not one of text, markdown", digestFormat)
+		}
+		since, err := parseSince(digestSince, time.Now())
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		d := buildDigest(".", cfg, since)
+		window := "since " + digestSince
+		if _, err := time.Parse(time.DateOnly, digestSince); err != nil {
+			window = "last " + digestSince
+		}
+		if digestNotify {
+			names := notify.Names(cfg.Notifications)
+			if len(names) == 0 {
+				return fmt.Errorf("--notify: no slack or teams notifications configured in %s", configPath)
+			}
+			var msg strings.Builder
+			writeDigestMarkdown(&msg, d, window)
+			if err := notify.Post(cfg.Notifications, msg.String()); err != nil {
+				return err
+			}
+			fmt.Printf("Posted digest to %s\n", strings.Join(names, ", "))
+			return nil
+		}
+		if digestFormat == "markdown" {
+			writeDigestMarkdown(stdout(cfg), d, window)
+			return nil
+		}
+		return writeDigestText(stdout(cfg), d, window)
+	},
+}
+
+// parseSince reads --since: a duration back from now ("24h", "90m", or
+// whole days like "7d") or a date ("2006-01-02").
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("--since: %q is neither a duration (24h, 7d) nor a date (YYYY-MM-DD)", s)
+}
+
+// stationDigest is what one station did in the digest window.
+type stationDigest struct {
+	name     string
+	reviewed int // distinct watched-branch commits the station ran for
+	commits  int // station commits made
+	added    int
+	deleted  int
+	files    int
+	failures []state.StationRun
+}
+
+// buildDigest summarises each station's runs since the given time from its
+// run history, and its changes from the station commits on its branch.
+func buildDigest(dir string, cfg *config.Config, since time.Time) []stationDigest {
+	var digest []stationDigest
+	for _, s := range cfg.Stations {
+		d := stationDigest{name: s.Name}
+		reviewed := map[string]bool{}
+		for _, r := range state.ReadStationRuns(dir, s.Name) {
+			if r.Started.Before(since) {
+				continue
+			}
+			reviewed[r.Commit] = true
+			if r.Result != "ok" && r.Result != "awaiting approval" {
+				d.failures = append(d.failures, r)
+			}
+		}
+		d.reviewed = len(reviewed)
+		stationChanges(dir, s.Name, since, &d)
+		digest = append(digest, d)
+	}
+	return digest
+}
+
+// stationChanges counts the commits the station authored since the given
+// time, and the lines and files they changed. Rebases rewrite committer
+// dates but keep author dates, so the window is applied to the latter.
+func stationChanges(dir, name string, since time.Time, d *stationDigest) {
+	branch := git.StationBranchName(name)
+	if !git.BranchExists(dir, branch) {
+		return
+	}
+	out, err := git.Run(dir, "log", "--no-merges", "--since="+since.Format(time.RFC3339),
+		"--format=%x00%at %(trailers:key="+runner.StationTrailer+",valueonly,separator=%x2C)%x00%s", "--numstat", branch)
+	if err != nil {
+		return
+	}
+	files := map[string]bool{}
+	counting := false
+	for _, line := range strings.Split(out, "\n") {
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			at, rest, _ := strings.Cut(header, " ")
+			trailer, subject, _ := strings.Cut(rest, "\x00")
+			secs, _ := strconv.ParseInt(at, 10, 64)
+			// Older station commits predate the Line-Station trailer.
+			station, ok := trailer, trailer != ""
+			if !ok {
+				station, ok = runner.CommitStation(subject)
+			}
+			counting = ok && station == name && !time.Unix(secs, 0).Before(since)
+			if counting {
+				d.commits++
+			}
+			continue
+		}
+		// numstat: added<TAB>deleted<TAB>path, "-" for binary files
+		fields := strings.SplitN(line, "\t", 3)
+		if !counting || len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		d.added += added
+		d.deleted += deleted
+		files[fields[2]] = true
+	}
+	d.files = len(files)
+}
+
+// plural formats n with its noun, e.g. "1 commit" or "3 commits".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// changeSummary describes a station's commits, e.g. "2 commits (+12 -3 in
+// 4 files)".
+func (d stationDigest) changeSummary() string {
+	if d.commits == 0 {
+		return "no changes"
+	}
+	return fmt.Sprintf("%s (+%d -%d in %s)", plural(d.commits, "commit"), d.added, d.deleted, plural(d.files, "file"))
+}
+
+// failureTime formats when a failed run started.
+func failureTime(r state.StationRun) string {
+	return r.Started.Local().Format("Mon 15:04")
+}
+
+// writeDigestText prints the digest as a table followed by the failures.
+func writeDigestText(out *printer, digest []stationDigest, window string) error {
+	fmt.Fprintf(out, "%s:\n\n", strings.ToUpper(window[:1])+window[1:])
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Station\tReviewed\tChanges\tFailures\t")
+	for _, d := range digest {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t\n", d.name, d.reviewed, d.changeSummary(), len(d.failures))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	heading := "\nFailures:\n"
+	for _, d := range digest {
+		for _, r := range d.failures {
+			fmt.Fprint(out, heading)
+			heading = ""
+			fmt.Fprintf(out, "  %s\n", out.paint(colorRed, fmt.Sprintf("✗ %s %s: %s", d.name, failureTime(r), r.Result)))
+		}
+	}
+	return nil
+}
+
+// writeDigestMarkdown prints the digest as a Markdown list, which pastes
+// cleanly into chat tools that do not render tables.
+func writeDigestMarkdown(out io.Writer, digest []stationDigest, window string) {
+	fmt.Fprintf(out, "**line digest**, %s\n\n", window)
+	for _, d := range digest {
+		fmt.Fprintf(out, "- **%s**: %s reviewed, %s, %s\n", d.name, plural(d.reviewed, "commit"), d.changeSummary(), plural(len(d.failures), "failure"))
+		for _, r := range d.failures {
+			fmt.Fprintf(out, "  - %s: `%s`\n", failureTime(r), strings.ReplaceAll(r.Result, "`", "'"))
+		}
+	}
+}
+
+func init() {
+	digestCmd.Flags().StringVar(&digestSince, "since", "24h", "how far back to look: a duration (24h, 7d) or a date (YYYY-MM-DD)")
+	digestCmd.Flags().StringVar(&digestFormat, "format", "text", "output format: text or markdown")
+	digestCmd.Flags().BoolVar(&digestNotify, "notify", false, "post the Markdown digest to the configured notifications instead of printing it")
+	rootCmd.AddCommand(digestCmd)
+}
@@ -18,36 +18,91 @@ PURPOSE
   a sequence of prompts that run automatically on every commit.
 
 COMMANDS
-  init        Install Git hooks (pre-commit for gates, post-commit for run),
-              the /line-rebase and /line-preview skills, configure Claude
+  init        Install Git hooks (pre-commit and commit-msg for gates;
+              post-commit, post-merge and post-rewrite after a rebase for
+              run; reference-transaction for run --refs), the
+              /line-rebase and /line-preview skills, configure Claude
               Code's statusline, and install PostToolUse and Stop hooks
               running line auto-rebase-hook.
               Adds .gitignore entries for temporary files introduced by line.
               Preserves any existing pre-commit hooks. Safe to re-run —
-              converges state.
+              converges state. --preset go-backend|ts-frontend first writes
+              line.yaml with security, tests, docs, changelog and
+              dependencies stations tuned for the stack (never overwrites).
+              --pre-push also installs a pre-push hook running push_gates.
   remove      Undo everything that init installs, creates, or configures.
-              Removes assembly-line blocks from pre-commit and post-commit
-              hooks (preserving other content), removes the /line-rebase and
-              /line-preview skill directories, removes the statusLine key and
-              PostToolUse/Stop hook entries from .claude/settings.json, and
-              removes the assembly-line block from .gitignore. Safe to run
-              even when line was never initialized (no-op).
+              Removes assembly-line blocks from the pre-commit, commit-msg,
+              pre-push, post-commit, post-merge, post-rewrite and
+              reference-transaction hooks (preserving other content),
+              removes the /line-rebase and /line-preview skill directories,
+              removes the statusLine key and PostToolUse/Stop hook entries
+              from .claude/settings.json, and removes the assembly-line
+              block from .gitignore. Safe to run even when line was never
+              initialized (no-op).
   run         Execute the station pipeline (called by the post-commit hook).
-              Stations run in sequence, each in an ephemeral Git worktree.
+              Stations run in sequence, each in an ephemeral Git worktree
+              under $XDG_CACHE_HOME/line/<repo>-<hash>/ (see paths).
+              Exits 0 even if stations fail; --fail-on station-failure exits
+              2 when one fails, --fail-on skip also exits 3 when the run or
+              a station is skipped. Station commits carry Triggered-By,
+              Triggered-Branch and Line-Station trailers. --refs runs
+              only the stations with watches (skipped while a run is in
+              progress, which runs them when it finishes). --station <name>
+              [--range <from>..<to>] runs one station on demand for those
+              watched-branch commits (default: since its last run), then
+              the stations after it; it fails while a run is in progress.
+  trigger [--commit <rev>] [--station <name>]
+              Hidden; run for tools such as webhook receivers. Runs the
+              line for that watched-branch commit (default: its head)
+              whatever is checked out, skipping one a clean cycle already
+              covered; --station runs one station as run --station --range
+              ..<rev>. --fail-on as for run.
+  ci          Run the line once in a CI job for $GITHUB_SHA or
+              $CI_COMMIT_SHA (else HEAD); detached HEAD is fine. No PID
+              file or takeover. Exits 2 when a station fails (--fail-on as
+              for run). Appends results to $GITHUB_STEP_SUMMARY if set.
+              --push fetches station branches from --remote (origin) first
+              and force-pushes them after.
   gate        Run all gates (called by the pre-commit hook). Non-zero exit
-              from any gate blocks the commit.
+              from any gate blocks the commit. --hook commit-msg <file>
+              runs the gates with hook: commit-msg ({msg_file} is the
+              message file; skipped for station commits). --hook pre-push
+              runs the push_gates instead, once per commit being pushed
+              (refs read from stdin; called by the pre-push hook init
+              --pre-push installs); a failure rejects the push. A gate's
+              output is shown only if it fails (--verbose: always); each run
+              writes .line/gate-last.json (hook, ok, failed, and per gate
+              name, run, ref, ok, exit_code, duration_seconds, output). With
+              settings.gate_staged, unstaged changes are left out: the
+              gates run in a temporary checkout of the staged files.
+              gate export --format pre-commit|lefthook prints the gates and
+              push_gates as a .pre-commit-config.yaml or lefthook.yml.
   clear       Stop any active line run, terminate all agents, clear all state
-              files, and drop station branches and worktrees. Prompts for
-              confirmation unless --force is passed.
+              files, and drop station branches, worktrees and cached build
+              directories, also of stations no longer in the config.
+              Prompts for confirmation unless --force is passed.
   status      Show station status. Header: ⏸ (grey) for inactive or ▶ (green)
               for active, followed by the config file name. Output includes
               headings. Stations listed starting with the watched branch; each
               shows a shortref of HEAD and a dirty-directory indicator, with
               per-station symbols: ✓ up-to-date — the only commits between
               the station and the watched branch HEAD are skip-marker commits
-              (green); ● agent running (orange, with uptime, e.g. 52s/5m 32s);
-              ○ pending (yellow); ✗ failed (red). Use -f to refresh every
-              2 seconds, flicker-free with a hidden cursor. Status is
+              (green); ● agent running (orange, with uptime, e.g. 52s/5m 32s,
+              and "no output for 12m 5s" past settings.stall_after);
+              ○ pending (yellow); ⚠ conflict (magenta, with the conflicting
+              files — see resolve); ✗ failed (red, with e.g. "agent exited
+              137 (killed)" or "stalled: no output for 20m" when its agent
+              failed); ✗ quarantined (red, until a time — see retry);
+              ◇ awaiting approval (cyan, with the held change's shortstat —
+              see approve). Use -f to
+              refresh every 2 seconds, flicker-free with a hidden cursor.
+              Stations with nothing else to report show the watched-branch
+              range their last run reviewed ("reviewed 4 commits
+              (abc1234..def5678)"), also in the station commit's body.
+              Commits skipped (markers, .lineignore) since the last run
+              are summarised under the header with their reasons, and a
+              cycle aborted by a failing settings.hooks command is shown
+              there too. Status is
               computed on-demand, not cached. A commit-distance indicator is
               shown between each station name and its HEAD ref: H marks HEAD;
               each + after H is one commit ahead; each - before H on the
@@ -56,7 +111,18 @@ COMMANDS
   statusline  One-line status for Claude Code's statusline integration.
               Uses ▶/⏸ symbols matching line status. Prompts to run
               /line-rebase when terminal station has unpicked commits.
-              No external dependencies.
+              No external dependencies. Branch heads are read with one
+              git for-each-ref and the rendered line is cached in
+              .line/statusline-cache (10s, keyed on refs and agent state).
+              --format plain uses ASCII symbols; --format prompt prints a
+              compact segment for shell prompts (one symbol per station,
+              ↑ when changes are ready); --no-color or NO_COLOR drops
+              colors. settings.statusline themes symbols and colors per
+              state and caps the width (max_width, truncate:
+              end|names|middle|active); the terminal width ("columns" in
+              stdin JSON, else COLUMNS) caps it too. --compact (or
+              settings.statusline.layout: compact) chains the stations:
+              "⏸ main ▸ review✓ ▸ docs●".
   rebase      Deterministic stash → rebase → unstash from the terminal station
               branch onto the watched branch. Must be run from the watched
               branch. On conflict: aborts, restores stash, reports failure.
@@ -64,7 +130,45 @@ COMMANDS
               git in mid-rebase state with conflict markers instead of aborting.
               Output lists conflicted files and step-by-step resolution
               instructions. If a stash was created, instructions include the
-              final git stash pop step.
+              final git stash pop step. Gates run against the terminal
+              branch first (in a throwaway worktree); if any fail the rebase
+              is refused. --force --reason "<why>" lands anyway and records
+              a Gate-Override trailer on an empty [skip line] commit.
+  retry <station>
+              Clear a failed station's consecutive failure count and
+              quarantine. Stations failing twice in a row are skipped for
+              1m, doubling per further failure up to 1h.
+  migrate-station <old> <new>
+              Carry a renamed station's branch, held commits, state, log
+              and cached build directories over to its new name. Status
+              and run warn about such leftovers. Refuses during a run, or
+              when <new> has state of its own unless --force.
+  approve <station>
+              Move an approval: manual station's branch to its held commit
+              and continue the line from the next station.
+  reject <station>
+              Discard a station's held commit. Both refuse during a run.
+  show <station>
+              One-screen summary of a station: status, last run (start,
+              duration, outcome, commits reviewed), last commit on its branch, watched branch
+              head, commits behind / unpicked, and the last -n (default 10)
+              lines of its log, after those of a failed agent's output.
+  logs <station> | logs --merge [station...]
+              The last -n (default 10) lines of a station's log; -f keeps
+              printing new ones. --merge interleaves several stations' logs
+              (all by default) for stations running in parallel, each line
+              prefixed with its time and the station's name in the
+              station's color, ordered by when the logs were written.
+              --run <id> prints only what that run (line show) wrote.
+              Escape sequences are dropped unless --raw
+              (settings.log_filter: strip-ansi keeps them out of the logs).
+  resolve <station>
+              Resolve a station's rebase conflict (on_conflict: keep) by
+              hand. Replays the rebase onto the station's predecessor in a
+              throwaway worktree and opens $SHELL there (--mergetool runs
+              git mergetool instead). On exit the files are staged and the
+              rebase continued; leftover conflict markers abort it and leave
+              the branch unchanged.
   auto-rebase-hook
               PostToolUse and Stop hook. When auto_rebase is true and the
               terminal station has unpicked commits, performs a rebase and
@@ -73,13 +177,97 @@ COMMANDS
               conflicted files with resolution instructions via decision:
               block. Deduplicates attempts — does not re-attempt for the
               same terminal ref. Exits silently when: no config, auto_rebase
-              is false, no stations, no unpicked commits, already attempted
+              is false, the pipeline is disabled, no stations, no unpicked commits, already attempted
               for the current ref, or a line run is in progress. line clear
               removes the dedup marker.
+  ping [--max-age 1h] [--json]
+              Health check, non-zero exit on failure: post-commit hook
+              installed and line not disabled; runner idle or alive (not a
+              dead PID); the watched branch head finished a cycle, was
+              skipped, or was committed within --max-age.
+  attribution
+              Blame the terminal station branch: per file and in total, the
+              percentage of lines each station vs. humans introduced.
+              --json prints line counts.
+  digest      Per station over the last 24h (--since 7d or YYYY-MM-DD):
+              commits reviewed, commits made (+/- lines, files) and failed
+              runs, from the run history in .line/stations/. --format
+              markdown prints a list for chat; --notify posts it to the
+              configured notifications.
+  stats       Per station over the last 30d (--since, --by week|day):
+              commits reviewed, modified vs. noop (ratio), average latency
+              from watched-branch commit to station commit, commits and
+              +/- lines in total and per period. --json for dashboards.
+  rpc         JSON-RPC 2.0 on stdin/stdout, LSP Content-Length framing, for
+              editor extensions. Methods: initialize, status (line status
+              as JSON), log {station, lines}, subscribe/unsubscribe ({} for
+              status notifications on change, {station} for log
+              notifications), trigger {commit, station} (optional; line
+              trigger), retry/approve/reject {station},
+              shutdown.
+  serve --github-webhook [--addr :8787] [--remote origin]
+              Receive GitHub push events signed with $LINE_WEBHOOK_SECRET
+              (others get 401). A push to the watched branch is fetched and
+              runs trigger --commit <sha>; one to a tag or branch a station
+              watches is fetched and runs run --refs; others are ignored.
+              Needs a clone without the watched branch checked out.
+  paths [config|global|state|logs|worktrees]
+              Print where line keeps the config, global config, .line/
+              state, station logs ($XDG_STATE_HOME/line/<repo>-<hash>/logs)
+              and worktrees ($XDG_CACHE_HOME/line/<repo>-<hash>/worktrees).
+              With a name, print only that path.
+  completion  Print a shell completion script (bash, zsh, fish, powershell).
+              Station arguments complete from the config; resolve only
+              offers stations with a recorded conflict, approve and reject
+              only stations awaiting approval.
   schema      Output the YAML configuration schema to stdout.
   validate    Validate line.yaml and print specific errors, or "valid".
+  config view Print the effective config (global defaults merged in).
+  config set <key> <value>
+              Set a dotted key in line.yaml (settings.verify true,
+              stations.review.prompt "..."; list entries by name or index),
+              keeping comments. Unknown keys and invalid values are refused.
+  lint-prompts
+              Check station prompts with static heuristics: directives that
+              contradict the preamble (commit, push, open a pull request,
+              switch branches), prompts over 8000 characters, unresolved
+              placeholders ({{...}}, ${VAR}, <TODO ...>). Exits 1 with one
+              finding per line, else prints "no problems found".
+  simulate <station> [--commit <rev> | --range <from>..<to>]
+           [--patch <file>] [--dry-run]
+              Run one station's agent in a throwaway detached worktree and
+              print its context (commits under review, full prompt), the
+              agent's output and the diff. --patch reviews a patch applied
+              as a synthetic commit; --dry-run skips the agent. Touches no
+              branch, state or status.
+  context <station> [--range <from>..<to>] [--out <file>]
+              Print the exact context the station's agent is given (command,
+              commits under review, full prompt) for the watched branch head
+              or a range. Deterministic, for snapshot tests.
+  release <version> [--dry-run]
+              Tag the watched branch head (annotated) with release notes
+              since the last tag: changelog station entries, commits by
+              Conventional Commits type, and per-station notes.
   explain     Print this reference (what you are reading now).
 
+GLOBAL FLAGS
+  -p, --path <file>      Config file (default line.yaml).
+  -v, --verbose          Also log routine run steps (debug level).
+  -q, --quiet            Only log warnings and errors; hides agent output.
+  --log-format text|json JSON writes one object per run event to stderr
+                         (time, level, msg, event, station, error, output).
+  --ascii                ASCII instead of Unicode symbols in status, show,
+                         digest and statusline (also settings.ascii).
+  --no-color             No ANSI colors (also NO_COLOR). Colors are only
+                         printed to a terminal, unless CLICOLOR_FORCE=1.
+
+TRACING
+  Set OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT)
+  to export each run as an OpenTelemetry trace over OTLP/HTTP, JSON
+  encoding only: line run > station <name> > agent <name>, plus a span per
+  git command. Spans are sent when the run ends; OTEL_EXPORTER_OTLP_HEADERS,
+  OTEL_SERVICE_NAME and OTEL_SDK_DISABLED are honoured. Export errors warn.
+
   Skill: /line-rebase
     Safely rebase changes from the terminal station branch back onto the
     watched branch. Stashes work, rebases, unstashes. No work is lost.
@@ -103,28 +291,171 @@ CONFIG FORMAT (line.yaml)
     watches: main                                # Git branch to watch (required)
     auto_rebase: false                           # enable auto-rebase hook (optional)
     auto_resolve: false                          # leave conflicts for agent resolution (optional)
+    on_conflict: reset                           # station rebase conflicts: reset | keep | agent (optional)
+    on_force_push: rebase                        # after a force-push of watches: rebase | reset (optional)
+    debounce: 2s                                 # wait for further commits before a run (optional)
+    stall_after: 10m                             # flag agents silent this long, 0 = never (optional)
+    stall_timeout: 20m                           # kill agents silent this long, fail the station (optional)
+    log_filter: keep                             # agent output in station logs: keep | strip-ansi (optional)
+    agent_rate: 2/min                            # at most this many agent starts per s|min|hour (optional)
+    verify: false                                # re-run gates on each station commit (optional)
+    gate_staged: false                           # gates check only staged changes (optional)
+    commit_author: "Line Bot <line@example.com>" # author/committer of station commits (optional)
+    commit_signing:                              # sign station commits (optional)
+      format: ssh                                # openpgp (default) | ssh | x509
+      key: ~/.ssh/id_ed25519.pub                 # git user.signingkey
+    worktree:                                    # untracked files for worktrees, never committed (optional)
+      copy: [".env", ".tool-versions"]           # copied from the repo
+      symlink: ["node_modules"]                  # symlinked to the repo's copy
+    machine_commits:                             # bot commits that don't trigger the line (optional)
+      authors: ["^dependabot"]                   # regexps matched against "Name <email>"
+      trailers: ["Triggered-By"]                 # trailer keys marking a machine commit
+    hooks:                                       # commands run in the repo root once per cycle (optional)
+      before_all: git fetch                      # before the first station; failing aborts the cycle
+      after_all: ./scripts/audit.sh              # after the last station; LINE_RESULT: ok | failed
+    routes:                                      # Conventional Commits type -> stations (optional)
+      feat: [review, test]                       # feat: commits run only these
+      chore: []                                  # chore: commits run none; other types run all
+    ascii: false                                 # ASCII instead of Unicode symbols, like --ascii (optional)
+    statusline:                                  # statusline theme (optional)
+      symbols: {up_to_date: "ok"}                # symbol per state
+      colors: {pending: blue, failed: "196"}     # color name or 256-color number per state
+      max_width: 60                              # cap the width, 0 = unlimited
+      truncate: names                            # end (default) | middle | names | active
+      layout: compact                            # full (default) | compact, like --compact
 
   gates:
     - name: lint                                 # gate name (required)
       run: "golangci-lint run ./..."             # shell command (required)
+    - name: commitlint
+      hook: commit-msg                           # pre-commit (default) | commit-msg (optional)
+      run: "npx commitlint --edit {msg_file}"    # {msg_file}: the commit message file
+
+  push_gates:                                    # run by the pre-push hook (optional)
+    - name: test
+      run: "go test ./..."                       # LINE_PUSH_REF/FROM/TO set
 
   stations:
     - name: review                               # unique name → branch line/stn/review
       prompt: "Review the code for issues."      # prompt text
+      squash: true                               # keep one unpicked commit (optional)
+      verify: "go test ./..."                    # must pass before committing (optional)
+      on_verify_failure: repair                  # fail (default) | repair (optional)
+      max_repair_attempts: 2                     # repair rounds before failing (optional)
+      approval: manual                           # auto (default) | manual: hold commits for line approve (optional)
+      after: "gofmt -w ."                        # run in the worktree before verify/commit (optional)
+      cache: ["target/", ".venv/"]               # build dirs kept between runs, never committed (optional)
+      context: ["jira:PROJ", "github-issues", "beads"] # append issues and items the commits touch (optional)
+      root: services/api                         # monorepo package: only its changes trigger, agent stays in it (optional)
+      changelog: CHANGELOG.md                    # changelog station: gets commits by type, prompt optional (optional)
+      watches: "tag:v*"                          # tag:<glob> | branch:<glob>: run per new ref, not in the line (optional)
+      trigger: manual                            # auto (default) | manual: only line run --station runs it (optional)
+      on_interrupt: resume                       # discard (default) | commit | resume: a killed agent's changes (optional)
+      follows: lint-fix                          # run after this station, e.g. one from line.d/ (optional)
+      on_success: "notify-send done"             # run in the repo root after success (optional)
+      on_failure: "notify-send failed"           # run in the repo root after failure (optional)
     - name: test
       command: custom-agent                      # overrides agent.command
       args: ["--flag", "-p"]                     # overrides agent.args
       prompt: "Run all tests, fix failures."
 
+  notifications:                                 # chat webhooks (optional)
+    slack:
+      webhook_url: "${SLACK_WEBHOOK_URL}"          # env vars expanded (required)
+      template: "{{.Station}} failed: {{.Result}}" # failure message (optional)
+    teams:
+      webhook_url: "${TEAMS_WEBHOOK_URL}"          # posted as an Adaptive Card
+    email:
+      smtp: "smtp.example.com:587"               # host:port, STARTTLS if offered (required)
+      username: "${SMTP_USER}"                   # env vars expanded (optional)
+      password: "${SMTP_PASSWORD}"
+      from: "Line Bot <line@example.com>"        # (required)
+      to: ["team@example.com"]                   # (required)
+      failures: 2                                # mail after N failures in a row (default 1)
+
 CONFIG SEMANTICS
   - settings.watches is required. All other top-level keys are optional.
+  - agent and settings defaults may also come from the global config
+    ($XDG_CONFIG_HOME/line/config.yaml, default ~/.config/line/config.yaml);
+    keys set in line.yaml override it. gates and stations are repo-only.
+  - line.d/*.yaml next to line.yaml may add stations, gates and push_gates
+    (nothing else), after line.yaml's, file by file in name order. Names
+    must be unique across the files. station.follows names the station it
+    runs after, e.g. one from another file; stations following each other
+    in a cycle are an error. Errors in those files are prefixed with the
+    file, e.g. "line.d/api.yaml: stations[0].name: ...".
   - Each station needs a resolvable command: either station.command or
     agent.command must be set. station.command takes priority.
   - Station args follow the same inheritance: station.args overrides agent.args.
   - The prompt is appended as the final argument to the resolved command+args.
+  - Agents run with LINE_RUN_ID (kept in the station's run history, shown by
+    line show), LINE_STATION, LINE_TRIGGER_COMMIT and LINE_RANGE (<from>..<to>,
+    or just the commit on a first run) in their environment.
   - Station names must be unique — each maps to a Git branch (line/stn/<name>).
   - Gates run in order; any failure blocks the commit.
+  - push_gates run in order from the pre-push hook, for each pushed commit:
+    in place when it is the clean HEAD, else in a throwaway worktree. They
+    get LINE_PUSH_REMOTE, LINE_PUSH_REF, LINE_PUSH_FROM (empty for a new
+    ref) and LINE_PUSH_TO; any failure rejects the push.
+  - A station's verify command runs in its worktree after the agent and
+    before committing; the station only commits if it exits 0. With
+    on_verify_failure: repair the agent is re-run with the verify output
+    in its prompt and asked to fix the failure, up to
+    max_repair_attempts times, before the station fails verification.
+  - A station's after command runs in its worktree after the agent (and
+    after each repair), before verify and the commit; its changes are
+    committed too and a non-zero exit fails the station. on_success /
+    on_failure run in the repo root when the station finishes, with
+    LINE_STATION, LINE_BRANCH and LINE_RESULT set; their failures only warn.
+  - A station's cache directories are moved out of its worktree when it
+    finishes and back in on its next run ($XDG_CACHE_HOME/line/<repo>-<hash>/
+    artifacts/<station>); line clear deletes them.
+  - A station's context providers append the issues referenced in the
+    reviewed commits' messages to its prompt (up to 10): jira:PROJ finds
+    PROJ-123 via JIRA_URL (JIRA_USER, JIRA_API_TOKEN); github-issues finds
+    #123 in origin's GitHub repo, or github-issues:owner/repo (GITHUB_TOKEN,
+    GITHUB_API_URL). Unfetchable issues are skipped with a warning.
+    beads[:<path>] (default .beads/issues.jsonl) adds the store's open
+    items that mention the changed files; the agent closes or annotates
+    them by writing .line-result.json ({"close": [{"id", "reason"}],
+    "annotate": [{"id", "note"}]}), applied to the store and committed.
+  - With settings.verify, station commits bypass the pre-commit hook and the
+    gates run in the station worktree before committing instead; a failure
+    leaves the changes uncommitted, marks the station "failed verification",
+    appends the gate output to the station log (line paths logs) and blocks
+    downstream stations.
   - Stations run in order; a failed station blocks subsequent stations.
+  - notifications.slack / .teams post each station failure to the webhook,
+    rendered with template (text/template: .Station, .Branch, .Commit,
+    .Result); line digest --notify posts the digest. Webhook errors warn.
+  - notifications.email mails the diffstat when a run moves the terminal
+    station branch, and a station's error plus log tail once it has failed
+    "failures" times in a row (once per streak). Mail errors warn.
+  - A station with watches: tag:<glob> or branch:<glob> is not part of the
+    ordered line: after each run (or on line run --refs) it runs once per
+    matching ref that is new or moved, from the ref's commit, reviewing
+    the commits since the previous matching tag or the branch's last seen
+    commit. The first time only the newest match runs. Seen commits are
+    kept per ref in .line/stations/<name>.refs; commits carry
+    Triggered-Ref. approval: manual is not allowed with watches.
+  - A station with trigger: manual stays in the line but runs pass changes
+    through it; line run --station <name> runs it (then the stations after
+    it). Not allowed with watches.
+  - A station with approval: manual keeps its new commit under
+    refs/line/approval/<name> without moving its branch and stops the
+    line; line approve moves the branch and continues, line reject
+    discards the commit.
+  - settings.on_conflict decides what happens when a station branch conflicts
+    while rebasing onto its predecessor: reset (default) drops the station's
+    commits and restarts from the predecessor; keep aborts, leaves the branch
+    and records .line/stations/<name>.conflict, blocking the line; agent runs
+    the station's agent on the conflicted files, falling back to reset.
+  - settings.on_force_push decides what happens once the watched branch was
+    force-pushed (it no longer contains the commit the line last ran for):
+    rebase (default) replays only each station's own commits, from the
+    merge-base of its branch and that commit, so the replaced commits do not
+    come back; reset discards the stations' commits and restarts them from
+    the rewritten branch.
 
 CONSTRAINTS
   - line prepends a preamble prompt to each station's configured prompt
@@ -134,15 +465,43 @@ CONSTRAINTS
   - Stations must not re-trigger line run.
   - Stations run in isolated ephemeral Git worktrees under the system temp dir.
   - Commits containing [skip ci], [ci skip], [skip line], or [line skip] in
-    the message do not trigger the line.
+    the message do not trigger the line, nor do commits matched by
+    settings.machine_commits. [skip line:a,b] skips just those
+    stations and [line only:a,b] all others; skipped stations pass the
+    changes through to the next station without running their agent.
+  - A station with a root only runs when its reviewed commits change files
+    under it (minus .lineignore, within .lineonly); otherwise it passes
+    changes through. Its agent runs in <worktree>/<root>, is told to stay
+    there, and changing files outside the root fails the station.
+  - settings.routes skips a station, passing changes through, when every
+    commit it reviews has a routed type (feat(x)!: ...) and none of those
+    types lists it.
+  - settings.hooks.before_all runs in the repo root before each cycle reads
+    the watched branch, after_all once its stations are done (LINE_RESULT
+    is ok or failed, LINE_COMMIT the commit). A failing hook aborts the
+    cycle: line run exits non-zero and line status shows "last cycle
+    aborted" until a cycle gets through.
   - Changes to files listed in .lineignore (gitignore syntax) are ignored.
+    Where git sets core.ignoreCase (case-insensitive filesystems), its
+    patterns and station roots match paths regardless of case.
+  - A .lineonly file (gitignore syntax) lists the only paths whose changes
+    trigger the line; .lineignore still applies within them.
+  - A commit with the same tree as the one the line last ran cleanly for
+    (amended message, no-op rebase) is skipped as "unchanged tree".
   - If a new commit arrives while the line is running, agents are stopped,
     existing station-branch commits are preserved, and the line restarts from
     the beginning with the latest commit.
   - Stations 'just work' — if Git state is bad they catch up to the watched
     branch and resume from there.
   - Line runs are independent of rebases on the watched branch.
-  - Stations rebase onto their predecessor (not merge) to keep history linear.`
+  - Kill switch: while .line/disabled exists or LINE_DISABLED=1 is set,
+    run and auto-rebase-hook do nothing; status shows a red PIPELINE
+    DISABLED banner and statusline is prefixed with "disabled".
+  - Stations rebase onto their predecessor (not merge) to keep history linear.
+  - settings.agent_rate paces agent starts across all stations and runs of
+    the repo (token bucket in .line/agent-rate); an agent failing with a
+    provider rate-limit error (429, "rate limit", "overloaded") is run
+    again after 10s, 20s and 40s before its station fails.`
 
 var explainCmd = &cobra.Command{
 	Use:   "explain",
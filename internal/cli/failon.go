@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/re-cinq/assembly-line/internal/runner"
+)
+
+// Exit codes for line run --fail-on. Other errors exit 1.
+const (
+	exitStationFailed = 2
+	exitSkipped       = 3
+)
+
+// Values for line run --fail-on.
+const (
+	failOnNone           = "none"
+	failOnStationFailure = "station-failure"
+	failOnSkip           = "skip"
+)
+
+var failOn string
+
+// ExitError is an error that asks the process to exit with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// runOutcome records the failures and skips of a run as its events pass.
+type runOutcome struct {
+	failed  []string
+	skipped []string
+	results []stationResult
+}
+
+// stationResult is what happened to one station during a run.
+type stationResult struct {
+	station string
+	result  string
+}
+
+// observe notes station results, failures and skips. It is only called
+// from the runner's goroutine for these events, so it needs no locking.
+func (o *runOutcome) observe(e runner.Event) {
+	switch {
+	case e.Kind == runner.EventStationDone && e.Err != nil:
+		o.failed = append(o.failed, e.Station)
+		o.results = append(o.results, stationResult{e.Station, "failed: " + e.Err.Error()})
+	case e.Kind == runner.EventStationDone:
+		o.results = append(o.results, stationResult{e.Station, "ok"})
+	case e.Kind == runner.EventAwaitingApproval:
+		o.results = append(o.results, stationResult{e.Station, e.Message})
+	case e.Kind == runner.EventSkipped:
+		what := e.Station
+		if what == "" {
+			what = "run"
+		} else {
+			o.results = append(o.results, stationResult{e.Station, e.Message})
+		}
+		o.skipped = append(o.skipped, what)
+	}
+}
+
+// sink wraps next so that the outcome observes every event.
+func (o *runOutcome) sink(next runner.EventSink) runner.EventSink {
+	return runner.SinkFunc(func(e runner.Event) {
+		if e.Kind != runner.EventAgentOutput {
+			o.observe(e)
+		}
+		next.Emit(e)
+	})
+}
+
+// err turns the outcome into an ExitError according to --fail-on.
+func (o *runOutcome) err(mode string) error {
+	if mode == failOnNone {
+		return nil
+	}
+	if len(o.failed) > 0 {
+		return &ExitError{Code: exitStationFailed, Err: fmt.Errorf("station %s failed (--fail-on %s)", o.failed[0], mode)}
+	}
+	if mode == failOnSkip && len(o.skipped) > 0 {
+		return &ExitError{Code: exitSkipped, Err: fmt.Errorf("%s skipped (--fail-on %s)", o.skipped[0], mode)}
+	}
+	return nil
+}
+
+// checkFailOn validates a --fail-on value.
+func checkFailOn(mode string) error {
+	switch mode {
+	case failOnNone, failOnStationFailure, failOnSkip:
+		return nil
+	}
+	return fmt.Errorf("--fail-on: %q is not one of station-failure, skip, none", mode)
+}
@@ -2,27 +2,76 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/gate"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/paths"
+	"github.com/re-cinq/assembly-line/internal/state"
 	"github.com/spf13/cobra"
 )
 
+var gateHook string
+
 var gateCmd = &cobra.Command{
 	Use:   "gate",
-	Short: "Run pre-commit gates",
+	Short: "Run pre-commit gates, or the commit-msg or push gates with --hook",
+	// The commit-msg hook hands on the message file, the pre-push hook its
+	// remote name and URL.
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		switch gateHook {
+		case config.GateHookPreCommit, "pre-push":
+		case config.GateHookCommitMsg:
+			if len(args) == 0 {
+				return fmt.Errorf("--hook commit-msg needs the commit message file")
+			}
+			// Station commits carry messages line writes itself.
+			if os.Getenv("LINE_RUNNING") == "1" {
+				return nil
+			}
+		default:
+			return fmt.Errorf("--hook: %q is not one of pre-commit, commit-msg, pre-push", gateHook)
+		}
 		cfg, err := config.Load(configPath)
 		if err != nil {
 			return err
 		}
 
-		gates := make([]gate.Gate, len(cfg.Gates))
-		for i, g := range cfg.Gates {
-			gates[i] = gate.Gate{Name: g.Name, Run: g.Run}
+		report := state.GateReport{Hook: gateHook, Started: time.Now()}
+		if gateHook == "pre-push" {
+			remote := ""
+			if len(args) > 0 {
+				remote = args[0]
+			}
+			updates, err := gate.ReadPushUpdates(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("reading pushed refs: %w", err)
+			}
+			err = runPushGates(".", cfg, remote, updates, &report)
+			finishGateReport(&report)
+			if err != nil {
+				return fmt.Errorf("push gates failed: %w", err)
+			}
+			return nil
 		}
 
-		if err := gate.RunGates(gates, "."); err != nil {
+		gates := gate.FromConfig(cfg.GatesFor(gateHook))
+		switch {
+		case gateHook == config.GateHookCommitMsg:
+			gates = withMsgFile(gates, args[0])
+			report.Gates, err = gate.Run(gates, ".", stderr(cfg), verbose)
+		case cfg.Settings.GateStaged && len(gates) > 0 && git.HasUnstagedChanges("."):
+			report.Gates, err = runGatesStaged(".", cfg, gates)
+		default:
+			report.Gates, err = gate.Run(gates, ".", stderr(cfg), verbose)
+		}
+		finishGateReport(&report)
+		if err != nil {
 			return fmt.Errorf("gates failed: %w", err)
 		}
 
@@ -30,6 +79,162 @@ var gateCmd = &cobra.Command{
 	},
 }
 
+// withMsgFile substitutes the commit message file for {msg_file} in the
+// gates' commands, and sets LINE_MSG_FILE for them.
+func withMsgFile(gates []gate.Gate, path string) []gate.Gate {
+	os.Setenv("LINE_MSG_FILE", path)
+	quoted := "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	for i := range gates {
+		gates[i].Run = strings.ReplaceAll(gates[i].Run, "{msg_file}", quoted)
+	}
+	return gates
+}
+
+// finishGateReport completes the report and writes .line/gate-last.json. A
+// report that cannot be written only warns: the gates decide the outcome.
+func finishGateReport(r *state.GateReport) {
+	r.Finished = time.Now()
+	r.OK = true
+	if r.Gates == nil {
+		r.Gates = []state.GateResult{}
+	}
+	for _, g := range r.Gates {
+		if !g.OK {
+			r.OK, r.Failed = false, g.Name
+		}
+	}
+	if err := state.WriteGateReport(".", *r); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: writing gate report: %v\n", err)
+	}
+}
+
+// runPushGates runs the push gates once per commit being pushed, with
+// LINE_PUSH_REMOTE, LINE_PUSH_REF, LINE_PUSH_FROM and LINE_PUSH_TO set.
+// A clean HEAD is checked in place; any other commit in a throwaway
+// detached worktree, so what is checked is what is pushed.
+func runPushGates(dir string, cfg *config.Config, remote string, updates []gate.PushUpdate, report *state.GateReport) error {
+	if len(cfg.PushGates) == 0 {
+		return nil
+	}
+	head, _ := git.Run(dir, "rev-parse", "HEAD")
+	dirty, _ := git.IsDirty(dir)
+	checked := map[string]bool{}
+	for _, u := range updates {
+		if checked[u.LocalSHA] {
+			continue
+		}
+		checked[u.LocalSHA] = true
+		fmt.Fprintf(os.Stderr, "gate: checking %s (%s)\n", u.RemoteRef, git.ShortHash(u.LocalSHA))
+
+		// The gates are child processes: they inherit these.
+		os.Setenv("LINE_PUSH_REMOTE", remote)
+		os.Setenv("LINE_PUSH_REF", u.RemoteRef)
+		os.Setenv("LINE_PUSH_FROM", u.RemoteSHA)
+		os.Setenv("LINE_PUSH_TO", u.LocalSHA)
+
+		var (
+			results []state.GateResult
+			err     error
+		)
+		if u.LocalSHA == head && !dirty {
+			results, err = gate.Run(gate.FromConfig(cfg.PushGates), dir, stderr(cfg), verbose)
+		} else {
+			results, err = runGatesAt(dir, cfg, u.LocalSHA)
+		}
+		for _, r := range results {
+			r.Ref = u.RemoteRef
+			report.Gates = append(report.Gates, r)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", u.RemoteRef, err)
+		}
+	}
+	return nil
+}
+
+// runGatesAt runs the push gates in a throwaway detached worktree of commit.
+func runGatesAt(dir string, cfg *config.Config, commit string) ([]state.GateResult, error) {
+	tmp, err := paths.TempDir(dir, "push-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating gate worktree dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	wtPath := filepath.Join(tmp, "tree")
+	if err := git.AddDetachedWorktree(dir, wtPath, commit); err != nil {
+		return nil, fmt.Errorf("adding gate worktree: %w", err)
+	}
+	defer func() { _ = git.RemoveWorktree(dir, wtPath) }()
+	if wt := cfg.Settings.Worktree; wt != nil {
+		if _, err := git.ProvisionWorktree(dir, wtPath, wt.Copy, wt.Symlink); err != nil {
+			return nil, err
+		}
+	}
+	return gate.Run(gate.FromConfig(cfg.PushGates), wtPath, stderr(cfg), verbose)
+}
+
+// runGatesStaged runs the pre-commit gates against exactly what is staged
+// (settings.gate_staged): in a throwaway worktree holding the staged tree,
+// so unstaged edits cannot make them pass or fail. Before the first commit
+// there is nothing to check out from, and the working tree is checked.
+func runGatesStaged(dir string, cfg *config.Config, gates []gate.Gate) ([]state.GateResult, error) {
+	tree, err := git.StagedTree(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading staged files: %w", err)
+	}
+	if _, err := git.Run(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		fmt.Fprintln(os.Stderr, "gate: no commits yet, checking the working tree")
+		return gate.Run(gates, dir, stderr(cfg), verbose)
+	}
+	tmp, err := paths.TempDir(dir, "staged-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating gate worktree dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	wtPath := filepath.Join(tmp, "tree")
+	if err := git.AddDetachedWorktree(dir, wtPath, "HEAD"); err != nil {
+		return nil, fmt.Errorf("adding gate worktree: %w", err)
+	}
+	defer func() { _ = git.RemoveWorktree(dir, wtPath) }()
+	if err := git.CheckoutTree(wtPath, tree); err != nil {
+		return nil, fmt.Errorf("checking out staged files: %w", err)
+	}
+	if wt := cfg.Settings.Worktree; wt != nil {
+		if _, err := git.ProvisionWorktree(dir, wtPath, wt.Copy, wt.Symlink); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintln(os.Stderr, "gate: unstaged changes, checking the staged files only")
+	return gate.Run(gates, wtPath, stderr(cfg), verbose)
+}
+
+var gateExportFormat string
+
+var gateExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the gates as a pre-commit or lefthook config",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gateExportFormat != gate.FormatPreCommit && gateExportFormat != gate.FormatLefthook {
+			return fmt.Errorf("--format: %q is not one of %s, %s", gateExportFormat, gate.FormatPreCommit, gate.FormatLefthook)
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		out, err := gate.Export(cfg, gateExportFormat, filepath.Base(configPath))
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(out)
+		return err
+	},
+}
+
 func init() {
+	gateExportCmd.Flags().StringVar(&gateExportFormat, "format", "", "config to write: pre-commit (.pre-commit-config.yaml) or lefthook (lefthook.yml)")
+	gateCmd.AddCommand(gateExportCmd)
+	gateCmd.Flags().StringVar(&gateHook, "hook", "pre-commit", "which gates to run: pre-commit (gates), commit-msg (gates with hook: commit-msg, for the message file argument) or pre-push (push_gates, for the refs on stdin)")
 	rootCmd.AddCommand(gateCmd)
 }
@@ -2,21 +2,46 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/re-cinq/assembly-line/internal/git"
 	"github.com/re-cinq/assembly-line/internal/gitignore"
 	"github.com/re-cinq/assembly-line/internal/hooks"
+	"github.com/re-cinq/assembly-line/internal/preset"
 	"github.com/re-cinq/assembly-line/internal/settings"
 	"github.com/re-cinq/assembly-line/internal/skill"
 	"github.com/spf13/cobra"
 )
 
+var (
+	initPreset  string
+	initPrePush bool
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Install assembly-line git hooks and skills in the current repository",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// --preset writes line.yaml first so a clash fails before
+		// anything is installed.
+		if initPreset != "" {
+			watches, err := git.CurrentBranch(".")
+			if err != nil || watches == "" || watches == "HEAD" {
+				watches = "main"
+			}
+			if err := preset.Write(configPath, initPreset, watches); err != nil {
+				return err
+			}
+			fmt.Printf("wrote %s from the %s preset (watching %s)\n", configPath, initPreset, watches)
+		}
 		if err := hooks.Install("."); err != nil {
 			return fmt.Errorf("installing hooks: %w", err)
 		}
+		if initPrePush {
+			if err := hooks.InstallPrePush("."); err != nil {
+				return fmt.Errorf("installing hooks: %w", err)
+			}
+		}
 		if err := skill.Install("."); err != nil {
 			return fmt.Errorf("installing skills: %w", err)
 		}
@@ -35,5 +60,8 @@ var initCmd = &cobra.Command{
 }
 
 func init() {
+	initCmd.Flags().StringVar(&initPreset, "preset", "", "write line.yaml from a built-in preset ("+strings.Join(preset.Names(), ", ")+")")
+	initCmd.Flags().BoolVar(&initPrePush, "pre-push", false, "also install a pre-push hook running the push_gates")
+	_ = initCmd.RegisterFlagCompletionFunc("preset", cobra.FixedCompletions(preset.Names(), cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.AddCommand(initCmd)
 }
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var lintPromptsCmd = &cobra.Command{
+	Use:   "lint-prompts",
+	Short: "Check station prompts for common mistakes before they cost agent runs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		problems := config.LintPrompts(cfg)
+		if len(problems) == 0 {
+			fmt.Println("no problems found")
+			return nil
+		}
+
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		os.Exit(1)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintPromptsCmd)
+}
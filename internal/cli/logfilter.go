@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// logFilterCmd is what tmux pipes agent output through on its way to the
+// station log with settings.log_filter: strip-ansi.
+var logFilterCmd = &cobra.Command{
+	Use:    "log-filter",
+	Short:  "Copy stdin to stdout without ANSI escape sequences",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return state.CopyStripANSI(os.Stdout, os.Stdin)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logFilterCmd)
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+// Global logging flags.
+var (
+	logFormat string
+	verbose   bool
+	quiet     bool
+)
+
+// logLevel maps --verbose and --quiet onto a slog level.
+func logLevel() slog.Level {
+	switch {
+	case verbose:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// checkLogFlags validates --log-format.
+func checkLogFlags() error {
+	switch logFormat {
+	case "text", "json":
+		return nil
+	}
+	return fmt.Errorf("--log-format: %q is not one of text, json", logFormat)
+}
+
+// runEvents returns the sink for a run's progress: line's usual text output,
+// through printers, or one JSON record per event on stderr for log
+// collectors.
+func runEvents(cfg *config.Config) runner.EventSink {
+	if logFormat == "json" {
+		h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel()})
+		return runner.LogSink{Logger: slog.New(h)}
+	}
+	sink := runner.NewTextSink()
+	sink.Out, sink.Err = stdout(cfg), stderr(cfg)
+	sink.Level = logLevel()
+	return sink
+}
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&logFormat, "log-format", "text", "log format for run output: text or json")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "also log routine steps (debug level), and show the output of gates that pass")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "only log warnings and errors")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return checkLogFlags()
+	}
+}
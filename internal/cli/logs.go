@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsLines  int
+	logsFollow bool
+	logsMerge  bool
+	logsRun    string
+	logsRaw    bool
+)
+
+// logsInterval is how often line logs -f looks for new output.
+const logsInterval = 500 * time.Millisecond
+
+// logColors are the prefix colors line logs --merge gives stations, in
+// line order.
+var logColors = []string{colorCyan, colorMagenta, colorGreen, colorOrange, colorYellow, colorRed}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [station...]",
+	Short: "Print a station's log, or interleave several with --merge",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		if logsRun != "" {
+			if logsMerge || logsFollow || len(args) > 1 {
+				return fmt.Errorf("--run prints one finished run: it takes at most one station, and no --merge or -f")
+			}
+			return printRunLog(".", cfg, args, logsRun, logsPrinter(cfg))
+		}
+		if !logsMerge && len(args) != 1 {
+			return fmt.Errorf("name one station, or use --merge to interleave several")
+		}
+		logs, err := stationLogs(cfg, args)
+		if err != nil {
+			return err
+		}
+		w := logsPrinter(cfg)
+		logs.printTail(w, logsLines)
+		for logsFollow {
+			time.Sleep(logsInterval)
+			logs.printNew(w, time.Now())
+		}
+		return nil
+	},
+}
+
+// logsPrinter returns where line logs prints: stdout, or with --raw a
+// printer that passes the logs' escape sequences through as stored.
+func logsPrinter(cfg *config.Config) *printer {
+	if logsRaw {
+		return newPrinter(os.Stdout, false, true)
+	}
+	return stdout(cfg)
+}
+
+// logText returns log text as line logs prints it: without ANSI escapes
+// unless --raw.
+func logText(s string) string {
+	if logsRaw {
+		return s
+	}
+	return state.StripANSI(s)
+}
+
+// printRunLog prints what the run with the given ID wrote to its station's
+// log, looking it up in the run history of the named station or of every
+// station.
+func printRunLog(dir string, cfg *config.Config, names []string, id string, w *printer) error {
+	if len(names) == 0 {
+		for _, s := range cfg.AllStations() {
+			names = append(names, s.Name)
+		}
+	}
+	for _, name := range names {
+		if _, ok := findStation(cfg, name); !ok {
+			return fmt.Errorf("unknown station %q", name)
+		}
+		for _, r := range state.ReadStationRuns(dir, name) {
+			if r.ID != id {
+				continue
+			}
+			if r.LogTo <= r.LogFrom {
+				return fmt.Errorf("run %s of %s wrote nothing to its log", id, name)
+			}
+			text, err := state.ReadStationLogRange(dir, name, r.LogFrom, r.LogTo)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, strings.Trim(logText(text), "\n"))
+			return nil
+		}
+	}
+	return fmt.Errorf("no run %s in the history of %s", id, strings.Join(names, ", "))
+}
+
+// findStation returns the station of the line with the given name, and its
+// index in line order.
+func findStation(cfg *config.Config, name string) (int, bool) {
+	i := slices.IndexFunc(cfg.AllStations(), func(s config.Station) bool { return s.Name == name })
+	return i, i >= 0
+}
+
+// stationLog is one station's log as line logs reads it.
+type stationLog struct {
+	name    string
+	path    string
+	color   string
+	offset  int64  // bytes read so far
+	partial string // an unterminated last line, held back until it ends
+}
+
+// logSet is the logs line logs prints. With merge set each line is
+// prefixed with when it was written and the station's name.
+type logSet struct {
+	logs  []*stationLog
+	merge bool
+	width int // longest station name
+}
+
+// stationLogs returns the logs of the named stations, or of every station
+// of the line when none are named.
+func stationLogs(cfg *config.Config, names []string) (*logSet, error) {
+	if len(names) == 0 {
+		for _, s := range cfg.AllStations() {
+			names = append(names, s.Name)
+		}
+	}
+	set := &logSet{merge: logsMerge}
+	for _, name := range names {
+		i, ok := findStation(cfg, name)
+		if !ok {
+			return nil, fmt.Errorf("unknown station %q", name)
+		}
+		set.logs = append(set.logs, &stationLog{name: name, path: state.StationLogPath(".", name), color: logColors[i%len(logColors)]})
+		set.width = max(set.width, len(name))
+	}
+	return set, nil
+}
+
+// printTail prints the last n lines of each log. Merged, the logs are
+// ordered by when they were last written, each line stamped with that
+// time: the log files do not record when each line was.
+func (set *logSet) printTail(w *printer, n int) {
+	type tail struct {
+		log     *stationLog
+		lines   []string
+		written time.Time
+	}
+	var tails []tail
+	for _, l := range set.logs {
+		fi, err := os.Stat(l.path)
+		if err != nil {
+			continue
+		}
+		l.offset = fi.Size()
+		lines := state.StationLogTail(".", l.name, n)
+		if logsRaw {
+			lines = state.StationLogTailRaw(".", l.name, n)
+		}
+		tails = append(tails, tail{l, lines, fi.ModTime()})
+	}
+	slices.SortStableFunc(tails, func(a, b tail) int { return a.written.Compare(b.written) })
+	for _, t := range tails {
+		set.print(w, t.log, t.written, t.lines)
+	}
+}
+
+// printNew prints the lines appended to each log since it was last read,
+// stamped with now, the logs written first first.
+func (set *logSet) printNew(w *printer, now time.Time) {
+	type update struct {
+		log     *stationLog
+		lines   []string
+		written time.Time
+	}
+	var updates []update
+	for _, l := range set.logs {
+		lines, written := l.readNew()
+		if len(lines) > 0 {
+			updates = append(updates, update{l, lines, written})
+		}
+	}
+	slices.SortStableFunc(updates, func(a, b update) int { return a.written.Compare(b.written) })
+	for _, u := range updates {
+		set.print(w, u.log, now, u.lines)
+	}
+}
+
+// readNew returns the complete lines appended to the log since it was last
+// read, and when the log was last written.
+func (l *stationLog) readNew() ([]string, time.Time) {
+	fi, err := os.Stat(l.path)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	if fi.Size() < l.offset {
+		l.offset, l.partial = 0, "" // cleared (line clear)
+	}
+	if fi.Size() == l.offset {
+		return nil, fi.ModTime()
+	}
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	defer f.Close()
+	if _, err := f.Seek(l.offset, io.SeekStart); err != nil {
+		return nil, time.Time{}
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	l.offset += int64(len(data))
+	text := l.partial + string(data)
+	end := strings.LastIndex(text, "\n")
+	l.partial = text[end+1:]
+	if end < 0 {
+		return nil, fi.ModTime()
+	}
+	return strings.Split(logText(text[:end]), "\n"), fi.ModTime()
+}
+
+// print prints lines of a log, prefixed with written and the station's
+// name when merged.
+func (set *logSet) print(w *printer, l *stationLog, written time.Time, lines []string) {
+	for _, line := range lines {
+		if !set.merge {
+			fmt.Fprintln(w, line)
+			continue
+		}
+		prefix := fmt.Sprintf("%s %-*s │", written.Local().Format(time.TimeOnly), set.width, l.name)
+		fmt.Fprintf(w, "%s %s\n", w.paint(l.color, prefix), line)
+	}
+}
+
+func init() {
+	logsCmd.ValidArgsFunction = completeStations(nil)
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 10, "number of log lines to show from each log")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "keep printing lines as they are written")
+	logsCmd.Flags().BoolVar(&logsMerge, "merge", false, "interleave the logs of several stations (all by default), prefixing each line with its time and station")
+	logsCmd.Flags().BoolVar(&logsRaw, "raw", false, "print the logs as stored, escape sequences included")
+	logsCmd.Flags().StringVar(&logsRun, "run", "", "print what the run with this ID (line show, LINE_RUN_ID) wrote to the log")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
+	"github.com/spf13/cobra"
+)
+
+var migrateStationForce bool
+
+var migrateStationCmd = &cobra.Command{
+	Use:   "migrate-station <old> <new>",
+	Short: "Carry a renamed station's branch and state over to its new name",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		if statusdata.RunnerActive(".") {
+			return fmt.Errorf("line run is active; wait for it to finish or stop it with line clear")
+		}
+		if err := runner.MigrateStation(".", cfg, args[0], args[1], migrateStationForce); err != nil {
+			return err
+		}
+		fmt.Printf("Moved %s's branch and state to %s.\n", args[0], args[1])
+		return nil
+	},
+}
+
+// orphanWarnings describes the station branches and state the config has
+// no station for, with how to carry them over if a station was renamed.
+func orphanWarnings(dir string, cfg *config.Config) []string {
+	var warnings []string
+	for _, name := range runner.OrphanedStations(dir, cfg) {
+		warnings = append(warnings, fmt.Sprintf("⚠ branch or state of unknown station %q — renamed? line migrate-station %s <new name>", name, name))
+	}
+	return warnings
+}
+
+// completeOrphans completes the first argument of migrate-station with the
+// orphaned station names and the second with the config's stations.
+func completeOrphans(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 1 {
+		return completeStations(nil)(cmd, nil, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, name := range runner.OrphanedStations(".", cfg) {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	migrateStationCmd.Flags().BoolVar(&migrateStationForce, "force", false, "replace the branch and state the new name already has")
+	migrateStationCmd.ValidArgsFunction = completeOrphans
+	rootCmd.AddCommand(migrateStationCmd)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/paths"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// pathNames lists the entries printed by line paths, in order.
+var pathNames = []string{"config", "global", "state", "logs", "worktrees"}
+
+var pathsCmd = &cobra.Command{
+	Use:       "paths [config|global|state|logs|worktrees]",
+	Short:     "Print where line keeps its config, state, logs and worktrees",
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: pathNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, err := linePaths(".")
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			fmt.Println(all[args[0]])
+			return nil
+		}
+		for _, name := range pathNames {
+			fmt.Printf("%-10s %s\n", name, all[name])
+		}
+		return nil
+	},
+}
+
+// linePaths resolves every location line uses for the repo in dir.
+func linePaths(dir string) (map[string]string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := paths.Logs(abs)
+	if err != nil {
+		return nil, err
+	}
+	worktrees, err := paths.Worktrees(abs)
+	if err != nil {
+		return nil, err
+	}
+	cfgPath := configPath
+	if !filepath.IsAbs(cfgPath) {
+		cfgPath = filepath.Join(abs, cfgPath)
+	}
+	return map[string]string{
+		"config":    cfgPath,
+		"global":    config.GlobalPath(),
+		"state":     state.Dir(abs),
+		"logs":      logs,
+		"worktrees": worktrees,
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(pathsCmd)
+}
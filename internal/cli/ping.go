@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/hooks"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pingMaxAge time.Duration
+	pingJSON   bool
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check that the line is alive and keeping up, for monitoring and cron",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		checks := ping(".", cfg, pingMaxAge, time.Now())
+		if pingJSON {
+			if err := writePingJSON(os.Stdout, checks); err != nil {
+				return err
+			}
+		} else {
+			writePingText(stdout(cfg), checks)
+		}
+		failed := 0
+		for _, c := range checks {
+			if !c.OK {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("unhealthy: %s failed", plural(failed, "check"))
+		}
+		return nil
+	},
+}
+
+// pingCheck is one health check and what it found.
+type pingCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// ping checks that line run is triggered, is not stuck or dead, and has
+// finished a cycle for the watched branch's head within maxAge of it
+// being committed.
+func ping(dir string, cfg *config.Config, maxAge time.Duration, now time.Time) []pingCheck {
+	var checks []pingCheck
+
+	trigger := pingCheck{Name: "trigger", OK: true, Detail: "post-commit hook installed"}
+	if !hooks.Installed(dir, "post-commit") {
+		trigger = pingCheck{Name: "trigger", Detail: "post-commit hook missing or not executable; run line init"}
+	} else if state.Disabled(dir) {
+		trigger = pingCheck{Name: "trigger", Detail: "pipeline disabled (.line/disabled or LINE_DISABLED)"}
+	}
+	checks = append(checks, trigger)
+
+	pid, _ := state.ReadPID(dir)
+	running := pid > 0 && state.IsProcessRunning(pid)
+	runner := pingCheck{Name: "runner", OK: true, Detail: "idle"}
+	switch {
+	case running:
+		runner.Detail = fmt.Sprintf("running (pid %d)", pid)
+	case pid > 0:
+		runner = pingCheck{Name: "runner", Detail: fmt.Sprintf("pid %d exited without finishing its run", pid)}
+	}
+	checks = append(checks, runner)
+
+	return append(checks, cycleCheck(dir, cfg, running, maxAge, now))
+}
+
+// cycleCheck checks that the watched branch's head was run or skipped, or
+// is still within maxAge of being committed.
+func cycleCheck(dir string, cfg *config.Config, running bool, maxAge time.Duration, now time.Time) pingCheck {
+	check := pingCheck{Name: "cycle"}
+	head, err := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+	if err != nil {
+		check.Detail = fmt.Sprintf("cannot resolve %s: %v", cfg.Settings.Watches, err)
+		return check
+	}
+	short := git.ShortHash(head)
+	if c, ok := state.ReadLastCycle(dir); ok && c.Commit == head {
+		check.OK = true
+		check.Detail = fmt.Sprintf("%s finished %s ago", short, age(now.Sub(c.Finished)))
+		return check
+	}
+	for _, s := range state.ReadSkippedCommits(dir) {
+		if s.Commit == head {
+			check.OK = true
+			check.Detail = fmt.Sprintf("%s skipped (%s)", short, s.Reason)
+			return check
+		}
+	}
+
+	committed := now
+	if ct, err := git.Run(dir, "log", "-1", "--format=%ct", head); err == nil {
+		if secs, err := strconv.ParseInt(ct, 10, 64); err == nil {
+			committed = time.Unix(secs, 0)
+		}
+	}
+	waiting := now.Sub(committed)
+	check.OK = waiting <= maxAge
+	switch {
+	case running:
+		check.Detail = fmt.Sprintf("%s running, committed %s ago", short, age(waiting))
+	default:
+		check.Detail = fmt.Sprintf("%s not run, committed %s ago", short, age(waiting))
+	}
+	if !check.OK {
+		check.Detail += fmt.Sprintf(" (max age %s)", maxAge)
+	}
+	return check
+}
+
+// age formats a duration to the second.
+func age(d time.Duration) string {
+	return max(d, 0).Round(time.Second).String()
+}
+
+// writePingText prints one line per check.
+func writePingText(out *printer, checks []pingCheck) {
+	for _, c := range checks {
+		mark := out.paint(colorGreen, "✓")
+		if !c.OK {
+			mark = out.paint(colorRed, "✗")
+		}
+		fmt.Fprintf(out, "%s %-8s %s\n", mark, c.Name, c.Detail)
+	}
+}
+
+// writePingJSON prints the checks and overall health as JSON.
+func writePingJSON(out io.Writer, checks []pingCheck) error {
+	healthy := true
+	for _, c := range checks {
+		healthy = healthy && c.OK
+	}
+	data, err := json.MarshalIndent(struct {
+		Healthy bool        `json:"healthy"`
+		Checks  []pingCheck `json:"checks"`
+	}{healthy, checks}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+func init() {
+	pingCmd.Flags().DurationVar(&pingMaxAge, "max-age", time.Hour, "how long after a commit to the watched branch its cycle may still be pending")
+	pingCmd.Flags().BoolVar(&pingJSON, "json", false, "print the checks as JSON")
+	rootCmd.AddCommand(pingCmd)
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"golang.org/x/term"
+)
+
+// ANSI color codes for STAT-2 color coding
+const (
+	colorReset   = "\033[0m"
+	colorGreen   = "\033[32m"
+	colorOrange  = "\033[33m"
+	colorYellow  = "\033[93m"
+	colorRed     = "\033[31m"
+	colorGrey    = "\033[90m"
+	colorMagenta = "\033[35m"
+	colorCyan    = "\033[36m"
+)
+
+// noColorFlag is set by --no-color.
+var noColorFlag bool
+
+// sgrRE matches ANSI color and style sequences (SGR), but not the cursor
+// movement status -f redraws with.
+var sgrRE = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// printer is where commands print for people. It rewrites symbols into
+// ASCII when asked to, and drops colors where they would be noise: when it
+// is not writing to a terminal, and with --no-color or NO_COLOR. Colors in
+// what passes through, like agent output, are dropped along with line's own.
+type printer struct {
+	w     io.Writer
+	ascii bool
+	color bool
+}
+
+// newPrinter returns a printer writing to w. Tests and commands with
+// output of their own can build one for any writer.
+func newPrinter(w io.Writer, ascii, color bool) *printer {
+	return &printer{w: w, ascii: ascii, color: color}
+}
+
+// stdout returns the printer for a command's output on os.Stdout, per
+// --ascii and settings.ascii and colorOutput.
+func stdout(cfg *config.Config) *printer {
+	return newPrinter(os.Stdout, asciiOutput(cfg), colorOutput(os.Stdout))
+}
+
+// stderr is stdout for os.Stderr, where run and gate report progress.
+func stderr(cfg *config.Config) *printer {
+	return newPrinter(os.Stderr, asciiOutput(cfg), colorOutput(os.Stderr))
+}
+
+// colorAllowed reports whether colors may be used at all: neither
+// --no-color nor NO_COLOR (https://no-color.org) is set.
+func colorAllowed() bool {
+	return !noColorFlag && os.Getenv("NO_COLOR") == ""
+}
+
+// colorOutput reports whether output to f is colored: when colors are
+// allowed and f is a terminal, or CLICOLOR_FORCE is set to keep them in
+// pipes and logs.
+func colorOutput(f *os.File) bool {
+	if !colorAllowed() {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// paint wraps text in color, or returns it as is when the printer is not
+// colored.
+func (p *printer) paint(color, text string) string {
+	if !p.color || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+func (p *printer) Write(b []byte) (int, error) {
+	s := string(b)
+	if !p.color {
+		s = sgrRE.ReplaceAllString(s, "")
+	}
+	var err error
+	if p.ascii {
+		_, err = asciiReplacer.WriteString(p.w, s)
+	} else {
+		_, err = io.WriteString(p.w, s)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "print without ANSI colors (also when NO_COLOR is set; CLICOLOR_FORCE=1 keeps colors when output is not a terminal)")
+}
@@ -10,12 +10,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var leaveConflicts bool
+var (
+	leaveConflicts bool
+	forceRebase    bool
+	forceReason    string
+)
 
 var rebaseCmd = &cobra.Command{
 	Use:   "rebase",
 	Short: "Rebase onto the terminal station branch to pick up line changes",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if forceRebase && strings.TrimSpace(forceReason) == "" {
+			return fmt.Errorf("--force requires a justification via --reason")
+		}
+
 		cfg, err := config.Load(configPath)
 		if err != nil {
 			return err
@@ -23,6 +31,8 @@ var rebaseCmd = &cobra.Command{
 
 		r := rebase.Run(".", cfg, rebase.Options{
 			LeaveConflicts: leaveConflicts,
+			Force:          forceRebase,
+			Reason:         forceReason,
 		})
 
 		if r.Error != nil {
@@ -32,6 +42,12 @@ var rebaseCmd = &cobra.Command{
 			fmt.Println("Nothing to rebase — already up to date.")
 			return nil
 		}
+		if r.GateFailed && !r.Rebased {
+			fmt.Print(r.GateOutput)
+			fmt.Println("Refusing to rebase: gates failed against the terminal station branch.")
+			fmt.Println("Use --force --reason \"<justification>\" to land anyway.")
+			return fmt.Errorf("rebase blocked: %w", r.GateError)
+		}
 		if r.Conflict {
 			if leaveConflicts {
 				return printConflictInstructions(r)
@@ -51,6 +67,9 @@ var rebaseCmd = &cobra.Command{
 			fmt.Printf(" Changed files: %s", strings.Join(r.ChangedFiles, ", "))
 		}
 		fmt.Println()
+		if r.GateFailed {
+			fmt.Printf("Gates failed (%v); override recorded with reason: %s\n", r.GateError, forceReason)
+		}
 
 		return nil
 	},
@@ -75,5 +94,9 @@ func printConflictInstructions(r rebase.Result) error {
 func init() {
 	rebaseCmd.Flags().BoolVar(&leaveConflicts, "leave-conflicts", false,
 		"Leave git in mid-rebase state with conflict markers instead of aborting")
+	rebaseCmd.Flags().BoolVar(&forceRebase, "force", false,
+		"Rebase even if gates fail against the terminal station branch (requires --reason)")
+	rebaseCmd.Flags().StringVar(&forceReason, "reason", "",
+		"Justification recorded in a Gate-Override trailer when using --force")
 	rootCmd.AddCommand(rebaseCmd)
 }
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var releaseDryRun bool
+
+var releaseCmd = &cobra.Command{
+	Use:   "release <version>",
+	Short: "Tag the watched branch with release notes collated since the last tag",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		version := args[0]
+		if _, err := git.Run(".", "check-ref-format", "refs/tags/"+version); err != nil {
+			return fmt.Errorf("%q is not a valid tag name", version)
+		}
+		if _, err := git.Run(".", "rev-parse", "--verify", "--quiet", "refs/tags/"+version); err == nil {
+			return fmt.Errorf("tag %s already exists", version)
+		}
+		to, err := git.Run(".", "rev-parse", "--verify", cfg.Settings.Watches+"^{commit}")
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", cfg.Settings.Watches, err)
+		}
+		from, _ := git.Run(".", "describe", "--tags", "--abbrev=0", to)
+
+		notes := runner.ReleaseNotes(".", cfg, version, from, to)
+		fmt.Print(notes)
+		if releaseDryRun {
+			return nil
+		}
+		if _, err := git.Run(".", "tag", "--annotate", "--cleanup=verbatim", "--message", notes, version, to); err != nil {
+			return fmt.Errorf("tagging %s: %w", version, err)
+		}
+		fmt.Printf("\ntagged %s at %s (%s)\n", version, git.ShortHash(to), cfg.Settings.Watches)
+		return nil
+	},
+}
+
+func init() {
+	releaseCmd.Flags().BoolVar(&releaseDryRun, "dry-run", false, "print the release notes without tagging")
+	rootCmd.AddCommand(releaseCmd)
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/paths"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
+	"github.com/spf13/cobra"
+)
+
+var resolveMergetool bool
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <station>",
+	Short: "Resolve a station's rebase conflict by hand",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		return resolveStation(".", cfg, args[0])
+	},
+}
+
+// resolveStation replays a conflicted station's rebase onto its predecessor
+// in a throwaway worktree and hands it to the user (a shell, or git
+// mergetool). The station branch only moves if the rebase completes.
+func resolveStation(dir string, cfg *config.Config, name string) error {
+	predecessor, ok := stationPredecessor(cfg, name)
+	if !ok {
+		return fmt.Errorf("unknown station %q", name)
+	}
+	if _, conflicted := state.ReadStationConflict(dir, name); !conflicted {
+		return fmt.Errorf("station %s has no recorded conflict", name)
+	}
+	if statusdata.RunnerActive(dir) {
+		return fmt.Errorf("a line run is in progress; wait for it to finish or run line clear")
+	}
+
+	tmpDir, err := paths.TempDir(dir, "resolve-*")
+	if err != nil {
+		return err
+	}
+	wtPath := filepath.Join(tmpDir, name)
+	branchName := git.StationBranchName(name)
+	if err := git.AddWorktree(dir, wtPath, branchName); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("adding worktree: %w", err)
+	}
+	defer func() {
+		_ = git.RemoveWorktree(dir, wtPath)
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	opts := runner.CommitOptions(cfg.Settings)
+	if err := runner.RebaseStation(wtPath, predecessor, opts); err == nil {
+		_ = state.RemoveStationConflict(dir, name)
+		fmt.Printf("%s rebased cleanly onto %s — nothing to resolve.\n", branchName, predecessor)
+		return nil
+	}
+
+	for git.RebaseInProgress(wtPath) {
+		files, _ := git.ConflictedFiles(wtPath)
+		if err := handOver(wtPath, branchName, predecessor, files); err != nil {
+			_ = git.RebaseAbort(wtPath)
+			return err
+		}
+		if !git.RebaseInProgress(wtPath) {
+			break // the user ran git rebase --continue themselves
+		}
+		if err := git.RebaseContinue(wtPath, opts); err != nil {
+			// A later commit stopping with fresh conflicts goes round
+			// again; anything else (e.g. markers left behind) gives up.
+			remaining, _ := git.ConflictedFiles(wtPath)
+			if !git.RebaseInProgress(wtPath) || len(remaining) == 0 {
+				_ = git.RebaseAbort(wtPath)
+				return fmt.Errorf("%v; %s is unchanged", err, branchName)
+			}
+		}
+	}
+
+	_ = state.RemoveStationConflict(dir, name)
+	_ = state.RemoveStationFailed(dir, name)
+	fmt.Printf("Resolved: %s is now rebased onto %s. The next line run continues from here.\n", branchName, predecessor)
+	return nil
+}
+
+// handOver gives the stopped rebase to the user: git mergetool with
+// --mergetool, otherwise an interactive $SHELL in the worktree.
+func handOver(wtPath, branchName, predecessor string, files []string) error {
+	var c *exec.Cmd
+	if resolveMergetool {
+		c = exec.Command("git", "mergetool")
+	} else {
+		fmt.Printf("Rebasing %s onto %s stopped with conflicts in:\n", branchName, predecessor)
+		for _, f := range files {
+			fmt.Printf("  %s\n", f)
+		}
+		fmt.Printf("\nA shell is open in %s.\n", wtPath)
+		fmt.Println("Fix the conflict markers, then exit the shell; line stages the files and continues the rebase.")
+		fmt.Println("Exit with conflicts still present to abort and leave the branch unchanged.")
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		c = exec.Command(shell)
+	}
+	c.Dir = wtPath
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(git.CleanEnv(os.Environ(), "GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE"), "LINE_RUNNING=1")
+	if err := c.Run(); err != nil && resolveMergetool {
+		return fmt.Errorf("git mergetool: %w", err)
+	}
+	return nil
+}
+
+// stationPredecessor returns the branch a station rebases onto: the watched
+// branch for the first station, otherwise the previous station's branch.
+func stationPredecessor(cfg *config.Config, name string) (string, bool) {
+	predecessor := cfg.Settings.Watches
+	for _, s := range cfg.Stations {
+		if s.Name == name {
+			return predecessor, true
+		}
+		predecessor = git.StationBranchName(s.Name)
+	}
+	return "", false
+}
+
+func init() {
+	resolveCmd.Flags().BoolVar(&resolveMergetool, "mergetool", false, "run git mergetool in the worktree instead of opening a shell")
+	rootCmd.AddCommand(resolveCmd)
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var retryCmd = &cobra.Command{
+	Use:   "retry <station>",
+	Short: "Clear a station's failures and quarantine so the next run retries it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		return retryStation(".", cfg, args[0])
+	},
+}
+
+// retryStation resets a station's consecutive failure count, quarantine and
+// failed marker.
+func retryStation(dir string, cfg *config.Config, name string) error {
+	if _, ok := stationPredecessor(cfg, name); !ok {
+		if _, ok := cfg.RefStation(name); !ok {
+			return fmt.Errorf("unknown station %q", name)
+		}
+	}
+	if state.ReadStationBackoff(dir, name).Failures == 0 && !state.ReadStationFailed(dir, name) {
+		return fmt.Errorf("station %s has not failed", name)
+	}
+	if err := state.RemoveStationBackoff(dir, name); err != nil {
+		return err
+	}
+	if err := state.RemoveStationFailed(dir, name); err != nil {
+		return err
+	}
+	fmt.Printf("Cleared %s; it runs again on the next line run.\n", name)
+	return nil
+}
+
+// retryableStation reports whether a station has failures to clear.
+func retryableStation(name string) bool {
+	return state.ReadStationBackoff(".", name).Failures > 0 || state.ReadStationFailed(".", name)
+}
+
+func init() {
+	retryCmd.ValidArgsFunction = completeStations(retryableStation)
+	rootCmd.AddCommand(retryCmd)
+}
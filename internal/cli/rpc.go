@@ -0,0 +1,437 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
+	"github.com/spf13/cobra"
+)
+
+// rpcProtocolVersion is bumped on incompatible changes to line rpc's
+// methods, parameters or results.
+const rpcProtocolVersion = 1
+
+// How often subscriptions look for changes.
+const (
+	rpcStatusInterval = 2 * time.Second
+	rpcLogInterval    = 500 * time.Millisecond
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCommandFailed  = -32000
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Serve status, logs and commands as JSON-RPC on stdin/stdout for editors",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := config.Load(configPath); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return newRPCServer(".", os.Stdin, os.Stdout).serve()
+	},
+}
+
+// rpcError is a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// rpcRequest is an incoming request, or a notification when ID is empty.
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// rpcStationParams are the params of methods that take a station.
+type rpcStationParams struct {
+	Station string `json:"station"`
+	Lines   int    `json:"lines,omitempty"`
+	// Commit is the watched-branch commit a trigger runs for (RUN-49).
+	Commit string `json:"commit,omitempty"`
+}
+
+// rpcStatus is the result of status and the params of status
+// notifications.
+type rpcStatus struct {
+	Active   bool         `json:"active"`
+	Disabled bool         `json:"disabled"`
+	Watches  string       `json:"watches"`
+	Head     string       `json:"head"`
+	Aborted  string       `json:"aborted,omitempty"` // why the last cycle was aborted (RUN-45)
+	Stations []rpcStation `json:"stations"`
+}
+
+// rpcStation is one station in a status snapshot. State is one of the
+// line status names: "pending", "agent running", "awaiting approval",
+// "conflict", "quarantined", "failed", "failed verification", "up to date".
+type rpcStation struct {
+	Name      string     `json:"name"`
+	Branch    string     `json:"branch"`
+	State     string     `json:"state"`
+	Detail    string     `json:"detail,omitempty"`
+	Conflicts []string   `json:"conflicts,omitempty"`
+	Since     *time.Time `json:"since,omitempty"` // when the running agent started
+	Head      string     `json:"head,omitempty"`
+	Ahead     int        `json:"ahead"`  // unpicked commits
+	Behind    int        `json:"behind"` // watched-branch commits not yet picked up
+
+	// The running agent's last heartbeat and the output it had written by
+	// then (RUN-42).
+	HeartbeatAt *time.Time `json:"heartbeat_at,omitempty"`
+	OutputBytes int64      `json:"output_bytes,omitempty"`
+}
+
+// rpcServer speaks JSON-RPC 2.0 with LSP-style Content-Length framing, so
+// editor extensions can reuse their language-client transport.
+type rpcServer struct {
+	dir string
+	in  *bufio.Reader
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	subMu sync.Mutex
+	subs  map[string]context.CancelFunc // by station; "" is the status subscription
+}
+
+func newRPCServer(dir string, in io.Reader, out io.Writer) *rpcServer {
+	return &rpcServer{dir: dir, in: bufio.NewReader(in), out: out, subs: map[string]context.CancelFunc{}}
+}
+
+// serve handles requests until stdin closes or the client sends shutdown.
+// Requests are handled concurrently: a long trigger does not hold up
+// status or log calls.
+func (s *rpcServer) serve() error {
+	defer s.unsubscribeAll()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		body, err := readRPCMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &rpcError{rpcParseError, err.Error()})
+			continue
+		}
+		if req.Method == "shutdown" {
+			s.reply(req.ID, nil, nil)
+			return nil
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.handle(req)
+			if len(req.ID) > 0 {
+				s.reply(req.ID, result, err)
+			}
+		}()
+	}
+}
+
+// handle dispatches a request to its method.
+func (s *rpcServer) handle(req rpcRequest) (any, error) {
+	var p rpcStationParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, &rpcError{rpcCommandFailed, err.Error()}
+	}
+	if p.Station != "" {
+		if _, ok := stationPredecessor(cfg, p.Station); !ok {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("unknown station %q", p.Station)}
+		}
+	}
+	needStation := func() error {
+		if p.Station == "" {
+			return &rpcError{rpcInvalidParams, req.Method + ": station is required"}
+		}
+		return nil
+	}
+
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": rpcProtocolVersion,
+			"version":         Version,
+			"methods":         []string{"initialize", "status", "log", "subscribe", "unsubscribe", "trigger", "retry", "approve", "reject", "shutdown"},
+			"notifications":   []string{"status", "log"},
+		}, nil
+	case "status":
+		return rpcSnapshot(s.dir, cfg), nil
+	case "log":
+		if err := needStation(); err != nil {
+			return nil, err
+		}
+		lines := p.Lines
+		if lines <= 0 {
+			lines = 50
+		}
+		return map[string]any{"station": p.Station, "lines": nonNil(state.StationLogTail(s.dir, p.Station, lines))}, nil
+	case "subscribe":
+		s.subscribe(p.Station)
+		return nil, nil
+	case "unsubscribe":
+		s.unsubscribe(p.Station)
+		return nil, nil
+	case "trigger":
+		if p.Commit == "" && p.Station == "" {
+			return s.runLine("run")
+		}
+		args := []string{"trigger"}
+		if p.Commit != "" {
+			args = append(args, "--commit", p.Commit)
+		}
+		if p.Station != "" {
+			args = append(args, "--station", p.Station)
+		}
+		return s.runLine(args...)
+	case "retry", "approve", "reject":
+		if err := needStation(); err != nil {
+			return nil, err
+		}
+		return s.runLine(req.Method, p.Station)
+	}
+	return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+// runLine runs a line command as a child process, so its progress output
+// cannot interleave with the protocol on stdout.
+func (s *rpcServer) runLine(args ...string) (any, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, &rpcError{rpcCommandFailed, err.Error()}
+	}
+	cmd := exec.Command(self, append(args, "--path", configPath)...)
+	cmd.Dir = s.dir
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(state.StripANSI(string(out)))
+	if err != nil {
+		if output == "" {
+			output = err.Error()
+		}
+		return nil, &rpcError{rpcCommandFailed, output}
+	}
+	return map[string]string{"output": output}, nil
+}
+
+// subscribe starts status notifications (station "") or log notifications
+// for a station. Resubscribing restarts the subscription.
+func (s *rpcServer) subscribe(station string) {
+	s.unsubscribe(station)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.subMu.Lock()
+	s.subs[station] = cancel
+	s.subMu.Unlock()
+	if station == "" {
+		go s.watchStatus(ctx)
+	} else {
+		go s.watchLog(ctx, station)
+	}
+}
+
+func (s *rpcServer) unsubscribe(station string) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if cancel, ok := s.subs[station]; ok {
+		cancel()
+		delete(s.subs, station)
+	}
+}
+
+func (s *rpcServer) unsubscribeAll() {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for station, cancel := range s.subs {
+		cancel()
+		delete(s.subs, station)
+	}
+}
+
+// watchStatus sends a status notification now and whenever the snapshot
+// changes.
+func (s *rpcServer) watchStatus(ctx context.Context) {
+	var last []byte
+	for {
+		if cfg, err := config.Load(configPath); err == nil {
+			snap := rpcSnapshot(s.dir, cfg)
+			if data, _ := json.Marshal(snap); !bytes.Equal(data, last) {
+				last = data
+				s.notify("status", snap)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rpcStatusInterval):
+		}
+	}
+}
+
+// watchLog sends log notifications with the text appended to a station's
+// log since the subscription started.
+func (s *rpcServer) watchLog(ctx context.Context, station string) {
+	path := state.StationLogPath(s.dir, station)
+	var offset int64
+	if fi, err := os.Stat(path); err == nil {
+		offset = fi.Size()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rpcLogInterval):
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			offset = 0
+			continue
+		}
+		if fi.Size() < offset {
+			offset = 0 // cleared (line clear)
+		}
+		if fi.Size() == offset {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.NewSectionReader(f, offset, fi.Size()-offset))
+		f.Close()
+		if err != nil {
+			continue
+		}
+		offset += int64(len(data))
+		s.notify("log", map[string]string{"station": station, "text": state.StripANSI(string(data))})
+	}
+}
+
+// rpcSnapshot computes the same state as line status.
+func rpcSnapshot(dir string, cfg *config.Config) rpcStatus {
+	data, _ := statusdata.Gather(dir, cfg)
+	snap := rpcStatus{
+		Active:   data.Active,
+		Disabled: data.Disabled,
+		Watches:  data.Watches,
+		Head:     git.ShortHash(data.Head),
+		Stations: []rpcStation{},
+	}
+	if c, ok := state.ReadLastCycle(dir); ok {
+		snap.Aborted = c.Aborted
+	}
+	for _, info := range data.Stations {
+		st := rpcStation{Name: info.Name, Branch: info.Branch, State: info.State, Detail: info.Detail, Conflicts: info.Conflicts, Head: git.ShortHash(info.Head)}
+		if info.Running() {
+			st.Since = &info.Since
+			if h, ok := state.ReadStationHeartbeat(dir, info.Name); ok {
+				st.HeartbeatAt, st.OutputBytes = &h.At, h.OutputBytes
+			}
+		}
+		if info.Head != "" && data.Head != "" {
+			st.Ahead, st.Behind, _ = git.RevDistance(dir, data.Head, info.Branch)
+		}
+		snap.Stations = append(snap.Stations, st)
+	}
+	return snap
+}
+
+// nonNil keeps empty lists as [] rather than null in results.
+func nonNil(lines []string) []string {
+	if lines == nil {
+		return []string{}
+	}
+	return lines
+}
+
+// reply sends a response: the result, or the error.
+func (s *rpcServer) reply(id json.RawMessage, result any, err error) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	msg := map[string]any{"jsonrpc": "2.0", "id": id}
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{rpcCommandFailed, err.Error()}
+		}
+		msg["error"] = rerr
+	} else {
+		msg["result"] = result
+	}
+	s.write(msg)
+}
+
+// notify sends a notification to the client.
+func (s *rpcServer) notify(method string, params any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *rpcServer) write(msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+// readRPCMessage reads one Content-Length framed message.
+func readRPCMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading message header: %w", err)
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading message body: %w", err)
+	}
+	return body, nil
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/runner"
 	"github.com/spf13/cobra"
@@ -10,15 +12,40 @@ var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run the assembly line pipeline (post-commit)",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFailOn(failOn); err != nil {
+			return err
+		}
+		if runRange != "" && runStation == "" {
+			return fmt.Errorf("--range needs --station")
+		}
+		if runStation != "" && runRefs {
+			return fmt.Errorf("--station and --refs cannot be combined")
+		}
 		cfg, err := config.Load(configPath)
 		if err != nil {
 			return err
 		}
 
-		return runner.Run(".", cfg)
+		// Failures reported through --fail-on are not usage errors.
+		cmd.SilenceUsage = true
+		var outcome runOutcome
+		if err := runner.Run(".", cfg, runner.Options{Events: outcome.sink(runEvents(cfg)), Refs: runRefs, Station: runStation, Range: runRange}); err != nil {
+			return err
+		}
+		return outcome.err(failOn)
 	},
 }
 
+var (
+	runRefs    bool
+	runStation string
+	runRange   string
+)
+
 func init() {
+	runCmd.Flags().BoolVar(&runRefs, "refs", false, "only run the stations with watches, for new or moved tags and branches")
+	runCmd.Flags().StringVar(&runStation, "station", "", "run just this station now (e.g. one with trigger: manual), then the stations after it")
+	runCmd.Flags().StringVar(&runRange, "range", "", "with --station, the watched-branch commits to review as <from>..<to> (default: those since its last run)")
+	runCmd.Flags().StringVar(&failOn, "fail-on", failOnNone, "exit non-zero when a station fails (station-failure) or also when the run or a station is skipped (skip); none always exits 0")
 	rootCmd.AddCommand(runCmd)
 }
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+// webhookSecretEnv holds the secret GitHub signs webhook deliveries with.
+const webhookSecretEnv = "LINE_WEBHOOK_SECRET"
+
+var (
+	serveGitHub bool
+	serveAddr   string
+	serveRemote string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the line for pushes GitHub reports (--github-webhook)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !serveGitHub {
+			return fmt.Errorf("line serve needs a mode: --github-webhook")
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		secret := os.Getenv(webhookSecretEnv)
+		if secret == "" {
+			return fmt.Errorf("%s is not set; set it to the webhook's secret", webhookSecretEnv)
+		}
+		if _, err := git.Run(".", "remote", "get-url", serveRemote); err != nil {
+			return fmt.Errorf("no remote %q to fetch pushes from", serveRemote)
+		}
+		// Fetching cannot update the branch checked out.
+		if current, _ := git.CurrentBranch("."); current == cfg.Settings.Watches {
+			return fmt.Errorf("%s is checked out here, so fetching cannot update it; check out something else, e.g. git switch --detach", current)
+		}
+
+		ln, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		fmt.Printf("Listening for GitHub push events on http://%s/\n", ln.Addr())
+		srv := &http.Server{
+			Handler:           &webhook.Handler{Secret: []byte(secret), OnPush: (&pushReceiver{dir: "."}).receive},
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		return srv.Serve(ln)
+	},
+}
+
+// pushReceiver fetches the pushed refs the line watches and runs the line
+// for them.
+type pushReceiver struct {
+	dir string
+	mu  sync.Mutex // fetches one push at a time, so ref updates don't race
+}
+
+func (p *pushReceiver) receive(push webhook.Push) webhook.Response {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return webhook.Response{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+	args := pushCommand(cfg, push.Ref, push.After)
+	if args == nil {
+		return webhook.Response{Status: http.StatusOK, Message: fmt.Sprintf("ignored: nothing watches %s", push.Ref)}
+	}
+	if push.Deleted {
+		return webhook.Response{Status: http.StatusOK, Message: fmt.Sprintf("ignored: %s was deleted", push.Ref)}
+	}
+
+	p.mu.Lock()
+	_, err = git.Run(p.dir, "fetch", "--quiet", serveRemote, "+"+push.Ref+":"+push.Ref)
+	p.mu.Unlock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "assembly-line: fetching %s: %v\n", push.Ref, err)
+		return webhook.Response{Status: http.StatusBadGateway, Message: fmt.Sprintf("fetching %s: %v", push.Ref, err)}
+	}
+
+	// The run outlives the delivery, which GitHub times out after 10s;
+	// a later push takes over from it as a later commit would.
+	self, err := os.Executable()
+	if err != nil {
+		return webhook.Response{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+	run := exec.Command(self, append(args, "--path", configPath)...)
+	run.Dir = p.dir
+	run.Stdout, run.Stderr = os.Stderr, os.Stderr
+	if err := run.Start(); err != nil {
+		return webhook.Response{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+	go func() { _ = run.Wait() }()
+	msg := fmt.Sprintf("%s at %s: line %s", push.Ref, git.ShortHash(push.After), strings.Join(args, " "))
+	fmt.Fprintln(os.Stderr, msg)
+	return webhook.Response{Status: http.StatusAccepted, Message: msg}
+}
+
+// pushCommand returns the line command a push moving ref to commit calls
+// for: line trigger for the watched branch, line run --refs for the tags
+// and branches of stations with watches, nil for anything else.
+func pushCommand(cfg *config.Config, ref, commit string) []string {
+	if ref == "refs/heads/"+cfg.Settings.Watches {
+		return []string{"trigger", "--commit", commit}
+	}
+	for _, s := range cfg.RefStations {
+		w, err := config.ParseWatches(s.Watches)
+		if err != nil {
+			continue
+		}
+		if name, ok := strings.CutPrefix(ref, w.Namespace()); ok {
+			if match, _ := path.Match(w.Pattern, name); match {
+				return []string{"run", "--refs"}
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	serveCmd.Flags().BoolVar(&serveGitHub, "github-webhook", false, "receive GitHub push events (signed with $"+webhookSecretEnv+")")
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8787", "address to listen on")
+	serveCmd.Flags().StringVar(&serveRemote, "remote", "origin", "remote to fetch pushed refs from")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
+	"github.com/spf13/cobra"
+)
+
+var showLogLines int
+
+var showCmd = &cobra.Command{
+	Use:   "show <station>",
+	Short: "Show a one-screen summary of a station",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		return showStation(".", cfg, args[0])
+	},
+}
+
+// showStation prints a station's status, last run, latest commit, distance
+// from the watched branch and log tail.
+func showStation(dir string, cfg *config.Config, name string) error {
+	var station config.Station
+	found := false
+	for _, s := range cfg.Stations {
+		if s.Name == name {
+			station, found = s, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("unknown station %q", name)
+	}
+
+	watches := cfg.Settings.Watches
+	branchName := git.StationBranchName(name)
+	watchedFullRef, _ := git.Run(dir, "rev-parse", watches)
+	exists := git.BranchExists(dir, branchName)
+	info := statusdata.StationState(dir, cfg.Settings, station, watchedFullRef, exists)
+
+	status := info.State
+	if len(info.Conflicts) > 0 {
+		status += " in " + strings.Join(info.Conflicts, ", ")
+	}
+	if info.Detail != "" {
+		status += ": " + info.Detail
+	}
+	if info.Running() {
+		status += fmt.Sprintf(" (%s)", statusdata.Uptime(info.Since))
+	}
+	symbol, color := stationLook(info.State)
+
+	w := stdout(cfg)
+	fmt.Fprintf(w, "%-11s%s (%s)\n", "Station:", name, branchName)
+	fmt.Fprintf(w, "%-11s%s\n", "Status:", w.paint(color, symbol+" "+status))
+
+	if run, ok := state.ReadStationLastRun(dir, name); ok {
+		fmt.Fprintf(w, "%-11s%s, took %s — %s\n", "Last run:", run.Started.Local().Format("2006-01-02 15:04:05"),
+			run.Finished.Sub(run.Started).Round(time.Second), run.Result)
+		if run.ID != "" {
+			fmt.Fprintf(w, "%-11s%s\n", "Run ID:", run.ID)
+		}
+		if reviewed := runner.ReviewedSummary(dir, run); reviewed != "" {
+			fmt.Fprintf(w, "%-11s%s\n", "Reviewed:", strings.TrimPrefix(reviewed, "reviewed "))
+		}
+	} else {
+		fmt.Fprintf(w, "%-11s%s\n", "Last run:", "never")
+	}
+
+	if exists {
+		commit, _ := git.Run(dir, "log", "-1", "--format=%h %s (%cr)", branchName)
+		fmt.Fprintf(w, "%-11s%s\n", "Commit:", commit)
+	} else {
+		fmt.Fprintf(w, "%-11s%s\n", "Commit:", "-")
+	}
+
+	watchedShort, _ := git.Run(dir, "log", "-1", "--format=%h %s", watches)
+	fmt.Fprintf(w, "%-11s%s @ %s\n", "Watched:", watches, watchedShort)
+
+	if exists && watchedFullRef != "" {
+		ahead, behind, err := git.RevDistance(dir, watchedFullRef, branchName)
+		if err == nil {
+			fmt.Fprintf(w, "%-11s%d behind %s, %d unpicked\n", "Pending:", behind, watches, ahead)
+		}
+	}
+
+	// RUN-41: the end of a failed agent's output.
+	if exit, ok := state.ReadStationAgentExit(dir, name); ok && len(exit.Output) > 0 && state.ReadStationFailed(dir, name) {
+		out := exit.Output[max(0, len(exit.Output)-showLogLines):]
+		fmt.Fprintf(w, "\nAgent output (last %d lines):\n", len(out))
+		for _, l := range out {
+			fmt.Fprintf(w, "  %s\n", l)
+		}
+	}
+
+	if tail := state.StationLogTail(dir, name, showLogLines); len(tail) > 0 {
+		fmt.Fprintf(w, "\nLog (last %d lines):\n", len(tail))
+		for _, l := range tail {
+			fmt.Fprintf(w, "  %s\n", l)
+		}
+	}
+	return nil
+}
+
+func init() {
+	showCmd.Flags().IntVarP(&showLogLines, "lines", "n", 10, "number of log lines to show")
+	rootCmd.AddCommand(showCmd)
+}
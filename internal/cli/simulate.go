@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateCommit string
+	simulateRange  string
+	simulatePatch  string
+	simulateDryRun bool
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate <station>",
+	Short: "Try a station's prompt in a throwaway worktree without touching the line",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		var station *config.Station
+		for i := range cfg.Stations {
+			if cfg.Stations[i].Name == args[0] {
+				station = &cfg.Stations[i]
+			}
+		}
+		if station == nil {
+			return fmt.Errorf("unknown station %q", args[0])
+		}
+
+		opts := runner.SimulateOptions{Commit: simulateCommit, Patch: simulatePatch, DryRun: simulateDryRun, Out: os.Stdout}
+		if simulateRange != "" {
+			from, to, ok := strings.Cut(simulateRange, "..")
+			if !ok || from == "" || to == "" {
+				return fmt.Errorf("--range: %q is not of the form <from>..<to>", simulateRange)
+			}
+			opts.From, opts.Commit = from, to
+		}
+		cmd.SilenceUsage = true
+		return runner.Simulate(".", cfg, *station, opts)
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateCommit, "commit", "", "commit to run against (default: the watched branch head)")
+	simulateCmd.Flags().StringVar(&simulateRange, "range", "", "commits to review, as <from>..<to>; the agent runs at <to>")
+	simulateCmd.Flags().StringVar(&simulatePatch, "patch", "", "apply this patch on top as a synthetic commit to review")
+	simulateCmd.Flags().BoolVar(&simulateDryRun, "dry-run", false, "print the context without running the agent")
+	simulateCmd.ValidArgsFunction = completeStations(nil)
+	simulateCmd.MarkFlagsMutuallyExclusive("commit", "range")
+	rootCmd.AddCommand(simulateCmd)
+}
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsSince string
+	statsBy    string
+	statsJSON  bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report per-station review and code-churn statistics",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsBy != "day" && statsBy != "week" {
+			return fmt.Errorf("--by: %q is not one of day, week", statsBy)
+		}
+		since, err := parseSince(statsSince, time.Now())
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		report := buildStats(".", cfg, since, statsBy)
+		if statsJSON {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		return writeStatsText(stdout(cfg), report)
+	},
+}
+
+// churnPeriod is the lines a station's commits changed in one day or week.
+type churnPeriod struct {
+	Start   string `json:"start"` // YYYY-MM-DD; weeks start on Monday
+	Commits int    `json:"commits"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+}
+
+// stationStats is one station's activity in the stats window.
+type stationStats struct {
+	Station  string `json:"station"`
+	Reviewed int    `json:"reviewed"` // watched-branch commits with a completed run
+	Modified int    `json:"modified"` // of those, runs that made a station commit
+	Noop     int    `json:"noop"`     // of those, runs that changed nothing
+	Commits  int    `json:"commits"`
+	Added    int    `json:"added"`
+	Deleted  int    `json:"deleted"`
+	// AvgLatency is the mean time from a watched-branch commit to the
+	// station commit it triggered, in seconds; nil without station commits.
+	AvgLatency *float64      `json:"avg_latency_seconds"`
+	Periods    []churnPeriod `json:"periods"`
+}
+
+// statsReport is the JSON form of line stats.
+type statsReport struct {
+	Since    time.Time      `json:"since"`
+	By       string         `json:"by"`
+	Stations []stationStats `json:"stations"`
+}
+
+// buildStats computes each station's statistics since the given time: its
+// reviews from its run history, and its commits, their lines and latency
+// from git history.
+func buildStats(dir string, cfg *config.Config, since time.Time, by string) statsReport {
+	report := statsReport{Since: since, By: by, Stations: []stationStats{}}
+	committed := commitTimes(dir, cfg.Settings.Watches)
+	for _, s := range cfg.Stations {
+		st := stationStats{Station: s.Name, Periods: []churnPeriod{}}
+		triggered := stationChurn(dir, s.Name, since, by, committed, &st)
+		reviewed := map[string]bool{}
+		for _, r := range state.ReadStationRuns(dir, s.Name) {
+			if r.Started.Before(since) || r.Commit == "" || reviewed[r.Commit] {
+				continue
+			}
+			if r.Result != "ok" && r.Result != "awaiting approval" {
+				continue
+			}
+			reviewed[r.Commit] = true
+			if triggered[r.Commit] {
+				st.Modified++
+			} else {
+				st.Noop++
+			}
+		}
+		st.Reviewed = len(reviewed)
+		report.Stations = append(report.Stations, st)
+	}
+	return report
+}
+
+// commitTimes maps each commit on the watched branch to its commit time.
+func commitTimes(dir, watches string) map[string]time.Time {
+	times := map[string]time.Time{}
+	out, err := git.Run(dir, "log", "--format=%H %ct", watches)
+	if err != nil {
+		return times
+	}
+	for _, line := range strings.Split(out, "\n") {
+		hash, ct, _ := strings.Cut(line, " ")
+		if secs, err := strconv.ParseInt(ct, 10, 64); err == nil {
+			times[hash] = time.Unix(secs, 0)
+		}
+	}
+	return times
+}
+
+// stationChurn adds the station's commits since the given time to st: their
+// lines, per period, and their latency. It returns the watched-branch
+// commits any of the station's commits were triggered by, at any time.
+// Rebases rewrite committer dates but keep author dates, so the window and
+// latency use the latter.
+func stationChurn(dir, name string, since time.Time, by string, committed map[string]time.Time, st *stationStats) map[string]bool {
+	triggered := map[string]bool{}
+	branch := git.StationBranchName(name)
+	if !git.BranchExists(dir, branch) {
+		return triggered
+	}
+	out, err := git.Run(dir, "log", "--no-merges",
+		"--format=%x00%at %(trailers:key="+runner.StationTrailer+",valueonly,separator=%x2C)%x00%(trailers:key="+runner.TriggeredByTrailer+",valueonly,separator=%x2C)%x00%s",
+		"--numstat", branch)
+	if err != nil {
+		return triggered
+	}
+	var (
+		period   *churnPeriod
+		latency  time.Duration
+		measured int
+	)
+	for _, line := range strings.Split(out, "\n") {
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			period = nil
+			fields := strings.SplitN(header, "\x00", 3)
+			if len(fields) != 3 {
+				continue
+			}
+			at, trailer, _ := strings.Cut(fields[0], " ")
+			// Older station commits predate the Line-Station trailer.
+			station, ok := trailer, trailer != ""
+			if !ok {
+				station, ok = runner.CommitStation(fields[2])
+			}
+			if !ok || station != name {
+				continue
+			}
+			if fields[1] != "" {
+				triggered[fields[1]] = true
+			}
+			secs, _ := strconv.ParseInt(at, 10, 64)
+			authored := time.Unix(secs, 0)
+			if authored.Before(since) {
+				continue
+			}
+			st.Commits++
+			if t, ok := committed[fields[1]]; ok && !authored.Before(t) {
+				latency += authored.Sub(t)
+				measured++
+			}
+			period = churnPeriodFor(st, periodStart(authored, by))
+			period.Commits++
+			continue
+		}
+		// numstat: added<TAB>deleted<TAB>path, "-" for binary files
+		fields := strings.SplitN(line, "\t", 3)
+		if period == nil || len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		st.Added += added
+		st.Deleted += deleted
+		period.Added += added
+		period.Deleted += deleted
+	}
+	if measured > 0 {
+		avg := (latency / time.Duration(measured)).Seconds()
+		st.AvgLatency = &avg
+	}
+	return triggered
+}
+
+// periodStart returns the local date the day or week of t starts on.
+func periodStart(t time.Time, by string) string {
+	t = t.Local()
+	if by == "week" {
+		t = t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+	return t.Format(time.DateOnly)
+}
+
+// churnPeriodFor returns the station's period starting on start, adding it
+// in date order if it is new. git log lists newest first.
+func churnPeriodFor(st *stationStats, start string) *churnPeriod {
+	for i := range st.Periods {
+		if st.Periods[i].Start == start {
+			return &st.Periods[i]
+		}
+	}
+	i := 0
+	for i < len(st.Periods) && st.Periods[i].Start < start {
+		i++
+	}
+	st.Periods = append(st.Periods[:i], append([]churnPeriod{{Start: start}}, st.Periods[i:]...)...)
+	return &st.Periods[i]
+}
+
+// noopRatio formats the share of reviews that changed nothing.
+func (st stationStats) noopRatio() string {
+	return percent(st.Noop, st.Reviewed)
+}
+
+// avgLatency formats the mean latency, or "-" without station commits.
+func (st stationStats) avgLatency() string {
+	if st.AvgLatency == nil {
+		return "-"
+	}
+	return time.Duration(*st.AvgLatency * float64(time.Second)).Round(time.Second).String()
+}
+
+// writeStatsText prints the per-station table, then the lines each station
+// changed per day or week.
+func writeStatsText(out io.Writer, report statsReport) error {
+	fmt.Fprintf(out, "Since %s:\n\n", report.Since.Local().Format("2006-01-02 15:04"))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Station\tReviewed\tModified\tNoop\tNoop ratio\tAvg latency\tCommits\tAdded\tDeleted\t")
+	for _, st := range report.Stations {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%d\t+%d\t-%d\t\n",
+			st.Station, st.Reviewed, st.Modified, st.Noop, st.noopRatio(), st.avgLatency(), st.Commits, st.Added, st.Deleted)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var rows []string
+	for _, st := range report.Stations {
+		for _, p := range st.Periods {
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%d\t+%d\t-%d\t", p.Start, st.Station, p.Commits, p.Added, p.Deleted))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "\nLines changed by %s:\n\n", report.By)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(report.By[:1])+report.By[1:]+"\tStation\tCommits\tAdded\tDeleted\t")
+	for _, row := range rows {
+		fmt.Fprintln(w, row)
+	}
+	return w.Flush()
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&statsSince, "since", "30d", "how far back to look: a duration (24h, 30d) or a date (YYYY-MM-DD)")
+	statsCmd.Flags().StringVar(&statsBy, "by", "week", "period for lines changed over time: day or week")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "print the statistics as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -5,7 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,20 +13,14 @@ import (
 	"github.com/re-cinq/assembly-line/internal/git"
 	"github.com/re-cinq/assembly-line/internal/runner"
 	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
 	"github.com/re-cinq/assembly-line/internal/tmux"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
-// ANSI color codes for STAT-2 color coding
-const (
-	colorReset  = "\033[0m"
-	colorGreen  = "\033[32m"
-	colorOrange = "\033[33m"
-	colorYellow = "\033[93m"
-	colorRed    = "\033[31m"
-	colorGrey   = "\033[90m"
-)
+// disabledBanner is shown by status and statusline while the kill switch is on.
+const disabledBanner = "PIPELINE DISABLED (.line/disabled or LINE_DISABLED=1)"
 
 var followFlag bool
 
@@ -78,70 +72,52 @@ var statusCmd = &cobra.Command{
 	},
 }
 
-// stationInfo holds the computed display state for a station.
-type stationInfo struct {
-	symbol    string
-	color     string
-	name      string    // "pending", "agent running", "failed", "up to date"
-	startTime time.Time // non-zero when agent is running
-}
-
-// computeStationInfo returns the display state for a station based on process
-// and git state (STAT-5: on-demand computation).
-func computeStationInfo(dir string, station config.Station, watchedFullRef, watchedBranch string) stationInfo {
-	branchName := git.StationBranchName(station.Name)
-	if !git.BranchExists(dir, branchName) {
-		return stationInfo{symbol: "○", color: colorYellow, name: "pending"}
-	}
-
-	agentPID, startTime, _ := state.ReadStationPID(dir, station.Name)
-	if agentPID > 0 && state.IsProcessRunning(agentPID) {
-		return stationInfo{symbol: "●", color: colorOrange, name: "agent running", startTime: startTime}
-	}
-	if state.ReadStationFailed(dir, station.Name) {
-		return stationInfo{symbol: "✗", color: colorRed, name: "failed"}
+// stationLook returns the symbol and color line status shows a station
+// state (statusdata.Station.State) with.
+func stationLook(st string) (symbol, color string) {
+	switch st {
+	case statusdata.AgentRunning:
+		return "●", colorOrange
+	case statusdata.AwaitingApproval:
+		return "◇", colorCyan
+	case statusdata.Conflict:
+		return "⚠", colorMagenta
+	case statusdata.Quarantined, statusdata.Failed, statusdata.FailedVerification:
+		return "✗", colorRed
+	case statusdata.UpToDate, statusdata.Done:
+		return "✓", colorGreen
 	}
-	if watchedFullRef != "" && git.IsAncestor(dir, watchedFullRef, branchName) {
-		return stationInfo{symbol: "✓", color: colorGreen, name: "up to date"}
-	}
-	// STAT-8: If the only commits between station and watched branch are
-	// skip-marker commits, the station is still up to date.
-	if watchedFullRef != "" && git.OnlySkipCommitsBetween(dir, branchName, watchedBranch, runner.SkipMarkers) {
-		return stationInfo{symbol: "✓", color: colorGreen, name: "up to date"}
-	}
-	return stationInfo{symbol: "○", color: colorYellow, name: "pending"}
-}
-
-// formatUptime formats the duration since startTime as a human-readable string.
-func formatUptime(startTime time.Time) string {
-	d := time.Since(startTime)
-	s := int(d.Seconds())
-	if s < 60 {
-		return fmt.Sprintf("%ds", s)
-	}
-	m := s / 60
-	s = s % 60
-	if m < 60 {
-		return fmt.Sprintf("%dm %ds", m, s)
-	}
-	h := m / 60
-	m = m % 60
-	return fmt.Sprintf("%dh %dm", h, m)
+	return "○", colorYellow
 }
 
 func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 	// When clearEOL is true (follow mode), append ANSI erase-to-end-of-line
 	// after each line to prevent stale characters from shorter redraws.
+	out := stdout(cfg)
 	eol := "\n"
 	if clearEOL {
 		eol = "\033[K\n"
 	}
 
+	// Resolve HEAD and every branch through one cat-file process rather
+	// than a rev-parse per lookup; status -f repeats this every refresh.
+	batch, err := git.NewBatch(dir)
+	if err != nil {
+		return err
+	}
+	defer batch.Close()
+
 	// Pre-compute watched branch info and station distances for the
 	// commit-distance indicator column.
-	watchedRef, _ := git.HeadShortRef(dir)
+	headHash, _ := batch.Resolve("HEAD")
+	watchedRef := git.ShortHash(headHash)
 	watchedDirty, _ := git.IsDirty(dir)
-	watchedFullRef, _ := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+	watchedFullRef, _ := batch.Resolve(cfg.Settings.Watches)
+
+	stationHeads := make([]string, len(cfg.Stations))
+	for i, station := range cfg.Stations {
+		stationHeads[i], _ = batch.Resolve(git.StationBranchName(station.Name))
+	}
 
 	type stationDist struct {
 		ahead, behind int
@@ -152,7 +128,7 @@ func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 	if watchedFullRef != "" {
 		for i, station := range cfg.Stations {
 			branchName := git.StationBranchName(station.Name)
-			if git.BranchExists(dir, branchName) {
+			if stationHeads[i] != "" {
 				ahead, behind, err := git.RevDistance(dir, watchedFullRef, branchName)
 				if err == nil {
 					if behind > n {
@@ -185,45 +161,95 @@ func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 	pid, _ := state.ReadPID(dir)
 	configName := filepath.Base(configPath)
 	if pid > 0 && state.IsProcessRunning(pid) {
-		fmt.Fprintf(os.Stdout, "%s▶%s %s%s", colorGreen, colorReset, configName, eol)
+		fmt.Fprintf(out, "%s %s%s", out.paint(colorGreen, "▶"), configName, eol)
 	} else {
-		fmt.Fprintf(os.Stdout, "%s⏸%s %s%s", colorGrey, colorReset, configName, eol)
+		fmt.Fprintf(out, "%s %s%s", out.paint(colorGrey, "⏸"), configName, eol)
+	}
+
+	if state.Disabled(dir) {
+		fmt.Fprintf(out, "%s%s", out.paint(colorRed, disabledBanner), eol)
+	}
+	if skipped := state.ReadSkippedCommits(dir); len(skipped) > 0 {
+		fmt.Fprintf(out, "%s%s", out.paint(colorGrey, skipSummary(skipped)), eol)
+	}
+	if c, ok := state.ReadLastCycle(dir); ok && c.Aborted != "" {
+		fmt.Fprintf(out, "%s%s", out.paint(colorRed, "✗ last cycle aborted: "+c.Aborted), eol)
+	}
+	for _, w := range orphanWarnings(dir, cfg) {
+		fmt.Fprintf(out, "%s%s", out.paint(colorYellow, w), eol)
 	}
 
 	// Blank line + column headers (indicator column has no header)
-	fmt.Fprintf(os.Stdout, "%s", eol)
-	fmt.Fprintf(os.Stdout, "%-21s%-*s%-9s%s%s", "Stations", indW, "", "Head", "Status", eol)
+	fmt.Fprintf(out, "%s", eol)
+	fmt.Fprintf(out, "%-21s%-*s%-9s%s%s", "Stations", indW, "", "Head", "Status", eol)
 
 	// Print watched branch
 	dirtyStr := ""
 	if watchedDirty {
 		dirtyStr = "(dirty)"
 	}
-	fmt.Fprintf(os.Stdout, "%-21s%-*s%-9s%s%s", cfg.Settings.Watches, indW, masterInd, watchedRef, dirtyStr, eol)
+	fmt.Fprintf(out, "%-21s%-*s%-9s%s%s", cfg.Settings.Watches, indW, masterInd, watchedRef, dirtyStr, eol)
 
 	// Print each station, tracking the first running station for log display
 	var runningStation string
 	for i, station := range cfg.Stations {
-		branchName := git.StationBranchName(station.Name)
 		ref := "-"
 
-		if git.BranchExists(dir, branchName) {
-			if branchRef, err := git.Run(dir, "rev-parse", "--short", branchName); err == nil {
-				ref = branchRef
-			}
+		exists := stationHeads[i] != ""
+		if exists {
+			ref = git.ShortHash(stationHeads[i])
 		}
 
-		info := computeStationInfo(dir, station, watchedFullRef, cfg.Settings.Watches)
+		info := statusdata.StationState(dir, cfg.Settings, station, watchedFullRef, exists)
 		extra := ""
-		if !info.startTime.IsZero() {
+		if info.Running() {
 			// STAT-7: Show uptime duration instead of PID/start time
-			extra = fmt.Sprintf(" (%s)", formatUptime(info.startTime))
+			extra = fmt.Sprintf(" (%s)", statusdata.Uptime(info.Since))
 			if runningStation == "" {
 				runningStation = station.Name
 			}
 		}
+		if len(info.Conflicts) > 0 {
+			extra = fmt.Sprintf(" %s — line resolve %s", strings.Join(info.Conflicts, ", "), station.Name)
+		}
+		if info.Detail != "" {
+			extra += " " + info.Detail
+		}
+		if extra == "" {
+			if run, ok := state.ReadStationLastRun(dir, station.Name); ok {
+				if reviewed := runner.ReviewedSummary(dir, run); reviewed != "" {
+					extra = " " + reviewed
+				}
+			}
+		}
+		if station.Trigger == config.TriggerManual && !info.Running() {
+			extra = " manual" + extra
+		}
 
-		fmt.Fprintf(os.Stdout, "%s  %s %-17s%-*s%-9s[%s]%s%s%s", info.color, info.symbol, station.Name, indW, stnInds[i], ref, info.name, extra, colorReset, eol)
+		symbol, color := stationLook(info.State)
+		fmt.Fprintf(out, "%s%s", out.paint(color, fmt.Sprintf("  %s %-17s%-*s%-9s[%s]%s", symbol, station.Name, indW, stnInds[i], ref, info.State, extra)), eol)
+	}
+
+	// Stations with watches follow tags or branches rather than the watched
+	// branch, so they have no distance indicator.
+	for _, station := range cfg.RefStations {
+		ref := "-"
+		if head, _ := batch.Resolve(git.StationBranchName(station.Name)); head != "" {
+			ref = git.ShortHash(head)
+		}
+		info := statusdata.RefStationState(dir, cfg.Settings, station)
+		extra := ""
+		if info.Running() {
+			extra = fmt.Sprintf(" (%s)", statusdata.Uptime(info.Since))
+			if runningStation == "" {
+				runningStation = station.Name
+			}
+		}
+		if info.Detail != "" {
+			extra += " " + info.Detail
+		}
+		symbol, color := stationLook(info.State)
+		fmt.Fprintf(out, "%s%s", out.paint(color, fmt.Sprintf("  %s %-17s%-*s%-9s[%s]%s", symbol, station.Name, indW, "", ref, info.State, extra)), eol)
 	}
 
 	// In follow mode, show last lines of the running agent's output.
@@ -232,26 +258,16 @@ func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 		// Fixed rows: runner indicator + blank + column headers + watched branch
 		//             + stations + blank separator + log header + 1 trailing
 		//             newline (prevents the last \n from scrolling the terminal)
-		fixedRows := 7 + len(cfg.Stations)
+		fixedRows := 7 + len(cfg.Stations) + len(cfg.RefStations)
 		logLines, termWidth := logWindowSize(fixedRows)
-		if !printAgentLog(dir, runningStation, eol, logLines, termWidth) && !tmux.Available() {
-			fmt.Fprintf(os.Stdout, "%s%sInstall tmux to see streaming agent output%s%s", eol, colorGrey, colorReset, eol)
+		if !printAgentLog(out, dir, runningStation, eol, logLines, termWidth) && !tmux.Available() {
+			fmt.Fprintf(out, "%s%s%s", eol, out.paint(colorGrey, "Install tmux to see streaming agent output"), eol)
 		}
 	}
 
 	return nil
 }
 
-// ansiRE matches ANSI escape sequences (CSI, OSC, and single-char escapes).
-var ansiRE = regexp.MustCompile(`\x1b(?:\[[0-9;?]*[a-zA-Z]|\][^\x07]*\x07|\[[^\x1b]*|.)`)
-
-// stripANSI removes ANSI escape sequences and carriage returns from s.
-func stripANSI(s string) string {
-	s = ansiRE.ReplaceAllString(s, "")
-	s = strings.ReplaceAll(s, "\r", "")
-	return s
-}
-
 // logWindowSize returns the number of lines available for the log window and
 // the terminal width. Lines are truncated to the terminal width to prevent
 // wrapping from blowing past the calculated height.
@@ -274,7 +290,7 @@ func logWindowSize(fixedRows int) (lines, width int) {
 // Prefers tmux capture-pane (clean rendered pane content, live in interactive mode)
 // over the raw pipe-pane log file (which contains ANSI escape sequences).
 // Lines are truncated to termWidth to prevent wrapping (0 means no truncation).
-func printAgentLog(dir, stationName, eol string, lineCount, termWidth int) bool {
+func printAgentLog(out *printer, dir, stationName, eol string, lineCount, termWidth int) bool {
 	var lines []string
 
 	// Prefer capture-pane: in interactive mode (no -p), Claude Code streams
@@ -291,16 +307,7 @@ func printAgentLog(dir, stationName, eol string, lineCount, termWidth int) bool
 
 	// Fall back to pipe-pane log file (strip ANSI escape sequences)
 	if len(lines) == 0 {
-		logPath := state.StationLogPath(dir, stationName)
-		if data, err := os.ReadFile(logPath); err == nil && len(data) > 0 {
-			cleaned := stripANSI(string(data))
-			all := trimBlankLines(strings.Split(cleaned, "\n"))
-			start := 0
-			if len(all) > lineCount {
-				start = len(all) - lineCount
-			}
-			lines = all[start:]
-		}
+		lines = state.StationLogTail(dir, stationName, lineCount)
 	}
 
 	if len(lines) == 0 {
@@ -308,10 +315,10 @@ func printAgentLog(dir, stationName, eol string, lineCount, termWidth int) bool
 	}
 
 	// Print separator and output in grey, truncating lines to terminal width
-	fmt.Fprintf(os.Stdout, "%s", eol)
-	fmt.Fprintf(os.Stdout, "%s--- %s ---%s%s", colorGrey, stationName, colorReset, eol)
+	fmt.Fprintf(out, "%s", eol)
+	fmt.Fprintf(out, "%s%s", out.paint(colorGrey, "--- "+stationName+" ---"), eol)
 	for _, line := range lines {
-		fmt.Fprintf(os.Stdout, "%s%s%s%s", colorGrey, truncateLine(line, termWidth), colorReset, eol)
+		fmt.Fprintf(out, "%s%s", out.paint(colorGrey, truncateLine(line, termWidth)), eol)
 	}
 	return true
 }
@@ -383,3 +390,26 @@ func init() {
 	statusCmd.Flags().BoolVarP(&followFlag, "follow", "f", false, "refresh every 2 seconds")
 	rootCmd.AddCommand(statusCmd)
 }
+
+// skipSummary describes the commits skipped since the last run, e.g.
+// "skipped 3 commits since the last run (2 ignored paths, 1 [skip ci])".
+func skipSummary(skipped []state.SkippedCommit) string {
+	counts := map[string]int{}
+	var reasons []string
+	for _, s := range skipped {
+		if counts[s.Reason] == 0 {
+			reasons = append(reasons, s.Reason)
+		}
+		counts[s.Reason]++
+	}
+	sort.SliceStable(reasons, func(i, j int) bool { return counts[reasons[i]] > counts[reasons[j]] })
+	parts := make([]string, len(reasons))
+	for i, r := range reasons {
+		parts[i] = fmt.Sprintf("%d %s", counts[r], r)
+	}
+	noun := "commits"
+	if len(skipped) == 1 {
+		noun = "commit"
+	}
+	return fmt.Sprintf("skipped %d %s since the last run (%s)", len(skipped), noun, strings.Join(parts, ", "))
+}
@@ -1,26 +1,165 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/re-cinq/assembly-line/internal/config"
-	"github.com/re-cinq/assembly-line/internal/git"
-	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/statusdata"
 	"github.com/spf13/cobra"
 )
 
+var (
+	statuslineFormat  string
+	statuslineCompact bool
+)
+
+// Renderings for line statusline --format.
+const (
+	statuslineClaude = "claude" // runner symbol and each station by name (default)
+	statuslinePlain  = "plain"  // as claude, with ASCII symbols for limited fonts
+	statuslinePrompt = "prompt" // one symbol per station, for shell prompts
+)
+
+// statuslineStyle selects how the statusline is rendered.
+type statuslineStyle struct {
+	format  string
+	color   bool
+	compact bool               // the chain layout, "main ▸ review✓ ▸ docs●"
+	ascii   bool               // --ascii or settings.ascii
+	theme   *config.Statusline // settings.statusline, or nil
+	columns int                // terminal width, 0 if unknown
+}
+
+// widthLimit returns the width the line must fit in: the smaller of
+// settings.statusline.max_width and the terminal width, 0 for none.
+func (s statuslineStyle) widthLimit() int {
+	limit := s.columns
+	if s.theme != nil && s.theme.MaxWidth > 0 && (limit == 0 || s.theme.MaxWidth < limit) {
+		limit = s.theme.MaxWidth
+	}
+	return limit
+}
+
+// truncate returns the settings.statusline.truncate strategy.
+func (s statuslineStyle) truncate() string {
+	if s.theme == nil || s.theme.Truncate == "" {
+		return config.TruncateEnd
+	}
+	return s.theme.Truncate
+}
+
+// variant names the style for the statusline cache; "" is the default.
+func (s statuslineStyle) variant() string {
+	v := s.format
+	if v == statuslineClaude {
+		v = ""
+	}
+	if s.ascii && s.format != statuslinePlain {
+		v = strings.TrimPrefix(v+"-ascii", "-")
+	}
+	if s.compact {
+		v = strings.TrimPrefix(v+"-compact", "-")
+	}
+	if !s.color {
+		v = strings.TrimPrefix(v+"-nocolor", "-")
+	}
+	return v
+}
+
+// paint colors text unless colors are off.
+func (s statuslineStyle) paint(color, text string) string {
+	if !s.color || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// plainText reports whether the line is limited to ASCII: --format plain,
+// --ascii or settings.ascii.
+func (s statuslineStyle) plainText() bool {
+	return s.format == statuslinePlain || s.ascii
+}
+
+// ellipsis marks where the line or a station name was cut.
+func (s statuslineStyle) ellipsis() string {
+	if s.plainText() {
+		return "..."
+	}
+	return "…"
+}
+
+// symbol returns the symbol for a state (a config.StatuslineStates key):
+// ASCII in plain text, else the theme's, else the default sym.
+func (s statuslineStyle) symbol(state, sym string) string {
+	if s.plainText() {
+		if ascii, ok := asciiSymbols[sym]; ok {
+			return ascii
+		}
+		return sym
+	}
+	if s.theme != nil {
+		if themed, ok := s.theme.Symbols[state]; ok {
+			return themed
+		}
+	}
+	return sym
+}
+
+// colorOf returns the escape sequence for a state: the theme's color, else
+// the default color.
+func (s statuslineStyle) colorOf(state, color string) string {
+	if s.theme == nil {
+		return color
+	}
+	name, ok := s.theme.Colors[state]
+	if !ok {
+		return color
+	}
+	if code, ok := config.StatuslineColors[name]; ok {
+		if code == "" {
+			return ""
+		}
+		return "\033[" + code + "m"
+	}
+	return "\033[38;5;" + name + "m"
+}
+
+// stateKey converts a station state to its config.StatuslineStates key,
+// e.g. "up to date" to "up_to_date".
+func stateKey(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
+
 var statuslineCmd = &cobra.Command{
 	Use:   "statusline",
 	Short: "One-line status for Claude Code statusline integration",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		switch statuslineFormat {
+		case statuslineClaude, statuslinePlain, statuslinePrompt:
+		default:
+			return fmt.Errorf("--format: %q is not one of claude, plain, prompt", statuslineFormat)
+		}
 		cfg, err := config.Load(configPath)
 		if err != nil {
 			return err
 		}
 
-		line, err := buildStatusLine(".", cfg)
+		// Claude Code reads the statusline through a pipe: colors stay on
+		// unless --no-color or NO_COLOR turns them off.
+		style := statuslineStyle{format: statuslineFormat, color: colorAllowed(),
+			theme: cfg.Settings.Statusline, columns: terminalColumns(os.Stdin), ascii: asciiOutput(cfg)}
+		// --format prompt is already a compact layout of its own.
+		style.compact = statuslineFormat != statuslinePrompt &&
+			(statuslineCompact || style.theme != nil && style.theme.Layout == config.LayoutCompact)
+		line, err := buildStatusLine(".", cfg, style)
 		if err != nil {
 			return err
 		}
@@ -30,41 +169,250 @@ var statuslineCmd = &cobra.Command{
 	},
 }
 
-func buildStatusLine(dir string, cfg *config.Config) (string, error) {
-	// Get the watched branch full ref for ancestor checks (STAT-5: on-demand)
-	watchedFullRef, _ := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+// statuslineStdinTimeout bounds how long the statusline waits for the JSON
+// Claude Code writes to its stdin, in case a caller leaves stdin open.
+const statuslineStdinTimeout = 200 * time.Millisecond
 
-	// Build station summaries with symbols and colors matching line status
-	var parts []string
-	for _, station := range cfg.Stations {
-		info := computeStationInfo(dir, station, watchedFullRef, cfg.Settings.Watches)
-		parts = append(parts, fmt.Sprintf("%s%s %s%s", info.color, info.symbol, station.Name, colorReset))
+// terminalColumns returns the width to fit the statusline in: "columns"
+// from the JSON object on stdin when there is one, else $COLUMNS, else 0.
+func terminalColumns(stdin *os.File) int {
+	if fi, err := stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice == 0 {
+		read := make(chan []byte, 1)
+		go func() {
+			data, _ := io.ReadAll(io.LimitReader(stdin, 1<<20))
+			read <- data
+		}()
+		select {
+		case data := <-read:
+			var input struct {
+				Columns int `json:"columns"`
+			}
+			if json.Unmarshal(data, &input) == nil && input.Columns > 0 {
+				return input.Columns
+			}
+		case <-time.After(statuslineStdinTimeout):
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// buildStatusLine renders the statusline, reusing the last rendering in
+// the same style while the line's state is unchanged.
+func buildStatusLine(dir string, cfg *config.Config, style statuslineStyle) (string, error) {
+	extra := fmt.Sprintf("columns=%d", style.columns)
+	if theme := cfg.Settings.Statusline; theme != nil {
+		extra += fmt.Sprintf(";theme=%v", *theme)
+	}
+	return statusdata.Cached(dir, cfg, style.variant(), extra, func(snap statusdata.Snapshot) string {
+		return renderStatusLine(snap, style)
+	})
+}
+
+// statuslineSegment is a run of statusline text in one color, after an
+// uncolored lead (its separator). name is a station name, which truncate:
+// names may shorten.
+type statuslineSegment struct {
+	lead         string
+	color        string
+	before, name string
+	after        string
+}
+
+func (seg statuslineSegment) text() string {
+	return seg.before + seg.name + seg.after
+}
+
+// statuslineWidth returns the visible width of the segments.
+func statuslineWidth(segs []statuslineSegment) int {
+	n := 0
+	for _, seg := range segs {
+		n += utf8.RuneCountInString(seg.lead) + utf8.RuneCountInString(seg.text())
+	}
+	return n
+}
+
+// stationView is how one station appears on the statusline.
+type stationView struct {
+	state     string // config.StatuslineStates key
+	color     string
+	symbol    string
+	name      string
+	conflicts []string
+	silence   string // a running agent's "no output for ..." (RUN-42)
+}
+
+// renderStatusLine renders the statusline from a snapshot of the line
+// (STAT-5: on-demand).
+func renderStatusLine(snap statusdata.Snapshot, style statuslineStyle) string {
+	prompt := style.format == statuslinePrompt
+
+	var head []statuslineSegment
+	if snap.Disabled {
+		head = append(head, statuslineSegment{color: style.colorOf("disabled", colorRed), before: style.symbol("disabled", "disabled")})
 	}
 
 	// Line runner ▶/⏸ symbol, matching status command colors
-	lineSymbol := colorGrey + "⏸" + colorReset
-	pid, _ := state.ReadPID(dir)
-	if pid > 0 && state.IsProcessRunning(pid) {
-		lineSymbol = colorGreen + "▶" + colorReset
-	}
-
-	result := fmt.Sprintf("%s %s", lineSymbol, strings.Join(parts, " "))
-
-	// SL-2: Check if terminal station has commits not in the watched branch
-	if len(cfg.Stations) > 0 {
-		terminalStation := cfg.Stations[len(cfg.Stations)-1]
-		terminalBranch := git.StationBranchName(terminalStation.Name)
-		if git.BranchExists(dir, terminalBranch) {
-			hasCommits, err := git.HasCommitsBetween(dir, cfg.Settings.Watches, terminalBranch)
-			if err == nil && hasCommits {
-				result += " | line changes available - /line-preview or /line-rebase"
+	runner := statuslineSegment{color: style.colorOf("idle", colorGrey), before: style.symbol("idle", "⏸")}
+	if snap.Active {
+		runner = statuslineSegment{color: style.colorOf("active", colorGreen), before: style.symbol("active", "▶")}
+	}
+	if len(head) > 0 {
+		runner.lead = " "
+	}
+	head = append(head, runner)
+	if style.compact {
+		head = append(head, statuslineSegment{lead: " ", before: snap.Watches})
+	}
+
+	// Station summaries with symbols and colors matching line status
+	var views []stationView
+	for _, st := range snap.Stations {
+		key := stateKey(st.State)
+		symbol, color := stationLook(st.State)
+		views = append(views, stationView{state: key, color: style.colorOf(key, color),
+			symbol: style.symbol(key, symbol), name: st.Name, conflicts: st.Conflicts})
+		if st.Running() {
+			views[len(views)-1].silence = st.Detail
+		}
+	}
+
+	// SL-2: the terminal station has commits not in the watched branch
+	var tail []statuslineSegment
+	if snap.Unpicked {
+		if prompt || style.compact {
+			up := "↑"
+			if style.plainText() {
+				up = "^"
 			}
+			tail = append(tail, statuslineSegment{lead: " ", before: up})
+		} else {
+			tail = append(tail, statuslineSegment{lead: " ", before: "| line changes available - /line-preview or /line-rebase"})
 		}
 	}
 
-	return result, nil
+	// build lays out the given stations; hidden up-to-date stations are
+	// summed up as e.g. "+3 ✓".
+	arrow := " ▸ "
+	if style.plainText() {
+		arrow = " > "
+	}
+	build := func(views []stationView, hidden int) []statuslineSegment {
+		segs := slices.Clone(head)
+		for i, v := range views {
+			seg := statuslineSegment{lead: " ", color: v.color, before: v.symbol}
+			switch {
+			case style.compact:
+				seg = statuslineSegment{lead: arrow, color: v.color, name: v.name, after: v.symbol}
+			case prompt:
+				if i > 0 {
+					seg.lead = ""
+				}
+			default:
+				seg.before += " "
+				seg.name = v.name
+				if len(v.conflicts) > 0 {
+					seg.after = " (" + strings.Join(v.conflicts, ", ") + ")"
+				}
+				if v.silence != "" {
+					seg.after = " (" + v.silence + ")"
+				}
+			}
+			segs = append(segs, seg)
+		}
+		if hidden > 0 {
+			segs = append(segs, statuslineSegment{lead: " ", color: style.colorOf("up_to_date", colorGreen),
+				before: fmt.Sprintf("+%d %s", hidden, style.symbol("up_to_date", "✓"))})
+		}
+		return append(segs, tail...)
+	}
+
+	segs := build(views, 0)
+	if limit := style.widthLimit(); limit > 0 && statuslineWidth(segs) > limit {
+		switch style.truncate() {
+		case config.TruncateActive:
+			var active []stationView
+			for _, v := range views {
+				if v.state != "up_to_date" {
+					active = append(active, v)
+				}
+			}
+			segs = build(active, len(views)-len(active))
+		case config.TruncateNames:
+			segs = shortenStationNames(segs, limit, style.plainText())
+		}
+		if width := statuslineWidth(segs); width > limit {
+			ellipsis := statuslineSegment{before: style.ellipsis()}
+			keep := max(limit-utf8.RuneCountInString(ellipsis.before), 0)
+			if style.truncate() == config.TruncateMiddle {
+				segs = slices.Concat(sliceStatusLine(segs, 0, (keep+1)/2), []statuslineSegment{ellipsis},
+					sliceStatusLine(segs, width-keep/2, width))
+			} else {
+				segs = append(sliceStatusLine(segs, 0, keep), ellipsis)
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, seg := range segs {
+		b.WriteString(seg.lead + style.paint(seg.color, seg.text()))
+	}
+	return b.String()
+}
+
+// shortenStationNames trims the longest station names one character at a
+// time ("review", "revi…", "rev…", down to "r…") until the segments fit
+// in maxWidth or no name can get shorter. In ASCII the mark is ".".
+func shortenStationNames(segs []statuslineSegment, maxWidth int, ascii bool) []statuslineSegment {
+	mark := '…'
+	if ascii {
+		mark = '.'
+	}
+	names := make([][]rune, len(segs))
+	for i, seg := range segs {
+		names[i] = []rune(seg.name)
+	}
+	for total := statuslineWidth(segs); total > maxWidth; total-- {
+		longest := -1
+		for i, name := range names {
+			if len(name) > 2 && (longest < 0 || len(name) > len(names[longest])) {
+				longest = i
+			}
+		}
+		if longest < 0 {
+			break
+		}
+		name := names[longest]
+		names[longest] = append(name[:len(name)-2], mark)
+	}
+	for i := range segs {
+		segs[i].name = string(names[i])
+	}
+	return segs
+}
+
+// sliceStatusLine returns the visible characters from..to of the
+// segments, keeping their colors.
+func sliceStatusLine(segs []statuslineSegment, from, to int) []statuslineSegment {
+	var out []statuslineSegment
+	pos := 0
+	for _, seg := range segs {
+		for _, part := range []statuslineSegment{{before: seg.lead}, {color: seg.color, before: seg.text()}} {
+			runes := []rune(part.before)
+			lo, hi := max(from-pos, 0), min(to-pos, len(runes))
+			if lo < hi {
+				out = append(out, statuslineSegment{color: part.color, before: string(runes[lo:hi])})
+			}
+			pos += len(runes)
+		}
+	}
+	return out
 }
 
 func init() {
+	statuslineCmd.Flags().StringVar(&statuslineFormat, "format", statuslineClaude, "rendering: claude (default), plain (ASCII symbols) or prompt (compact, for shell prompts)")
+	statuslineCmd.Flags().BoolVar(&statuslineCompact, "compact", false, "chain layout on one short line, e.g. \"⏸ main ▸ review✓ ▸ docs●\" (also settings.statusline.layout: compact)")
 	rootCmd.AddCommand(statuslineCmd)
 }
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	triggerCommit  string
+	triggerStation string
+	triggerFailOn  string
+)
+
+// triggerCmd is line run for tools (CI webhook receivers, editor plugins):
+// it names the commit to run for instead of reading the checkout.
+var triggerCmd = &cobra.Command{
+	Use:    "trigger",
+	Short:  "Run the line for a given watched-branch commit",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFailOn(triggerFailOn); err != nil {
+			return err
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		rev := triggerCommit
+		if rev == "" {
+			rev = cfg.Settings.Watches
+		}
+		commit, err := git.Run(".", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+		if err != nil {
+			return fmt.Errorf("unknown commit %q", rev)
+		}
+
+		cmd.SilenceUsage = true
+		var outcome runOutcome
+		if err := runner.Run(".", cfg, runner.Options{Events: outcome.sink(runEvents(cfg)), Commit: commit, Station: triggerStation}); err != nil {
+			return err
+		}
+		return outcome.err(triggerFailOn)
+	},
+}
+
+func init() {
+	triggerCmd.Flags().StringVar(&triggerCommit, "commit", "", "watched-branch commit to run for (default: the watched branch head)")
+	triggerCmd.Flags().StringVar(&triggerStation, "station", "", "run just this station for the commits up to --commit it hasn't reviewed, then the stations after it")
+	triggerCmd.Flags().StringVar(&triggerFailOn, "fail-on", failOnNone, "exit non-zero when a station fails (station-failure) or also when the run or a station is skipped (skip)")
+	rootCmd.AddCommand(triggerCmd)
+}
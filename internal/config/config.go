@@ -1,9 +1,19 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/re-cinq/assembly-line/internal/pathnorm"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,6 +25,28 @@ type Agent struct {
 type Gate struct {
 	Name string `yaml:"name"`
 	Run  string `yaml:"run"`
+	// Hook is the Git hook the gate runs from: pre-commit (default) or
+	// commit-msg, where {msg_file} in Run is the commit message file.
+	Hook string `yaml:"hook,omitempty"`
+
+	file string // the ConfigDir file the gate comes from, "" for the repo config
+}
+
+// Values for gates[].hook.
+const (
+	GateHookPreCommit = "pre-commit" // check the staged changes (default)
+	GateHookCommitMsg = "commit-msg" // check the commit message
+)
+
+// GatesFor returns the gates that run from the given hook, in order.
+func (c *Config) GatesFor(hook string) []Gate {
+	var gates []Gate
+	for _, g := range c.Gates {
+		if g.Hook == hook || g.Hook == "" && hook == GateHookPreCommit {
+			gates = append(gates, g)
+		}
+	}
+	return gates
 }
 
 type Station struct {
@@ -22,19 +54,443 @@ type Station struct {
 	Command string   `yaml:"command,omitempty"`
 	Args    []string `yaml:"args,omitempty"`
 	Prompt  string   `yaml:"prompt"`
+	Squash  bool     `yaml:"squash,omitempty"`
+	// Approval manual holds the station's commit until line approve.
+	Approval string `yaml:"approval,omitempty"`
+	// Verify is a shell command run in the worktree after the agent and
+	// before committing; the station only commits if it passes.
+	Verify            string `yaml:"verify,omitempty"`
+	OnVerifyFailure   string `yaml:"on_verify_failure,omitempty"`
+	MaxRepairAttempts *int   `yaml:"max_repair_attempts,omitempty"`
+	// After is a shell command run in the worktree after the agent (e.g. a
+	// formatter); its changes are committed with the agent's.
+	After string `yaml:"after,omitempty"`
+	// OnSuccess and OnFailure are shell commands run in the repo root once
+	// the station has finished.
+	OnSuccess string `yaml:"on_success,omitempty"`
+	OnFailure string `yaml:"on_failure,omitempty"`
+	// Cache lists build directories (e.g. target/, .venv/) kept from one
+	// run of the station to the next. They are never committed.
+	Cache []string `yaml:"cache,omitempty"`
+	// Context lists issue tracker providers ("jira:PROJ", "github-issues")
+	// whose tickets, referenced in the reviewed commits' messages, are
+	// appended to the prompt.
+	Context []string `yaml:"context,omitempty"`
+	// Root scopes the station to a package of a monorepo (e.g.
+	// "services/api"): only changes there trigger it, its agent runs there
+	// and may not change files outside it.
+	Root string `yaml:"root,omitempty"`
+	// Changelog makes the station maintain this changelog file: its prompt
+	// (ChangelogPrompt unless set) gets the reviewed commits grouped by
+	// Conventional Commits type, and line release collates its entries.
+	Changelog string `yaml:"changelog,omitempty"`
+	// Watches takes the station off the line: instead of reviewing the
+	// watched branch after its predecessor, it runs for each new or moved
+	// tag ("tag:v*") or branch ("branch:release/*") matching the pattern.
+	// Load moves such stations to Config.RefStations.
+	Watches string `yaml:"watches,omitempty"`
+	// Trigger manual keeps the station in the line but never runs its
+	// agent automatically: runs pass changes through it, and it only runs
+	// for line run --station.
+	Trigger string `yaml:"trigger,omitempty"`
+	// OnInterrupt says what becomes of the changes an agent left behind
+	// when its run was killed (the runner crashed, or was taken over):
+	// discard them (default), commit them, or resume with the agent.
+	OnInterrupt string `yaml:"on_interrupt,omitempty"`
+	// Follows names the station this one runs after in the line, e.g. one
+	// defined in another ConfigDir file. Stations otherwise run in the order
+	// they are listed, the repo config's first.
+	Follows string `yaml:"follows,omitempty"`
+
+	index int    // position in the stations lists, the repo config's first
+	file  string // the ConfigDir file the station comes from, "" for the repo config
+}
+
+// RefWatch is what a station with watches runs for: the tags or branches
+// whose short name matches Pattern (path.Match syntax).
+type RefWatch struct {
+	Kind    string // "tag" or "branch"
+	Pattern string
+}
+
+// ParseWatches reads a station's watches, e.g. "tag:v*".
+func ParseWatches(s string) (RefWatch, error) {
+	kind, pattern, _ := strings.Cut(s, ":")
+	if kind != "tag" && kind != "branch" || pattern == "" {
+		return RefWatch{}, fmt.Errorf("%q must be tag:<pattern> or branch:<pattern>", s)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return RefWatch{}, fmt.Errorf("%q: %w", s, err)
+	}
+	return RefWatch{Kind: kind, Pattern: pattern}, nil
+}
+
+// Namespace returns where the watched refs live, e.g. "refs/tags/".
+func (w RefWatch) Namespace() string {
+	if w.Kind == "tag" {
+		return "refs/tags/"
+	}
+	return "refs/heads/"
+}
+
+// ChangelogPrompt is the prompt of a changelog station that sets none.
+func ChangelogPrompt(file string) string {
+	return fmt.Sprintf("Update %s for the commits listed below: add one line per user-visible change under its Unreleased section, grouped as Added, Changed, Fixed and Removed (Keep a Changelog), creating the file or section if missing. Call out breaking changes. Skip refactors, tests and chores. Do not change any other file.", file)
+}
+
+// CleanRoot returns the station's root without redundant elements or a
+// trailing slash; empty when it has none or it is the repository root.
+func (s Station) CleanRoot() string {
+	if s.Root == "" {
+		return ""
+	}
+	root := pathnorm.Clean(s.Root)
+	if root == "." {
+		return ""
+	}
+	return root
+}
+
+// Behaviours for stations[].on_verify_failure.
+const (
+	OnVerifyFailureFail   = "fail"   // mark the station failed (default)
+	OnVerifyFailureRepair = "repair" // re-run the agent to fix it, up to max_repair_attempts
+)
+
+// Values for stations[].approval.
+const (
+	ApprovalAuto   = "auto"   // commit and continue downstream (default)
+	ApprovalManual = "manual" // hold the commit for line approve / line reject
+)
+
+// Values for stations[].trigger.
+const (
+	TriggerAuto   = "auto"   // run for every watched-branch commit (default)
+	TriggerManual = "manual" // only run for line run --station
+)
+
+// Values for stations[].on_interrupt.
+const (
+	OnInterruptDiscard = "discard" // start again from scratch (default)
+	OnInterruptCommit  = "commit"  // commit the changes left behind, without the agent
+	OnInterruptResume  = "resume"  // run the agent again on top of them
+)
+
+// DefaultMaxRepairAttempts bounds the repair loop when max_repair_attempts
+// is not set.
+const DefaultMaxRepairAttempts = 2
+
+// RepairAttempts returns how many times the agent may be asked to repair a
+// failed verify; zero unless on_verify_failure is repair.
+func (s Station) RepairAttempts() int {
+	if s.OnVerifyFailure != OnVerifyFailureRepair {
+		return 0
+	}
+	if s.MaxRepairAttempts != nil {
+		return *s.MaxRepairAttempts
+	}
+	return DefaultMaxRepairAttempts
 }
 
 type Settings struct {
 	Watches     string `yaml:"watches"`
 	AutoRebase  bool   `yaml:"auto_rebase"`
 	AutoResolve bool   `yaml:"auto_resolve"`
+	OnConflict  string `yaml:"on_conflict,omitempty"`
+	Verify      bool   `yaml:"verify,omitempty"`
+	// Debounce is how long line run waits (e.g. "2s") before reading the
+	// watched branch; triggers in the meantime are left to it.
+	Debounce string `yaml:"debounce,omitempty"`
+	// StallAfter is how long a running agent may write no output (e.g.
+	// "10m") before line status flags it; "0" never does.
+	StallAfter string `yaml:"stall_after,omitempty"`
+	// StallTimeout is how long a running agent may write no output (e.g.
+	// "20m") before it is killed and its station fails; unset never does.
+	StallTimeout string `yaml:"stall_timeout,omitempty"`
+	// LogFilter is what is done to agent output before it is stored in the
+	// station logs: LogFilterKeep or LogFilterStripANSI.
+	LogFilter string `yaml:"log_filter,omitempty"`
+	// AgentRate caps how often the stations' agents start, as
+	// "<n>/<unit>" (e.g. "2/min"); see ParseRate.
+	AgentRate string `yaml:"agent_rate,omitempty"`
+	// OnForcePush is what the stations do with their branches once the
+	// watched branch was force-pushed: OnForcePushRebase or OnForcePushReset.
+	OnForcePush string `yaml:"on_force_push,omitempty"`
+	// GateStaged runs the pre-commit gates against exactly what is staged,
+	// in a temporary checkout, when the working tree has unstaged changes.
+	GateStaged bool `yaml:"gate_staged,omitempty"`
+	// CommitAuthor ("Name <email>") is the author and committer of station
+	// commits; empty uses the repository's git identity.
+	CommitAuthor  string         `yaml:"commit_author,omitempty"`
+	CommitSigning *CommitSigning `yaml:"commit_signing,omitempty"`
+	// MachineCommits identifies bot commits (e.g. Renovate, Dependabot)
+	// that should not trigger the line.
+	MachineCommits *MachineCommits `yaml:"machine_commits,omitempty"`
+	Worktree       *Worktree       `yaml:"worktree,omitempty"`
+	Statusline     *Statusline     `yaml:"statusline,omitempty"`
+	Hooks          *Hooks          `yaml:"hooks,omitempty"`
+	// ASCII replaces Unicode symbols in status output, like --ascii.
+	ASCII bool `yaml:"ascii,omitempty"`
+	// Routes maps Conventional Commits types (feat, fix, chore) to the
+	// stations they run; an empty list runs none. Types not listed run
+	// every station.
+	Routes map[string][]string `yaml:"routes,omitempty"`
+}
+
+// Statusline themes line statusline: Symbols and Colors are keyed by
+// StatuslineStates, colors being StatuslineColors names or 256-color
+// numbers. A positive MaxWidth bounds the line's visible width, shortened
+// as Truncate says. Layout picks LayoutFull or LayoutCompact.
+type Statusline struct {
+	Symbols  map[string]string `yaml:"symbols,omitempty"`
+	Colors   map[string]string `yaml:"colors,omitempty"`
+	MaxWidth int               `yaml:"max_width,omitempty"`
+	Truncate string            `yaml:"truncate,omitempty"`
+	Layout   string            `yaml:"layout,omitempty"`
+}
+
+// Layouts for settings.statusline.layout.
+const (
+	LayoutFull    = "full"    // runner symbol, then each station's symbol and name (default)
+	LayoutCompact = "compact" // the watched branch and a chain of stations, "main ▸ review✓"
+)
+
+// StatuslineStates are the keys of settings.statusline symbols and colors:
+// the station states of line status, the runner's, and the kill switch.
+var StatuslineStates = []string{
+	"up_to_date", "pending", "agent_running", "awaiting_approval", "conflict",
+	"quarantined", "failed", "failed_verification", "active", "idle", "disabled",
+}
+
+// StatuslineColors maps settings.statusline color names to their ANSI SGR
+// parameters; "none" leaves the text uncolored.
+var StatuslineColors = map[string]string{
+	"none": "", "black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37", "grey": "90",
+	"bright_red": "91", "bright_green": "92", "bright_yellow": "93",
+	"bright_blue": "94", "bright_magenta": "95", "bright_cyan": "96",
+}
+
+// Strategies for settings.statusline.truncate.
+const (
+	TruncateEnd    = "end"    // cut the line at max_width with "…" (default)
+	TruncateNames  = "names"  // shorten station names first, then cut
+	TruncateMiddle = "middle" // cut the middle out, keeping both ends
+	TruncateActive = "active" // hide up-to-date stations first, then cut
+)
+
+// Worktree lists untracked files (paths relative to the repo root) to bring
+// into station and gate worktrees: Copy copies them, Symlink links back to
+// the repo's copy (e.g. node_modules). They are never committed.
+type Worktree struct {
+	Copy    []string `yaml:"copy,omitempty"`
+	Symlink []string `yaml:"symlink,omitempty"`
+}
+
+// Hooks are shell commands run in the repository root once per cycle:
+// BeforeAll before the first station, AfterAll after the last one, whatever
+// the stations' outcome. A failing hook aborts the cycle.
+type Hooks struct {
+	BeforeAll string `yaml:"before_all,omitempty"`
+	AfterAll  string `yaml:"after_all,omitempty"`
+}
+
+// MachineCommits matches commits made by bots: Authors are regular
+// expressions matched against "Name <email>", Trailers are trailer keys
+// (e.g. "Triggered-By") whose presence marks a machine commit.
+type MachineCommits struct {
+	Authors  []string `yaml:"authors,omitempty"`
+	Trailers []string `yaml:"trailers,omitempty"`
+}
+
+// CommitSigning signs station commits with a GPG, SSH or X.509 key.
+type CommitSigning struct {
+	Format string `yaml:"format,omitempty"` // openpgp (default), ssh or x509
+	Key    string `yaml:"key"`
+}
+
+// Strategies for settings.on_conflict, applied when a station branch does not
+// rebase cleanly onto its predecessor.
+const (
+	OnConflictReset = "reset" // discard station commits and restart from the predecessor (default)
+	OnConflictKeep  = "keep"  // abort, keep the station branch and block the line for manual resolution
+	OnConflictAgent = "agent" // ask the station's agent to resolve the conflict markers
+)
+
+// Policies for settings.on_force_push, applied when the watched branch no
+// longer contains the commit the line last ran for (RUN-44).
+const (
+	OnForcePushRebase = "rebase" // replay only the station's own commits onto the rewritten branch (default)
+	OnForcePushReset  = "reset"  // discard the station's commits and restart from the rewritten branch
+)
+
+// Settings.LogFilter values.
+const (
+	LogFilterKeep      = "keep"       // store agent output as the terminal got it (default)
+	LogFilterStripANSI = "strip-ansi" // drop ANSI escape sequences and carriage returns
+)
+
+// Rate is a number of events per period, e.g. settings.agent_rate. The zero
+// Rate is unlimited.
+type Rate struct {
+	N   int
+	Per time.Duration
+}
+
+func (r Rate) String() string {
+	unit := "s"
+	switch r.Per {
+	case time.Minute:
+		unit = "min"
+	case time.Hour:
+		unit = "hour"
+	}
+	return fmt.Sprintf("%d/%s", r.N, unit)
+}
+
+// rateUnits are the periods a Rate may be given in.
+var rateUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+}
+
+// ParseRate reads a rate such as "2/min" or "100/hour".
+func ParseRate(s string) (Rate, error) {
+	n, unit, ok := strings.Cut(s, "/")
+	count, err := strconv.Atoi(n)
+	per, known := rateUnits[unit]
+	if !ok || err != nil || count < 1 || !known {
+		return Rate{}, fmt.Errorf("%q is not <n>/<s|min|hour> (e.g. 2/min)", s)
+	}
+	return Rate{N: count, Per: per}, nil
+}
+
+// AgentRateLimit returns settings.agent_rate, or the zero Rate when unset
+// or invalid.
+func (s Settings) AgentRateLimit() Rate {
+	r, _ := ParseRate(s.AgentRate)
+	return r
+}
+
+// DebounceDuration returns settings.debounce, or 0 when unset or invalid.
+func (s Settings) DebounceDuration() time.Duration {
+	d, _ := time.ParseDuration(s.Debounce)
+	return max(d, 0)
+}
+
+// DefaultStallAfter is settings.stall_after when unset.
+const DefaultStallAfter = 10 * time.Minute
+
+// StallAfterDuration returns settings.stall_after, DefaultStallAfter when
+// unset or invalid, or 0 when stalls are not flagged.
+func (s Settings) StallAfterDuration() time.Duration {
+	d, err := time.ParseDuration(s.StallAfter)
+	if err != nil || d < 0 {
+		return DefaultStallAfter
+	}
+	return d
+}
+
+// StallTimeoutDuration returns settings.stall_timeout, or 0 when unset or
+// invalid.
+func (s Settings) StallTimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(s.StallTimeout)
+	return max(d, 0)
+}
+
+// ForcePushPolicy returns the effective on_force_push policy.
+func (s Settings) ForcePushPolicy() string {
+	if s.OnForcePush == "" {
+		return OnForcePushRebase
+	}
+	return s.OnForcePush
+}
+
+// ConflictStrategy returns the effective on_conflict strategy.
+func (s Settings) ConflictStrategy() string {
+	if s.OnConflict == "" {
+		return OnConflictReset
+	}
+	return s.OnConflict
 }
 
 type Config struct {
-	Agent    Agent     `yaml:"agent"`
-	Settings Settings  `yaml:"settings"`
-	Gates    []Gate    `yaml:"gates"`
-	Stations []Station `yaml:"stations"`
+	Agent    Agent    `yaml:"agent"`
+	Settings Settings `yaml:"settings"`
+	Gates    []Gate   `yaml:"gates"`
+	// PushGates run from the pre-push hook (line init --pre-push) against
+	// the commits being pushed: slower checks than the pre-commit gates.
+	PushGates     []Gate         `yaml:"push_gates,omitempty"`
+	Stations      []Station      `yaml:"stations"`
+	Notifications *Notifications `yaml:"notifications,omitempty"`
+
+	// RefStations are the stations with watches, which Load takes out of
+	// Stations: they are not part of the line.
+	RefStations []Station `yaml:"-"`
+}
+
+// AllStations returns the line's stations and the stations watching refs,
+// in the order they are listed.
+func (c *Config) AllStations() []Station {
+	all := append(append([]Station(nil), c.Stations...), c.RefStations...)
+	slices.SortStableFunc(all, func(a, b Station) int { return a.index - b.index })
+	return all
+}
+
+// RefStation returns the station watching refs with the given name.
+func (c *Config) RefStation(name string) (Station, bool) {
+	for _, s := range c.RefStations {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Station{}, false
+}
+
+// Station returns the station with the given name, watching refs or not.
+func (c *Config) Station(name string) (Station, bool) {
+	for _, s := range c.AllStations() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Station{}, false
+}
+
+// Notifications posts station failures (and line digest --notify) to chat
+// through incoming webhooks, and mails the end of a run to Email.
+type Notifications struct {
+	Slack *Webhook `yaml:"slack,omitempty"`
+	Teams *Webhook `yaml:"teams,omitempty"`
+	Email *Email   `yaml:"email,omitempty"`
+}
+
+// Webhook is a chat incoming webhook. URL may reference environment
+// variables ("${SLACK_WEBHOOK_URL}") so the secret stays out of line.yaml.
+// Template is a text/template for failure messages.
+type Webhook struct {
+	URL      string `yaml:"webhook_url"`
+	Template string `yaml:"template,omitempty"`
+}
+
+// Email sends notifications through an SMTP server ("host:port"). SMTP,
+// Username and Password may reference environment variables. Mail is sent
+// when a run leaves changes on the terminal station branch, and when a
+// station has failed Failures times in a row (default 1).
+type Email struct {
+	SMTP     string   `yaml:"smtp"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Failures int      `yaml:"failures,omitempty"`
+}
+
+// FailureThreshold returns after how many consecutive failures a station's
+// failure is mailed.
+func (e Email) FailureThreshold() int {
+	return max(e.Failures, 1)
 }
 
 // ResolvedStation holds the fully resolved command/args for a station.
@@ -43,15 +499,86 @@ type ResolvedStation struct {
 	Command string
 	Args    []string
 	Prompt  string
+	Context []string
+	Root    string // slash-separated, cleaned; empty for the whole repo
+	// Changelog is the changelog file a changelog station maintains.
+	Changelog string
+	// StallTimeout is settings.stall_timeout: agents writing no output for
+	// that long are killed. 0 never kills them.
+	StallTimeout time.Duration
+	// StripANSI is settings.log_filter: strip-ansi, for the agent's log.
+	StripANSI bool
+	// AgentRate is settings.agent_rate, shared by all stations' agents.
+	AgentRate Rate
+	// Env is added to the agent's environment, e.g. LINE_RUN_ID.
+	Env []string
 }
 
+// globalDefaults is the part of the config that may be set user-wide in
+// the global config file.
+type globalDefaults struct {
+	Agent    Agent    `yaml:"agent"`
+	Settings Settings `yaml:"settings"`
+}
+
+// GlobalPath returns the user-wide config file:
+// $XDG_CONFIG_HOME/line/config.yaml, or ~/.config/line/config.yaml when
+// XDG_CONFIG_HOME is unset.
+func GlobalPath() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "line", "config.yaml")
+}
+
+// loadGlobal reads agent and settings defaults from the global config file
+// into cfg. A missing file is not an error.
+func loadGlobal(cfg *Config) error {
+	path := GlobalPath()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading global config: %w", err)
+	}
+
+	var g globalDefaults
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&g); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing global config %s (only agent and settings are allowed): %w", path, err)
+	}
+	cfg.Agent = g.Agent
+	cfg.Settings = g.Settings
+	return nil
+}
+
+// Load reads the repo config at path, layered over the defaults from the
+// global config file (GlobalPath): keys set in the repo config win,
+// field by field.
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
+	return load(data, path)
+}
 
+// load is Load for the repo config's contents, read from path.
+func load(data []byte, path string) (*Config, error) {
 	var cfg Config
+	if err := loadGlobal(&cfg); err != nil {
+		return nil, err
+	}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
@@ -59,10 +586,95 @@ func Load(path string) (*Config, error) {
 	if cfg.Settings.Watches == "" {
 		return nil, fmt.Errorf("config: settings.watches is required")
 	}
+	if err := loadConfigDir(&cfg, path); err != nil {
+		return nil, err
+	}
+
+	var line []Station
+	for i, s := range cfg.Stations {
+		s.index = i
+		if s.Watches != "" {
+			cfg.RefStations = append(cfg.RefStations, s)
+		} else {
+			line = append(line, s)
+		}
+	}
+	cfg.Stations = orderStations(line)
 
 	return &cfg, nil
 }
 
+// ConfigDir is the directory next to the repo config whose *.yaml files,
+// e.g. one per team, add stations and gates to it.
+const ConfigDir = "line.d"
+
+// configFile is what a ConfigDir file may set.
+type configFile struct {
+	Gates     []Gate    `yaml:"gates"`
+	PushGates []Gate    `yaml:"push_gates"`
+	Stations  []Station `yaml:"stations"`
+}
+
+// loadConfigDir appends the gates and stations of the ConfigDir files next
+// to the repo config at path to cfg's, in file name order.
+func loadConfigDir(cfg *Config, path string) error {
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), ConfigDir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := ConfigDir + "/" + filepath.Base(f)
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		var cf configFile
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cf); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parsing %s (only gates, push_gates and stations are allowed): %w", name, err)
+		}
+		for i := range cf.Gates {
+			cf.Gates[i].file = name
+		}
+		for i := range cf.PushGates {
+			cf.PushGates[i].file = name
+		}
+		for i := range cf.Stations {
+			cf.Stations[i].file = name
+		}
+		cfg.Gates = append(cfg.Gates, cf.Gates...)
+		cfg.PushGates = append(cfg.PushGates, cf.PushGates...)
+		cfg.Stations = append(cfg.Stations, cf.Stations...)
+	}
+	return nil
+}
+
+// orderStations puts each station after the one it follows, keeping the
+// listed order otherwise. With a cycle the stations stay as listed;
+// Validate reports it.
+func orderStations(stations []Station) []Station {
+	listed := make(map[string]bool, len(stations))
+	for _, s := range stations {
+		listed[s.Name] = true
+	}
+	ordered := make([]Station, 0, len(stations))
+	placed := make(map[string]bool, len(stations))
+	rest := stations
+	for len(rest) > 0 {
+		i := slices.IndexFunc(rest, func(s Station) bool {
+			return s.Follows == "" || !listed[s.Follows] || placed[s.Follows]
+		})
+		if i < 0 {
+			return stations
+		}
+		ordered = append(ordered, rest[i])
+		placed[rest[i].Name] = true
+		rest = slices.Delete(slices.Clone(rest), i, i+1)
+	}
+	return ordered
+}
+
 // ResolveStation returns the fully resolved command and args for a station,
 // falling back to the top-level agent defaults.
 func (c *Config) ResolveStation(s Station) ResolvedStation {
@@ -76,10 +688,21 @@ func (c *Config) ResolveStation(s Station) ResolvedStation {
 		args = c.Agent.Args
 	}
 
+	prompt := s.Prompt
+	if prompt == "" && s.Changelog != "" {
+		prompt = ChangelogPrompt(s.Changelog)
+	}
+
 	return ResolvedStation{
-		Name:    s.Name,
-		Command: cmd,
-		Args:    args,
-		Prompt:  s.Prompt,
+		Name:         s.Name,
+		Command:      cmd,
+		Args:         args,
+		Prompt:       prompt,
+		Context:      s.Context,
+		Root:         s.CleanRoot(),
+		Changelog:    s.Changelog,
+		StallTimeout: c.Settings.StallTimeoutDuration(),
+		StripANSI:    c.Settings.LogFilter == LogFilterStripANSI,
+		AgentRate:    c.Settings.AgentRateLimit(),
 	}
 }
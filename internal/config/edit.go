@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Set sets key, a dotted path such as settings.watches or
+// stations.review.prompt, to value in the config file at path, editing the
+// YAML in place so comments and the order of keys survive. Stations and
+// gates are addressed by name or by index. value is parsed as YAML, so
+// "true", "3" and "[a, b]" keep their types. Missing mappings on the way are
+// created. The file is left untouched if the result has unknown keys or
+// validation errors the config did not have before.
+func Set(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	v, err := parseValue(value)
+	if err != nil {
+		return err
+	}
+	if err := setPath(doc.Content[0], strings.Split(key, "."), v); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := checkEdit(path, data, buf.Bytes()); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
+
+// parseValue parses a value given on the command line as a YAML node; an
+// empty value is an empty string.
+func parseValue(value string) (*yaml.Node, error) {
+	if value == "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "", Style: yaml.DoubleQuotedStyle}, nil
+	}
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &n); err != nil {
+		return nil, fmt.Errorf("value: %w", err)
+	}
+	v := n.Content[0]
+	// A value read on its own is laid out as a block; inline is tidier.
+	if v.Kind == yaml.MappingNode || v.Kind == yaml.SequenceNode {
+		v.Style = yaml.FlowStyle
+	}
+	return v, nil
+}
+
+// setPath sets the node at path under n to v.
+func setPath(n *yaml.Node, path []string, v *yaml.Node) error {
+	seg := path[0]
+	if seg == "" {
+		return errors.New("empty key")
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			if n.Content[i].Value != seg {
+				continue
+			}
+			if len(path) == 1 {
+				v.LineComment = n.Content[i+1].LineComment
+				n.Content[i+1] = v
+				return nil
+			}
+			return setPath(n.Content[i+1], path[1:], v)
+		}
+		k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}
+		if len(path) == 1 {
+			n.Content = append(n.Content, k, v)
+			return nil
+		}
+		child := &yaml.Node{Kind: yaml.MappingNode}
+		n.Content = append(n.Content, k, child)
+		return setPath(child, path[1:], v)
+	case yaml.SequenceNode:
+		i := slices.IndexFunc(n.Content, func(item *yaml.Node) bool { return itemName(item) == seg })
+		if i < 0 {
+			var err error
+			if i, err = strconv.Atoi(seg); err != nil || i < 0 || i >= len(n.Content) {
+				return fmt.Errorf("no entry named or numbered %q", seg)
+			}
+		}
+		if len(path) == 1 {
+			n.Content[i] = v
+			return nil
+		}
+		return setPath(n.Content[i], path[1:], v)
+	case yaml.ScalarNode, yaml.AliasNode:
+		if n.Tag == "!!null" {
+			// "key:" with no value: fill it in as a mapping.
+			*n = yaml.Node{Kind: yaml.MappingNode, HeadComment: n.HeadComment, LineComment: n.LineComment}
+			return setPath(n, path, v)
+		}
+	}
+	return fmt.Errorf("%q is not a mapping or list", seg)
+}
+
+// itemName returns the name of a list entry, such as a station or gate.
+func itemName(n *yaml.Node) string {
+	if n.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "name" {
+			return n.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// checkEdit rejects an edited config at path with keys line does not know,
+// or with validation errors the config before did not have.
+func checkEdit(path string, before, after []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(after))
+	dec.KnownFields(true)
+	var strict Config
+	if err := dec.Decode(&strict); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("not set: %w", err)
+	}
+	cfg, err := load(after, path)
+	if err != nil {
+		return fmt.Errorf("not set: %w", err)
+	}
+	var known []string
+	if old, err := load(before, path); err == nil {
+		known = Validate(old)
+	}
+	for _, e := range Validate(cfg) {
+		if !slices.Contains(known, e) {
+			return fmt.Errorf("not set: %s", e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxPromptLength is the prompt length, in characters, beyond which
+// LintPrompts warns: long prompts dilute the instructions that matter and
+// are paid for on every run.
+const MaxPromptLength = 8000
+
+// maxArgLength is Linux's limit on a single command-line argument
+// (MAX_ARG_STRLEN); the prompt is passed to the agent as one argument.
+const maxArgLength = 128 * 1024
+
+// gitDirectives match instructions that conflict with how line runs agents:
+// line commits the agent's changes itself, on the station branch, and tells
+// the agent not to commit (the preamble, RUN-12).
+var gitDirectives = regexp.MustCompile(`(?i)\b(git\s+(commit|push|checkout|switch|rebase|merge|reset)\b|(commit|push)\s+(the|your|all|these|any|those)?\s*(changes|work|fix(es)?)\b|(open|create|raise)\s+an?\s+(pull|merge)\s+request|(open|create|raise)\s+an?\s+(pr|mr)\b|(create|switch to|check out)\s+an?\s*(new\s+)?branch\b)`)
+
+// negation marks a directive as a prohibition ("do not commit"), which
+// agrees with the preamble.
+var negation = regexp.MustCompile(`(?i)\b(not|never|don't|do\s+not|no|avoid|without)\b`)
+
+// placeholders match template syntax and fill-me-in markers that line never
+// substitutes: prompts are passed to the agent verbatim. Bare words like
+// TODO are left alone; "fix the TODO comments" is a fine prompt.
+var placeholders = regexp.MustCompile(`\{\{[^}]*\}\}|\$\{[A-Za-z_][A-Za-z0-9_]*\}|<(TODO|FIXME|TBD|INSERT|PLACEHOLDER)[^>]*>|\[(TODO|FIXME|TBD|INSERT|PLACEHOLDER)[^\]]*\]`)
+
+// sentenceEnd splits a prompt into sentences and lines for the negation
+// check, so "Do not commit. Fix the tests." does not excuse later lines.
+var sentenceEnd = regexp.MustCompile(`[.!?;]\s+|\n+`)
+
+// LintPrompts checks station prompts for likely mistakes with static
+// heuristics: directives that conflict with the preamble, excessive length
+// and unresolved placeholders. Returns one human/agent-readable finding per
+// problem, like Validate.
+func LintPrompts(cfg *Config) []string {
+	var problems []string
+	for i, s := range cfg.Stations {
+		where := fmt.Sprintf("stations[%d].prompt (%s)", i, s.Name)
+		prompt := s.Prompt
+		if prompt == "" {
+			continue // reported by Validate
+		}
+
+		for _, sentence := range sentenceEnd.Split(prompt, -1) {
+			for _, loc := range gitDirectives.FindAllStringIndex(sentence, -1) {
+				if negation.MatchString(sentence[:loc[0]]) {
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("%s: %q conflicts with the preamble: line commits the agent's changes on the station branch and tells it not to commit, push or switch branches",
+					where, strings.TrimSpace(sentence[loc[0]:loc[1]])))
+			}
+		}
+
+		switch n := len(prompt); {
+		case n > maxArgLength:
+			problems = append(problems, fmt.Sprintf("%s: %d characters exceeds the %d-byte limit on a single command-line argument; the agent would fail to start", where, n, maxArgLength))
+		case n > MaxPromptLength:
+			problems = append(problems, fmt.Sprintf("%s: %d characters is over %d; long prompts dilute the instructions that matter and are paid for on every run", where, n, MaxPromptLength))
+		}
+
+		seen := map[string]bool{}
+		for _, p := range placeholders.FindAllString(prompt, -1) {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			problems = append(problems, fmt.Sprintf("%s: unresolved placeholder %q; prompts are passed to the agent verbatim", where, p))
+		}
+	}
+	return problems
+}
@@ -48,10 +48,221 @@ func Schema() []byte {
 						"default":     false,
 						"description": "When true and auto_rebase is true, rebase conflicts are left for agent resolution instead of aborting. The hook reports conflicted files with resolution instructions.",
 					},
+					"on_conflict": map[string]any{
+						"type":        "string",
+						"enum":        []string{"reset", "keep", "agent"},
+						"default":     "reset",
+						"description": "What a station does when its branch conflicts while rebasing onto its predecessor. reset discards the station's commits and starts again from the predecessor; keep aborts, leaves the branch untouched and blocks the line until resolved; agent runs the station's agent on the conflicted files to resolve them.",
+					},
+					"on_force_push": map[string]any{
+						"type":        "string",
+						"enum":        []string{"rebase", "reset"},
+						"default":     "rebase",
+						"description": "What the stations do with their branches once the watched branch was force-pushed, i.e. no longer contains the commit the line last ran for. rebase replays only each station's own commits onto the rewritten branch, dropping the replaced ones; reset discards the stations' commits and starts again from the rewritten branch.",
+					},
+					"debounce": map[string]any{
+						"type":        "string",
+						"pattern":     `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+						"description": "How long line run waits before reading the watched branch, as a Go duration (e.g. \"2s\"). Triggers that arrive meanwhile are coalesced into the waiting run, so a burst of commits gets one cycle. Unset runs straight away.",
+					},
+					"stall_after": map[string]any{
+						"type":        "string",
+						"pattern":     `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`,
+						"default":     "10m",
+						"description": "How long a running agent may write no output, as a Go duration (e.g. \"10m\"), before line status, line show and the statusline flag it with \"no output for <time>\". \"0\" turns the check off.",
+					},
+					"stall_timeout": map[string]any{
+						"type":        "string",
+						"pattern":     `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+						"description": "How long a running agent may write no output, as a Go duration (e.g. \"20m\"), before it is killed and its station fails with the reason \"stalled\". Unlike stall_after, which only flags the agent, this stops it. Unset never kills agents.",
+					},
+					"log_filter": map[string]any{
+						"type":        "string",
+						"enum":        []string{"keep", "strip-ansi"},
+						"default":     "keep",
+						"description": "What is done to agent output before it is stored in the station logs. keep stores it as the terminal got it; strip-ansi drops ANSI escape sequences and carriage returns, so the logs read well in less. line logs strips them either way, unless --raw.",
+					},
+					"agent_rate": map[string]any{
+						"type":        "string",
+						"pattern":     `^[1-9][0-9]*/(s|sec|second|m|min|minute|h|hour)$`,
+						"description": "How many agents may start per second, minute or hour across all stations, e.g. \"2/min\": a token bucket holding that many starts, refilled evenly over the period. An agent start beyond it waits for its turn. Unset starts agents straight away.",
+					},
+					"verify": map[string]any{
+						"type":        "boolean",
+						"default":     false,
+						"description": "When true, each station's changes are checked by re-running the gates in its worktree before they are committed. A failure marks the station 'failed verification', records the gate output in the station log and nothing is committed, so downstream stations never pick up the change.",
+					},
+					"gate_staged": map[string]any{
+						"type":        "boolean",
+						"default":     false,
+						"description": "When true, line gate checks exactly what will be committed: if the working tree has unstaged changes (e.g. a partially staged file), the pre-commit gates run in a temporary checkout of the staged files instead of the working tree.",
+					},
+					"commit_author": map[string]any{
+						"type":        "string",
+						"description": "Author and committer for station commits, as \"Name <email>\" (e.g. \"Line Bot <line@example.com>\"). Makes agent commits distinguishable from human ones. Defaults to the repository's git identity.",
+					},
+					"commit_signing": map[string]any{
+						"type":                 "object",
+						"description":          "Sign station commits, for repositories that require signed commits.",
+						"required":             []string{"key"},
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"format": map[string]any{
+								"type":        "string",
+								"enum":        []string{"openpgp", "ssh", "x509"},
+								"default":     "openpgp",
+								"description": "Signature format (git's gpg.format).",
+							},
+							"key": map[string]any{
+								"type":        "string",
+								"description": "Signing key (git's user.signingkey): a GPG key ID, or the path to an SSH public key.",
+							},
+						},
+					},
+					"worktree": map[string]any{
+						"type":                 "object",
+						"description":          "Untracked local files that station and gate worktrees need (paths relative to the repository root). They are never committed; paths missing from the repository are skipped.",
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"copy": map[string]any{
+								"type":        "array",
+								"items":       map[string]any{"type": "string"},
+								"description": "Files or directories copied into each worktree (e.g. \".env\", \".tool-versions\").",
+							},
+							"symlink": map[string]any{
+								"type":        "array",
+								"items":       map[string]any{"type": "string"},
+								"description": "Files or directories symlinked from the repository into each worktree (e.g. \"node_modules\").",
+							},
+						},
+					},
+					"hooks": map[string]any{
+						"type":                 "object",
+						"description":          "Shell commands run in the repository root once per cycle (e.g. git fetch, warming caches, writing an audit log). A failing hook aborts the cycle; line status reports it.",
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"before_all": map[string]any{
+								"type":        "string",
+								"description": "Run before the first station, before the watched branch is read. If it fails, no station runs.",
+							},
+							"after_all": map[string]any{
+								"type":        "string",
+								"description": "Run after the last station, whatever the stations' outcome; LINE_RESULT is ok when every station ran or was skipped, failed otherwise.",
+							},
+						},
+					},
+					"routes": map[string]any{
+						"type":                 "object",
+						"description":          "Conventional Commits routing: maps a commit type (feat, fix, chore, ...) to the stations its commits run, e.g. {feat: [docs, tests], fix: [regression], chore: []}. A station whose reviewed commits all have routed types, none routed to it, is skipped and passes changes through. Commits of other types, or without a type, run every station.",
+						"propertyNames":        map[string]any{"pattern": "^[a-z]+$"},
+						"additionalProperties": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					},
+					"ascii": map[string]any{
+						"type":        "boolean",
+						"default":     false,
+						"description": "Print ASCII instead of Unicode symbols in status, show, digest and statusline, like --ascii, for terminals and CI logs that mangle Unicode.",
+					},
+					"statusline": map[string]any{
+						"type":                 "object",
+						"description":          "Theme for line statusline: symbols and colors per state, and a maximum width. Useful in the global config.",
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"symbols": map[string]any{
+								"type":                 "object",
+								"description":          "Symbol per state, replacing the default (e.g. up_to_date: \"ok\"). --format plain keeps its ASCII symbols.",
+								"propertyNames":        map[string]any{"enum": StatuslineStates},
+								"additionalProperties": map[string]any{"type": "string"},
+							},
+							"colors": map[string]any{
+								"type":                 "object",
+								"description":          "Color per state: black, red, green, yellow, blue, magenta, cyan, white, grey, bright_red, bright_green, bright_yellow, bright_blue, bright_magenta, bright_cyan, none, or a 256-color number.",
+								"propertyNames":        map[string]any{"enum": StatuslineStates},
+								"additionalProperties": map[string]any{"type": "string"},
+							},
+							"max_width": map[string]any{
+								"type":        "integer",
+								"minimum":     0,
+								"description": "Maximum visible width of the statusline in characters; 0 means unlimited. The terminal width, when known, also applies.",
+							},
+							"layout": map[string]any{
+								"type":        "string",
+								"enum":        []string{"full", "compact"},
+								"default":     "full",
+								"description": "full shows the runner symbol and each station's symbol and name; compact chains the watched branch and stations, e.g. \"⏸ main ▸ review✓ ▸ docs●\", for hosts with little room (same as --compact).",
+							},
+							"truncate": map[string]any{
+								"type":        "string",
+								"enum":        []string{"end", "names", "middle", "active"},
+								"default":     "end",
+								"description": "How a statusline wider than max_width or the terminal is shortened: end cuts it with an ellipsis; middle cuts out the middle, keeping both ends; names first shortens station names; active first hides up-to-date stations, counting them as +N.",
+							},
+						},
+					},
+					"machine_commits": map[string]any{
+						"type":                 "object",
+						"description":          "Commits made by bots (e.g. Renovate, Dependabot) that do not trigger the line, like commits carrying a skip marker.",
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"authors": map[string]any{
+								"type":        "array",
+								"items":       map[string]any{"type": "string"},
+								"description": "Regular expressions matched against the commit author as \"Name <email>\" (e.g. \"^dependabot\" or \"<bot@example.com>$\").",
+							},
+							"trailers": map[string]any{
+								"type":        "array",
+								"items":       map[string]any{"type": "string"},
+								"description": "Trailer keys (e.g. \"Triggered-By\") whose presence in the commit message marks a machine commit.",
+							},
+						},
+					},
+				},
+			},
+			"notifications": map[string]any{
+				"description": "Notifications. Chat webhooks get station failures as they happen and `line digest --notify` posts the digest; email gets finished runs with changes and repeated failures.",
+				"type":        "object",
+				"additionalProperties": false,
+				"properties": map[string]any{
+					"slack": webhookSchema("Slack incoming webhook."),
+					"teams": webhookSchema("Microsoft Teams workflow webhook (messages are sent as Adaptive Cards)."),
+					"email": map[string]any{
+						"type":                 "object",
+						"description":          "Plain-text email over SMTP: sent when a run leaves new changes on the terminal station branch (with their diffstat), and when a station has failed `failures` times in a row (with the tail of its log).",
+						"required":             []string{"smtp", "from", "to"},
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"smtp": map[string]any{
+								"type":        "string",
+								"description": "SMTP server as host:port (e.g. \"smtp.example.com:587\"). STARTTLS is used when offered.",
+							},
+							"username": map[string]any{
+								"type":        "string",
+								"description": "SMTP username; environment variables are expanded. No authentication when empty.",
+							},
+							"password": map[string]any{
+								"type":        "string",
+								"description": "SMTP password; environment variables are expanded (e.g. \"${SMTP_PASSWORD}\").",
+							},
+							"from": map[string]any{
+								"type":        "string",
+								"description": "Sender address, optionally with a name (e.g. \"Line Bot <line@example.com>\").",
+							},
+							"to": map[string]any{
+								"type":        "array",
+								"items":       map[string]any{"type": "string"},
+								"description": "Recipient addresses.",
+							},
+							"failures": map[string]any{
+								"type":        "integer",
+								"minimum":     0,
+								"default":     1,
+								"description": "Mail a station failure once it has failed this many times in a row; one mail per streak.",
+							},
+						},
+					},
 				},
 			},
 			"gates": map[string]any{
-				"description": "Ordered list of pre-commit checks. Each gate runs as a Git pre-commit hook; if any gate fails, the commit is rejected.",
+				"description": "Ordered list of commit checks. Each gate runs from the Git pre-commit hook, or the commit-msg hook with hook: commit-msg; if any gate fails, the commit is rejected.",
 				"type":        "array",
 				"items": map[string]any{
 					"type":     "object",
@@ -66,6 +277,31 @@ func Schema() []byte {
 							"type":        "string",
 							"description": "Shell command to execute. Exit 0 means pass; non-zero means the commit is blocked.",
 						},
+						"hook": map[string]any{
+							"type":        "string",
+							"enum":        []string{"pre-commit", "commit-msg"},
+							"default":     "pre-commit",
+							"description": "The Git hook the gate runs from. commit-msg gates check the commit message: {msg_file} in run is replaced with the (shell-quoted) message file path, also in LINE_MSG_FILE, e.g. \"npx commitlint --edit {msg_file}\". Station commits, whose messages line writes, skip them.",
+						},
+					},
+				},
+			},
+			"push_gates": map[string]any{
+				"description": "Ordered list of pre-push checks, for slower checks like full test suites. They run from the Git pre-push hook that `line init --pre-push` installs, against each ref being pushed (checked out in a throwaway worktree unless it is the clean HEAD), with LINE_PUSH_REMOTE, LINE_PUSH_REF, LINE_PUSH_FROM (the remote's previous commit, empty for a new ref) and LINE_PUSH_TO set; if any fails, the push is rejected.",
+				"type":        "array",
+				"items": map[string]any{
+					"type":     "object",
+					"required": []string{"name", "run"},
+					"additionalProperties": false,
+					"properties": map[string]any{
+						"name": map[string]any{
+							"type":        "string",
+							"description": "Human-readable name for this gate (e.g. \"test\").",
+						},
+						"run": map[string]any{
+							"type":        "string",
+							"description": "Shell command to execute. Exit 0 means pass; non-zero means the push is rejected.",
+						},
 					},
 				},
 			},
@@ -94,6 +330,84 @@ func Schema() []byte {
 							"type":        "string",
 							"description": "The prompt text passed to the agent command as its final argument. Describes what this station should do.",
 						},
+						"verify": map[string]any{
+							"type":        "string",
+							"description": "Shell command run in the station worktree after the agent finishes and before committing (e.g. \"go test ./...\"). The station only commits if it exits 0.",
+						},
+						"after": map[string]any{
+							"type":        "string",
+							"description": "Shell command run in the station worktree after the agent and before verify and commit (e.g. a formatter or lockfile regeneration). Its changes are committed with the agent's; if it exits non-zero the station fails.",
+						},
+						"on_success": map[string]any{
+							"type":        "string",
+							"description": "Shell command run in the repository root after the station succeeds (or holds its commit for approval). LINE_STATION, LINE_BRANCH and LINE_RESULT are set.",
+						},
+						"on_failure": map[string]any{
+							"type":        "string",
+							"description": "Shell command run in the repository root after the station fails. LINE_STATION, LINE_BRANCH and LINE_RESULT (the error) are set.",
+						},
+						"cache": map[string]any{
+							"type":        "array",
+							"items":       map[string]any{"type": "string"},
+							"description": "Build directories (e.g. \"target/\", \".venv/\", \"node_modules/\"), relative to the repository root, moved out of the worktree when the station finishes and back in on its next run, so builds stay incremental. They are never committed.",
+						},
+						"watches": map[string]any{
+							"type":        "string",
+							"pattern":     "^(tag|branch):.+$",
+							"description": "Takes the station off the line and runs it for each new or moved tag (\"tag:v*\") or branch (\"branch:release/*\") whose short name matches the pattern (* does not match /), reviewing the commits since the previous matching tag, or since the branch was last seen. The newest matching ref runs when the station is added; older ones count as seen. approval: manual is not supported.",
+						},
+						"changelog": map[string]any{
+							"type":        "string",
+							"description": "Makes this a changelog station maintaining the given file (e.g. \"CHANGELOG.md\"): its prompt gets the reviewed commits grouped by Conventional Commits type (breaking changes, features, fixes, performance, other), and line release collates the entries added to the file since the last tag. prompt becomes optional, defaulting to a Keep a Changelog instruction.",
+						},
+						"root": map[string]any{
+							"type":        "string",
+							"description": "Monorepo package the station is scoped to, relative to the repository root (e.g. \"services/api\"). The station only runs when the reviewed commits change files there (outside .lineignore), its agent runs in that directory and is told to stay in it, its context only lists those commits, and changes outside it fail the station.",
+						},
+						"context": map[string]any{
+							"type":        "array",
+							"items":       map[string]any{"type": "string", "pattern": "^(jira:[A-Z][A-Z0-9_]+|github-issues(:[\\w.-]+/[\\w.-]+)?|beads(:.+)?)$"},
+							"description": "Issue tracker providers: tickets referenced in the reviewed commits' messages are fetched and appended to the prompt. \"jira:PROJ\" finds PROJ-123 (JIRA_URL, JIRA_USER, JIRA_API_TOKEN); \"github-issues\" finds #123 in the origin repository, or \"github-issues:owner/repo\" (GITHUB_TOKEN, GITHUB_API_URL). \"beads\" (or \"beads:<path>\", default .beads/issues.jsonl) adds the store's open items that mention the changed files; the agent closes or annotates them by writing .line-result.json, applied to the store in the station's commit. Unreachable issues are skipped with a warning.",
+						},
+						"on_verify_failure": map[string]any{
+							"type":        "string",
+							"enum":        []string{"fail", "repair"},
+							"default":     "fail",
+							"description": "What happens when verify fails: fail marks the station 'failed verification' without committing; repair asks the agent to fix the failure and verifies again, up to max_repair_attempts times.",
+						},
+						"max_repair_attempts": map[string]any{
+							"type":        "integer",
+							"minimum":     0,
+							"default":     2,
+							"description": "How many repair rounds on_verify_failure: repair may run before the station fails.",
+						},
+						"squash": map[string]any{
+							"type":        "boolean",
+							"default":     false,
+							"description": "When true, the station keeps a single commit on top of its predecessor: each run folds its changes into the station's not-yet-picked-up commits instead of adding another one.",
+						},
+						"trigger": map[string]any{
+							"type":        "string",
+							"enum":        []string{"auto", "manual"},
+							"default":     "auto",
+							"description": "manual keeps the station in the line but never runs it automatically: runs pass changes through it (like a skipped station) and it only runs for `line run --station <name> [--range a..b]`. For expensive or risky stations. Not supported with watches.",
+						},
+						"on_interrupt": map[string]any{
+							"type":        "string",
+							"enum":        []string{"discard", "commit", "resume"},
+							"default":     "discard",
+							"description": "What the next run does with the changes an agent left in its worktree when its run was killed (the runner crashed or a new commit took over): discard starts again from scratch; commit commits them without running the agent again; resume puts them back and runs the agent again, asked to continue where it left off. The changes are kept in refs/line/interrupted/<name> until then.",
+						},
+						"follows": map[string]any{
+							"type":        "string",
+							"description": "The station this one runs after in the line, e.g. one defined in another line.d/*.yaml file. Stations otherwise run in the order they are listed: those of line.yaml first, then those of each line.d file in file name order. Stations following each other in a cycle are a validation error.",
+						},
+						"approval": map[string]any{
+							"type":        "string",
+							"enum":        []string{"auto", "manual"},
+							"default":     "auto",
+							"description": "manual holds the station's commit as 'awaiting approval' and stops the line until `line approve <station>` (commit and continue downstream) or `line reject <station>` (discard).",
+						},
 					},
 				},
 			},
@@ -103,3 +417,24 @@ func Schema() []byte {
 	out, _ := json.MarshalIndent(schema, "", "  ")
 	return out
 }
+
+// webhookSchema describes a notifications webhook.
+func webhookSchema(description string) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"description":          description,
+		"required":             []string{"webhook_url"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"webhook_url": map[string]any{
+				"type":        "string",
+				"description": "Webhook URL. Environment variables are expanded (e.g. \"${SLACK_WEBHOOK_URL}\"), so the secret can stay out of line.yaml.",
+			},
+			"template": map[string]any{
+				"type":        "string",
+				"default":     "line: station {{.Station}} failed on {{.Commit}}: {{.Result}}",
+				"description": "Go text/template for failure messages. Fields: .Station, .Branch (line/stn/<name>), .Commit (short hash of the watched-branch commit), .Result (the error).",
+			},
+		},
+	}
+}
@@ -1,37 +1,319 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/issues"
+)
+
+// commitType is a Conventional Commits type as settings.routes keys it.
+var commitType = regexp.MustCompile(`^[a-z]+$`)
 
 // Validate checks a loaded Config for semantic errors beyond what Load catches.
 // Returns a list of human/agent-readable error strings, one per issue.
 func Validate(cfg *Config) []string {
 	var errs []string
 
-	seen := make(map[string]bool)
-	for i, s := range cfg.Stations {
+	// Stations from ConfigDir files are named after their file, e.g.
+	// "line.d/api.yaml: stations[0]", and may not reuse another file's names.
+	all := cfg.AllStations()
+	seen := make(map[string]Station)
+	for i, s := range all {
+		at := stationKey(all, i)
 		if s.Name == "" {
-			errs = append(errs, fmt.Sprintf("stations[%d].name: required field is empty", i))
-		} else if seen[s.Name] {
-			errs = append(errs, fmt.Sprintf("stations[%d].name: duplicate station name %q", i, s.Name))
+			errs = append(errs, fmt.Sprintf("%s.name: required field is empty", at))
+		} else if f, ok := seen[s.Name]; ok && f.file != s.file {
+			errs = append(errs, fmt.Sprintf("%s.name: duplicate station name %q, also defined in %s", at, s.Name, stationKey(all, f.index)))
+		} else if ok {
+			errs = append(errs, fmt.Sprintf("%s.name: duplicate station name %q", at, s.Name))
 		} else {
-			seen[s.Name] = true
+			seen[s.Name] = s
 		}
 
-		if s.Prompt == "" {
-			errs = append(errs, fmt.Sprintf("stations[%d].prompt: required field is empty", i))
+		if s.Prompt == "" && s.Changelog == "" {
+			errs = append(errs, fmt.Sprintf("%s.prompt: required field is empty", at))
+		}
+		if s.Changelog != "" && !filepath.IsLocal(s.Changelog) {
+			errs = append(errs, fmt.Sprintf("%s.changelog: %q must be a relative path inside the repository", at, s.Changelog))
 		}
 
 		if s.Command == "" && cfg.Agent.Command == "" {
-			errs = append(errs, fmt.Sprintf("stations[%d]: no resolvable command (set station command or agent.command)", i))
+			errs = append(errs, fmt.Sprintf("%s: no resolvable command (set station command or agent.command)", at))
+		}
+
+		switch s.Approval {
+		case "", ApprovalAuto, ApprovalManual:
+		default:
+			errs = append(errs, fmt.Sprintf("%s.approval: %q is not one of auto, manual", at, s.Approval))
+		}
+
+		switch s.Trigger {
+		case "", TriggerAuto, TriggerManual:
+		default:
+			errs = append(errs, fmt.Sprintf("%s.trigger: %q is not one of auto, manual", at, s.Trigger))
+		}
+
+		switch s.OnInterrupt {
+		case "", OnInterruptDiscard, OnInterruptCommit, OnInterruptResume:
+		default:
+			errs = append(errs, fmt.Sprintf("%s.on_interrupt: %q is not one of discard, commit, resume", at, s.OnInterrupt))
+		}
+
+		switch s.OnVerifyFailure {
+		case "", OnVerifyFailureFail, OnVerifyFailureRepair:
+		default:
+			errs = append(errs, fmt.Sprintf("%s.on_verify_failure: %q is not one of fail, repair", at, s.OnVerifyFailure))
+		}
+		if s.MaxRepairAttempts != nil && *s.MaxRepairAttempts < 0 {
+			errs = append(errs, fmt.Sprintf("%s.max_repair_attempts: must not be negative", at))
+		}
+		for j, p := range s.Cache {
+			if !filepath.IsLocal(p) {
+				errs = append(errs, fmt.Sprintf("%s.cache[%d]: %q must be a relative path inside the repository", at, j, p))
+			}
+		}
+		if s.Root != "" && !filepath.IsLocal(s.Root) {
+			errs = append(errs, fmt.Sprintf("%s.root: %q must be a relative path inside the repository", at, s.Root))
+		}
+		for j, spec := range s.Context {
+			if err := issues.CheckSpec(spec); err != nil {
+				errs = append(errs, fmt.Sprintf("%s.context[%d]: %v", at, j, err))
+			}
+		}
+		if s.Watches != "" {
+			if _, err := ParseWatches(s.Watches); err != nil {
+				errs = append(errs, fmt.Sprintf("%s.watches: %v", at, err))
+			}
+			if s.Approval == ApprovalManual {
+				errs = append(errs, fmt.Sprintf("%s.approval: manual is not supported for a station with watches", at))
+			}
+			if s.Trigger == TriggerManual {
+				errs = append(errs, fmt.Sprintf("%s.trigger: manual is not supported for a station with watches", at))
+			}
+			if s.Follows != "" {
+				errs = append(errs, fmt.Sprintf("%s.follows: not supported for a station with watches", at))
+			}
+		}
+		if s.Verify == "" && (s.OnVerifyFailure != "" || s.MaxRepairAttempts != nil) {
+			errs = append(errs, fmt.Sprintf("%s: on_verify_failure and max_repair_attempts have no effect without verify", at))
+		}
+	}
+	for i, s := range all {
+		if s.Follows == "" || s.Watches != "" {
+			continue
+		}
+		if f, ok := seen[s.Follows]; !ok || f.Watches != "" {
+			errs = append(errs, fmt.Sprintf("%s.follows: %q is not a station of the line", stationKey(all, i), s.Follows))
+		} else if cycle := followsCycle(seen, s); cycle != nil {
+			errs = append(errs, fmt.Sprintf("%s.follows: stations follow each other in a cycle (%s)", stationKey(all, i), strings.Join(cycle, " -> ")))
 		}
 	}
 
 	for i, g := range cfg.Gates {
+		at := gateKey("gates", cfg.Gates, i)
+		if g.Name == "" {
+			errs = append(errs, fmt.Sprintf("%s.name: required field is empty", at))
+		} else if j := slices.IndexFunc(cfg.Gates[:i], func(o Gate) bool { return o.Name == g.Name && o.file != g.file }); j >= 0 {
+			errs = append(errs, fmt.Sprintf("%s.name: duplicate gate name %q, also defined in %s", at, g.Name, gateKey("gates", cfg.Gates, j)))
+		}
+		if g.Run == "" {
+			errs = append(errs, fmt.Sprintf("%s.run: required field is empty", at))
+		}
+		switch g.Hook {
+		case "", GateHookPreCommit, GateHookCommitMsg:
+		default:
+			errs = append(errs, fmt.Sprintf("%s.hook: %q is not one of pre-commit, commit-msg", at, g.Hook))
+		}
+	}
+	for i, g := range cfg.PushGates {
+		at := gateKey("push_gates", cfg.PushGates, i)
 		if g.Name == "" {
-			errs = append(errs, fmt.Sprintf("gates[%d].name: required field is empty", i))
+			errs = append(errs, fmt.Sprintf("%s.name: required field is empty", at))
+		} else if j := slices.IndexFunc(cfg.PushGates[:i], func(o Gate) bool { return o.Name == g.Name && o.file != g.file }); j >= 0 {
+			errs = append(errs, fmt.Sprintf("%s.name: duplicate gate name %q, also defined in %s", at, g.Name, gateKey("push_gates", cfg.PushGates, j)))
 		}
 		if g.Run == "" {
-			errs = append(errs, fmt.Sprintf("gates[%d].run: required field is empty", i))
+			errs = append(errs, fmt.Sprintf("%s.run: required field is empty", at))
+		}
+	}
+
+	switch cfg.Settings.OnConflict {
+	case "", OnConflictReset, OnConflictKeep, OnConflictAgent:
+	default:
+		errs = append(errs, fmt.Sprintf("settings.on_conflict: %q is not one of reset, keep, agent", cfg.Settings.OnConflict))
+	}
+	switch cfg.Settings.OnForcePush {
+	case "", OnForcePushRebase, OnForcePushReset:
+	default:
+		errs = append(errs, fmt.Sprintf("settings.on_force_push: %q is not one of rebase, reset", cfg.Settings.OnForcePush))
+	}
+	if d := cfg.Settings.Debounce; d != "" {
+		if v, err := time.ParseDuration(d); err != nil || v < 0 {
+			errs = append(errs, fmt.Sprintf("settings.debounce: %q is not a duration (e.g. 2s)", d))
+		}
+	}
+	if d := cfg.Settings.StallAfter; d != "" {
+		if v, err := time.ParseDuration(d); err != nil || v < 0 {
+			errs = append(errs, fmt.Sprintf("settings.stall_after: %q is not a duration (e.g. 10m)", d))
+		}
+	}
+	if d := cfg.Settings.StallTimeout; d != "" {
+		if v, err := time.ParseDuration(d); err != nil || v < 0 {
+			errs = append(errs, fmt.Sprintf("settings.stall_timeout: %q is not a duration (e.g. 20m)", d))
+		}
+	}
+
+	switch cfg.Settings.LogFilter {
+	case "", LogFilterKeep, LogFilterStripANSI:
+	default:
+		errs = append(errs, fmt.Sprintf("settings.log_filter: %q is not one of keep, strip-ansi", cfg.Settings.LogFilter))
+	}
+
+	if r := cfg.Settings.AgentRate; r != "" {
+		if _, err := ParseRate(r); err != nil {
+			errs = append(errs, fmt.Sprintf("settings.agent_rate: %v", err))
+		}
+	}
+
+	if a := cfg.Settings.CommitAuthor; a != "" {
+		if addr, err := mail.ParseAddress(a); err != nil || addr.Name == "" {
+			errs = append(errs, fmt.Sprintf("settings.commit_author: %q must look like \"Name <email>\"", a))
+		}
+	}
+
+	if sg := cfg.Settings.CommitSigning; sg != nil {
+		if sg.Key == "" {
+			errs = append(errs, "settings.commit_signing.key: required field is empty")
+		}
+		switch sg.Format {
+		case "", "openpgp", "ssh", "x509":
+		default:
+			errs = append(errs, fmt.Sprintf("settings.commit_signing.format: %q is not one of openpgp, ssh, x509", sg.Format))
+		}
+	}
+
+	if mc := cfg.Settings.MachineCommits; mc != nil {
+		for i, pattern := range mc.Authors {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Sprintf("settings.machine_commits.authors[%d]: invalid regular expression %q: %v", i, pattern, err))
+			}
+		}
+		for i, key := range mc.Trailers {
+			if key == "" || strings.ContainsAny(key, ": \t") {
+				errs = append(errs, fmt.Sprintf("settings.machine_commits.trailers[%d]: %q is not a trailer key", i, key))
+			}
+		}
+	}
+
+	if wt := cfg.Settings.Worktree; wt != nil {
+		lists := []struct {
+			key   string
+			paths []string
+		}{{"copy", wt.Copy}, {"symlink", wt.Symlink}}
+		for _, l := range lists {
+			for i, p := range l.paths {
+				if !filepath.IsLocal(p) {
+					errs = append(errs, fmt.Sprintf("settings.worktree.%s[%d]: %q must be a relative path inside the repository", l.key, i, p))
+				}
+			}
+		}
+	}
+
+	if n := cfg.Notifications; n != nil {
+		webhooks := []struct {
+			key     string
+			webhook *Webhook
+		}{{"slack", n.Slack}, {"teams", n.Teams}}
+		for _, w := range webhooks {
+			if w.webhook == nil {
+				continue
+			}
+			if w.webhook.URL == "" {
+				errs = append(errs, fmt.Sprintf("notifications.%s.webhook_url: required field is empty", w.key))
+			} else if !strings.Contains(w.webhook.URL, "$") {
+				// URLs taken from the environment are only known at run time.
+				if u, err := url.Parse(w.webhook.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+					errs = append(errs, fmt.Sprintf("notifications.%s.webhook_url: must be an http(s) URL", w.key))
+				}
+			}
+			if _, err := template.New(w.key).Parse(w.webhook.Template); err != nil {
+				errs = append(errs, fmt.Sprintf("notifications.%s.template: %v", w.key, err))
+			}
+		}
+		if e := n.Email; e != nil {
+			if e.SMTP == "" {
+				errs = append(errs, "notifications.email.smtp: required field is empty")
+			} else if _, _, err := net.SplitHostPort(e.SMTP); err != nil && !strings.Contains(e.SMTP, "$") {
+				errs = append(errs, fmt.Sprintf("notifications.email.smtp: %q must be host:port", e.SMTP))
+			}
+			if _, err := mail.ParseAddress(e.From); err != nil {
+				errs = append(errs, fmt.Sprintf("notifications.email.from: %q is not an email address", e.From))
+			}
+			if len(e.To) == 0 {
+				errs = append(errs, "notifications.email.to: required field is empty")
+			}
+			for i, rcpt := range e.To {
+				if _, err := mail.ParseAddress(rcpt); err != nil {
+					errs = append(errs, fmt.Sprintf("notifications.email.to[%d]: %q is not an email address", i, rcpt))
+				}
+			}
+			if e.Failures < 0 {
+				errs = append(errs, "notifications.email.failures: must not be negative")
+			}
+		}
+	}
+
+	if sl := cfg.Settings.Statusline; sl != nil {
+		for _, key := range slices.Sorted(maps.Keys(sl.Symbols)) {
+			if !slices.Contains(StatuslineStates, key) {
+				errs = append(errs, fmt.Sprintf("settings.statusline.symbols.%s: not a state (one of %s)", key, strings.Join(StatuslineStates, ", ")))
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(sl.Colors)) {
+			if !slices.Contains(StatuslineStates, key) {
+				errs = append(errs, fmt.Sprintf("settings.statusline.colors.%s: not a state (one of %s)", key, strings.Join(StatuslineStates, ", ")))
+			}
+			color := sl.Colors[key]
+			if _, ok := StatuslineColors[color]; !ok {
+				if n, err := strconv.Atoi(color); err != nil || n < 0 || n > 255 {
+					errs = append(errs, fmt.Sprintf("settings.statusline.colors.%s: %q is not a color name or a number from 0 to 255", key, color))
+				}
+			}
+		}
+		if sl.MaxWidth < 0 {
+			errs = append(errs, "settings.statusline.max_width: must not be negative")
+		}
+		switch sl.Layout {
+		case "", LayoutFull, LayoutCompact:
+		default:
+			errs = append(errs, fmt.Sprintf("settings.statusline.layout: %q is not one of full, compact", sl.Layout))
+		}
+		switch sl.Truncate {
+		case "", TruncateEnd, TruncateNames, TruncateMiddle, TruncateActive:
+		default:
+			errs = append(errs, fmt.Sprintf("settings.statusline.truncate: %q is not one of end, names, middle, active", sl.Truncate))
+		}
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(cfg.Settings.Routes)) {
+		if !commitType.MatchString(t) {
+			errs = append(errs, fmt.Sprintf("settings.routes.%s: not a commit type (lowercase letters, e.g. feat, fix, chore)", t))
+		}
+		for i, name := range cfg.Settings.Routes[t] {
+			if _, ok := seen[name]; !ok {
+				errs = append(errs, fmt.Sprintf("settings.routes.%s[%d]: %q is not a station", t, i, name))
+			}
 		}
 	}
 
@@ -41,3 +323,51 @@ func Validate(cfg *Config) []string {
 
 	return errs
 }
+
+// stationKey locates all[i] in error messages: stations[<i>] in the repo
+// config, or "<file>: stations[<j>]" in a ConfigDir file.
+func stationKey(all []Station, i int) string {
+	s := all[i]
+	if s.file == "" {
+		return fmt.Sprintf("stations[%d]", i)
+	}
+	j := 0
+	for _, o := range all[:i] {
+		if o.file == s.file {
+			j++
+		}
+	}
+	return fmt.Sprintf("%s: stations[%d]", s.file, j)
+}
+
+// gateKey is stationKey for gates[i] of the list under key.
+func gateKey(key string, gates []Gate, i int) string {
+	g := gates[i]
+	if g.file == "" {
+		return fmt.Sprintf("%s[%d]", key, i)
+	}
+	j := 0
+	for _, o := range gates[:i] {
+		if o.file == g.file {
+			j++
+		}
+	}
+	return fmt.Sprintf("%s: %s[%d]", g.file, key, j)
+}
+
+// followsCycle returns the names of the stations s follows its way back to
+// itself, e.g. [a b a], or nil if it is not in a cycle. Each cycle is
+// returned for one station only, the one listed first.
+func followsCycle(stations map[string]Station, s Station) []string {
+	cycle := []string{s.Name}
+	for next, ok := stations[s.Follows]; ok; next, ok = stations[next.Follows] {
+		if next.Name == s.Name {
+			return append(cycle, s.Name)
+		}
+		if next.index < s.index || slices.Contains(cycle, next.Name) {
+			return nil
+		}
+		cycle = append(cycle, next.Name)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package gate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats line gate export writes.
+const (
+	FormatPreCommit = "pre-commit" // .pre-commit-config.yaml
+	FormatLefthook  = "lefthook"   // lefthook.yml
+)
+
+// hookGates pairs a Git hook with the configured gates that run from it.
+type hookGates struct {
+	hook  string
+	gates []config.Gate
+}
+
+// byHook returns the gates grouped by the Git hook they run from, in hook
+// order, leaving out hooks without gates.
+func byHook(cfg *config.Config) []hookGates {
+	var out []hookGates
+	for _, h := range []hookGates{
+		{config.GateHookPreCommit, cfg.GatesFor(config.GateHookPreCommit)},
+		{config.GateHookCommitMsg, cfg.GatesFor(config.GateHookCommitMsg)},
+		{"pre-push", cfg.PushGates},
+	} {
+		if len(h.gates) > 0 {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// Export converts the configured gates and push gates into the config file
+// of another Git hook manager, so teams standardised on one can keep
+// line.yaml as the source of truth. {msg_file} becomes the manager's own
+// placeholder for the commit message file; the LINE_* variables line gate
+// sets are not available there.
+func Export(cfg *config.Config, format, source string) ([]byte, error) {
+	var doc any
+	switch format {
+	case FormatPreCommit:
+		doc = preCommitConfig(cfg)
+	case FormatLefthook:
+		doc = lefthookConfig(cfg)
+	default:
+		return nil, fmt.Errorf("format %q is not one of %s, %s", format, FormatPreCommit, FormatLefthook)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated by line gate export from %s: change the gates there and export again.\n", source)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type preCommitFile struct {
+	InstallHookTypes []string `yaml:"default_install_hook_types,flow"`
+	// FailFast stops at the first failing hook, like line gate.
+	FailFast bool            `yaml:"fail_fast"`
+	Repos    []preCommitRepo `yaml:"repos"`
+}
+
+type preCommitRepo struct {
+	Repo  string          `yaml:"repo"`
+	Hooks []preCommitHook `yaml:"hooks"`
+}
+
+type preCommitHook struct {
+	ID            string   `yaml:"id"`
+	Name          string   `yaml:"name"`
+	Entry         string   `yaml:"entry"`
+	Language      string   `yaml:"language"`
+	PassFilenames bool     `yaml:"pass_filenames"`
+	AlwaysRun     bool     `yaml:"always_run"`
+	Stages        []string `yaml:"stages,flow"`
+}
+
+// preCommitConfig maps each gate to a local, system-language hook of the
+// pre-commit framework, run through sh -c as line runs it. Commit-msg gates
+// are handed the message file as $1.
+func preCommitConfig(cfg *config.Config) preCommitFile {
+	repo := preCommitRepo{Repo: "local", Hooks: []preCommitHook{}}
+	var types []string
+	for _, h := range byHook(cfg) {
+		types = append(types, h.hook)
+		for _, g := range h.gates {
+			run := g.Run
+			if h.hook == config.GateHookCommitMsg {
+				run = strings.ReplaceAll(run, "{msg_file}", `"$1"`)
+			}
+			repo.Hooks = append(repo.Hooks, preCommitHook{
+				ID:            g.Name,
+				Name:          g.Name,
+				Entry:         "sh -c " + shellQuote(run) + " --",
+				Language:      "system",
+				PassFilenames: h.hook == config.GateHookCommitMsg,
+				AlwaysRun:     true,
+				Stages:        []string{h.hook},
+			})
+		}
+	}
+	return preCommitFile{InstallHookTypes: types, FailFast: true, Repos: []preCommitRepo{repo}}
+}
+
+type lefthookHook struct {
+	// Piped runs the commands one after another in priority order,
+	// stopping at the first that fails, like line gate.
+	Piped    bool                       `yaml:"piped"`
+	Commands map[string]lefthookCommand `yaml:"commands"`
+}
+
+type lefthookCommand struct {
+	Priority int    `yaml:"priority"`
+	Run      string `yaml:"run"`
+}
+
+// lefthookConfig maps each hook's gates to piped lefthook commands, ordered
+// by priority. Commit-msg gates get the message file as {1}.
+func lefthookConfig(cfg *config.Config) map[string]lefthookHook {
+	out := map[string]lefthookHook{}
+	for _, h := range byHook(cfg) {
+		hook := lefthookHook{Piped: true, Commands: map[string]lefthookCommand{}}
+		for i, g := range h.gates {
+			run := g.Run
+			if h.hook == config.GateHookCommitMsg {
+				run = strings.ReplaceAll(run, "{msg_file}", "{1}")
+			}
+			// Gate names need not be unique; lefthook's command names are.
+			name := g.Name
+			for n := 2; hook.Commands[name].Run != ""; n++ {
+				name = fmt.Sprintf("%s-%d", g.Name, n)
+			}
+			hook.Commands[name] = lefthookCommand{Priority: i + 1, Run: run}
+		}
+		out[h.hook] = hook
+	}
+	return out
+}
+
+// shellQuote single-quotes s for sh (and for pre-commit's shlex split).
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
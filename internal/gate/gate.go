@@ -1,24 +1,89 @@
 package gate
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"os/exec"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
 )
 
+// maxReportOutput caps the output kept per gate in its result: the end,
+// where test runners and linters sum up, matters most.
+const maxReportOutput = 64 << 10
+
 type Gate struct {
 	Name string
 	Run  string
 }
 
-// RunGates executes gates in order, failing fast on the first error.
-func RunGates(gates []Gate, dir string) error {
+// FromConfig converts configured gates into runnable gates.
+func FromConfig(gates []config.Gate) []Gate {
+	out := make([]Gate, len(gates))
+	for i, g := range gates {
+		out[i] = Gate{Name: g.Name, Run: g.Run}
+	}
+	return out
+}
+
+// Run executes gates in order, failing fast on the first error, and returns
+// the result of each gate that ran. A gate's output is captured; it is
+// written to out as it comes when verbose, and otherwise only if the gate
+// fails. Progress messages go to out too.
+func Run(gates []Gate, dir string, out io.Writer, verbose bool) ([]state.GateResult, error) {
+	var results []state.GateResult
+	for _, g := range gates {
+		fmt.Fprintf(out, "gate: running %s\n", g.Name)
+		var buf bytes.Buffer
+		cmd := exec.Command("sh", "-c", g.Run)
+		cmd.Dir = dir
+		cmd.Stdout = &buf
+		if verbose {
+			cmd.Stdout = io.MultiWriter(&buf, out)
+		}
+		cmd.Stderr = cmd.Stdout
+		started := time.Now()
+		err := cmd.Run()
+		r := state.GateResult{Name: g.Name, Run: g.Run, OK: err == nil, Duration: time.Since(started).Seconds(), Output: tail(buf.String())}
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			r.ExitCode = exitErr.ExitCode()
+		case err != nil:
+			r.ExitCode = -1
+		}
+		results = append(results, r)
+		if err != nil {
+			if !verbose {
+				_, _ = out.Write(buf.Bytes())
+			}
+			return results, fmt.Errorf("gate %q failed: %w", g.Name, err)
+		}
+	}
+	return results, nil
+}
+
+// tail cuts s to its last maxReportOutput bytes.
+func tail(s string) string {
+	if len(s) <= maxReportOutput {
+		return s
+	}
+	return "…" + s[len(s)-maxReportOutput:]
+}
+
+// RunGatesTo executes gates in order like Run, writing their output to
+// stdout and stderr as it comes, and progress messages to stderr.
+func RunGatesTo(gates []Gate, dir string, stdout, stderr io.Writer) error {
 	for _, g := range gates {
-		fmt.Fprintf(os.Stderr, "gate: running %s\n", g.Name)
+		fmt.Fprintf(stderr, "gate: running %s\n", g.Name)
 		cmd := exec.Command("sh", "-c", g.Run)
 		cmd.Dir = dir
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("gate %q failed: %w", g.Name, err)
 		}
@@ -0,0 +1,36 @@
+package gate
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// PushUpdate is one ref a git push is about to update, as the pre-push hook
+// reads it on stdin. RemoteSHA is empty when the remote ref is new.
+type PushUpdate struct {
+	LocalRef  string
+	LocalSHA  string
+	RemoteRef string
+	RemoteSHA string
+}
+
+// ReadPushUpdates parses the pre-push hook's stdin, "<local ref> <local sha>
+// <remote ref> <remote sha>" per line. Deletions, and refs the remote
+// already has at the pushed commit, have nothing to check and are left out.
+func ReadPushUpdates(r io.Reader) ([]PushUpdate, error) {
+	var updates []PushUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) != 4 || strings.Trim(f[1], "0") == "" || f[1] == f[3] {
+			continue
+		}
+		u := PushUpdate{LocalRef: f[0], LocalSHA: f[1], RemoteRef: f[2], RemoteSHA: f[3]}
+		if strings.Trim(u.RemoteSHA, "0") == "" {
+			u.RemoteSHA = ""
+		}
+		updates = append(updates, u)
+	}
+	return updates, scanner.Err()
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// shortHashLen is the abbreviation length used when displaying hashes
+// resolved through a Batch.
+const shortHashLen = 7
+
+// Batch resolves revisions through a single long-lived
+// `git cat-file --batch-check` process, so callers that need many lookups
+// (e.g. line status refreshing every station) pay for one fork instead of
+// one per lookup. A Batch is not safe for concurrent use.
+type Batch struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+// NewBatch starts a batch resolver for the repository at dir.
+func NewBatch(dir string) (*Batch, error) {
+	cmd := exec.Command("git", "cat-file", "--batch-check=%(objectname)")
+	cmd.Dir = dir
+	cmd.Env = append(CleanEnv(os.Environ(), gitEnvKeys...), "GIT_TERMINAL_PROMPT=0")
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	return &Batch{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+// Resolve returns the full object hash for rev, or false if rev does not
+// name an object (missing or ambiguous).
+func (b *Batch) Resolve(rev string) (string, bool) {
+	if rev == "" || strings.ContainsAny(rev, "\n") {
+		return "", false
+	}
+	if _, err := io.WriteString(b.in, rev+"\n"); err != nil {
+		return "", false
+	}
+	line, err := b.out.ReadString('\n')
+	if err != nil {
+		return "", false
+	}
+	line = strings.TrimSpace(line)
+	if strings.HasSuffix(line, " missing") || strings.HasSuffix(line, " ambiguous") {
+		return "", false
+	}
+	return line, true
+}
+
+// Close stops the batch process.
+func (b *Batch) Close() error {
+	_ = b.in.Close()
+	return b.cmd.Wait()
+}
+
+// ShortHash abbreviates a full hash for display.
+func ShortHash(hash string) string {
+	if len(hash) <= shortHashLen {
+		return hash
+	}
+	return hash[:shortHashLen]
+}
@@ -1,13 +1,14 @@
 package git
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/paths"
+	"github.com/re-cinq/assembly-line/internal/trace"
 )
 
 // gitEnvKeys lists git environment variable names that must be stripped from
@@ -45,14 +46,48 @@ func CleanEnv(environ []string, keys ...string) []string {
 
 // Run executes a git command in the given directory.
 func Run(dir string, args ...string) (string, error) {
+	return runEnv(dir, nil, args...)
+}
+
+// runEnv is Run with extra KEY=value environment entries, which take
+// precedence over the inherited environment.
+func runEnv(dir string, env []string, args ...string) (out string, err error) {
+	span := trace.Child("git "+subcommand(args), "git.args", traceArgs(args))
+	defer func() { span.End(err) }()
+
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
-	cmd.Env = append(CleanEnv(os.Environ(), gitEnvKeys...), "GIT_TERMINAL_PROMPT=0")
-	out, err := cmd.CombinedOutput()
+	cmd.Env = append(append(CleanEnv(os.Environ(), gitEnvKeys...), "GIT_TERMINAL_PROMPT=0"), env...)
+	raw, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("git %s: %s: %w", strings.Join(args, " "), strings.TrimSpace(string(out)), err)
+		return "", fmt.Errorf("git %s: %s: %w", strings.Join(args, " "), strings.TrimSpace(string(raw)), err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// subcommand returns the git subcommand in args, after any -c options.
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
 	}
-	return strings.TrimSpace(string(out)), nil
+	return ""
+}
+
+// maxTraceArgs bounds the git.args span attribute, which can hold whole
+// commit messages.
+const maxTraceArgs = 200
+
+func traceArgs(args []string) string {
+	s := strings.Join(args, " ")
+	if len(s) > maxTraceArgs {
+		s = s[:maxTraceArgs] + "..."
+	}
+	return s
 }
 
 // CurrentBranch returns the current branch name.
@@ -66,15 +101,55 @@ func BranchExists(dir, branch string) bool {
 	return err == nil
 }
 
+// BranchHeads returns the full commit hash of every local branch matching the
+// given for-each-ref patterns (e.g. "refs/heads/line/stn/"), keyed by short
+// branch name. It resolves any number of branches with a single git process.
+func BranchHeads(dir string, patterns ...string) (map[string]string, error) {
+	args := append([]string{"for-each-ref", "--format=%(refname:short) %(objectname)"}, patterns...)
+	out, err := Run(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		name, hash, ok := strings.Cut(line, " ")
+		if ok {
+			heads[name] = hash
+		}
+	}
+	return heads, nil
+}
+
 // CreateBranch creates a new branch from a starting point.
 func CreateBranch(dir, branch, startPoint string) error {
 	_, err := Run(dir, "branch", branch, startPoint)
 	return err
 }
 
-// Rebase rebases the current branch onto the given ref.
-func Rebase(dir, onto string) error {
-	_, err := Run(dir, "rebase", onto)
+// Rebase rebases the current branch onto the given ref. The replayed
+// commits get the committer and signature of opts.
+func Rebase(dir, onto string, opts CommitOptions) error {
+	return rebase(dir, opts, onto)
+}
+
+// RebaseForkPoint rebases the current branch onto ref using ref's reflog to
+// find where the branch forked, so commits that ref has since rewritten
+// (e.g. squashed) are dropped instead of replayed.
+func RebaseForkPoint(dir, onto string, opts CommitOptions) error {
+	return rebase(dir, opts, "--fork-point", onto)
+}
+
+// RebaseOnto rebases the commits of the current branch since upstream onto
+// onto, leaving out those upstream already had, e.g. the versions of a branch
+// that has since been rewritten.
+func RebaseOnto(dir, onto, upstream string, opts CommitOptions) error {
+	return rebase(dir, opts, "--onto", onto, upstream)
+}
+
+// rebase runs git rebase with args, committing as opts says and never
+// opening an editor.
+func rebase(dir string, opts CommitOptions, args ...string) error {
+	_, err := runEnv(dir, opts.env(), append(append(opts.configArgs(), "-c", "core.editor=true", "rebase"), args...)...)
 	return err
 }
 
@@ -84,14 +159,88 @@ func RebaseAbort(dir string) error {
 	return err
 }
 
+// RebaseContinue stages the working tree (excluding .line/) and continues an
+// in-progress rebase without opening an editor, committing as opts says. It
+// refuses to continue while conflict markers remain in the staged files.
+func RebaseContinue(dir string, opts CommitOptions) error {
+	if _, err := Run(dir, "add", "-A"); err != nil {
+		return err
+	}
+	_, _ = Run(dir, "reset", "--", ".line/")
+	if _, err := Run(dir, "diff", "--cached", "--check"); err != nil && strings.Contains(err.Error(), "conflict marker") {
+		return fmt.Errorf("conflict markers remain")
+	}
+	return rebase(dir, opts, "--continue")
+}
+
+// RebaseInProgress returns true if a rebase is stopped in dir.
+func RebaseInProgress(dir string) bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := Run(dir, "rev-parse", "--git-path", name)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitOptions control the identity and signature of commits made by
+// CommitAll and replayed by the rebase helpers. Zero values fall back to the
+// repository's git config.
+type CommitOptions struct {
+	AuthorName  string
+	AuthorEmail string
+	SignFormat  string // gpg.format: openpgp, ssh or x509
+	SignKey     string // user.signingkey; signing is enabled when set
+	NoVerify    bool   // skip the pre-commit hook (gates are run separately)
+	// Unstage lists paths that are never committed, e.g. the files
+	// ProvisionWorktree put in the worktree.
+	Unstage []string
+}
+
+// env returns the identity variables for the options. They are passed as
+// environment rather than user.name/user.email so they also win over any
+// GIT_AUTHOR_* or GIT_COMMITTER_* the caller inherited.
+func (o CommitOptions) env() []string {
+	var env []string
+	if o.AuthorName != "" {
+		env = append(env, "GIT_AUTHOR_NAME="+o.AuthorName, "GIT_COMMITTER_NAME="+o.AuthorName)
+	}
+	if o.AuthorEmail != "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+o.AuthorEmail, "GIT_COMMITTER_EMAIL="+o.AuthorEmail)
+	}
+	return env
+}
+
+// configArgs returns the `git -c` flags that enable signing.
+func (o CommitOptions) configArgs() []string {
+	var args []string
+	if o.SignKey != "" {
+		if o.SignFormat != "" {
+			args = append(args, "-c", "gpg.format="+o.SignFormat)
+		}
+		args = append(args, "-c", "user.signingkey="+o.SignKey, "-c", "commit.gpgsign=true")
+	}
+	return args
+}
+
 // CommitAll stages all changes and commits with the given message.
 // It excludes the .line/ directory which contains runtime state.
-func CommitAll(dir, message string) error {
+func CommitAll(dir, message string, opts CommitOptions) error {
 	if _, err := Run(dir, "add", "-A"); err != nil {
 		return err
 	}
 	// Unstage .line/ - it's runtime state, not project code
 	_, _ = Run(dir, "reset", "--", ".line/")
+	if len(opts.Unstage) > 0 {
+		_, _ = Run(dir, append([]string{"reset", "--"}, opts.Unstage...)...)
+	}
 	// Check if there's anything to commit
 	status, err := Run(dir, "status", "--porcelain")
 	if err != nil {
@@ -100,7 +249,11 @@ func CommitAll(dir, message string) error {
 	if status == "" {
 		return nil // Nothing to commit
 	}
-	_, err = Run(dir, "commit", "-m", message)
+	args := append(opts.configArgs(), "commit", "-m", message)
+	if opts.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	_, err = runEnv(dir, opts.env(), args...)
 	return err
 }
 
@@ -118,6 +271,35 @@ func IsDirty(dir string) (bool, error) {
 	return out != "", nil
 }
 
+// hookIndex returns the GIT_INDEX_FILE entry a running hook was given, if
+// any: for git commit <paths> or -a that is a temporary index holding what
+// will be committed, rather than the repository's own.
+func hookIndex() []string {
+	if f := os.Getenv("GIT_INDEX_FILE"); f != "" {
+		return []string{"GIT_INDEX_FILE=" + f}
+	}
+	return nil
+}
+
+// HasUnstagedChanges reports whether tracked files in the working tree differ
+// from what is staged, in the index a running hook was given.
+func HasUnstagedChanges(dir string) bool {
+	_, err := runEnv(dir, hookIndex(), "diff", "--quiet")
+	return err != nil
+}
+
+// StagedTree writes what is staged, in the index a running hook was given,
+// as a tree and returns its hash.
+func StagedTree(dir string) (string, error) {
+	return runEnv(dir, hookIndex(), "write-tree")
+}
+
+// CheckoutTree replaces the index and working tree of worktree dir with tree.
+func CheckoutTree(dir, tree string) error {
+	_, err := Run(dir, "read-tree", "-u", "--reset", tree)
+	return err
+}
+
 // ConflictedFiles returns the list of files with unresolved merge conflicts.
 func ConflictedFiles(dir string) ([]string, error) {
 	out, err := Run(dir, "diff", "--name-only", "--diff-filter=U")
@@ -142,9 +324,22 @@ func DiffFiles(dir, from, to string) ([]string, error) {
 	return strings.Split(out, "\n"), nil
 }
 
-// LastCommitMessage returns the message of the most recent commit.
-func LastCommitMessage(dir string) (string, error) {
-	return Run(dir, "log", "-1", "--format=%s")
+// IgnoreCase reports whether the repository is on a case-insensitive
+// filesystem, as git detected it when the repository was created
+// (core.ignoreCase).
+func IgnoreCase(dir string) bool {
+	out, err := Run(dir, "config", "--type=bool", "core.ignoreCase")
+	return err == nil && out == "true"
+}
+
+// CommitSubject returns the subject line of the message of rev.
+func CommitSubject(dir, rev string) (string, error) {
+	return Run(dir, "log", "-1", "--format=%s", rev)
+}
+
+// CommitMessage returns the full message of rev, subject and body.
+func CommitMessage(dir, rev string) (string, error) {
+	return Run(dir, "log", "-1", "--format=%B", rev)
 }
 
 // StationBranchName returns the branch name for a station.
@@ -152,6 +347,59 @@ func StationBranchName(name string) string {
 	return "line/stn/" + name
 }
 
+// ApprovalRefName returns the ref holding a station commit that awaits
+// approval. It keeps the commit reachable while the branch is unchanged.
+func ApprovalRefName(name string) string {
+	return "refs/line/approval/" + name
+}
+
+// InterruptedRefName returns the ref keeping the changes a station's agent
+// left behind when its run was interrupted.
+func InterruptedRefName(name string) string {
+	return "refs/line/interrupted/" + name
+}
+
+// Snapshot records the changes in the working tree of dir, staged as
+// CommitAll would stage them, in a commit on top of HEAD that no branch
+// points to, and returns it: "" when there are none.
+func Snapshot(dir, message string, unstage []string) (string, error) {
+	if _, err := Run(dir, "add", "-A"); err != nil {
+		return "", err
+	}
+	_, _ = Run(dir, "reset", "--", ".line/")
+	if len(unstage) > 0 {
+		_, _ = Run(dir, append([]string{"reset", "--"}, unstage...)...)
+	}
+	tree, err := Run(dir, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	if head, _ := Run(dir, "rev-parse", "HEAD^{tree}"); head == tree {
+		return "", nil
+	}
+	return Run(dir, "commit-tree", tree, "-p", "HEAD", "-m", message)
+}
+
+// ApplySnapshot puts the changes of a Snapshot commit into the working tree
+// of dir, unstaged. If they do not apply, the working tree is reset and the
+// error returned.
+func ApplySnapshot(dir, commit string) error {
+	if _, err := Run(dir, "cherry-pick", "--no-commit", commit); err != nil {
+		_, _ = Run(dir, "reset", "--hard", "-q")
+		_, _ = Run(dir, "clean", "-fdq")
+		return err
+	}
+	_, err := Run(dir, "reset", "-q")
+	return err
+}
+
+// ResetSoft moves the current branch to ref, keeping the index and working
+// tree so the difference is left staged.
+func ResetSoft(dir, ref string) error {
+	_, err := Run(dir, "reset", "--soft", ref)
+	return err
+}
+
 // ResetHard resets the current branch to the given ref.
 func ResetHard(dir, ref string) error {
 	_, err := Run(dir, "reset", "--hard", ref)
@@ -184,14 +432,24 @@ func HasCommitsBetween(dir, from, to string) (bool, error) {
 	return out != "0", nil
 }
 
-// OnlySkipCommitsBetween returns true if from..to contains at least one commit
-// and every commit message contains a skip marker.
-func OnlySkipCommitsBetween(dir, from, to string, skipMarkers []string) bool {
+// CommitSubjects returns the subject line of every commit in from..to,
+// newest first, using a single git log invocation.
+func CommitSubjects(dir, from, to string) ([]string, error) {
 	out, err := Run(dir, "log", "--format=%s", from+".."+to)
 	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// OnlySkipCommitsBetween returns true if from..to contains at least one commit
+// and every commit subject contains a skip marker.
+func OnlySkipCommitsBetween(dir, from, to string, skipMarkers []string) bool {
+	subjects, err := CommitSubjects(dir, from, to)
+	if err != nil || len(subjects) == 0 {
 		return false
 	}
-	for _, subject := range strings.Split(out, "\n") {
+	for _, subject := range subjects {
 		hasMarker := false
 		for _, marker := range skipMarkers {
 			if strings.Contains(subject, marker) {
@@ -206,21 +464,10 @@ func OnlySkipCommitsBetween(dir, from, to string, skipMarkers []string) bool {
 	return true
 }
 
-// WorktreeBaseDir returns a deterministic temp directory path for worktrees
-// belonging to the given repo: /tmp/line-<8-char-sha256-of-abs-repo-path>/
+// WorktreeBaseDir returns the deterministic directory holding worktrees
+// belonging to the given repo (see paths.Worktrees).
 func WorktreeBaseDir(repoDir string) (string, error) {
-	abs, err := filepath.Abs(repoDir)
-	if err != nil {
-		return "", fmt.Errorf("resolving repo path: %w", err)
-	}
-	// Resolve symlinks to get a canonical path (e.g. /var -> /private/var on macOS)
-	abs, err = filepath.EvalSymlinks(abs)
-	if err != nil {
-		return "", fmt.Errorf("resolving symlinks: %w", err)
-	}
-	h := sha256.Sum256([]byte(abs))
-	tag := hex.EncodeToString(h[:])[:8]
-	return filepath.Join(os.TempDir(), "line-"+tag), nil
+	return paths.Worktrees(repoDir)
 }
 
 // AddWorktree creates a git worktree at worktreePath for the given branch.
@@ -229,6 +476,13 @@ func AddWorktree(repoDir, worktreePath, branch string) error {
 	return err
 }
 
+// AddDetachedWorktree creates a git worktree at worktreePath with a detached
+// HEAD at ref, leaving ref's branch free to be checked out elsewhere.
+func AddDetachedWorktree(repoDir, worktreePath, ref string) error {
+	_, err := Run(repoDir, "worktree", "add", "--detach", worktreePath, ref)
+	return err
+}
+
 // RemoveWorktree force-removes a git worktree.
 func RemoveWorktree(repoDir, worktreePath string) error {
 	_, err := Run(repoDir, "worktree", "remove", "--force", worktreePath)
@@ -253,8 +507,41 @@ func DeleteBranch(dir, name string) error {
 	return err
 }
 
+// RenameBranch renames a local branch, replacing any branch already called
+// to.
+func RenameBranch(dir, from, to string) error {
+	_, err := Run(dir, "branch", "-M", from, to)
+	return err
+}
+
 // PruneWorktrees prunes stale worktree bookkeeping entries.
 func PruneWorktrees(repoDir string) error {
 	_, err := Run(repoDir, "worktree", "prune")
 	return err
 }
+
+// UpdateRef points ref at newValue. If oldValue is non-empty the update
+// only happens while ref still points at it.
+func UpdateRef(dir, ref, newValue, oldValue string) error {
+	args := []string{"update-ref", ref, newValue}
+	if oldValue != "" {
+		args = append(args, oldValue)
+	}
+	_, err := Run(dir, args...)
+	return err
+}
+
+// DeleteRef deletes ref, ignoring refs that do not exist.
+func DeleteRef(dir, ref string) error {
+	if _, err := Run(dir, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return nil
+	}
+	_, err := Run(dir, "update-ref", "-d", ref)
+	return err
+}
+
+// ShortStat returns git's one-line summary of the changes between from and
+// to, e.g. "2 files changed, 5 insertions(+)".
+func ShortStat(dir, from, to string) (string, error) {
+	return Run(dir, "diff", "--shortstat", from, to)
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ProvisionWorktree copies (copyPaths) or symlinks (symlinkPaths) untracked
+// files such as .env or node_modules from the main repo into a worktree.
+// Paths are relative to the repo root; those missing from the repo, or
+// already present in the worktree (e.g. tracked files), are left alone. It
+// returns the paths it created, which CommitOptions.Unstage keeps out of
+// commits.
+func ProvisionWorktree(repoDir, wtPath string, copyPaths, symlinkPaths []string) ([]string, error) {
+	var created []string
+	provision := func(rel string, link bool) error {
+		src := filepath.Join(repoDir, rel)
+		dst := filepath.Join(wtPath, rel)
+		if _, err := os.Lstat(src); err != nil {
+			return nil
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if link {
+			abs, err := filepath.Abs(src)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(abs, dst); err != nil {
+				return err
+			}
+		} else if err := copyTree(src, dst); err != nil {
+			return err
+		}
+		created = append(created, rel)
+		return nil
+	}
+	for _, rel := range copyPaths {
+		if err := provision(rel, false); err != nil {
+			return created, fmt.Errorf("copying %s into worktree: %w", rel, err)
+		}
+	}
+	for _, rel := range symlinkPaths {
+		if err := provision(rel, true); err != nil {
+			return created, fmt.Errorf("linking %s into worktree: %w", rel, err)
+		}
+	}
+	return created, nil
+}
+
+// copyTree copies a file, symlink or directory tree, keeping file modes.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
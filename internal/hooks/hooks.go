@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/re-cinq/assembly-line/internal/markers"
 )
@@ -16,12 +17,56 @@ line gate
 %s`, markers.Start, markers.End)
 }
 
+// commitMsgBlock runs the commit-msg gates on the message file.
+func commitMsgBlock() string {
+	return fmt.Sprintf(`%s
+line gate --hook commit-msg "$1"
+%s`, markers.Start, markers.End)
+}
+
+// prePushBlock runs the push gates, handing on the remote and the refs
+// being pushed (stdin).
+func prePushBlock() string {
+	return fmt.Sprintf(`%s
+line gate --hook pre-push "$@"
+%s`, markers.Start, markers.End)
+}
+
 func postCommitBlock() string {
 	return fmt.Sprintf(`%s
 line run &
 %s`, markers.Start, markers.End)
 }
 
+// postMergeBlock runs the line after git merge and git pull, which do not
+// fire post-commit.
+func postMergeBlock() string {
+	return fmt.Sprintf(`%s
+line run &
+%s`, markers.Start, markers.End)
+}
+
+// postRewriteBlock runs the line after git rebase (including git pull
+// --rebase). Amends already fire post-commit, so they are ignored.
+func postRewriteBlock() string {
+	return fmt.Sprintf(`%s
+if [ "$1" = rebase ]; then
+  line run &
+fi
+%s`, markers.Start, markers.End)
+}
+
+// referenceTransactionBlock runs the stations watching tags and branches
+// (line run --refs) when tags or branches other than line's own change,
+// e.g. after git tag or git fetch, which fire no other hook.
+func referenceTransactionBlock() string {
+	return fmt.Sprintf(`%s
+if [ "$1" = committed ] && grep -E ' refs/(tags|heads)/' | grep -qv ' refs/heads/line/'; then
+  line run --refs >/dev/null 2>&1 &
+fi
+%s`, markers.Start, markers.End)
+}
+
 // Install installs or updates the assembly-line hooks in the given git repo.
 func Install(repoDir string) error {
 	hooksDir := filepath.Join(repoDir, ".git", "hooks")
@@ -32,24 +77,55 @@ func Install(repoDir string) error {
 	if err := installHook(hooksDir, "pre-commit", preCommitBlock()); err != nil {
 		return err
 	}
+	if err := installHook(hooksDir, "commit-msg", commitMsgBlock()); err != nil {
+		return err
+	}
 	if err := installHook(hooksDir, "post-commit", postCommitBlock()); err != nil {
 		return err
 	}
+	if err := installHook(hooksDir, "post-merge", postMergeBlock()); err != nil {
+		return err
+	}
+	if err := installHook(hooksDir, "post-rewrite", postRewriteBlock()); err != nil {
+		return err
+	}
+	if err := installHook(hooksDir, "reference-transaction", referenceTransactionBlock()); err != nil {
+		return err
+	}
 	return nil
 }
 
-// Remove removes assembly-line blocks from pre-commit and post-commit hooks.
+// InstallPrePush installs or updates the assembly-line pre-push hook, which
+// runs the push gates. Only line init --pre-push installs it.
+func InstallPrePush(repoDir string) error {
+	return installHook(filepath.Join(repoDir, ".git", "hooks"), "pre-push", prePushBlock())
+}
+
+// Remove removes assembly-line blocks from the pre-commit, commit-msg,
+// pre-push, post-commit, post-merge, post-rewrite and reference-transaction
+// hooks.
 func Remove(repoDir string) error {
 	hooksDir := filepath.Join(repoDir, ".git", "hooks")
-	if err := removeHook(hooksDir, "pre-commit"); err != nil {
-		return err
-	}
-	if err := removeHook(hooksDir, "post-commit"); err != nil {
-		return err
+	for _, name := range []string{"pre-commit", "commit-msg", "pre-push", "post-commit", "post-merge", "post-rewrite", "reference-transaction"} {
+		if err := removeHook(hooksDir, name); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// Installed reports whether the named hook holds an assembly-line block and
+// is executable, so git runs it.
+func Installed(repoDir, name string) bool {
+	path := filepath.Join(repoDir, ".git", "hooks", name)
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&0o111 == 0 {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), markers.Start)
+}
+
 func removeHook(hooksDir, name string) error {
 	path := filepath.Join(hooksDir, name)
 	if err := markers.RemoveFromFile(path, shebang+"\n", 0o755); err != nil {
@@ -3,44 +3,82 @@ package ignore
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/pathnorm"
 	gitignore "github.com/sabhiram/go-gitignore"
 )
 
-const ignoreFile = ".lineignore"
+const (
+	ignoreFile = ".lineignore"
+	onlyFile   = ".lineonly"
+)
 
-// Matcher checks files against .lineignore patterns.
+// Matcher checks files against .lineignore patterns and, when there is a
+// .lineonly, the include-only patterns it lists.
 type Matcher struct {
-	gi *gitignore.GitIgnore
+	gi         *gitignore.GitIgnore
+	only       *gitignore.GitIgnore
+	ignoreCase bool
 }
 
-// Load loads .lineignore from the given directory.
-// Returns a Matcher that matches nothing if no .lineignore exists.
+// Load loads .lineignore and .lineonly from the given directory.
+// Returns a Matcher that matches nothing if neither exists.
+// On a case-insensitive filesystem (core.ignoreCase) patterns match paths
+// regardless of case, as git's own .gitignore matching does there.
 func Load(dir string) (*Matcher, error) {
-	path := filepath.Join(dir, ignoreFile)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return &Matcher{}, nil
+	m := &Matcher{ignoreCase: git.IgnoreCase(dir)}
+	var err error
+	if m.gi, err = m.compile(filepath.Join(dir, ignoreFile)); err != nil {
+		return nil, err
+	}
+	if m.only, err = m.compile(filepath.Join(dir, onlyFile)); err != nil {
+		return nil, err
 	}
+	return m, nil
+}
 
-	gi, err := gitignore.CompileIgnoreFile(path)
+// compile compiles the gitignore-syntax patterns in path, or returns nil
+// if it does not exist.
+func (m *Matcher) compile(path string) (*gitignore.GitIgnore, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &Matcher{gi: gi}, nil
+	lines := strings.Split(string(data), "\n")
+	if m.ignoreCase {
+		for i, l := range lines {
+			lines[i] = strings.ToLower(l)
+		}
+	}
+	return gitignore.CompileIgnoreLines(lines...), nil
 }
 
-// AllIgnored returns true if all given file paths match the ignore patterns.
+// AllIgnored returns true if all given file paths match the ignore patterns
+// or, with a .lineonly, none of its patterns.
 func (m *Matcher) AllIgnored(files []string) bool {
-	if m.gi == nil {
+	if m.gi == nil && m.only == nil {
 		return false
 	}
 	if len(files) == 0 {
 		return false
 	}
 	for _, f := range files {
-		if !m.gi.MatchesPath(f) {
+		if !m.ignored(pathnorm.Key(f, m.ignoreCase)) {
 			return false
 		}
 	}
 	return true
 }
+
+// ignored reports whether the normalized path f does not trigger the line.
+func (m *Matcher) ignored(f string) bool {
+	if m.only != nil && !m.only.MatchesPath(f) {
+		return true
+	}
+	return m.gi != nil && m.gi.MatchesPath(f)
+}
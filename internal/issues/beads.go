@@ -0,0 +1,143 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Beads is the provider for a beads (bd) issue store committed to the
+// repository: "beads", or "beads:<path>" for a store elsewhere.
+const Beads = "beads"
+
+// DefaultBeadsPath is where bd keeps its JSONL export.
+const DefaultBeadsPath = ".beads/issues.jsonl"
+
+// BeadsPath returns the store a beads spec names, and whether spec is one.
+func BeadsPath(spec string) (string, bool) {
+	name, arg, _ := strings.Cut(spec, ":")
+	if name != Beads {
+		return "", false
+	}
+	if arg == "" {
+		arg = DefaultBeadsPath
+	}
+	return arg, true
+}
+
+// Item is an open item of an in-repo store.
+type Item struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Design      string `json:"design"`
+	Notes       string `json:"notes"`
+	Status      string `json:"status"`
+}
+
+// RelevantItems returns the items of a beads store (its JSONL contents)
+// that are not closed and mention one of files, by path or by file name.
+func RelevantItems(store []byte, files []string) []Item {
+	var items []Item
+	for _, line := range bytes.Split(store, []byte("\n")) {
+		var it Item
+		if json.Unmarshal(line, &it) != nil || it.ID == "" || it.Status == "closed" || it.Status == "tombstone" {
+			continue
+		}
+		text := strings.Join([]string{it.Title, it.Description, it.Design, it.Notes}, "\n")
+		if slices.ContainsFunc(files, func(f string) bool { return mentions(text, f) }) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// mentions reports whether text names file by path, or by a file name with
+// an extension (bare names like "Makefile" match too much prose).
+func mentions(text, file string) bool {
+	if strings.Contains(text, file) {
+		return true
+	}
+	base := path.Base(file)
+	return strings.Contains(base, ".") && strings.Contains(text, base)
+}
+
+// Result is what an agent writes to ResultFile to close or annotate items.
+type Result struct {
+	Close []struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	} `json:"close"`
+	Annotate []struct {
+		ID   string `json:"id"`
+		Note string `json:"note"`
+	} `json:"annotate"`
+}
+
+// ResultFile is where, relative to its worktree, an agent writes a Result.
+const ResultFile = ".line-result.json"
+
+// Apply closes and annotates the items of a beads store as r says, at now.
+// Only the lines of changed items are rewritten. Returns the new store and
+// the IDs r names that are not in it.
+func Apply(store []byte, r Result, now time.Time) ([]byte, []string, error) {
+	lines := bytes.Split(store, []byte("\n"))
+	index := map[string]int{}
+	for i, line := range lines {
+		var it Item
+		if json.Unmarshal(line, &it) == nil && it.ID != "" {
+			index[it.ID] = i
+		}
+	}
+
+	var missing []string
+	edit := func(id string, change func(fields map[string]any)) error {
+		i, ok := index[id]
+		if !ok {
+			missing = append(missing, id)
+			return nil
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(lines[i], &fields); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		change(fields)
+		fields["updated_at"] = now.Format(time.RFC3339Nano)
+		line, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		lines[i] = line
+		return nil
+	}
+
+	for _, c := range r.Close {
+		err := edit(c.ID, func(fields map[string]any) {
+			fields["status"] = "closed"
+			fields["closed_at"] = now.Format(time.RFC3339Nano)
+			if c.Reason != "" {
+				fields["close_reason"] = c.Reason
+			}
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, a := range r.Annotate {
+		err := edit(a.ID, func(fields map[string]any) {
+			notes, _ := fields["notes"].(string)
+			if notes != "" {
+				notes += "\n\n"
+			}
+			fields["notes"] = notes + a.Note
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return bytes.Join(lines, []byte("\n")), missing, nil
+}
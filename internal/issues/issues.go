@@ -0,0 +1,190 @@
+// Package issues fetches the issue tracker tickets that commit messages
+// refer to, so station agents see the intent behind a change and not just
+// its diff.
+package issues
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Issue is a ticket referenced by a commit message.
+type Issue struct {
+	Key   string // as referenced, e.g. PROJ-12 or #34
+	Title string
+	Body  string
+}
+
+// Provider finds references to its tracker's issues in text and fetches
+// them.
+type Provider interface {
+	Refs(text string) []string
+	Fetch(ref string) (Issue, error)
+}
+
+// Provider names for stations[].context, e.g. "jira:PROJ" or
+// "github-issues".
+const (
+	Jira         = "jira"
+	GitHubIssues = "github-issues"
+)
+
+// client bounds how long a slow tracker can hold up a station.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+var (
+	jiraProject = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+	githubRepo  = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+	githubRef   = regexp.MustCompile(`(?:^|[\s(])#(\d+)\b`)
+	// githubRemote matches the owner/repo of a GitHub remote URL, over
+	// HTTPS or SSH.
+	githubRemote = regexp.MustCompile(`github\.com[:/]([\w.-]+/[\w.-]+?)(?:\.git)?/?$`)
+)
+
+// CheckSpec reports whether spec names a known provider: "jira:<PROJECT>",
+// "github-issues", "github-issues:<owner>/<repo>", "beads" or
+// "beads:<path>".
+func CheckSpec(spec string) error {
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case Beads:
+		if p, _ := BeadsPath(spec); !filepath.IsLocal(p) {
+			return fmt.Errorf("%q: %q must be a relative path inside the repository", spec, p)
+		}
+	case Jira:
+		if !jiraProject.MatchString(arg) {
+			return fmt.Errorf("%q: jira needs a project key, e.g. jira:PROJ", spec)
+		}
+	case GitHubIssues:
+		if arg != "" && !githubRepo.MatchString(arg) {
+			return fmt.Errorf("%q: repository must be <owner>/<repo>", spec)
+		}
+	default:
+		return fmt.Errorf("%q is not one of jira:<PROJECT>, github-issues[:<owner>/<repo>], beads[:<path>]", spec)
+	}
+	return nil
+}
+
+// New returns the provider for a tracker spec; beads stores are read with
+// RelevantItems instead. remote is the repository's origin URL,
+// from which github-issues takes the repository when spec does not name one.
+func New(spec, remote string) (Provider, error) {
+	if err := CheckSpec(spec); err != nil {
+		return nil, err
+	}
+	name, arg, _ := strings.Cut(spec, ":")
+	if name == Jira {
+		base := strings.TrimRight(os.Getenv("JIRA_URL"), "/")
+		if base == "" {
+			return nil, fmt.Errorf("%s: JIRA_URL is not set", spec)
+		}
+		return jira{project: arg, base: base}, nil
+	}
+	if arg == "" {
+		m := githubRemote.FindStringSubmatch(remote)
+		if m == nil {
+			return nil, fmt.Errorf("%s: origin is not a GitHub repository; use github-issues:<owner>/<repo>", spec)
+		}
+		arg = m[1]
+	}
+	base := strings.TrimRight(os.Getenv("GITHUB_API_URL"), "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	return github{repo: arg, base: base}, nil
+}
+
+// jira reads issues of one project through the Jira REST API at JIRA_URL,
+// authenticating as JIRA_USER with JIRA_API_TOKEN, or with JIRA_API_TOKEN
+// alone as a personal access token.
+type jira struct {
+	project string
+	base    string
+}
+
+func (j jira) Refs(text string) []string {
+	re := regexp.MustCompile(`\b` + j.project + `-\d+\b`)
+	return re.FindAllString(text, -1)
+}
+
+func (j jira) Fetch(ref string) (Issue, error) {
+	req, err := http.NewRequest("GET", j.base+"/rest/api/2/issue/"+url.PathEscape(ref)+"?fields=summary,description", nil)
+	if err != nil {
+		return Issue{}, err
+	}
+	token := os.Getenv("JIRA_API_TOKEN")
+	if user := os.Getenv("JIRA_USER"); user != "" {
+		req.SetBasicAuth(user, token)
+	} else if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	var resp struct {
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+		} `json:"fields"`
+	}
+	if err := get(req, &resp); err != nil {
+		return Issue{}, fmt.Errorf("jira %s: %w", ref, err)
+	}
+	return Issue{Key: ref, Title: resp.Fields.Summary, Body: resp.Fields.Description}, nil
+}
+
+// github reads issues (and pull requests) of one repository through the
+// GitHub REST API at GITHUB_API_URL, authenticating with GITHUB_TOKEN.
+type github struct {
+	repo string
+	base string
+}
+
+func (g github) Refs(text string) []string {
+	var refs []string
+	for _, m := range githubRef.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, "#"+m[1])
+	}
+	return refs
+}
+
+func (g github) Fetch(ref string) (Issue, error) {
+	req, err := http.NewRequest("GET", g.base+"/repos/"+g.repo+"/issues/"+strings.TrimPrefix(ref, "#"), nil)
+	if err != nil {
+		return Issue{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	var resp struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := get(req, &resp); err != nil {
+		return Issue{}, fmt.Errorf("github %s%s: %w", g.repo, ref, err)
+	}
+	return Issue{Key: ref, Title: resp.Title, Body: resp.Body}, nil
+}
+
+func get(req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may carry credentials; report the failure without it.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("tracker returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+)
+
+// smtpTimeout bounds a whole SMTP exchange, so an unreachable mail server
+// cannot hold up the line.
+const smtpTimeout = 30 * time.Second
+
+// Mail sends a plain-text email through the configured SMTP server. It
+// upgrades to TLS when the server offers STARTTLS and authenticates when a
+// username is set.
+func Mail(e *config.Email, subject, body string) error {
+	addr := os.ExpandEnv(e.SMTP)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("email: smtp %q: %w", e.SMTP, err)
+	}
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return fmt.Errorf("email: from %q: %w", e.From, err)
+	}
+	var to []string
+	for _, rcpt := range e.To {
+		a, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return fmt.Errorf("email: to %q: %w", rcpt, err)
+		}
+		to = append(to, a.Address)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("email: %w", err)
+	}
+	defer c.Close()
+
+	if err := send(c, host, e, from.Address, to, message(from, e.To, subject, body)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return c.Quit()
+}
+
+// send runs the SMTP transaction on an open client.
+func send(c *smtp.Client, host string, e *config.Email, from string, to []string, msg []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if user := os.ExpandEnv(e.Username); user != "" {
+		if err := c.Auth(smtp.PlainAuth("", user, os.ExpandEnv(e.Password), host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// message formats the headers and body, with CRLF line endings.
+func message(from *mail.Address, to []string, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.TrimRight(body, "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
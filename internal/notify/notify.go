@@ -0,0 +1,143 @@
+// Package notify posts messages to Slack and Microsoft Teams incoming
+// webhooks.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+)
+
+// DefaultTemplate formats a station failure when the webhook sets no
+// template.
+const DefaultTemplate = "line: station {{.Station}} failed on {{.Commit}}: {{.Result}}"
+
+// Failure is what a failure message template can refer to.
+type Failure struct {
+	Station string // station name
+	Branch  string // station branch, e.g. line/stn/review
+	Commit  string // short hash of the watched-branch commit
+	Result  string // the error that stopped the station
+}
+
+// client bounds how long a slow webhook can hold up the line.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// target is a configured webhook and how to shape its payload.
+type target struct {
+	name    string
+	webhook *config.Webhook
+	payload func(markdown string) any
+}
+
+func targets(n *config.Notifications) []target {
+	if n == nil {
+		return nil
+	}
+	var ts []target
+	if n.Slack != nil {
+		ts = append(ts, target{"slack", n.Slack, slackPayload})
+	}
+	if n.Teams != nil {
+		ts = append(ts, target{"teams", n.Teams, teamsPayload})
+	}
+	return ts
+}
+
+// Names returns the configured notifiers, e.g. ["slack", "teams"].
+func Names(n *config.Notifications) []string {
+	var names []string
+	for _, t := range targets(n) {
+		names = append(names, t.name)
+	}
+	return names
+}
+
+// Failed posts a station failure to every configured webhook, each with its
+// own template.
+func Failed(n *config.Notifications, f Failure) error {
+	var errs []error
+	for _, t := range targets(n) {
+		text := t.webhook.Template
+		if text == "" {
+			text = DefaultTemplate
+		}
+		tmpl, err := template.New(t.name).Parse(text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: template: %w", t.name, err))
+			continue
+		}
+		var msg strings.Builder
+		if err := tmpl.Execute(&msg, f); err != nil {
+			errs = append(errs, fmt.Errorf("%s: template: %w", t.name, err))
+			continue
+		}
+		errs = append(errs, post(t, msg.String()))
+	}
+	return errors.Join(errs...)
+}
+
+// Post sends a Markdown message to every configured webhook.
+func Post(n *config.Notifications, markdown string) error {
+	var errs []error
+	for _, t := range targets(n) {
+		errs = append(errs, post(t, markdown))
+	}
+	return errors.Join(errs...)
+}
+
+func post(t target, markdown string) error {
+	endpoint := os.ExpandEnv(t.webhook.URL)
+	if endpoint == "" {
+		return fmt.Errorf("%s: webhook_url %q is empty", t.name, t.webhook.URL)
+	}
+	body, err := json.Marshal(t.payload(markdown))
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The URL is a secret; report the failure without it.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("%s: posting to webhook: %w", t.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: webhook returned %s", t.name, resp.Status)
+	}
+	return nil
+}
+
+// slackPayload converts Markdown bold (**x**) to Slack's mrkdwn (*x*).
+func slackPayload(markdown string) any {
+	return map[string]string{"text": strings.ReplaceAll(markdown, "**", "*")}
+}
+
+// teamsPayload wraps the message in an Adaptive Card, the format Teams
+// workflow webhooks accept. TextBlocks render Markdown.
+func teamsPayload(markdown string) any {
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    []map[string]any{{"type": "TextBlock", "text": markdown, "wrap": true}},
+			},
+		}},
+	}
+}
@@ -0,0 +1,33 @@
+// Package pathnorm normalizes repository paths before line compares them:
+// to forward slashes (Windows hands out backslashes), without redundant
+// elements, and lower-cased on a case-insensitive filesystem, where git
+// sets core.ignoreCase and Docs/ and docs/ are the same directory.
+package pathnorm
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Clean returns p slash-separated and without redundant elements or a
+// leading "./"; "." for the repository root.
+func Clean(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// Key returns p cleaned, and folded to lower case when ignoreCase is set,
+// for comparing it with other keys.
+func Key(p string, ignoreCase bool) string {
+	p = Clean(p)
+	if ignoreCase {
+		p = strings.ToLower(p)
+	}
+	return p
+}
+
+// Under reports whether p is dir or inside it.
+func Under(p, dir string, ignoreCase bool) bool {
+	p, dir = Key(p, ignoreCase), Key(dir, ignoreCase)
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,94 @@
+// Package paths decides where line keeps its runtime files outside the
+// repository: disposable worktrees under $XDG_CACHE_HOME and station logs
+// under $XDG_STATE_HOME, each namespaced per repository.
+package paths
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Namespace returns the per-repo directory name used under the XDG base
+// directories: the repo's base name plus the first 8 hex characters of the
+// sha256 of its canonical absolute path, e.g. "myapp-1a2b3c4d".
+func Namespace(repoDir string) (string, error) {
+	abs, err := filepath.Abs(repoDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving repo path: %w", err)
+	}
+	// Resolve symlinks to get a canonical path (e.g. /var -> /private/var on macOS)
+	abs, err = filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("resolving symlinks: %w", err)
+	}
+	h := sha256.Sum256([]byte(abs))
+	return filepath.Base(abs) + "-" + hex.EncodeToString(h[:])[:8], nil
+}
+
+// xdgDir returns $env, or ~/<fallback> when it is unset or not absolute (as
+// the XDG spec requires), or the system temp dir when there is no home.
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, fallback)
+	}
+	return os.TempDir()
+}
+
+// Cache returns the repo's cache directory: $XDG_CACHE_HOME/line/<namespace>.
+func Cache(repoDir string) (string, error) {
+	ns, err := Namespace(repoDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), "line", ns), nil
+}
+
+// Worktrees returns the directory holding the repo's ephemeral station
+// worktrees. line run empties it at the start and end of every run.
+func Worktrees(repoDir string) (string, error) {
+	cache, err := Cache(repoDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "worktrees"), nil
+}
+
+// Artifacts returns the directory where a station's cached build
+// directories (stations[].cache) are kept between runs.
+func Artifacts(repoDir, station string) (string, error) {
+	cache, err := Cache(repoDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "artifacts", station), nil
+}
+
+// TempDir creates a new directory for a one-off worktree (line resolve, the
+// landing gates) in the repo's cache directory, outside Worktrees so a
+// concurrent line run does not remove it. The caller removes it.
+func TempDir(repoDir, pattern string) (string, error) {
+	cache, err := Cache(repoDir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(cache, 0o755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(cache, pattern)
+}
+
+// Logs returns the directory holding the repo's station logs:
+// $XDG_STATE_HOME/line/<namespace>/logs.
+func Logs(repoDir string) (string, error) {
+	ns, err := Namespace(repoDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state")), "line", ns, "logs"), nil
+}
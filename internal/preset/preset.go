@@ -0,0 +1,66 @@
+// Package preset holds the built-in line.yaml presets of line init
+// --preset: curated stations with tuned prompts for common stacks.
+package preset
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+)
+
+//go:embed presets
+var presetsFS embed.FS
+
+// Names returns the built-in presets, e.g. ["go-backend", "ts-frontend"].
+func Names() []string {
+	entries, _ := fs.ReadDir(presetsFS, "presets")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	return names
+}
+
+// Render returns the preset's line.yaml, watching the given branch.
+func Render(name, watches string) ([]byte, error) {
+	data, err := presetsFS.ReadFile(path.Join("presets", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	tmpl, err := template.New(name).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Watches string }{watches}); err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes the preset's config to configPath, refusing to replace an
+// existing config.
+func Write(configPath, name, watches string) error {
+	data, err := Render(name, watches)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists; remove it to start from the %s preset", configPath, name)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -1,17 +1,28 @@
 package rebase
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/gate"
 	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/paths"
 )
 
+// gateOverrideTrailer is the commit trailer recording why a rebase landed
+// despite failing gates.
+const gateOverrideTrailer = "Gate-Override"
+
 // Options controls rebase behavior.
 type Options struct {
 	LeaveConflicts bool
+	// Force lands the terminal branch even when gates fail against it.
+	// Reason is recorded in a Gate-Override trailer on the watched branch.
+	Force  bool
+	Reason string
 }
 
 // Result describes the outcome of a rebase operation.
@@ -23,6 +34,9 @@ type Result struct {
 	ConflictFiles []string
 	Stashed       bool
 	StashConflict bool
+	GateFailed    bool
+	GateError     error
+	GateOutput    string
 	Error         error
 }
 
@@ -67,6 +81,16 @@ func Run(dir string, cfg *config.Config, opts Options) Result {
 		return Result{NothingToDo: true}
 	}
 
+	// Interlock: the terminal branch must pass the configured gates before
+	// it is allowed onto the watched branch.
+	gateOutput, gateErr, err := runGates(dir, cfg, terminalBranch)
+	if err != nil {
+		return Result{Error: err}
+	}
+	if gateErr != nil && !opts.Force {
+		return Result{GateFailed: true, GateError: gateErr, GateOutput: gateOutput}
+	}
+
 	// Record pre-rebase HEAD so we can diff afterwards.
 	oldHead, err := git.Run(dir, "rev-parse", "HEAD")
 	if err != nil {
@@ -84,8 +108,11 @@ func Run(dir string, cfg *config.Config, opts Options) Result {
 		}
 	}
 
-	// Rebase onto terminal station.
-	if err := git.Rebase(dir, terminalBranch); err != nil {
+	// Rebase onto terminal station. The landed commits have already been
+	// through the line, so keep the post-rewrite hook from re-running it.
+	os.Setenv("LINE_RUNNING", "1")
+	defer os.Unsetenv("LINE_RUNNING")
+	if err := git.Rebase(dir, terminalBranch, git.CommitOptions{}); err != nil {
 		if opts.LeaveConflicts {
 			// Leave git in mid-rebase state with conflict markers.
 			files, _ := git.ConflictedFiles(dir)
@@ -103,6 +130,17 @@ func Run(dir string, cfg *config.Config, opts Options) Result {
 		return Result{Conflict: true}
 	}
 
+	// Record the override before restoring WIP so the stash cannot leak
+	// into the trailer commit.
+	if gateErr != nil {
+		if err := recordOverride(dir, terminalBranch, opts.Reason, gateErr); err != nil {
+			if dirty {
+				_ = git.StashPop(dir)
+			}
+			return Result{Error: err}
+		}
+	}
+
 	// Restore stash if we created one.
 	if dirty {
 		if err := git.StashPop(dir); err != nil {
@@ -119,9 +157,53 @@ func Run(dir string, cfg *config.Config, opts Options) Result {
 	return Result{
 		Rebased:      true,
 		ChangedFiles: files,
+		GateFailed:   gateErr != nil,
+		GateError:    gateErr,
+		GateOutput:   gateOutput,
 	}
 }
 
+// runGates runs the configured gates against the terminal branch tree in a
+// throwaway detached worktree. It returns the combined gate output and the
+// gate failure, if any; err reports a problem setting up the worktree.
+func runGates(dir string, cfg *config.Config, terminalBranch string) (output string, gateErr, err error) {
+	gates := cfg.GatesFor(config.GateHookPreCommit)
+	if len(gates) == 0 {
+		return "", nil, nil
+	}
+	tmp, err := paths.TempDir(dir, "land-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating gate worktree dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	wtPath := filepath.Join(tmp, "tree")
+	if err := git.AddDetachedWorktree(dir, wtPath, terminalBranch); err != nil {
+		return "", nil, fmt.Errorf("adding gate worktree: %w", err)
+	}
+	defer func() { _ = git.RemoveWorktree(dir, wtPath) }()
+	if wt := cfg.Settings.Worktree; wt != nil {
+		if _, err := git.ProvisionWorktree(dir, wtPath, wt.Copy, wt.Symlink); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	gateErr = gate.RunGatesTo(gate.FromConfig(gates), wtPath, &out, &out)
+	return out.String(), gateErr, nil
+}
+
+// recordOverride commits an empty marker commit on the watched branch whose
+// trailer records the justification for landing past failing gates. The
+// commit skips hooks (the gates would block it) and carries a skip marker so
+// it does not retrigger the line.
+func recordOverride(dir, terminalBranch, reason string, gateErr error) error {
+	msg := fmt.Sprintf("assembly-line: landed %s despite failing gates [skip line]\n\n%v\n\n%s: %s",
+		terminalBranch, gateErr, gateOverrideTrailer, reason)
+	_, err := git.Run(dir, "commit", "--allow-empty", "--no-verify", "-m", msg)
+	return err
+}
+
 // rebaseInProgress returns true if .git/rebase-merge or .git/rebase-apply exists.
 func rebaseInProgress(dir string) bool {
 	for _, name := range []string{"rebase-merge", "rebase-apply"} {
@@ -1,11 +1,15 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/re-cinq/assembly-line/internal/git"
@@ -18,6 +22,48 @@ const agentDoneMarker = ".line-agent-done"
 
 const preamble = "IMPORTANT: Do NOT commit any changes. Do NOT run git commit. Make file changes only. The system will handle committing."
 
+// agentTailLines is how many lines of a failed agent's output are kept
+// (RUN-41).
+const agentTailLines = 50
+
+// AgentExitError is the error of an agent that exited unsuccessfully: how it
+// exited, and the end of its output (RUN-41).
+type AgentExitError struct {
+	state.AgentExit
+}
+
+// Error reads like exec's, e.g. "exit status 1", naming the signal that
+// killed the agent, e.g. "exit status 137 (killed)".
+func (e *AgentExitError) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("exit status %d (%s)", e.Code, e.Signal)
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// agentStalledError is the error of an agent killed for writing no output
+// for settings.stall_timeout (RUN-43).
+type agentStalledError struct {
+	timeout time.Duration
+}
+
+func (e *agentStalledError) Error() string {
+	return fmt.Sprintf("%s: no output for %s", state.FailedStalled, shortDuration(e.timeout))
+}
+
+// shortDuration formats d without trailing zero units, e.g. 20m rather than
+// 20m0s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
 // agentProcess represents a running agent subprocess.
 type agentProcess struct {
 	cmd          *exec.Cmd // nil when using tmux path
@@ -26,28 +72,38 @@ type agentProcess struct {
 	logPath      string    // path to pipe-pane log file
 	stationName  string
 	repoDir      string
-	worktreeDir  string    // worktree path (for done marker detection)
-	isClaudeCode bool      // true when the agent command is Claude Code
+	worktreeDir  string      // worktree path (for done marker detection)
+	isClaudeCode bool        // true when the agent command is Claude Code
+	logOffset    int64       // size of the log file before the agent started
+	tail         *outputTail // end of the output of a direct subprocess
 }
 
 // startAgent launches an agent subprocess with the given command, args, and prompt.
 // If tmux is available, the agent runs inside a tmux session for observability.
 // Otherwise it falls back to direct subprocess execution.
 // RUN-12: The preamble is prepended to the prompt.
-func startAgent(dir, command string, args []string, prompt, stationName, repoDir string) (*agentProcess, error) {
+func startAgent(dir, command string, args, env []string, prompt, stationName, repoDir string, stripANSI bool, ev EventSink) (*agentProcess, error) {
 	if tmux.Available() && stationName != "" {
-		agent, err := startAgentTmux(dir, command, args, prompt, stationName, repoDir)
+		agent, err := startAgentTmux(dir, command, args, env, prompt, stationName, repoDir, stripANSI)
 		if err == nil {
 			return agent, nil
 		}
 		// tmux setup failed — fall back to direct execution
-		fmt.Fprintf(os.Stderr, "assembly-line: tmux setup failed, falling back to direct: %v\n", err)
+		emitf(ev, EventWarning, "", "tmux setup failed, falling back to direct: %v", err)
 	}
-	return startAgentDirect(dir, command, args, prompt)
+	tail := &outputTail{}
+	agent, err := startAgentDirect(dir, command, args, env, prompt, io.MultiWriter(outputWriter{ev: ev, station: stationName}, tail))
+	if err != nil {
+		return nil, err
+	}
+	agent.tail = tail
+	return agent, nil
 }
 
-// startAgentDirect launches an agent as a direct subprocess (original behavior).
-func startAgentDirect(dir, command string, args []string, prompt string) (*agentProcess, error) {
+// startAgentDirect launches an agent as a direct subprocess (original
+// behavior), with env added to its environment, sending its stdout and
+// stderr to output.
+func startAgentDirect(dir, command string, args, env []string, prompt string, output io.Writer) (*agentProcess, error) {
 	fullPrompt := preamble + "\n\n" + prompt
 	fullArgs := make([]string, len(args))
 	copy(fullArgs, args)
@@ -55,14 +111,17 @@ func startAgentDirect(dir, command string, args []string, prompt string) (*agent
 
 	cmd := exec.Command(command, fullArgs...)
 	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// Output is copied through a pipe; don't let background processes the
+	// agent left holding it open keep the station waiting.
+	cmd.WaitDelay = time.Second
 
 	// Build a clean environment for the agent:
 	// - Remove CLAUDECODE so Claude Code can launch as a fresh session
 	// - Set LINE_RUNNING=1 to prevent retriggering
-	env := git.CleanEnv(os.Environ(), "CLAUDECODE")
-	cmd.Env = append(env, "LINE_RUNNING=1")
+	// - Describe the run (RUN-46)
+	cmd.Env = append(append(git.CleanEnv(os.Environ(), "CLAUDECODE"), "LINE_RUNNING=1"), env...)
 
 	// Set process group so we can kill the whole group
 	setProcGroup(cmd)
@@ -81,7 +140,8 @@ func isClaudeCommand(command string) bool {
 }
 
 // startAgentTmux launches an agent inside a tmux session for observability.
-func startAgentTmux(dir, command string, args []string, prompt, stationName, repoDir string) (*agentProcess, error) {
+// With stripANSI its output reaches the station log through line log-filter.
+func startAgentTmux(dir, command string, args, env []string, prompt, stationName, repoDir string, stripANSI bool) (*agentProcess, error) {
 	sessionName := tmux.SessionName(repoDir, stationName)
 	claudeMode := isClaudeCommand(command)
 
@@ -117,8 +177,20 @@ func startAgentTmux(dir, command string, args []string, prompt, stationName, rep
 
 	// Prepend environment setup to the shell command
 	envPrefix := "export LINE_RUNNING=1; unset CLAUDECODE; "
+	for _, kv := range env {
+		envPrefix += "export " + shellescape(kv) + "; "
+	}
 	shellCmd = envPrefix + shellCmd
 
+	// Record the agent's exit status: tmux can mark the pane dead without
+	// ever reporting it. The shell's own messages (such as "Killed") are
+	// kept out of the agent's output.
+	statusPath, err := state.StationExitStatusPath(repoDir, stationName)
+	if err != nil {
+		return nil, fmt.Errorf("preparing agent exit status: %w", err)
+	}
+	shellCmd = "exec 3>&2 2>/dev/null; " + shellCmd + " 2>&3 3>&-; status=$?; echo $status > " + shellescape(statusPath) + "; exit $status"
+
 	// Create the tmux session (remain-on-exit is set atomically by NewSession)
 	if err := tmux.NewSession(sessionName, dir, shellCmd); err != nil {
 		return nil, fmt.Errorf("creating tmux session: %w", err)
@@ -134,7 +206,21 @@ func startAgentTmux(dir, command string, args []string, prompt, stationName, rep
 
 	// Set up pipe-pane to stream output to a log file
 	logPath := state.StationLogPath(repoDir, stationName)
-	if err := tmux.PipePane(sessionName, "cat >> "+shellescape(logPath)); err != nil {
+	_ = state.EnsureStationLogDir(repoDir, stationName)
+	var logOffset int64
+	if info, err := os.Stat(logPath); err == nil {
+		logOffset = info.Size()
+	}
+	pipeCmd := "cat >> " + shellescape(logPath)
+	if stripANSI {
+		self, err := os.Executable()
+		if err != nil {
+			_ = tmux.KillSession(sessionName)
+			return nil, fmt.Errorf("finding line for log-filter: %w", err)
+		}
+		pipeCmd = shellescape(self) + " log-filter >> " + shellescape(logPath)
+	}
+	if err := tmux.PipePane(sessionName, pipeCmd); err != nil {
 		_ = tmux.KillSession(sessionName)
 		return nil, fmt.Errorf("setting up pipe-pane: %w", err)
 	}
@@ -154,15 +240,41 @@ func startAgentTmux(dir, command string, args []string, prompt, stationName, rep
 		repoDir:      repoDir,
 		worktreeDir:  dir,
 		isClaudeCode: claudeMode,
+		logOffset:    logOffset,
 	}, nil
 }
 
-// wait waits for the agent to finish.
+// wait waits for the agent to finish. An agent that exits unsuccessfully
+// returns an *AgentExitError.
 func (a *agentProcess) wait() error {
 	if a.tmuxSession != "" {
 		return a.waitTmux()
 	}
-	return a.cmd.Wait()
+	err := a.cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	code := exitErr.ExitCode()
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		code = 128 + int(ws.Signal())
+	}
+	return a.exitError(code)
+}
+
+// exitError describes the agent's unsuccessful exit with code, where codes
+// above 128 mean it was killed by a signal, with the end of its output.
+func (a *agentProcess) exitError(code int) *AgentExitError {
+	exit := state.AgentExit{Code: code}
+	if code > 128 && code < 128+65 {
+		exit.Signal = syscall.Signal(code - 128).String()
+	}
+	if a.tail != nil {
+		exit.Output = lastLines(a.tail.String(), agentTailLines)
+	} else if a.logPath != "" {
+		exit.Output = lastLines(readFrom(a.logPath, a.logOffset), agentTailLines)
+	}
+	return &AgentExitError{exit}
 }
 
 // waitTmux polls the tmux pane until the process exits.
@@ -180,8 +292,12 @@ func (a *agentProcess) waitTmux() error {
 		}
 		if dead {
 			_ = tmux.KillSession(a.tmuxSession)
+			if code, ok := state.ReadStationExitStatus(a.repoDir, a.stationName); ok {
+				exitCode = code
+				_ = state.RemoveStationExitStatus(a.repoDir, a.stationName)
+			}
 			if exitCode != 0 {
-				return fmt.Errorf("exit status %d", exitCode)
+				return a.exitError(exitCode)
 			}
 			return nil
 		}
@@ -219,8 +335,118 @@ func (a *agentProcess) session() string {
 }
 
 // shellescape wraps a string in single quotes for safe shell interpolation.
-// Embedded single quotes are escaped as '\'' (end quote, escaped quote, start quote).
+// Embedded single quotes are escaped as '\” (end quote, escaped quote, start quote).
 func shellescape(s string) string {
 	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
 }
 
+// outputTailBytes bounds how much of an agent's output outputTail keeps.
+const outputTailBytes = 64 << 10
+
+// outputTail keeps the end of what is written to it.
+type outputTail struct {
+	mu    sync.Mutex
+	buf   []byte
+	total int64 // bytes written in all
+}
+
+func (t *outputTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total += int64(len(p))
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > outputTailBytes {
+		t.buf = append([]byte(nil), t.buf[len(t.buf)-outputTailBytes:]...)
+	}
+	return len(p), nil
+}
+
+// String returns what the tail holds.
+func (t *outputTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// outputBytes returns how many bytes of output the agent has written.
+func (a *agentProcess) outputBytes() int64 {
+	if a.tail != nil {
+		a.tail.mu.Lock()
+		defer a.tail.mu.Unlock()
+		return a.tail.total
+	}
+	if a.logPath == "" {
+		return 0
+	}
+	if info, err := os.Stat(a.logPath); err == nil {
+		return info.Size() - a.logOffset
+	}
+	return 0
+}
+
+// heartbeatInterval is how often the runner records a heartbeat of a
+// running agent.
+const heartbeatInterval = 5 * time.Second
+
+// heartbeat records a StationHeartbeat for the agent now and every
+// heartbeatInterval, until the returned function is called (RUN-42). With a
+// positive stallTimeout, an agent that writes no output for that long is
+// killed (RUN-43); stop reports whether it was.
+func heartbeat(repoDir, name string, agent *agentProcess, stallTimeout time.Duration) (stop func() (stalled bool)) {
+	now := time.Now()
+	h := state.StationHeartbeat{At: now, OutputAt: now}
+	_ = state.WriteStationHeartbeat(repoDir, name, h)
+	stalled := false
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if n := agent.outputBytes(); n != h.OutputBytes {
+					h.OutputBytes, h.OutputAt = n, now
+				}
+				h.At = now
+				_ = state.WriteStationHeartbeat(repoDir, name, h)
+				if stallTimeout > 0 && now.Sub(h.OutputAt) >= stallTimeout {
+					stalled = true
+					_ = state.KillProcessGroup(agent.pid())
+				}
+			}
+		}
+	}()
+	return func() bool {
+		close(done)
+		<-stopped
+		_ = state.RemoveStationHeartbeat(repoDir, name)
+		return stalled
+	}
+}
+
+// readFrom returns the contents of the file at path from offset on.
+func readFrom(path string, offset int64) string {
+	data, err := os.ReadFile(path)
+	if err != nil || offset > int64(len(data)) {
+		return ""
+	}
+	return string(data[offset:])
+}
+
+// lastLines returns the last n non-blank lines of s, with the carriage
+// returns of terminal output removed.
+func lastLines(s string, n int) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimRight(l, "\r"); strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
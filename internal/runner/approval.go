@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// errAwaitingApproval stops the line after a station with approval: manual
+// has produced a commit.
+var errAwaitingApproval = errors.New("awaiting approval")
+
+// holdForApproval sets the station's new commit aside for line approve: it
+// is kept under git.ApprovalRefName, the station branch goes back to base and
+// the station is marked awaiting approval. A run that committed nothing
+// needs no approval.
+func holdForApproval(dir, wtPath, name, base string) error {
+	head, err := git.Run(wtPath, "rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("station %s: %w", name, err)
+	}
+	if head == base {
+		return nil
+	}
+	summary, _ := git.ShortStat(wtPath, base, head)
+	if err := git.UpdateRef(dir, git.ApprovalRefName(name), head, ""); err != nil {
+		return fmt.Errorf("station %s: saving commit for approval: %w", name, err)
+	}
+	if err := git.ResetHard(wtPath, base); err != nil {
+		return fmt.Errorf("station %s: %w", name, err)
+	}
+	if err := state.WriteStationApproval(dir, name, state.StationApproval{Commit: head, Base: base, Summary: summary}); err != nil {
+		return err
+	}
+	return errAwaitingApproval
+}
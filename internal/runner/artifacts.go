@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/paths"
+)
+
+// restoreArtifacts moves the station's cached build directories back into
+// its fresh worktree (stations[].cache). Paths the worktree already has,
+// e.g. because they are tracked, are left alone.
+func restoreArtifacts(dir, wtPath string, station config.Station, ev EventSink) {
+	cacheDir, err := paths.Artifacts(dir, station.Name)
+	if err != nil {
+		return
+	}
+	for _, rel := range station.Cache {
+		src := filepath.Join(cacheDir, rel)
+		dst := filepath.Join(wtPath, rel)
+		if _, err := os.Lstat(src); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err == nil {
+			err = os.Rename(src, dst)
+		}
+		if err != nil {
+			emitf(ev, EventWarning, station.Name, "could not restore cached %s: %v", rel, err)
+		}
+	}
+}
+
+// saveArtifacts moves the station's build directories out of the worktree
+// before it is removed, replacing what was cached.
+func saveArtifacts(dir, wtPath string, station config.Station, ev EventSink) {
+	cacheDir, err := paths.Artifacts(dir, station.Name)
+	if err != nil {
+		return
+	}
+	for _, rel := range station.Cache {
+		src := filepath.Join(wtPath, rel)
+		dst := filepath.Join(cacheDir, rel)
+		if _, err := os.Lstat(src); err != nil {
+			continue
+		}
+		_ = os.RemoveAll(dst)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err == nil {
+			err = os.Rename(src, dst)
+		}
+		if err != nil {
+			emitf(ev, EventWarning, station.Name, "could not cache %s: %v", rel, err)
+		}
+	}
+}
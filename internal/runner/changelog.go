@@ -0,0 +1,219 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+)
+
+// semanticCommit is a watched-branch commit as a changelog sees it.
+type semanticCommit struct {
+	hash     string
+	kind     string // Conventional Commits type, lowercased; "" without one
+	scope    string
+	summary  string // the subject without its type and scope
+	breaking bool
+}
+
+// semanticSubject splits a Conventional Commits subject, "feat(api)!: add
+// export", into type, scope, breaking marker and description.
+var semanticSubject = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?(!)?:\s*(.*)$`)
+
+// semanticGroups orders changelog groups; types not listed go under Other.
+var semanticGroups = []struct{ kind, title string }{
+	{"feat", "Features"},
+	{"fix", "Fixes"},
+	{"perf", "Performance"},
+}
+
+// semanticCommits returns the commits in from..to (under root, if set),
+// newest first, leaving out station commits.
+func semanticCommits(dir, from, to, root string) []semanticCommit {
+	rangeArg := to
+	if from != "" {
+		rangeArg = from + ".." + to
+	}
+	format := "--format=%h%x00%s%x00%b%x00%(trailers:key=" + StationTrailer + ",valueonly,separator=%x2C)%x1e"
+	out, err := git.Run(dir, append([]string{"log", "--no-merges", format, rangeArg}, rootPathspec(dir, root)...)...)
+	if err != nil {
+		return nil
+	}
+	var commits []semanticCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x00", 4)
+		if len(fields) < 4 || strings.TrimSpace(fields[3]) != "" {
+			continue
+		}
+		if _, ok := CommitStation(fields[1]); ok {
+			continue
+		}
+		c := semanticCommit{hash: fields[0], summary: fields[1]}
+		if m := semanticSubject.FindStringSubmatch(fields[1]); m != nil {
+			c.kind, c.scope, c.breaking, c.summary = strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+		}
+		if strings.Contains(fields[2], "BREAKING CHANGE:") || strings.Contains(fields[2], "BREAKING-CHANGE:") {
+			c.breaking = true
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// writeSemanticCommits writes commits grouped as a changelog would: breaking
+// changes, then semanticGroups, then the rest.
+func writeSemanticCommits(b *strings.Builder, commits []semanticCommit) {
+	group := func(title string, keep func(semanticCommit) bool) {
+		first := true
+		for _, c := range commits {
+			if !keep(c) {
+				continue
+			}
+			if first {
+				fmt.Fprintf(b, "\n%s:\n", title)
+				first = false
+			}
+			line := c.summary
+			if c.scope != "" {
+				line = c.scope + ": " + line
+			}
+			fmt.Fprintf(b, "- %s (%s)\n", line, c.hash)
+		}
+	}
+	grouped := func(c semanticCommit) bool {
+		return slices.ContainsFunc(semanticGroups, func(g struct{ kind, title string }) bool { return g.kind == c.kind })
+	}
+	group("Breaking changes", func(c semanticCommit) bool { return c.breaking })
+	for _, g := range semanticGroups {
+		group(g.title, func(c semanticCommit) bool { return !c.breaking && c.kind == g.kind })
+	}
+	group("Other", func(c semanticCommit) bool { return !c.breaking && !grouped(c) })
+}
+
+// changelogSection lists the commits a changelog station records.
+func changelogSection(dir string, resolved config.ResolvedStation, from, to string) string {
+	commits := semanticCommits(dir, from, to, resolved.Root)
+	if len(commits) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Commits to record in %s:\n", resolved.Changelog)
+	writeSemanticCommits(&b, commits)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// maxNoteFiles bounds the files listed per station in release notes.
+const maxNoteFiles = 5
+
+// ReleaseNotes collates release notes for the commits in from..to (from is
+// the previous release tag, empty for the first): the entries added to the
+// changelog stations' files, the other commits grouped by Conventional
+// Commits type, and what each station contributed.
+func ReleaseNotes(dir string, cfg *config.Config, version, from, to string) string {
+	var b strings.Builder
+	b.WriteString(version + "\n")
+
+	for _, s := range cfg.Stations {
+		if s.Changelog == "" {
+			continue
+		}
+		if entries := changelogEntries(dir, s.Changelog, from, to); len(entries) > 0 {
+			fmt.Fprintf(&b, "\nChangelog (%s):\n%s\n", s.Changelog, strings.Join(entries, "\n"))
+		}
+	}
+
+	if commits := semanticCommits(dir, from, to, ""); len(commits) > 0 {
+		b.WriteString("\nChanges:\n")
+		writeSemanticCommits(&b, commits)
+	}
+
+	var notes []string
+	for _, s := range cfg.Stations {
+		if note := stationNote(dir, s.Name, from, to); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(&b, "\nStation notes:\n%s\n", strings.Join(notes, "\n"))
+	}
+	return b.String()
+}
+
+// changelogEntries returns the lines added to a changelog in from..to
+// (the whole file without from), minus blank lines, the file's title and
+// the Unreleased heading they were added under.
+func changelogEntries(dir, file, from, to string) []string {
+	var added []string
+	if from == "" {
+		content, err := git.Run(dir, "show", to+":"+file)
+		if err != nil {
+			return nil
+		}
+		added = strings.Split(content, "\n")
+	} else {
+		diff, err := git.Run(dir, "diff", "--unified=0", from, to, "--", file)
+		if err != nil {
+			return nil
+		}
+		for _, line := range strings.Split(diff, "\n") {
+			if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+				added = append(added, line[1:])
+			}
+		}
+	}
+	var entries []string
+	for _, line := range added {
+		heading := strings.HasPrefix(line, "#")
+		if strings.TrimSpace(line) == "" || heading && (strings.HasPrefix(line, "# ") || strings.Contains(strings.ToLower(line), "unreleased")) {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries
+}
+
+// stationNote summarizes the station's commits in from..to, e.g.
+// "- docs: 2 commits, README.md, docs/api.md".
+func stationNote(dir, name, from, to string) string {
+	rangeArg := to
+	if from != "" {
+		rangeArg = from + ".." + to
+	}
+	out, err := git.Run(dir, "log", "--format=%x1e%(trailers:key="+StationTrailer+",valueonly,separator=%x2C)%x00%s", "--name-only", rangeArg)
+	if err != nil {
+		return ""
+	}
+	commits := 0
+	var files []string
+	for _, record := range strings.Split(out, "\x1e") {
+		header, names, _ := strings.Cut(record, "\n")
+		trailer, subject, _ := strings.Cut(header, "\x00")
+		station := strings.TrimSpace(trailer)
+		if station == "" {
+			station, _ = CommitStation(subject)
+		}
+		if station != name {
+			continue
+		}
+		commits++
+		for _, f := range strings.Split(names, "\n") {
+			if f = strings.TrimSpace(f); f != "" && !slices.Contains(files, f) {
+				files = append(files, f)
+			}
+		}
+	}
+	if commits == 0 {
+		return ""
+	}
+	note := fmt.Sprintf("- %s: %s", name, commitCount(fmt.Sprint(commits)))
+	if len(files) > maxNoteFiles {
+		files = append(files[:maxNoteFiles], fmt.Sprintf("%d more", len(files)-maxNoteFiles))
+	}
+	if len(files) > 0 {
+		note += ", " + strings.Join(files, ", ")
+	}
+	return note
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/paths"
 	"github.com/re-cinq/assembly-line/internal/state"
 	"github.com/re-cinq/assembly-line/internal/tmux"
 )
@@ -32,20 +33,39 @@ func Clear(dir string, cfg *config.Config) error {
 	}
 	_ = git.PruneWorktrees(dir)
 
-	// 5. Delete station branches
+	// 5. Delete station branches, any commits held for approval, the changes
+	// of interrupted runs and cached build directories, also those of
+	// stations no longer in the config
+	names := OrphanedStations(dir, cfg)
 	for _, station := range cfg.Stations {
-		_ = git.DeleteBranch(dir, git.StationBranchName(station.Name))
+		names = append(names, station.Name)
+	}
+	for _, name := range names {
+		_ = git.DeleteBranch(dir, git.StationBranchName(name))
+		_ = git.DeleteRef(dir, git.ApprovalRefName(name))
+		_ = git.DeleteRef(dir, git.InterruptedRefName(name))
+		if artifacts, err := paths.Artifacts(dir, name); err == nil {
+			_ = os.RemoveAll(artifacts)
+		}
 	}
 
-	// 6. Remove .line/stations/ directory
+	// 6. Remove .line/stations/ directory and the station logs
 	_ = os.RemoveAll(filepath.Join(dir, ".line", "stations"))
+	_ = state.RemoveStationLogs(dir)
 
-	// 7. Remove .line/run.pid
+	// 7. Remove .line/run.pid and the settling marker
 	_ = state.RemovePID(dir)
+	_ = state.RemoveSettling(dir)
 
 	// 8. Remove .line/rebase-prompted marker
 	_ = state.RemoveRebasePrompted(dir)
 
+	// 9. Remove .line/statusline-cache, the skipped-commit record and the
+	// last cycle, so an unchanged tree runs again (RUN-36)
+	_ = state.RemoveStatuslineCache(dir)
+	_ = state.RemoveSkippedCommits(dir)
+	_ = state.RemoveLastCycle(dir)
+
 	fmt.Println("assembly-line cleared")
 	return nil
 }
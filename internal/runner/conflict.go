@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// maxConflictRounds bounds how many stopped rebase steps the agent is asked
+// to resolve before falling back to a reset.
+const maxConflictRounds = 5
+
+// handleConflict applies settings.on_conflict after a station's rebase onto
+// its predecessor stopped with conflicts in wtPath. A nil return means the
+// worktree is ready for the station's agent; an error blocks the line.
+func handleConflict(dir, wtPath string, cfg *config.Config, resolved config.ResolvedStation, predecessor string, ev EventSink) error {
+	name := resolved.Name
+	switch cfg.Settings.ConflictStrategy() {
+	case config.OnConflictKeep:
+		files, _ := git.ConflictedFiles(wtPath)
+		_ = git.RebaseAbort(wtPath)
+		_ = state.WriteStationConflict(dir, name, files)
+		emitf(ev, EventInfo, name, "rebase conflict with %s, keeping branch for manual resolution", predecessor)
+		return fmt.Errorf("rebase onto %s conflicts in %s", predecessor, strings.Join(files, ", "))
+
+	case config.OnConflictAgent:
+		err := resolveConflictWithAgent(dir, wtPath, resolved, predecessor, CommitOptions(cfg.Settings), ev)
+		if err == nil {
+			emitf(ev, EventInfo, name, "agent resolved rebase conflict with %s", predecessor)
+			return nil
+		}
+		emitf(ev, EventWarning, name, "agent could not resolve rebase conflict (%v)", err)
+	}
+
+	// RUN-6: If rebase fails, reset to predecessor and try again
+	emitf(ev, EventInfo, name, "rebase conflict, resetting to %s", predecessor)
+	_ = git.RebaseAbort(wtPath)
+	if err := git.ResetHard(wtPath, predecessor); err != nil {
+		return fmt.Errorf("station %s: reset failed: %w", name, err)
+	}
+	return nil
+}
+
+// resolveConflictWithAgent runs the station's agent on each stopped rebase
+// step, continuing the rebase with opts once the conflict markers are gone.
+func resolveConflictWithAgent(dir, wtPath string, resolved config.ResolvedStation, predecessor string, opts git.CommitOptions, ev EventSink) error {
+	for round := 0; round < maxConflictRounds; round++ {
+		files, err := git.ConflictedFiles(wtPath)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("rebase stopped without conflicted files")
+		}
+
+		emitf(ev, EventInfo, resolved.Name, "asking agent to resolve conflicts in %s", strings.Join(files, ", "))
+		agentErr, err := runAgent(dir, wtPath, resolved, conflictPrompt(predecessor, files), ev)
+		if err != nil {
+			return err
+		}
+		if agentErr != nil {
+			return fmt.Errorf("agent failed: %w", agentErr)
+		}
+
+		err = git.RebaseContinue(wtPath, opts)
+		if err == nil {
+			return nil
+		}
+		if !git.RebaseInProgress(wtPath) {
+			return err
+		}
+		if remaining, _ := git.ConflictedFiles(wtPath); len(remaining) == 0 {
+			// Still stopped on the same step: markers were left behind.
+			return err
+		}
+	}
+	return fmt.Errorf("still conflicting after %d rounds", maxConflictRounds)
+}
+
+// conflictPrompt asks the agent to resolve the conflict markers left by a
+// stopped rebase.
+func conflictPrompt(predecessor string, files []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rebasing this branch onto %s stopped with merge conflicts in:\n", predecessor)
+	for _, f := range files {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	b.WriteString("\nResolve every conflict by editing these files so that the intent of both sides is kept, and remove all conflict markers (<<<<<<<, =======, >>>>>>>). Do not run any git commands.")
+	return b.String()
+}
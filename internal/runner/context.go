@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// maxContextCommits bounds the commits listed in a station's context.
+const maxContextCommits = 20
+
+// contextAbbrev is the hash length used in contexts, fixed so snapshots do
+// not change as the repository grows.
+const contextAbbrev = 12
+
+// ResolveRange resolves the commits a station reviews: to (default the
+// watched branch head) and from, exclusive (default to's parent, empty for
+// a root commit).
+func ResolveRange(dir, watches, from, to string) (string, string, error) {
+	rev := to
+	if rev == "" {
+		rev = watches
+	}
+	to, err := git.Run(dir, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("resolving %s: %w", rev, err)
+	}
+	if from == "" {
+		from, _ = git.Run(dir, "rev-parse", "--verify", "--quiet", to+"^")
+		return from, to, nil
+	}
+	rev = from
+	if from, err = git.Run(dir, "rev-parse", "--verify", rev+"^{commit}"); err != nil {
+		return "", "", fmt.Errorf("resolving %s: %w", rev, err)
+	}
+	return from, to, nil
+}
+
+// WriteContext writes what a station's agent is given for the commits
+// from..to: its command and root, the commits under review (those touching
+// the root) and the full prompt, preamble and referenced issues included.
+// The output depends only on the config, the commits and their issues, so
+// it can be snapshot-tested.
+// Issues that could not be fetched are left out and reported in the
+// returned error.
+func WriteContext(w io.Writer, dir string, resolved config.ResolvedStation, from, to string) error {
+	fmt.Fprintf(w, "%-10s%s\n", "Station:", resolved.Name)
+	fmt.Fprintf(w, "%-10s%s\n", "Command:", strings.Join(append([]string{resolved.Command}, resolved.Args...), " ")+" <prompt>")
+
+	reviewed := strings.TrimPrefix(ReviewedSummary(dir, state.StationRun{RangeFrom: from, RangeTo: to}), "reviewed ")
+	rangeArg := to
+	if from != "" {
+		rangeArg = from + ".." + to
+	}
+	if resolved.Root != "" {
+		fmt.Fprintf(w, "%-10s%s\n", "Root:", resolved.Root)
+		n, _ := git.Run(dir, append([]string{"rev-list", "--count", rangeArg}, rootPathspec(dir, resolved.Root)...)...)
+		reviewed += fmt.Sprintf(", %s under %s", n, resolved.Root)
+	}
+	fmt.Fprintf(w, "%-10s%s\n", "Reviews:", reviewed)
+	log, _ := git.Run(dir, append([]string{"log", "--format=%h %s", fmt.Sprintf("--abbrev=%d", contextAbbrev),
+		fmt.Sprintf("--max-count=%d", maxContextCommits), rangeArg}, rootPathspec(dir, resolved.Root)...)...)
+	for _, line := range strings.Split(log, "\n") {
+		if line != "" {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	prompt, err := stationPrompt(dir, resolved, from, to, to)
+	fmt.Fprintln(w, "Prompt:")
+	for _, line := range strings.Split(preamble+"\n\n"+prompt, "\n") {
+		if line == "" {
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	return err
+}
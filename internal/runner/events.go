@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// EventKind identifies what an Event reports.
+type EventKind string
+
+const (
+	// EventSkipped: the run exits without running any station, or (with
+	// Station set) a station is not run; Message says why.
+	EventSkipped EventKind = "skipped"
+	// EventInfo: progress worth telling the user about (e.g. a conflict
+	// being resolved, a previous run being terminated).
+	EventInfo EventKind = "info"
+	// EventWarning: something went wrong but the run carries on.
+	EventWarning EventKind = "warning"
+	// EventStationStarted: a station is about to run.
+	EventStationStarted EventKind = "station_started"
+	// EventAgentOutput: a chunk of agent output, in Output. Agents running
+	// under tmux stream to their station log instead.
+	EventAgentOutput EventKind = "agent_output"
+	// EventCommitting: the station's changes are being committed.
+	EventCommitting EventKind = "committing"
+	// EventAwaitingApproval: a station with approval: manual produced a
+	// commit and the line stops until it is approved or rejected.
+	EventAwaitingApproval EventKind = "awaiting_approval"
+	// EventStationDone: a station finished; Err is set if it failed.
+	EventStationDone EventKind = "station_done"
+	// EventRunDone: the run finished.
+	EventRunDone EventKind = "run_done"
+)
+
+// Event is one thing that happened during a run. Station is empty for
+// run-wide events.
+type Event struct {
+	Kind    EventKind
+	Time    time.Time
+	Station string
+	Message string
+	Output  []byte
+	Err     error
+}
+
+// Level is the log level of the event: debug for routine steps, warn and
+// error for problems, info for everything else.
+func (e Event) Level() slog.Level {
+	switch e.Kind {
+	case EventCommitting, EventRunDone:
+		return slog.LevelDebug
+	case EventWarning:
+		return slog.LevelWarn
+	case EventStationDone:
+		if e.Err != nil {
+			return slog.LevelError
+		}
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// text returns the event as a human-readable message, without the station.
+func (e Event) text() string {
+	switch e.Kind {
+	case EventStationStarted:
+		return "station started"
+	case EventCommitting:
+		return "committing"
+	case EventStationDone:
+		if e.Err != nil {
+			return "station failed"
+		}
+		return "station done"
+	case EventRunDone:
+		return "run finished"
+	case EventAgentOutput:
+		return "agent output"
+	}
+	return e.Message
+}
+
+// EventSink receives the events of a run, in order. Emit is called from
+// the runner's goroutine and from agent output copiers, so implementations
+// must be safe for concurrent use.
+type EventSink interface {
+	Emit(Event)
+}
+
+// SinkFunc adapts a function to an EventSink.
+type SinkFunc func(Event)
+
+// Emit calls f(e).
+func (f SinkFunc) Emit(e Event) { f(e) }
+
+// TextSink renders events as line's plain-text output: agent output to Out,
+// progress messages to Err. Events below Level are dropped; the zero value
+// shows info and above.
+type TextSink struct {
+	Out   io.Writer
+	Err   io.Writer
+	Level slog.Level
+	mu    sync.Mutex
+}
+
+// NewTextSink returns a TextSink writing to stdout and stderr.
+func NewTextSink() *TextSink {
+	return &TextSink{Out: os.Stdout, Err: os.Stderr}
+}
+
+// Emit writes e to the sink's writers.
+func (t *TextSink) Emit(e Event) {
+	if e.Level() < t.Level {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case e.Kind == EventAgentOutput:
+		_, _ = t.Out.Write(e.Output)
+	case e.Kind == EventStationStarted:
+		fmt.Fprintf(t.Err, "assembly-line: running station %s\n", e.Station)
+	case e.Kind == EventStationDone && e.Err != nil:
+		fmt.Fprintf(t.Err, "assembly-line: station %s failed: %v\n", e.Station, e.Err)
+	default:
+		prefix := "assembly-line"
+		if e.Station != "" {
+			prefix = "station " + e.Station
+		}
+		fmt.Fprintf(t.Err, "%s: %s\n", prefix, e.text())
+		if len(e.Output) > 0 {
+			_, _ = t.Err.Write(e.Output)
+		}
+	}
+}
+
+// LogSink writes events as structured records to a slog.Logger, for
+// --log-format json.
+type LogSink struct {
+	Logger *slog.Logger
+}
+
+// Emit logs e at its level.
+func (l LogSink) Emit(e Event) {
+	attrs := []slog.Attr{slog.String("event", string(e.Kind))}
+	if e.Station != "" {
+		attrs = append(attrs, slog.String("station", e.Station))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+		var exitErr *AgentExitError
+		if errors.As(e.Err, &exitErr) {
+			attrs = append(attrs, slog.Int("exit_code", exitErr.Code))
+			if exitErr.Signal != "" {
+				attrs = append(attrs, slog.String("signal", exitErr.Signal))
+			}
+		}
+	}
+	if len(e.Output) > 0 {
+		attrs = append(attrs, slog.String("output", string(e.Output)))
+	}
+	l.Logger.LogAttrs(context.Background(), e.Level(), e.text(), attrs...)
+}
+
+// emitf sends a message event of the given kind.
+func emitf(ev EventSink, kind EventKind, station, format string, args ...any) {
+	ev.Emit(Event{Kind: kind, Time: time.Now(), Station: station, Message: fmt.Sprintf(format, args...)})
+}
+
+// outputWriter turns writes into EventAgentOutput events for a station.
+type outputWriter struct {
+	ev      EventSink
+	station string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.ev.Emit(Event{Kind: EventAgentOutput, Time: time.Now(), Station: w.station, Output: append([]byte(nil), p...)})
+	return len(p), nil
+}
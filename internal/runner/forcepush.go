@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// forcePushedBase returns the watched-branch commit a station branch was
+// last based on if the watched branch no longer contains it, i.e. it was
+// force-pushed since (RUN-44). That base is the merge-base of the branch and
+// the commit the line last ran for.
+func forcePushedBase(dir string, cfg *config.Config, branch string) (string, bool) {
+	last, ok := state.ReadLastCycle(dir)
+	if !ok || last.Commit == "" || git.IsAncestor(dir, last.Commit, cfg.Settings.Watches) {
+		return "", false
+	}
+	base, err := git.Run(dir, "merge-base", branch, last.Commit)
+	if err != nil || base == "" || git.IsAncestor(dir, base, cfg.Settings.Watches) {
+		return "", false
+	}
+	return base, true
+}
+
+// forcePushRebase returns how a station branch based on old, a commit the
+// force-pushed watched branch no longer contains, catches up with its
+// predecessor under settings.on_force_push. Rebasing it as usual would
+// replay the replaced commits along with the station's own.
+func forcePushRebase(cfg *config.Config, station config.Station, predecessor, old string, ev EventSink) func(dir, onto string, opts git.CommitOptions) error {
+	watches := cfg.Settings.Watches
+	if cfg.Settings.ForcePushPolicy() == config.OnForcePushReset {
+		emitf(ev, EventWarning, station.Name, "%s was force-pushed, discarding the station's commits (settings.on_force_push: reset)", watches)
+		return func(dir, onto string, _ git.CommitOptions) error {
+			return git.ResetHard(dir, onto)
+		}
+	}
+	emitf(ev, EventInfo, station.Name, "%s was force-pushed, replaying the station's own commits since %s (settings.on_force_push: rebase)", watches, git.ShortHash(old))
+	if predecessor != watches {
+		// The predecessor station was rewritten the same way: its reflog
+		// tells its replaced commits apart.
+		return git.RebaseForkPoint
+	}
+	return func(dir, onto string, opts git.CommitOptions) error {
+		return git.RebaseOnto(dir, onto, old, opts)
+	}
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// runAfter runs the station's after command in the worktree, so its changes
+// are committed along with the agent's. Failure output is appended to the
+// station log.
+func runAfter(dir, wtPath string, station config.Station, ev EventSink) error {
+	if station.After == "" {
+		return nil
+	}
+	out, err := runShell(wtPath, station.After, hookEnv(station.Name, "")...)
+	if err == nil {
+		return nil
+	}
+	ev.Emit(Event{Kind: EventWarning, Time: time.Now(), Station: station.Name, Message: fmt.Sprintf("after failed: %v", err), Output: out})
+	_ = state.AppendStationLog(dir, station.Name, fmt.Sprintf("\n--- after %q failed: %v ---\n%s", station.After, err, out))
+	return fmt.Errorf("after %q failed: %w", station.After, err)
+}
+
+// runOutcomeHook runs on_success or on_failure in the repo root once a
+// station has finished. A failing hook is reported but does not change the
+// station's outcome.
+func runOutcomeHook(dir string, station config.Station, result string, failed bool) error {
+	command, which := station.OnSuccess, "on_success"
+	if failed {
+		command, which = station.OnFailure, "on_failure"
+	}
+	if command == "" {
+		return nil
+	}
+	out, err := runShell(dir, command, hookEnv(station.Name, result)...)
+	if len(out) > 0 {
+		_ = state.AppendStationLog(dir, station.Name, fmt.Sprintf("\n--- %s ---\n%s", which, out))
+	}
+	if err != nil {
+		return fmt.Errorf("%s %q failed: %w", which, command, err)
+	}
+	return nil
+}
+
+// runCycleHook runs one of settings.hooks in the repo root (RUN-45). Its
+// failure aborts the cycle: the returned error is recorded as the cycle's
+// for line status.
+func runCycleHook(dir, which, command string, env []string, ev EventSink) error {
+	if command == "" {
+		return nil
+	}
+	out, err := runShell(dir, command, env...)
+	if err != nil {
+		ev.Emit(Event{Kind: EventWarning, Time: time.Now(), Message: fmt.Sprintf("settings.hooks.%s failed, aborting the cycle: %v", which, err), Output: out})
+		return fmt.Errorf("settings.hooks.%s failed: %w", which, err)
+	}
+	ev.Emit(Event{Kind: EventInfo, Time: time.Now(), Message: "ran settings.hooks." + which, Output: out})
+	return nil
+}
+
+// hookEnv describes the station to its hook commands.
+func hookEnv(name, result string) []string {
+	env := []string{"LINE_STATION=" + name, "LINE_BRANCH=" + git.StationBranchName(name)}
+	if result != "" {
+		env = append(env, "LINE_RESULT="+result)
+	}
+	return env
+}
+
+// runShell runs a shell command in workDir with line's retrigger guard set
+// and returns its combined output.
+func runShell(workDir, command string, env ...string) ([]byte, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = workDir
+	cmd.Env = append(append(git.CleanEnv(os.Environ(), "CLAUDECODE"), "LINE_RUNNING=1"), env...)
+	return cmd.CombinedOutput()
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// interruptedPrompt is added to the prompt of a station resuming the work
+// of an interrupted run (on_interrupt: resume).
+const interruptedPrompt = "\n\nA previous run of this station was interrupted before it finished. " +
+	"The changes it had made so far are already in the working tree: review them and continue where it left off."
+
+// preserveInterrupted keeps the work of agents whose run was killed (RUN-40):
+// a station still marked in progress left its changes in its worktree. They
+// are recorded under git.InterruptedRefName before the worktree is cleaned
+// up, unless the station discards them (on_interrupt: discard, the default).
+func preserveInterrupted(dir string, cfg *config.Config, ev EventSink) {
+	baseDir, err := git.WorktreeBaseDir(dir)
+	if err != nil {
+		return
+	}
+	for _, station := range cfg.AllStations() {
+		preserveStation(dir, cfg, station, filepath.Join(baseDir, station.Name), ev)
+	}
+}
+
+// preserveStation does preserveInterrupted for one station whose worktree is
+// at wtPath.
+func preserveStation(dir string, cfg *config.Config, station config.Station, wtPath string, ev EventSink) {
+	started, ok := state.ReadStationInProgress(dir, station.Name)
+	if !ok {
+		return
+	}
+	defer func() { _ = state.RemoveStationInProgress(dir, station.Name) }()
+	if _, err := os.Stat(wtPath); err != nil {
+		return
+	}
+	since := started.Local().Format(time.TimeOnly)
+
+	if station.OnInterrupt == "" || station.OnInterrupt == config.OnInterruptDiscard {
+		if dirty, _ := git.IsDirty(wtPath); dirty {
+			emitf(ev, EventInfo, station.Name, "discarding the changes of the run interrupted since %s (on_interrupt: discard)", since)
+		}
+		return
+	}
+
+	unstage := station.Cache
+	if wt := cfg.Settings.Worktree; wt != nil {
+		unstage = append(append(unstage[:len(unstage):len(unstage)], wt.Copy...), wt.Symlink...)
+	}
+	commit, err := git.Snapshot(wtPath, "assembly-line: interrupted run of station "+station.Name, unstage)
+	if err != nil {
+		emitf(ev, EventWarning, station.Name, "keeping the interrupted run's changes: %v", err)
+		return
+	}
+	if commit == "" {
+		return
+	}
+	if err := git.UpdateRef(dir, git.InterruptedRefName(station.Name), commit, ""); err != nil {
+		emitf(ev, EventWarning, station.Name, "keeping the interrupted run's changes: %v", err)
+		return
+	}
+	emitf(ev, EventInfo, station.Name, "kept the changes of the run interrupted since %s (on_interrupt: %s)", since, station.OnInterrupt)
+}
+
+// restoreInterrupted puts the changes kept by preserveInterrupted back into
+// the station's fresh worktree, and returns the station's on_interrupt if it
+// did: "" means the station runs as usual. The kept changes are used once.
+func restoreInterrupted(dir, wtPath string, station config.Station, ev EventSink) string {
+	ref := git.InterruptedRefName(station.Name)
+	commit, err := git.Run(dir, "rev-parse", "--verify", "--quiet", ref)
+	if err != nil || commit == "" {
+		return ""
+	}
+	defer func() { _ = git.DeleteRef(dir, ref) }()
+	if station.OnInterrupt != config.OnInterruptCommit && station.OnInterrupt != config.OnInterruptResume {
+		return ""
+	}
+	if err := git.ApplySnapshot(wtPath, commit); err != nil {
+		emitf(ev, EventWarning, station.Name, "the interrupted run's changes no longer apply, starting again: %v", err)
+		return ""
+	}
+	emitf(ev, EventInfo, station.Name, "restored the changes of the interrupted run (on_interrupt: %s)", station.OnInterrupt)
+	return station.OnInterrupt
+}
@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/issues"
+)
+
+// Bounds on what stations[].context adds to a prompt: the commits searched
+// for references and changed files, the issues or items added and the
+// length of each description.
+const (
+	maxIssueCommits = 100
+	maxIssues       = 10
+	maxIssueBody    = 2000
+)
+
+// stationPrompt returns the station's prompt, told to stay within its root
+// (RUN-34), with the commits to record for a changelog station (RUN-35)
+// and what its context providers contribute for the commits
+// from..to that touch the root: the tracker issues their messages
+// reference, and the open items of in-repo stores, read at head, that
+// mention the files they change. Providers or issues that fail are left out
+// and reported in the returned error; the prompt is usable either way.
+func stationPrompt(dir string, resolved config.ResolvedStation, from, to, head string) (string, error) {
+	prompt := resolved.Prompt
+	if resolved.Root != "" {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n" + rootInstruction(resolved.Root)
+	}
+	if resolved.Changelog != "" && to != "" {
+		if section := changelogSection(dir, resolved, from, to); section != "" {
+			prompt = strings.TrimRight(prompt, "\n") + "\n\n" + section
+		}
+	}
+	if len(resolved.Context) == 0 || to == "" {
+		return prompt, nil
+	}
+	rangeArg := to
+	if from != "" {
+		rangeArg = from + ".." + to
+	}
+	logArgs := append([]string{fmt.Sprintf("--max-count=%d", maxIssueCommits), rangeArg}, rootPathspec(dir, resolved.Root)...)
+	messages, err := git.Run(dir, append([]string{"log", "--format=%B"}, logArgs...)...)
+	if err != nil {
+		return prompt, fmt.Errorf("reading commit messages: %w", err)
+	}
+	changed, _ := git.Run(dir, append([]string{"log", "--name-only", "--format="}, logArgs...)...)
+	var files []string
+	for _, f := range strings.Split(changed, "\n") {
+		if f != "" && !slices.Contains(files, f) {
+			files = append(files, f)
+		}
+	}
+	remote, _ := git.Run(dir, "remote", "get-url", "origin")
+
+	var errs []error
+	var found []issues.Issue
+	var seen []string
+	var stores []string
+	for _, spec := range resolved.Context {
+		if store, ok := issues.BeadsPath(spec); ok {
+			section, err := storeSection(dir, head, store, files)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if section != "" {
+				stores = append(stores, section)
+			}
+			continue
+		}
+		p, err := issues.New(spec, remote)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, ref := range p.Refs(messages) {
+			if slices.Contains(seen, ref) || len(seen) == maxIssues {
+				continue
+			}
+			seen = append(seen, ref)
+			issue, err := p.Fetch(ref)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			found = append(found, issue)
+		}
+	}
+	if len(found) == 0 && len(stores) == 0 {
+		return prompt, errors.Join(errs...)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(prompt, "\n"))
+	if len(found) > 0 {
+		b.WriteString("\n\nIssues referenced by the commits under review:\n")
+		for _, issue := range found {
+			writeIssue(&b, issue.Key, issue.Title, issue.Body)
+		}
+	}
+	for _, section := range stores {
+		b.WriteString("\n\n" + section)
+	}
+	return b.String(), errors.Join(errs...)
+}
+
+// storeSection lists the open items of the beads store at head that
+// mention one of files, and tells the agent how to close or annotate them.
+// A store missing at head contributes nothing.
+func storeSection(dir, head, store string, files []string) (string, error) {
+	data, err := git.Run(dir, "show", head+":"+store)
+	if err != nil {
+		return "", nil
+	}
+	items := issues.RelevantItems([]byte(data), files)
+	if len(items) == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Open items in %s that mention the changed files:\n", store)
+	for _, it := range items[:min(len(items), maxIssues)] {
+		writeIssue(&b, it.ID, it.Title, it.Description)
+	}
+	fmt.Fprintf(&b, "\nTo close an item you resolved, or to note progress on one, write %s (do not edit %s yourself):\n", issues.ResultFile, store)
+	b.WriteString(`{"close": [{"id": "<id>", "reason": "<why>"}], "annotate": [{"id": "<id>", "note": "<note>"}]}`)
+	return b.String(), nil
+}
+
+// writeIssue writes an issue or item as a "## <key>: <title>" section with
+// its description, cut at maxIssueBody.
+func writeIssue(b *strings.Builder, key, title, body string) {
+	fmt.Fprintf(b, "\n## %s: %s\n", key, title)
+	if body = strings.TrimSpace(body); body != "" {
+		if len(body) > maxIssueBody {
+			body = strings.ToValidUTF8(body[:maxIssueBody], "") + "…"
+		}
+		fmt.Fprintf(b, "\n%s\n", body)
+	}
+}
+
+// applyResult closes and annotates items of the station's beads store as
+// the agent asked in its result file, so the store changes are committed
+// with the agent's. The result file itself is removed. Without a beads
+// provider the file is not line's and is left alone.
+func applyResult(wtPath string, resolved config.ResolvedStation, now time.Time) error {
+	var store string
+	for _, spec := range resolved.Context {
+		if p, ok := issues.BeadsPath(spec); ok {
+			store = p
+			break
+		}
+	}
+	resultPath := filepath.Join(wtPath, issues.ResultFile)
+	data, err := os.ReadFile(resultPath)
+	if store == "" || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	_ = os.Remove(resultPath)
+	if err != nil {
+		return err
+	}
+
+	var r issues.Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("%s: %w", issues.ResultFile, err)
+	}
+	storePath := filepath.Join(wtPath, store)
+	contents, err := os.ReadFile(storePath)
+	if err != nil {
+		return err
+	}
+	updated, missing, err := issues.Apply(contents, r, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", store, err)
+	}
+	if err := os.WriteFile(storePath, updated, 0o644); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: no items %s in %s", issues.ResultFile, strings.Join(missing, ", "), store)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/trace"
+)
+
+// manualRange resolves a --range ("a..b", either side optional) to the
+// watched-branch commits a station run on demand reviews. Without a from,
+// it reviews what the station has not reviewed yet; without a to, up to the
+// watched branch head.
+func manualRange(dir, watches, name, rng string) (from, to string, err error) {
+	fromRev, toRev := "", watches
+	if rng != "" {
+		var ok bool
+		if fromRev, toRev, ok = strings.Cut(rng, ".."); !ok {
+			return "", "", fmt.Errorf("range %q is not <from>..<to>", rng)
+		}
+		if toRev == "" {
+			toRev = watches
+		}
+	}
+	if to, err = git.Run(dir, "rev-parse", "--verify", "--quiet", toRev+"^{commit}"); err != nil {
+		return "", "", fmt.Errorf("range: unknown commit %q", toRev)
+	}
+	if fromRev == "" {
+		return reviewBase(dir, name, to), to, nil
+	}
+	if from, err = git.Run(dir, "rev-parse", "--verify", "--quiet", fromRev+"^{commit}"); err != nil {
+		return "", "", fmt.Errorf("range: unknown commit %q", fromRev)
+	}
+	return from, to, nil
+}
+
+// runManual runs one station of the line on demand, for line run --station
+// (RUN-38): typically a station with trigger: manual, whose automatic runs
+// only pass changes through. The station works on top of its predecessor as
+// usual and reviews the commits in rng; if it succeeds, the stations after
+// it run as they would after an approval.
+func runManual(dir string, cfg *config.Config, name, rng string, ev EventSink) error {
+	i := -1
+	for j, s := range cfg.Stations {
+		if s.Name == name {
+			i = j
+		}
+	}
+	if i < 0 {
+		if s, ok := cfg.RefStation(name); ok {
+			return fmt.Errorf("station %s watches %s; line run --refs runs it", name, s.Watches)
+		}
+		return fmt.Errorf("unknown station %q", name)
+	}
+	predecessor := cfg.Settings.Watches
+	if i > 0 {
+		predecessor = git.StationBranchName(cfg.Stations[i-1].Name)
+		if !git.BranchExists(dir, predecessor) {
+			return fmt.Errorf("station %s has not run yet; run the line first", cfg.Stations[i-1].Name)
+		}
+	}
+	from, to, err := manualRange(dir, cfg.Settings.Watches, name, rng)
+	if err != nil {
+		return err
+	}
+
+	ok, err := runOnDemand(dir, cfg, cfg.Stations[i], predecessor, from, to, ev)
+	if err != nil {
+		return err
+	}
+	if !ok || i == len(cfg.Stations)-1 {
+		ev.Emit(Event{Kind: EventRunDone, Time: time.Now()})
+		return nil
+	}
+	// The stations after it pick the change up, taking over as a run does.
+	return runLine(dir, cfg, i+1, cfg.Settings.Watches, ev, false)
+}
+
+// runOnDemand runs the station for the commits from..to and records the
+// run. It reports whether the station succeeded, rather than failing or
+// waiting for approval.
+func runOnDemand(dir string, cfg *config.Config, station config.Station, predecessor, from, to string, ev EventSink) (ok bool, err error) {
+	// A deliberate run is not killed off by, nor kills, one in progress.
+	if pid, _ := state.ReadPID(dir); pid > 0 && pid != os.Getpid() && state.IsProcessRunning(pid) {
+		return false, fmt.Errorf("a run is in progress (PID %d); try again when it finishes, or stop it with line clear", pid)
+	}
+	if err := state.WritePID(dir, os.Getpid()); err != nil {
+		return false, fmt.Errorf("writing PID: %w", err)
+	}
+	defer func() { _ = state.RemovePID(dir) }()
+
+	os.Setenv("LINE_RUNNING", "1")
+	defer os.Unsetenv("LINE_RUNNING")
+
+	span := trace.Start("line run", "line.watches", cfg.Settings.Watches, "line.station", station.Name)
+	defer func() {
+		span.End(err)
+		if err := trace.Flush(); err != nil {
+			emitf(ev, EventWarning, "", "tracing: %v", err)
+		}
+	}()
+
+	started := time.Now()
+	ev.Emit(Event{Kind: EventStationStarted, Time: started, Station: station.Name})
+	run := state.StationRun{ID: newRunID(started), Started: started, Commit: to, RangeFrom: from, RangeTo: to, LogFrom: state.StationLogSize(dir, station.Name)}
+	runErr := runStation(dir, cfg, station, predecessor, false, run, ev)
+	run.Finished = time.Now()
+	if errors.Is(runErr, errAwaitingApproval) {
+		a, _ := state.ReadStationApproval(dir, station.Name)
+		run.Result = "awaiting approval"
+		recordRun(dir, station.Name, run)
+		recordBackoff(dir, station.Name, nil, ev)
+		stationHook(dir, station, run.Result, nil, ev)
+		ev.Emit(Event{Kind: EventAwaitingApproval, Time: time.Now(), Station: station.Name,
+			Message: fmt.Sprintf("awaiting approval (%s): line approve %s or line reject %s", a.Summary, station.Name, station.Name)})
+		return false, nil
+	}
+	run.Result = "ok"
+	if runErr != nil {
+		run.Result = runErr.Error()
+	}
+	recordRun(dir, station.Name, run)
+	ev.Emit(Event{Kind: EventStationDone, Time: time.Now(), Station: station.Name, Err: runErr})
+	recordBackoff(dir, station.Name, runErr, ev)
+	stationHook(dir, station, run.Result, runErr, ev)
+	if runErr != nil {
+		notifyFailure(dir, cfg, station.Name, to, run.Result, ev)
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/paths"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// OrphanedStations returns the names that have a station branch or station
+// state but no station in the config, typically because the config renamed
+// the station, sorted.
+func OrphanedStations(dir string, cfg *config.Config) []string {
+	var orphans []string
+	add := func(name string) {
+		if _, ok := cfg.Station(name); !ok && !slices.Contains(orphans, name) {
+			orphans = append(orphans, name)
+		}
+	}
+	heads, _ := git.BranchHeads(dir, "refs/heads/"+git.StationBranchName(""))
+	for branch := range heads {
+		add(strings.TrimPrefix(branch, git.StationBranchName("")))
+	}
+	for _, name := range state.StationsWithState(dir) {
+		add(name)
+	}
+	slices.Sort(orphans)
+	return orphans
+}
+
+// MigrateStation carries what the line keeps for a station over to its new
+// name after the config renamed it from from to to: the station branch, any
+// commit held for approval or kept from an interrupted run, the files in
+// .line/stations, the log and the cached build directories. to must be a
+// station in the config and from must not. Unless force is set, it refuses
+// when to has a branch or state of its own, e.g. because the line ran since
+// the rename; with force, those are replaced.
+func MigrateStation(dir string, cfg *config.Config, from, to string, force bool) error {
+	if _, ok := cfg.Station(from); ok {
+		return fmt.Errorf("station %q is still in the config; rename it there first", from)
+	}
+	if _, ok := cfg.Station(to); !ok {
+		return fmt.Errorf("unknown station %q", to)
+	}
+	if !slices.Contains(OrphanedStations(dir, cfg), from) {
+		return fmt.Errorf("nothing to migrate: no branch or state for %q", from)
+	}
+	if !force {
+		if git.BranchExists(dir, git.StationBranchName(to)) || slices.Contains(state.StationsWithState(dir), to) {
+			return fmt.Errorf("station %s already has a branch or state of its own; --force replaces it with %s's", to, from)
+		}
+	}
+	if pid, _, _ := state.ReadStationPID(dir, from); pid > 0 && state.IsProcessRunning(pid) {
+		return fmt.Errorf("an agent of %s is still running; stop it with line clear or wait for it to finish", from)
+	}
+
+	if git.BranchExists(dir, git.StationBranchName(from)) {
+		if err := git.RenameBranch(dir, git.StationBranchName(from), git.StationBranchName(to)); err != nil {
+			return fmt.Errorf("renaming branch: %w", err)
+		}
+	} else if err := git.DeleteBranch(dir, git.StationBranchName(to)); err != nil && git.BranchExists(dir, git.StationBranchName(to)) {
+		return fmt.Errorf("removing branch of %s: %w", to, err)
+	}
+	for _, refName := range []func(string) string{git.ApprovalRefName, git.InterruptedRefName} {
+		if err := moveRef(dir, refName(from), refName(to)); err != nil {
+			return err
+		}
+	}
+	if err := state.MoveStation(dir, from, to); err != nil {
+		return fmt.Errorf("moving state: %w", err)
+	}
+	if err := moveArtifacts(dir, from, to); err != nil {
+		return fmt.Errorf("moving cached build directories: %w", err)
+	}
+	_ = state.RemoveStatuslineCache(dir)
+	return nil
+}
+
+// moveRef points to where from points, or deletes to if from doesn't
+// exist, and deletes from.
+func moveRef(dir, from, to string) error {
+	hash, err := git.Run(dir, "rev-parse", "--verify", "--quiet", from)
+	if err != nil {
+		return git.DeleteRef(dir, to)
+	}
+	if err := git.UpdateRef(dir, to, hash, ""); err != nil {
+		return err
+	}
+	return git.DeleteRef(dir, from)
+}
+
+// moveArtifacts moves a station's cached build directories
+// (stations[].cache), replacing to's.
+func moveArtifacts(dir, from, to string) error {
+	src, err := paths.Artifacts(dir, from)
+	if err != nil {
+		return err
+	}
+	dst, err := paths.Artifacts(dir, to)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); err != nil {
+		return nil
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/notify"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// emailLogLines is how much of a failed station's log is mailed.
+const emailLogLines = 30
+
+// notifyFailure posts a station failure to the configured chat webhooks,
+// and mails it once the station has failed the configured number of times
+// in a row. Notification errors only warn.
+func notifyFailure(dir string, cfg *config.Config, name, watched, result string, ev EventSink) {
+	n := cfg.Notifications
+	if n == nil {
+		return
+	}
+	f := notify.Failure{Station: name, Branch: git.StationBranchName(name), Commit: git.ShortHash(watched), Result: result}
+	if err := notify.Failed(n, f); err != nil {
+		emitf(ev, EventWarning, name, "notification failed: %v", err)
+	}
+
+	if n.Email == nil {
+		return
+	}
+	// Only failures counted by recordBackoff (not conflicts left for
+	// manual resolution), and only once per streak.
+	failures := state.ReadStationBackoff(dir, name).Failures
+	if failures != n.Email.FailureThreshold() {
+		return
+	}
+	subject := fmt.Sprintf("line: station %s failed", name)
+	if failures > 1 {
+		subject = fmt.Sprintf("line: station %s failed %d times in a row", name, failures)
+	}
+	var body strings.Builder
+	commit, _ := git.Run(dir, "log", "-1", "--format=%h %s", watched)
+	fmt.Fprintf(&body, "Station %s (%s) failed on %s:\n\n    %s\n", name, f.Branch, commit, result)
+	if tail := state.StationLogTail(dir, name, emailLogLines); len(tail) > 0 {
+		body.WriteString("\nIts log ends with:\n\n")
+		for _, l := range tail {
+			fmt.Fprintf(&body, "    %s\n", l)
+		}
+	}
+	fmt.Fprintf(&body, "\nRun `line show %s` for details, or `line retry %s` once fixed.\n", name, name)
+	if err := notify.Mail(n.Email, subject, body.String()); err != nil {
+		emitf(ev, EventWarning, name, "notification failed: %v", err)
+	}
+}
+
+// terminalHead returns the commit the last station's branch points at, or
+// "" if there is none yet.
+func terminalHead(dir string, cfg *config.Config) string {
+	if len(cfg.Stations) == 0 {
+		return ""
+	}
+	head, _ := git.Run(dir, "rev-parse", "--verify", "--quiet", git.StationBranchName(cfg.Stations[len(cfg.Stations)-1].Name))
+	return head
+}
+
+// emailCompleted mails the diffstat of the changes the line has waiting on
+// the terminal branch after a run that moved it.
+func emailCompleted(dir string, cfg *config.Config, watched, subject string, ev EventSink) {
+	if cfg.Notifications == nil || cfg.Notifications.Email == nil {
+		return
+	}
+	terminal := git.StationBranchName(cfg.Stations[len(cfg.Stations)-1].Name)
+	stat, _ := git.Run(dir, "diff", "--stat", watched, terminal)
+	if stat == "" {
+		return
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "The line finished for %s %s with changes on %s:\n\n", git.ShortHash(watched), subject, terminal)
+	for _, l := range strings.Split(stat, "\n") {
+		fmt.Fprintf(&body, "    %s\n", strings.TrimSpace(l))
+	}
+	fmt.Fprintf(&body, "\nRun `line rebase` on %s to pick them up.\n", cfg.Settings.Watches)
+	mailSubject := fmt.Sprintf("line: changes ready for %s (%s)", cfg.Settings.Watches, git.ShortHash(watched))
+	if err := notify.Mail(cfg.Notifications.Email, mailSubject, body.String()); err != nil {
+		emitf(ev, EventWarning, "", "notification failed: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// An agent that exits unsuccessfully with a provider's rate-limit error in
+// the last rateLimitLines lines of its output is run again after
+// rateLimitBackoff, doubling with each further retry, up to
+// rateLimitRetries times before its station fails (RUN-50).
+const (
+	rateLimitRetries = 3
+	rateLimitBackoff = 10 * time.Second
+	rateLimitLines   = 5
+)
+
+// rateLimitRE matches the errors agents print when their provider turns them
+// away: HTTP 429 and 529 given as a status or error, rate_limit_error, "rate
+// limit exceeded" or "reached", "too many requests" and "overloaded" errors.
+// Output that merely mentions rate limits (429 tests passed, TestRateLimit,
+// internal/ratelimit/ratelimit.go:12: undefined: x, add a rate limiter) is not
+// a rate limit.
+var rateLimitRE = regexp.MustCompile(`(?i)\b(status|error|http(/[\d.]+)?|code)\b[^0-9\n]{0,10}\b(429|529)\b|\brate_limit_(error|exceeded)\b|\brate[ -]limit (exceeded|reached)|too many requests|overloaded_error|\b(is|are) (currently )?overloaded`)
+
+// rateLimited reports whether an agent failed because its provider's rate
+// limit was hit, judging by the last lines of its output.
+func rateLimited(agentErr error) bool {
+	var exitErr *AgentExitError
+	if !errors.As(agentErr, &exitErr) {
+		return false
+	}
+	lines := exitErr.Output
+	if len(lines) > rateLimitLines {
+		lines = lines[len(lines)-rateLimitLines:]
+	}
+	for _, line := range lines {
+		if rateLimitRE.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// paceAgent waits until settings.agent_rate lets another agent start.
+func paceAgent(dir string, resolved config.ResolvedStation, ev EventSink) {
+	rate := resolved.AgentRate
+	if rate.N == 0 {
+		return
+	}
+	wait, err := state.TakeAgentStart(dir, rate.N, rate.Per, time.Now())
+	if err != nil {
+		emitf(ev, EventWarning, resolved.Name, "settings.agent_rate: %v", err)
+		return
+	}
+	if wait > 0 {
+		emitf(ev, EventInfo, resolved.Name, "waiting %s for settings.agent_rate (%s)", shortDuration(wait.Round(time.Second)), rate)
+		time.Sleep(wait)
+	}
+}
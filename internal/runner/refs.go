@@ -0,0 +1,189 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/trace"
+)
+
+// refUpdate is a new or moved tag or branch a station with watches runs for.
+type refUpdate struct {
+	name   string // full ref name, e.g. refs/tags/v1.2.0
+	commit string
+	from   string // start of the reviewed range (exclusive); empty for all history
+}
+
+// watchedRefs lists the refs matching w with their commits, oldest first:
+// tags in version order, branches by commit date. Station branches are
+// never watched.
+func watchedRefs(dir string, w config.RefWatch) ([]refUpdate, error) {
+	sort := "--sort=version:refname"
+	if w.Kind == "branch" {
+		sort = "--sort=committerdate"
+	}
+	out, err := git.Run(dir, "for-each-ref", sort, "--format=%(refname)%00%(objectname)%00%(*objectname)", w.Namespace())
+	if err != nil {
+		return nil, err
+	}
+	var refs []refUpdate
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		short := strings.TrimPrefix(fields[0], w.Namespace())
+		if ok, _ := path.Match(w.Pattern, short); !ok || strings.HasPrefix(fields[0], "refs/heads/"+git.StationBranchName("")) {
+			continue
+		}
+		// Annotated tags point at a tag object; peel them to the commit.
+		commit := fields[1]
+		if fields[2] != "" {
+			commit = fields[2]
+		}
+		refs = append(refs, refUpdate{name: fields[0], commit: commit})
+	}
+	return refs, nil
+}
+
+// pendingRefs returns the refs the station has not run for yet, oldest
+// first, and the refs it has seen. The first time it looks, only the newest
+// matching ref is pending. A tag's run reviews the commits since the
+// previous matching tag; a branch's since the commit the station last saw
+// on it, or since it forked from the watched branch.
+func pendingRefs(dir string, cfg *config.Config, name string, w config.RefWatch) (map[string]string, []refUpdate, error) {
+	refs, err := watchedRefs(dir, w)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous, looked := state.ReadStationRefs(dir, name)
+	seen := map[string]string{} // deleted refs are forgotten
+	for i, r := range refs {
+		if commit, ok := previous[r.name]; ok {
+			seen[r.name] = commit
+		} else if !looked && i < len(refs)-1 {
+			seen[r.name] = r.commit
+		}
+	}
+
+	var pending []refUpdate
+	for i, r := range refs {
+		if seen[r.name] == r.commit {
+			continue
+		}
+		switch w.Kind {
+		case "tag":
+			for j := i - 1; j >= 0; j-- {
+				if refs[j].commit != r.commit && git.IsAncestor(dir, refs[j].commit, r.commit) {
+					r.from = refs[j].commit
+					break
+				}
+			}
+		case "branch":
+			if last := seen[r.name]; last != "" && git.IsAncestor(dir, last, r.commit) {
+				r.from = last
+			} else if base, err := git.Run(dir, "merge-base", cfg.Settings.Watches, r.commit); err == nil {
+				r.from = base
+			}
+		}
+		pending = append(pending, r)
+	}
+	return seen, pending, nil
+}
+
+// RefLabel describes a watched ref, e.g. "tag v1.2.0".
+func RefLabel(ref string) string {
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		return "tag " + tag
+	}
+	return "branch " + strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// runRefStations runs each station with watches for its pending refs, in
+// order (RUN-37). A failure stops the station until the next run; the refs
+// it did not get to stay pending.
+func runRefStations(dir string, cfg *config.Config, ev EventSink) {
+	for _, station := range cfg.RefStations {
+		w, err := config.ParseWatches(station.Watches)
+		if err != nil {
+			emitf(ev, EventWarning, station.Name, "watches: %v", err)
+			continue
+		}
+		seen, pending, err := pendingRefs(dir, cfg, station.Name, w)
+		if err != nil {
+			emitf(ev, EventWarning, station.Name, "listing watched refs: %v", err)
+			continue
+		}
+		for _, u := range pending {
+			if b := state.ReadStationBackoff(dir, station.Name); b.Quarantined(time.Now()) {
+				emitf(ev, EventSkipped, station.Name, "quarantined until %s after %d consecutive failures, skipping (line retry %s)",
+					b.Until.Format(time.TimeOnly), b.Failures, station.Name)
+				break
+			}
+			started := time.Now()
+			ev.Emit(Event{Kind: EventStationStarted, Time: started, Station: station.Name, Message: RefLabel(u.name)})
+			run := state.StationRun{ID: newRunID(started), Started: started, Commit: u.commit, RangeFrom: u.from, RangeTo: u.commit, Ref: u.name,
+				LogFrom: state.StationLogSize(dir, station.Name)}
+			err := runStation(dir, cfg, station, u.name, false, run, ev)
+			run.Finished = time.Now()
+			run.Result = "ok"
+			if err != nil {
+				run.Result = err.Error()
+			}
+			recordRun(dir, station.Name, run)
+			ev.Emit(Event{Kind: EventStationDone, Time: time.Now(), Station: station.Name, Err: err})
+			recordBackoff(dir, station.Name, err, ev)
+			stationHook(dir, station, run.Result, err, ev)
+			if err != nil {
+				notifyFailure(dir, cfg, station.Name, u.commit, run.Result, ev)
+				break
+			}
+			seen[u.name] = u.commit
+			_ = state.WriteStationRefs(dir, station.Name, seen)
+		}
+		_ = state.WriteStationRefs(dir, station.Name, seen)
+	}
+}
+
+// runRefLine runs only the stations with watches, e.g. for line run --refs
+// from the reference-transaction hook. A run in progress checks the
+// watched refs when it finishes, so it is left alone.
+func runRefLine(dir string, cfg *config.Config, ev EventSink) (err error) {
+	if len(cfg.RefStations) == 0 {
+		return nil
+	}
+	running, err := takeOverUnlessRunning(dir, ev)
+	if err != nil {
+		return err
+	}
+	if running {
+		emitf(ev, EventSkipped, "", "skipping (a run is in progress; it checks the watched refs when it finishes)")
+		return nil
+	}
+	span := trace.Start("line run", "line.refs", "true")
+	defer func() {
+		span.End(err)
+		if err := trace.Flush(); err != nil {
+			emitf(ev, EventWarning, "", "tracing: %v", err)
+		}
+	}()
+	defer func() { _ = state.RemovePID(dir) }()
+
+	os.Setenv("LINE_RUNNING", "1")
+	defer os.Unsetenv("LINE_RUNNING")
+
+	runRefStations(dir, cfg, ev)
+	ev.Emit(Event{Kind: EventRunDone, Time: time.Now()})
+	return nil
+}
+
+// refPrompt tells a station with watches what it runs for.
+func refPrompt(ref string) string {
+	return fmt.Sprintf("\n\nTriggered by %s.", RefLabel(ref))
+}
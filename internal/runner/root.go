@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/ignore"
+	"github.com/re-cinq/assembly-line/internal/pathnorm"
+)
+
+// rootPathspec returns the git pathspec limiting a command to the station's
+// root, or nothing for a station without one. On a case-insensitive
+// filesystem (core.ignoreCase) the root matches paths regardless of case.
+func rootPathspec(dir, root string) []string {
+	if root == "" {
+		return nil
+	}
+	if git.IgnoreCase(dir) {
+		return []string{"--", ":(icase)" + root}
+	}
+	return []string{"--", root}
+}
+
+// agentDir returns where the station's agent runs: its root inside the
+// worktree, or the worktree itself.
+func agentDir(wtPath string, resolved config.ResolvedStation) string {
+	return filepath.Join(wtPath, filepath.FromSlash(resolved.Root))
+}
+
+// rootSkips reports whether a station with a root has nothing to do for the
+// commits in from..to: none of them changes a file under its root that
+// .lineignore does not ignore (and .lineonly lists).
+func rootSkips(dir, root, from, to string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	rangeArg := to
+	if from != "" {
+		rangeArg = from + ".." + to
+	}
+	out, err := git.Run(dir, append([]string{"log", "--name-only", "--format=", rangeArg}, rootPathspec(dir, root)...)...)
+	if err != nil {
+		return "", false
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	if len(files) > 0 {
+		matcher, err := ignore.Load(dir)
+		if err != nil || !matcher.AllIgnored(files) {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("with no changes under %s", root), true
+}
+
+// rootInstruction tells the agent to stay within the station's root.
+func rootInstruction(root string) string {
+	return fmt.Sprintf("Work only within %s/ (paths are relative to it). Changes to files outside it fail the station.", root)
+}
+
+// outsideRoot returns the files changed in the worktree that are outside
+// the station's root, other than the allowed paths line put there itself
+// (provisioned files, cache directories).
+func outsideRoot(wtPath, root string, allowed []string) ([]string, error) {
+	if root == "" {
+		return nil, nil
+	}
+	changed, err := git.Run(wtPath, "diff", "HEAD", "--name-only", "--no-renames", "-z")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git.Run(wtPath, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	ignoreCase := git.IgnoreCase(wtPath)
+	var files []string
+	for _, f := range strings.Split(changed+"\x00"+untracked, "\x00") {
+		if f == "" || pathnorm.Under(f, root, ignoreCase) || slices.ContainsFunc(allowed, func(a string) bool { return pathnorm.Under(f, a, ignoreCase) }) {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
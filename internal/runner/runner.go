@@ -1,15 +1,20 @@
 package runner
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/git"
 	"github.com/re-cinq/assembly-line/internal/ignore"
 	"github.com/re-cinq/assembly-line/internal/state"
 	"github.com/re-cinq/assembly-line/internal/tmux"
+	"github.com/re-cinq/assembly-line/internal/trace"
 )
 
 // commitSkipMarker is appended to station commit messages. It must be one of
@@ -19,82 +24,408 @@ const commitSkipMarker = "[skip line]"
 // SkipMarkers are commit message markers that prevent retriggering.
 var SkipMarkers = []string{"[skip ci]", "[ci skip]", commitSkipMarker, "[line skip]"}
 
+// Options configures a run.
+type Options struct {
+	// Events receives the run's progress. Defaults to a TextSink on
+	// stdout/stderr.
+	Events EventSink
+	// CI runs the line once for the checked-out commit, e.g. in a CI job:
+	// HEAD may be detached, and no PID file is read or written, so there
+	// is no takeover of (or by) other runs.
+	CI bool
+	// Refs only runs the stations with watches, for their new or moved
+	// tags and branches (RUN-37).
+	Refs bool
+	// Station runs just the named station on demand, for the watched-branch
+	// commits in Range ("a..b", optional), then the stations after it
+	// (RUN-38).
+	Station string
+	Range   string
+	// Commit runs the line for this watched-branch commit rather than for
+	// the one checked out, whatever branch that is (RUN-49).
+	Commit string
+}
+
+// Failed stations are quarantined once they fail quarantineAfter times in a
+// row, for backoffBase doubling with each further failure up to backoffMax,
+// so an agent that fails deterministically does not run on every commit.
+const (
+	quarantineAfter = 2
+	backoffBase     = time.Minute
+	backoffMax      = time.Hour
+)
+
+// backoff returns how long a station is quarantined after the given number
+// of consecutive failures.
+func backoff(failures int) time.Duration {
+	d := backoffBase
+	for i := quarantineAfter; i < failures && d < backoffMax; i++ {
+		d *= 2
+	}
+	return min(d, backoffMax)
+}
+
+// recordBackoff updates a station's consecutive failure count after a run.
+// Only failures of the station itself count (those that mark it failed);
+// conflicts left for manual resolution do not.
+func recordBackoff(dir, name string, runErr error, ev EventSink) {
+	if runErr == nil {
+		_ = state.RemoveStationBackoff(dir, name)
+		return
+	}
+	if !state.ReadStationFailed(dir, name) {
+		return
+	}
+	b := state.ReadStationBackoff(dir, name)
+	b.Failures++
+	if b.Failures >= quarantineAfter {
+		b.Until = time.Now().Add(backoff(b.Failures))
+		emitf(ev, EventWarning, name, "failed %d times in a row, quarantined until %s", b.Failures, b.Until.Format(time.TimeOnly))
+	}
+	_ = state.WriteStationBackoff(dir, name, b)
+}
+
+// recordSkip notes why the commit rev of the watched branch was skipped,
+// for line status (RUN-25) and line ping.
+func recordSkip(dir, rev, reason string) {
+	if commit, err := git.Run(dir, "rev-parse", rev); err == nil {
+		_ = state.AppendSkippedCommit(dir, state.SkippedCommit{Commit: commit, Reason: reason})
+	}
+}
+
+// newRunID returns an ID for a station run started at t, unique enough to
+// tell runs apart in agents' own logs (RUN-46).
+func newRunID(t time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// runEnv describes a station run to its agent (RUN-46).
+func runEnv(station string, r state.StationRun) []string {
+	rng := r.RangeTo
+	if r.RangeFrom != "" {
+		rng = r.RangeFrom + ".." + r.RangeTo
+	}
+	return []string{"LINE_RUN_ID=" + r.ID, "LINE_STATION=" + station, "LINE_TRIGGER_COMMIT=" + r.Commit, "LINE_RANGE=" + rng}
+}
+
+// recordRun stores a station's run as its last run and in its history, for
+// line show and line digest, with where its output ends in the station's
+// log.
+func recordRun(dir, name string, r state.StationRun) {
+	r.LogTo = state.StationLogSize(dir, name)
+	_ = state.WriteStationLastRun(dir, name, r)
+	_ = state.AppendStationRun(dir, name, r)
+}
+
+// reviewBase returns the watched-branch commit the station's last
+// completed run reviewed up to, so a run for watched covers base..watched.
+// It is empty when the station never completed a run, or when that commit
+// is no longer in the watched branch's history.
+func reviewBase(dir, name, watched string) string {
+	runs := state.ReadStationRuns(dir, name)
+	for i := len(runs) - 1; i >= 0; i-- {
+		r := runs[i]
+		if r.Result != "ok" && r.Result != "awaiting approval" || r.Commit == "" {
+			continue
+		}
+		if r.Commit != watched && !git.IsAncestor(dir, r.Commit, watched) {
+			return ""
+		}
+		return r.Commit
+	}
+	return ""
+}
+
+// ReviewedSummary describes the watched-branch commits a run reviewed,
+// e.g. "reviewed 4 commits (abc1234..def5678)", or "" without a range.
+func ReviewedSummary(dir string, r state.StationRun) string {
+	if r.RangeTo == "" {
+		return ""
+	}
+	if r.RangeFrom == "" {
+		n, _ := git.Run(dir, "rev-list", "--count", r.RangeTo)
+		return fmt.Sprintf("reviewed %s (up to %s)", commitCount(n), git.ShortHash(r.RangeTo))
+	}
+	n, _ := git.Run(dir, "rev-list", "--count", r.RangeFrom+".."+r.RangeTo)
+	return fmt.Sprintf("reviewed %s (%s..%s)", commitCount(n), git.ShortHash(r.RangeFrom), git.ShortHash(r.RangeTo))
+}
+
+// commitCount formats a rev-list --count, e.g. "1 commit" or "4 commits".
+func commitCount(n string) string {
+	if n == "1" {
+		return "1 commit"
+	}
+	if n == "" {
+		n = "?"
+	}
+	return n + " commits"
+}
+
+// takeOver terminates any run already in progress (RUN-11) and records this
+// process as the runner. Runs take over one at a time, so two triggers in
+// quick succession cannot both start.
+func takeOver(dir string, ev EventSink) error {
+	unlock, err := state.LockRun(dir)
+	if err != nil {
+		return fmt.Errorf("locking run: %w", err)
+	}
+	defer unlock()
+	return takeOverLocked(dir, ev)
+}
+
+// takeOverUnlessRunning records this process as the runner unless another
+// run is in progress, in which case it reports running and leaves that run
+// alone. The check and the takeover happen under the run lock, so a run
+// starting at the same time cannot slip in between.
+func takeOverUnlessRunning(dir string, ev EventSink) (running bool, err error) {
+	unlock, err := state.LockRun(dir)
+	if err != nil {
+		return false, fmt.Errorf("locking run: %w", err)
+	}
+	defer unlock()
+	if pid, _ := state.ReadPID(dir); pid > 0 && pid != os.Getpid() && state.IsProcessRunning(pid) {
+		return true, nil
+	}
+	return false, takeOverLocked(dir, ev)
+}
+
+// takeOverLocked is takeOver for a caller holding the run lock.
+func takeOverLocked(dir string, ev EventSink) error {
+	existingPID, err := state.ReadPID(dir)
+	if err != nil {
+		emitf(ev, EventWarning, "", "warning: could not read PID: %v", err)
+	}
+	if existingPID > 0 && existingPID != os.Getpid() && state.IsProcessRunning(existingPID) {
+		emitf(ev, EventInfo, "", "terminating previous run (PID %d)", existingPID)
+		// Kill station agents first — they run in their own process groups
+		// (Setpgid) so killing the runner alone won't reach them.
+		state.KillAllStationAgents(dir)
+		if err := state.KillProcessGroup(existingPID); err != nil {
+			emitf(ev, EventWarning, "", "warning: could not kill previous run: %v", err)
+		}
+	}
+
+	if err := state.WritePID(dir, os.Getpid()); err != nil {
+		return fmt.Errorf("writing PID: %w", err)
+	}
+	return nil
+}
+
+// stationHook runs the station's on_success or on_failure command, warning
+// if it fails.
+func stationHook(dir string, station config.Station, result string, runErr error, ev EventSink) {
+	if err := runOutcomeHook(dir, station, result, runErr != nil); err != nil {
+		emitf(ev, EventWarning, station.Name, "%v", err)
+	}
+}
+
 // Run executes the full assembly line pipeline.
-func Run(dir string, cfg *config.Config) error {
+func Run(dir string, cfg *config.Config, opts Options) error {
+	ev := opts.Events
+	if ev == nil {
+		ev = NewTextSink()
+	}
+
 	// RUN-4 layer 2: Check env var guard
 	if os.Getenv("LINE_RUNNING") == "1" {
-		fmt.Fprintln(os.Stderr, "assembly-line: skipping (LINE_RUNNING=1)")
+		emitf(ev, EventSkipped, "", "skipping (LINE_RUNNING=1)")
 		return nil
 	}
 
-	// RUN-4 layer 1: Check if we're on the watched branch
-	currentBranch, err := git.CurrentBranch(dir)
-	if err != nil {
-		return fmt.Errorf("getting current branch: %w", err)
-	}
-	if currentBranch != cfg.Settings.Watches {
-		fmt.Fprintf(os.Stderr, "assembly-line: skipping (not on watched branch %s, on %s)\n", cfg.Settings.Watches, currentBranch)
+	// Kill switch: .line/disabled or LINE_DISABLED=1 stops all agent activity
+	if state.Disabled(dir) {
+		emitf(ev, EventSkipped, "", "skipping (pipeline disabled)")
 		return nil
 	}
 
-	// RUN-9: Check if the last commit message contains a skip marker
-	lastMsg, err := git.LastCommitMessage(dir)
+	// RUN-49: a trigger for a given commit (line trigger) does not depend
+	// on the checkout, only on the commit being on the watched branch.
+	if opts.Commit != "" && !git.IsAncestor(dir, opts.Commit, cfg.Settings.Watches) {
+		return fmt.Errorf("commit %s is not on %s", git.ShortHash(opts.Commit), cfg.Settings.Watches)
+	}
+	if opts.Refs {
+		return runRefLine(dir, cfg, ev)
+	}
+	if opts.Station != "" {
+		rng := opts.Range
+		if opts.Commit != "" {
+			rng = ".." + opts.Commit
+		}
+		return runManual(dir, cfg, opts.Station, rng, ev)
+	}
+
+	// A commit a clean cycle already covered needs no further run.
+	rev := "HEAD"
+	if opts.Commit != "" {
+		if last, ok := state.ReadLastCycle(dir); ok && last.OK && git.IsAncestor(dir, opts.Commit, last.Commit) {
+			emitf(ev, EventSkipped, "", "skipping (%s was reviewed in the cycle for %s)", git.ShortHash(opts.Commit), git.ShortHash(last.Commit))
+			return nil
+		}
+		rev = opts.Commit
+	} else if !opts.CI {
+		// RUN-4 layer 1: Check if we're on the watched branch
+		currentBranch, err := git.CurrentBranch(dir)
+		if err != nil {
+			return fmt.Errorf("getting current branch: %w", err)
+		}
+		if currentBranch != cfg.Settings.Watches {
+			emitf(ev, EventSkipped, "", "skipping (not on watched branch %s, on %s)", cfg.Settings.Watches, currentBranch)
+			return nil
+		}
+	}
+
+	// RUN-9: Check if the commit message contains a skip marker
+	msg, err := git.CommitSubject(dir, rev)
 	if err != nil {
-		return fmt.Errorf("getting last commit message: %w", err)
+		return fmt.Errorf("getting commit message: %w", err)
 	}
 	for _, marker := range SkipMarkers {
-		if strings.Contains(lastMsg, marker) {
-			fmt.Fprintf(os.Stderr, "assembly-line: skipping (commit contains %s)\n", marker)
+		if strings.Contains(msg, marker) {
+			emitf(ev, EventSkipped, "", "skipping (commit contains %s)", marker)
+			recordSkip(dir, rev, marker)
 			return nil
 		}
 	}
+	if who, ok := machineCommit(dir, rev, cfg.Settings.MachineCommits); ok {
+		emitf(ev, EventSkipped, "", "skipping (machine commit: %s)", who)
+		recordSkip(dir, rev, "machine commits")
+		return nil
+	}
 
-	// RUN-7, RUN-8: Check .lineignore
-	parentRef := "HEAD~1"
-	changedFiles, _ := git.DiffFiles(dir, parentRef, "HEAD")
+	// RUN-7, RUN-8, RUN-48: Check .lineignore and .lineonly
+	parentRef := rev + "~1"
+	changedFiles, _ := git.DiffFiles(dir, parentRef, rev)
 	if len(changedFiles) > 0 {
 		matcher, err := ignore.Load(dir)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "assembly-line: warning: could not load .lineignore: %v\n", err)
+			emitf(ev, EventWarning, "", "warning: could not load .lineignore or .lineonly: %v", err)
 		} else if matcher.AllIgnored(changedFiles) {
-			fmt.Fprintln(os.Stderr, "assembly-line: skipping (all changed files are ignored)")
+			emitf(ev, EventSkipped, "", "skipping (all changed files are ignored)")
+			recordSkip(dir, rev, "ignored paths")
 			return nil
 		}
 	}
 
-	// RUN-11: Check for existing runner and terminate it
-	existingPID, err := state.ReadPID(dir)
+	// RUN-36: a commit rewritten without changing its content (an amended
+	// message, a rebase that left the tree as it was) is not run again.
+	if last, ok := state.ReadLastCycle(dir); ok && last.OK && last.Tree != "" {
+		head, _ := git.Run(dir, "rev-parse", rev)
+		if tree, err := git.Run(dir, "rev-parse", rev+"^{tree}"); err == nil && tree == last.Tree && head != last.Commit {
+			emitf(ev, EventSkipped, "", "skipping (tree unchanged since %s)", git.ShortHash(last.Commit))
+			recordSkip(dir, rev, "unchanged tree")
+			return nil
+		}
+	}
+
+	for _, name := range OrphanedStations(dir, cfg) {
+		emitf(ev, EventWarning, "", "warning: branch or state of unknown station %q; if it was renamed, carry them over with line migrate-station %s <new name>", name, name)
+	}
+
+	if !opts.CI && cfg.Settings.DebounceDuration() > 0 {
+		pid, err := settle(dir, cfg.Settings.DebounceDuration(), ev)
+		if err != nil {
+			return err
+		}
+		if pid > 0 {
+			emitf(ev, EventInfo, "", "run PID %d is about to start and will pick this commit up", pid)
+			return nil
+		}
+	}
+	return runLine(dir, cfg, 0, rev, ev, opts.CI)
+}
+
+// settle debounces triggers (RUN-39): it takes over as the runner, then waits
+// for d before the line reads the watched branch, so the commits of a burst
+// (a rebase, a scripted series) get one cycle between them. A trigger that
+// arrives while a run is settling leaves its commit to that run, whose PID
+// settle returns instead.
+func settle(dir string, d time.Duration, ev EventSink) (coalescedInto int, err error) {
+	unlock, err := state.LockRun(dir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "assembly-line: warning: could not read PID: %v\n", err)
+		return 0, fmt.Errorf("locking run: %w", err)
 	}
-	if existingPID > 0 && state.IsProcessRunning(existingPID) {
-		fmt.Fprintf(os.Stderr, "assembly-line: terminating previous run (PID %d)\n", existingPID)
-		// Kill station agents first — they run in their own process groups
-		// (Setpgid) so killing the runner alone won't reach them.
-		state.KillAllStationAgents(dir)
-		if err := state.KillProcessGroup(existingPID); err != nil {
-			fmt.Fprintf(os.Stderr, "assembly-line: warning: could not kill previous run: %v\n", err)
+	if pid, _ := state.ReadPID(dir); pid > 0 && pid == state.ReadSettling(dir) && state.IsProcessRunning(pid) {
+		unlock()
+		return pid, nil
+	}
+	err = takeOverLocked(dir, ev)
+	if err == nil {
+		err = state.WriteSettling(dir, os.Getpid())
+	}
+	unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	emitf(ev, EventInfo, "", "waiting %s for further commits (settings.debounce)", d)
+	time.Sleep(d)
+
+	// From here on the line reads the watched branch: later triggers take
+	// over as usual.
+	if unlock, err = state.LockRun(dir); err != nil {
+		return 0, fmt.Errorf("locking run: %w", err)
+	}
+	defer unlock()
+	return 0, state.RemoveSettling(dir)
+}
+
+// Continue runs the stations after the named one, e.g. once its commit has
+// been approved. Unlike Run it does not look at the watched branch's HEAD.
+func Continue(dir string, cfg *config.Config, after string, opts Options) error {
+	ev := opts.Events
+	if ev == nil {
+		ev = NewTextSink()
+	}
+	if state.Disabled(dir) {
+		emitf(ev, EventSkipped, "", "skipping (pipeline disabled)")
+		return nil
+	}
+	for i, station := range cfg.Stations {
+		if station.Name == after {
+			return runLine(dir, cfg, i+1, cfg.Settings.Watches, ev, opts.CI)
 		}
 	}
+	return fmt.Errorf("unknown station %q", after)
+}
 
-	// Write our PID
-	if err := state.WritePID(dir, os.Getpid()); err != nil {
-		return fmt.Errorf("writing PID: %w", err)
+// runLine runs the stations from cfg.Stations[start] onwards, taking over
+// from any run already in progress unless ci is set. The station-scoped
+// markers (RUN-24) are read from the message of rev, the commit the run was
+// triggered for.
+func runLine(dir string, cfg *config.Config, start int, rev string, ev EventSink, ci bool) (err error) {
+	// TRACE-1: the run is the root span; its spans are exported as it ends.
+	span := trace.Start("line run", "line.watches", cfg.Settings.Watches)
+	defer func() {
+		span.End(err)
+		if err := trace.Flush(); err != nil {
+			emitf(ev, EventWarning, "", "tracing: %v", err)
+		}
+	}()
+
+	if !ci {
+		if err := takeOver(dir, ev); err != nil {
+			return err
+		}
+		defer func() { _ = state.RemovePID(dir) }()
+
+		// Clean up stale tmux sessions from previous runs
+		if tmux.Available() {
+			_ = tmux.CleanStaleSessions(dir)
+		}
 	}
-	defer func() { _ = state.RemovePID(dir) }()
+	_ = state.RemoveSkippedCommits(dir)
 
 	// Set env var to prevent retriggering
 	os.Setenv("LINE_RUNNING", "1")
 	defer os.Unsetenv("LINE_RUNNING")
 
-	// Clean up stale tmux sessions from previous runs
-	if tmux.Available() {
-		_ = tmux.CleanStaleSessions(dir)
-	}
-
 	// RUN-15: Clean up stale worktrees from previous runs and after this run.
 	// Remove directories first so that prune sees them as gone and cleans
-	// up the git bookkeeping entries.
+	// up the git bookkeeping entries. RUN-40: interrupted agents' changes
+	// are kept first.
+	preserveInterrupted(dir, cfg, ev)
 	if baseDir, err := git.WorktreeBaseDir(dir); err == nil {
 		_ = os.RemoveAll(baseDir)
 		defer os.RemoveAll(baseDir)
@@ -104,14 +435,139 @@ func Run(dir string, cfg *config.Config) error {
 	// RUN-1: Execute stations in sequence
 	// The chain: watched_branch -> station1 -> station2 -> ... -> stationN
 	predecessor := cfg.Settings.Watches
-	for _, station := range cfg.Stations {
-		fmt.Fprintf(os.Stderr, "assembly-line: running station %s\n", station.Name)
-		if err := runStation(dir, cfg, station, predecessor); err != nil {
-			fmt.Fprintf(os.Stderr, "assembly-line: station %s failed: %v\n", station.Name, err)
+	if start > 0 {
+		predecessor = git.StationBranchName(cfg.Stations[start-1].Name)
+	}
+
+	// RUN-45: settings.hooks.before_all runs before the watched branch is
+	// read, so it may fetch it.
+	hooks := cfg.Settings.Hooks
+	if hooks == nil {
+		hooks = &config.Hooks{}
+	}
+	cycleEnv := []string{"LINE_WATCHES=" + cfg.Settings.Watches}
+	started := time.Now()
+	if err := runCycleHook(dir, "before_all", hooks.BeforeAll, cycleEnv, ev); err != nil {
+		commit, _ := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+		_ = state.WriteLastCycle(dir, state.Cycle{Commit: commit, Started: started, Finished: time.Now(), Aborted: err.Error()})
+		return err
+	}
+
+	watched, _ := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+	span.SetAttr("line.commit", watched)
+	// PING-1: line ping checks that the latest commit got a cycle; RUN-36
+	// compares the next commit's tree with this one.
+	tree, _ := git.Run(dir, "rev-parse", watched+"^{tree}")
+	cycle := state.Cycle{Commit: watched, Tree: tree, Started: started}
+	defer func() {
+		cycle.Finished = time.Now()
+		_ = state.WriteLastCycle(dir, cycle)
+	}()
+	msg, _ := git.CommitMessage(dir, rev)
+	terminalBefore := terminalHead(dir, cfg)
+	scope := parseStationScope(msg)
+	for _, station := range cfg.Stations[start:] {
+		if _, waiting := state.ReadStationApproval(dir, station.Name); waiting {
+			emitf(ev, EventSkipped, station.Name, "awaiting approval, skipping (line approve %s or line reject %s)", station.Name, station.Name)
+			break
+		}
+		// RUN-24: a station skipped by the commit message, by
+		// settings.routes (RUN-33) or for lack of changes under its root
+		// (RUN-34) only catches up with its predecessor, so the stations
+		// after it still run.
+		// RUN-38: a station with trigger: manual only runs on demand.
+		if station.Trigger == config.TriggerManual {
+			emitf(ev, EventInfo, station.Name, "manual trigger, passing changes through (line run --station %s)", station.Name)
+			if err := runStation(dir, cfg, station, predecessor, true, state.StationRun{}, ev); err != nil {
+				ev.Emit(Event{Kind: EventStationDone, Time: time.Now(), Station: station.Name, Err: err})
+				break
+			}
+			predecessor = git.StationBranchName(station.Name)
+			continue
+		}
+		reason, skip := scope.skips(station.Name)
+		if skip {
+			reason = "by commit message (" + reason + ")"
+		} else {
+			base := reviewBase(dir, station.Name, watched)
+			if types, ok := routeSkips(dir, cfg.Settings.Routes, station.Name, base, watched); ok {
+				reason, skip = "by settings.routes ("+types+")", true
+			} else {
+				reason, skip = rootSkips(dir, station.CleanRoot(), base, watched)
+			}
+		}
+		if skip {
+			emitf(ev, EventSkipped, station.Name, "skipped %s, passing changes through", reason)
+			if err := runStation(dir, cfg, station, predecessor, true, state.StationRun{}, ev); err != nil {
+				ev.Emit(Event{Kind: EventStationDone, Time: time.Now(), Station: station.Name, Err: err})
+				break
+			}
+			predecessor = git.StationBranchName(station.Name)
+			continue
+		}
+		if b := state.ReadStationBackoff(dir, station.Name); b.Quarantined(time.Now()) {
+			emitf(ev, EventSkipped, station.Name, "quarantined until %s after %d consecutive failures, skipping (line retry %s)",
+				b.Until.Format(time.TimeOnly), b.Failures, station.Name)
+			break
+		}
+
+		started := time.Now()
+		ev.Emit(Event{Kind: EventStationStarted, Time: started, Station: station.Name})
+		run := state.StationRun{ID: newRunID(started), Started: started, Commit: watched, RangeFrom: reviewBase(dir, station.Name, watched), RangeTo: watched,
+			LogFrom: state.StationLogSize(dir, station.Name)}
+		err := runStation(dir, cfg, station, predecessor, false, run, ev)
+		run.Finished = time.Now()
+		if errors.Is(err, errAwaitingApproval) {
+			a, _ := state.ReadStationApproval(dir, station.Name)
+			run.Result = "awaiting approval"
+			recordRun(dir, station.Name, run)
+			recordBackoff(dir, station.Name, nil, ev)
+			stationHook(dir, station, "awaiting approval", nil, ev)
+			ev.Emit(Event{Kind: EventAwaitingApproval, Time: time.Now(), Station: station.Name,
+				Message: fmt.Sprintf("awaiting approval (%s): line approve %s or line reject %s", a.Summary, station.Name, station.Name)})
+			break
+		}
+		result := "ok"
+		if err != nil {
+			result = err.Error()
+		}
+		run.Result = result
+		recordRun(dir, station.Name, run)
+		ev.Emit(Event{Kind: EventStationDone, Time: time.Now(), Station: station.Name, Err: err})
+		recordBackoff(dir, station.Name, err, ev)
+		stationHook(dir, station, result, err, ev)
+		if err != nil {
+			notifyFailure(dir, cfg, station.Name, watched, result, ev)
 			break
 		}
 		predecessor = git.StationBranchName(station.Name)
 	}
 
+	// RUN-37: then the stations watching tags and branches.
+	runRefStations(dir, cfg, ev)
+
+	cycle.OK = len(cfg.Stations) == 0 || predecessor == git.StationBranchName(cfg.Stations[len(cfg.Stations)-1].Name)
+
+	// RUN-45: settings.hooks.after_all runs whatever the stations' outcome.
+	result := "failed"
+	if cycle.OK {
+		result = "ok"
+	}
+	if err := runCycleHook(dir, "after_all", hooks.AfterAll, append(cycleEnv, "LINE_COMMIT="+watched, "LINE_RESULT="+result), ev); err != nil {
+		cycle.OK = false
+		cycle.Aborted = err.Error()
+		return err
+	}
+
+	// Every station ran: tell the email recipients if there are new
+	// changes waiting on the terminal branch.
+	if len(cfg.Stations) > 0 && predecessor == git.StationBranchName(cfg.Stations[len(cfg.Stations)-1].Name) {
+		if head := terminalHead(dir, cfg); head != terminalBefore {
+			subject, _ := git.Run(dir, "log", "-1", "--format=%s", watched)
+			emailCompleted(dir, cfg, watched, subject, ev)
+		}
+	}
+
+	ev.Emit(Event{Kind: EventRunDone, Time: time.Now()})
 	return nil
 }
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+)
+
+// SimulateOptions selects what Simulate runs a station against.
+type SimulateOptions struct {
+	Commit string    // commit to run against; default the watched branch head
+	From   string    // start of the reviewed range (exclusive); default Commit's parent
+	Patch  string    // patch applied on top of Commit as a synthetic commit
+	DryRun bool      // print the context without running the agent
+	Out    io.Writer // where the context, agent output and diff go
+}
+
+// Simulate runs one station's agent in a throwaway detached worktree and
+// prints what it was given and what it changed. No branch, .line/ state or
+// status is touched: the worktree lives outside the line's worktree
+// directory and its changes are never committed.
+func Simulate(dir string, cfg *config.Config, station config.Station, opts SimulateOptions) error {
+	out := opts.Out
+	from, commit, err := ResolveRange(dir, cfg.Settings.Watches, opts.From, opts.Commit)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "line-simulate-")
+	if err != nil {
+		return err
+	}
+	wtPath := filepath.Join(tmp, station.Name)
+	if err := git.AddDetachedWorktree(dir, wtPath, commit); err != nil {
+		_ = os.RemoveAll(tmp)
+		return fmt.Errorf("adding worktree: %w", err)
+	}
+	defer func() {
+		_ = git.RemoveWorktree(dir, wtPath)
+		_ = os.RemoveAll(tmp)
+		_ = git.PruneWorktrees(dir)
+	}()
+
+	// A synthetic commit: the patch committed on top of the commit, inside
+	// the detached worktree, so it is reviewed like any other.
+	if opts.Patch != "" {
+		patch, err := filepath.Abs(opts.Patch)
+		if err != nil {
+			return err
+		}
+		if _, err := git.Run(wtPath, "apply", "--index", patch); err != nil {
+			return fmt.Errorf("applying %s: %w", opts.Patch, err)
+		}
+		if _, err := git.Run(wtPath, "commit", "--no-verify", "-m", "line simulate: "+filepath.Base(opts.Patch)); err != nil {
+			return fmt.Errorf("committing %s: %w", opts.Patch, err)
+		}
+		from = commit
+		if commit, err = git.Run(wtPath, "rev-parse", "HEAD"); err != nil {
+			return err
+		}
+	}
+
+	resolved := cfg.ResolveStation(station)
+	fmt.Fprintf(out, "%-10s%s (detached at %s)\n", "Worktree:", wtPath, git.ShortHash(commit))
+	ctxErr := WriteContext(out, wtPath, resolved, from, commit)
+	if ctxErr != nil {
+		fmt.Fprintf(out, "warning: context: %v\n", ctxErr)
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	var provisioned []string
+	if wt := cfg.Settings.Worktree; wt != nil {
+		if provisioned, err = git.ProvisionWorktree(dir, wtPath, wt.Copy, wt.Symlink); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "\n--- agent output ---\n")
+	prompt, _ := stationPrompt(wtPath, resolved, from, commit, "HEAD")
+	agent, err := startAgentDirect(agentDir(wtPath, resolved), resolved.Command, resolved.Args, nil, prompt, out)
+	if err != nil {
+		return err
+	}
+	agentErr := agent.wait()
+	_ = os.RemoveAll(filepath.Join(wtPath, ".claude"))
+	_ = os.RemoveAll(filepath.Join(agentDir(wtPath, resolved), ".claude"))
+	if outside, _ := outsideRoot(wtPath, resolved.Root, provisioned); len(outside) > 0 {
+		fmt.Fprintf(out, "warning: changed files outside root %s (a station run would fail): %s\n", resolved.Root, strings.Join(outside, ", "))
+	}
+	if err := applyResult(wtPath, resolved, time.Now()); err != nil {
+		fmt.Fprintf(out, "warning: context: %v\n", err)
+	}
+
+	// Stage everything to diff new files too, minus what was provisioned.
+	_, _ = git.Run(wtPath, "add", "-A")
+	if len(provisioned) > 0 {
+		_, _ = git.Run(wtPath, append([]string{"reset", "--quiet", "--"}, provisioned...)...)
+	}
+	diff, _ := git.Run(wtPath, "diff", "--cached", "--stat", "--patch")
+	fmt.Fprintf(out, "\n--- changes ---\n")
+	if diff == "" {
+		fmt.Fprintln(out, "No changes.")
+	} else {
+		fmt.Fprintln(out, diff)
+	}
+
+	if agentErr != nil {
+		return fmt.Errorf("agent exited with error: %w", agentErr)
+	}
+	return nil
+}
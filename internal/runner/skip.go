@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+)
+
+// Station-scoped markers: [skip line:docs,style] (or [line skip:...]) skips
+// the named stations, [line only:security] skips every other station.
+var (
+	scopedSkipRe = regexp.MustCompile(`\[(?:skip line|line skip):([^\]]*)\]`)
+	lineOnlyRe   = regexp.MustCompile(`\[line only:([^\]]*)\]`)
+)
+
+// stationScope is the set of stations a commit message opts in or out of.
+// Stations it skips pass their predecessor's changes through without running
+// their agent.
+type stationScope struct {
+	skip map[string]bool
+	only map[string]bool // nil when the message has no [line only:...] marker
+}
+
+// parseStationScope reads the station-scoped markers in a commit message.
+func parseStationScope(msg string) stationScope {
+	var s stationScope
+	for _, m := range scopedSkipRe.FindAllStringSubmatch(msg, -1) {
+		s.skip = addStationNames(s.skip, m[1])
+	}
+	for _, m := range lineOnlyRe.FindAllStringSubmatch(msg, -1) {
+		s.only = addStationNames(s.only, m[1])
+	}
+	return s
+}
+
+func addStationNames(set map[string]bool, list string) map[string]bool {
+	if set == nil {
+		set = map[string]bool{}
+	}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// skips reports whether the scope skips the station, and why.
+func (s stationScope) skips(name string) (string, bool) {
+	if s.skip[name] {
+		return fmt.Sprintf("[skip line:%s]", name), true
+	}
+	if s.only != nil && !s.only[name] {
+		return "not in [line only:...]", true
+	}
+	return "", false
+}
+
+// conventionalType matches the type of a Conventional Commits subject, e.g.
+// "feat" in "feat(api)!: add export".
+var conventionalType = regexp.MustCompile(`^([A-Za-z]+)(?:\([^)]*\))?!?:`)
+
+// routeSkips reports whether settings.routes skips the station for the
+// commits in from..to, or commit to alone without from: every commit has a
+// routed type and none of those types routes to the station. Commits without
+// a routed type (or without a type) run every station; skip-marker commits,
+// such as station commits, are left out. It also returns the types seen,
+// e.g. "chore, style".
+func routeSkips(dir string, routes map[string][]string, name, from, to string) (string, bool) {
+	if len(routes) == 0 {
+		return "", false
+	}
+	args := []string{"log", "-1", "--format=%s", to}
+	if from != "" {
+		args = []string{"log", "--format=%s", from + ".." + to}
+	}
+	subjects, err := git.Run(dir, args...)
+	if err != nil || subjects == "" {
+		return "", false
+	}
+	var types []string
+	for _, subject := range strings.Split(subjects, "\n") {
+		if slices.ContainsFunc(SkipMarkers, func(marker string) bool { return strings.Contains(subject, marker) }) {
+			continue
+		}
+		m := conventionalType.FindStringSubmatch(subject)
+		if m == nil {
+			return "", false
+		}
+		t := strings.ToLower(m[1])
+		stations, routed := routes[t]
+		if !routed || slices.Contains(stations, name) {
+			return "", false
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return "", false
+	}
+	return strings.Join(types, ", "), true
+}
+
+// machineCommit reports whether the commit rev was made by a bot, as
+// described by settings.machine_commits, and what identified it.
+func machineCommit(dir, rev string, mc *config.MachineCommits) (string, bool) {
+	if mc == nil {
+		return "", false
+	}
+	author, err := git.Run(dir, "log", "-1", "--format=%an <%ae>", rev)
+	if err != nil {
+		return "", false
+	}
+	for _, pattern := range mc.Authors {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(author) {
+			return "author " + author, true
+		}
+	}
+	if len(mc.Trailers) == 0 {
+		return "", false
+	}
+	trailers, _ := git.Run(dir, "log", "-1", "--format=%(trailers:only,unfold)", rev)
+	for _, line := range strings.Split(trailers, "\n") {
+		key, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		for _, t := range mc.Trailers {
+			if strings.EqualFold(strings.TrimSpace(key), t) {
+				return t + " trailer", true
+			}
+		}
+	}
+	return "", false
+}
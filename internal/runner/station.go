@@ -1,21 +1,40 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
+	"net/mail"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/git"
 	"github.com/re-cinq/assembly-line/internal/settings"
 	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/re-cinq/assembly-line/internal/trace"
 )
 
 // runStation executes a single station in an ephemeral git worktree (RUN-15).
-// The user's working tree is never disturbed.
-func runStation(dir string, cfg *config.Config, station config.Station, predecessor string) error {
+// The user's working tree is never disturbed. With passThrough the station
+// branch only catches up with its predecessor; the agent does not run.
+// run describes what the station runs for; it goes in the station commit's
+// message (stationCommitMessage).
+func runStation(dir string, cfg *config.Config, station config.Station, predecessor string, passThrough bool, run state.StationRun, ev EventSink) (err error) {
+	span := trace.Start("station "+station.Name, "line.station", station.Name, "line.pass_through", strconv.FormatBool(passThrough))
+	defer func() {
+		if errors.Is(err, errAwaitingApproval) {
+			span.SetAttr("line.result", "awaiting approval")
+			span.End(nil)
+			return
+		}
+		span.End(err)
+	}()
+
 	resolved := cfg.ResolveStation(station)
+	resolved.Env = runEnv(station.Name, run)
 	branchName := git.StationBranchName(station.Name)
 
 	// Create branch if it doesn't exist (RUN-6: catch up)
@@ -32,7 +51,9 @@ func runStation(dir string, cfg *config.Config, station config.Station, predeces
 	}
 	wtPath := filepath.Join(baseDir, station.Name)
 
-	// Clean up any leftover worktree at that path (crash recovery)
+	// Clean up any leftover worktree at that path (crash recovery), keeping
+	// the changes of an interrupted agent (RUN-40)
+	preserveStation(dir, cfg, station, wtPath, ev)
 	_ = git.RemoveWorktree(dir, wtPath)
 	_ = os.RemoveAll(wtPath)
 
@@ -48,36 +69,289 @@ func runStation(dir string, cfg *config.Config, station config.Station, predeces
 		_ = os.RemoveAll(wtPath)
 	}()
 
-	// Rebase onto predecessor to pick up changes (in the worktree)
-	if err := git.Rebase(wtPath, predecessor); err != nil {
-		// RUN-6: If rebase fails, reset to predecessor and try again
-		fmt.Fprintf(os.Stderr, "station %s: rebase conflict, resetting to %s\n", station.Name, predecessor)
-		_ = git.RebaseAbort(wtPath)
-		if err := git.ResetHard(wtPath, predecessor); err != nil {
-			return fmt.Errorf("station %s: reset failed: %w", station.Name, err)
+	// Rebase onto predecessor to pick up changes (in the worktree), without
+	// replaying the commits a squashing predecessor has replaced.
+	rebase := RebaseStation
+	// RUN-44: nor those of a force-pushed watched branch (stations
+	// watching tags and branches are off the line).
+	if old, ok := forcePushedBase(dir, cfg, branchName); ok && station.Watches == "" {
+		rebase = forcePushRebase(cfg, station, predecessor, old, ev)
+	}
+	// settings.commit_author and commit_signing hold for the replayed
+	// station commits as for new ones.
+	opts := CommitOptions(cfg.Settings)
+	if err := rebase(wtPath, predecessor, opts); err != nil {
+		if err := handleConflict(dir, wtPath, cfg, resolved, predecessor, ev); err != nil {
+			return err
+		}
+	}
+	_ = state.RemoveStationConflict(dir, station.Name)
+	if passThrough {
+		return nil
+	}
+
+	// on_interrupt: pick up the changes of an interrupted run (RUN-40).
+	interrupted := restoreInterrupted(dir, wtPath, station, ev)
+
+	// settings.worktree: bring untracked local files (.env, node_modules)
+	// into the worktree; they are kept out of the station's commit.
+	var provisioned []string
+	if wt := cfg.Settings.Worktree; wt != nil {
+		if provisioned, err = git.ProvisionWorktree(dir, wtPath, wt.Copy, wt.Symlink); err != nil {
+			return fmt.Errorf("station %s: %w", station.Name, err)
 		}
 	}
 
-	// Run the agent in the worktree (RUN-1, RUN-12)
-	agent, err := startAgent(wtPath, resolved.Command, resolved.Args, resolved.Prompt, station.Name, dir)
+	// stations[].cache: pick up the build directories of the previous run,
+	// and put them aside again before the worktree is removed.
+	if len(station.Cache) > 0 {
+		restoreArtifacts(dir, wtPath, station, ev)
+		defer saveArtifacts(dir, wtPath, station, ev)
+	}
+	base, err := git.Run(wtPath, "rev-parse", "HEAD")
 	if err != nil {
 		return fmt.Errorf("station %s: %w", station.Name, err)
 	}
 
+	// stations[].context: add the issues and items the reviewed commits
+	// refer to (RUN-31, RUN-32).
+	prompt, err := stationPrompt(wtPath, resolved, run.RangeFrom, run.RangeTo, "HEAD")
+	if err != nil {
+		emitf(ev, EventWarning, station.Name, "context: %v", err)
+	}
+	if run.Ref != "" {
+		prompt += refPrompt(run.Ref)
+	}
+	if interrupted == config.OnInterruptResume {
+		prompt += interruptedPrompt
+	}
+
+	// RUN-40: until the station returns, its worktree holds work that a
+	// killed runner would lose.
+	_ = state.WriteStationInProgress(dir, station.Name, time.Now())
+	defer func() { _ = state.RemoveStationInProgress(dir, station.Name) }()
+
+	// Run the agent in the worktree (RUN-1, RUN-12); on_interrupt: commit
+	// takes the interrupted run's changes as they are.
+	var agentErr error
+	if interrupted != config.OnInterruptCommit {
+		if agentErr, err = runAgent(dir, wtPath, resolved, prompt, ev); err != nil {
+			return fmt.Errorf("station %s: %w", station.Name, err)
+		}
+	}
+
+	// RUN-14: A failed station blocks the line and is reported as 'failed'
+	if agentErr != nil {
+		emitf(ev, EventWarning, station.Name, "agent exited with error: %v", agentErr)
+		// RUN-41: record how the agent exited, for line status and line show.
+		reason := fmt.Sprintf("agent exited: %v", agentErr)
+		var exitErr *AgentExitError
+		var stalledErr *agentStalledError
+		if errors.As(agentErr, &exitErr) {
+			reason = "agent " + exitErr.AgentExit.String()
+		} else if errors.As(agentErr, &stalledErr) {
+			reason = stalledErr.Error() // RUN-43
+		}
+		_ = state.WriteStationFailed(dir, station.Name, reason)
+		if exitErr != nil {
+			_ = state.WriteStationAgentExit(dir, station.Name, exitErr.AgentExit)
+		}
+		return fmt.Errorf("agent failed: %w", agentErr)
+	}
+
+	// RUN-34: a station with a root may only change files inside it.
+	if outside, err := outsideRoot(wtPath, resolved.Root, append(provisioned, station.Cache...)); err == nil && len(outside) > 0 {
+		msg := fmt.Sprintf("changed files outside root %s: %s", resolved.Root, strings.Join(outside, ", "))
+		_ = state.WriteStationFailed(dir, station.Name, msg)
+		return fmt.Errorf("%s", msg)
+	}
+
+	// RUN-32: close and annotate store items as the agent asked.
+	if err := applyResult(wtPath, resolved, time.Now()); err != nil {
+		emitf(ev, EventWarning, station.Name, "context: %v", err)
+	}
+
+	// after: post-process the agent's changes (formatters, lockfiles) so
+	// they are verified and committed together.
+	if err := runAfter(dir, wtPath, station, ev); err != nil {
+		_ = state.WriteStationFailed(dir, station.Name, err.Error())
+		return err
+	}
+
+	// verify: check the agent's changes before committing them, optionally
+	// letting the agent repair what it broke.
+	if station.Verify != "" {
+		if err := verifyBeforeCommit(dir, wtPath, station, resolved, prompt, ev); err != nil {
+			_ = state.WriteStationFailed(dir, station.Name, fmt.Sprintf("%s: %v", state.FailedVerification, err))
+			return err
+		}
+	}
+	// settings.verify: run the gates against the station's output before it
+	// is committed, so broken agent changes never reach the station branch
+	// and cannot flow downstream, even when the station is passed through.
+	if cfg.Settings.Verify && len(cfg.GatesFor(config.GateHookPreCommit)) > 0 {
+		if err := verifyStation(dir, wtPath, cfg, station.Name, ev); err != nil {
+			return err
+		}
+	}
+	_ = state.RemoveStationFailed(dir, station.Name)
+
+	// squash: fold the station's unpicked commits into this run's commit.
+	// After the rebase above, predecessor..HEAD is exactly the station's own
+	// commits, so a soft reset leaves them staged alongside the new changes.
+	if station.Squash {
+		if has, err := git.HasCommitsBetween(wtPath, predecessor, "HEAD"); err == nil && has {
+			if err := git.ResetSoft(wtPath, predecessor); err != nil {
+				emitf(ev, EventWarning, station.Name, "squash failed: %v", err)
+			}
+		}
+	}
+
+	// RUN-5: Commit any changes with skip marker (RUN-4, RUN-9)
+	ev.Emit(Event{Kind: EventCommitting, Time: time.Now(), Station: station.Name})
+	commitMsg := stationCommitMessage(dir, cfg.Settings.Watches, station.Name, run)
+	opts.Unstage = append(provisioned, station.Cache...)
+	if err := git.CommitAll(wtPath, commitMsg, opts); err != nil {
+		emitf(ev, EventWarning, station.Name, "commit failed: %v", err)
+	}
+
+	if station.Approval == config.ApprovalManual {
+		return holdForApproval(dir, wtPath, station.Name, base)
+	}
+	return nil
+}
+
+// RebaseStation rebases the station branch checked out in wtPath onto its
+// predecessor. Station predecessors may rewrite their own commits (squash),
+// so their reflog is used to avoid replaying the versions they replaced.
+func RebaseStation(wtPath, predecessor string, opts git.CommitOptions) error {
+	if strings.HasPrefix(predecessor, git.StationBranchName("")) {
+		return git.RebaseForkPoint(wtPath, predecessor, opts)
+	}
+	return git.Rebase(wtPath, predecessor, opts)
+}
+
+// stationCommitPrefix starts the subject of every station commit.
+const stationCommitPrefix = "assembly-line: station "
+
+// Trailers on every station commit, so tooling can attribute it without
+// parsing the subject.
+const (
+	TriggeredByTrailer     = "Triggered-By"     // the watched-branch commit the station ran for
+	TriggeredBranchTrailer = "Triggered-Branch" // the watched branch
+	TriggeredRefTrailer    = "Triggered-Ref"    // the tag or branch a station with watches ran for
+	StationTrailer         = "Line-Station"     // the station that made the commit
+)
+
+// stationCommitMessage builds a station commit's message: the subject with
+// its skip marker (RUN-4, RUN-9), what the run reviewed (RUN-29), and the
+// trailers recording the trigger and station (RUN-30).
+func stationCommitMessage(dir, watches, name string, run state.StationRun) string {
+	msg := fmt.Sprintf("%s%s %s\n\n", stationCommitPrefix, name, commitSkipMarker)
+	if reviewed := ReviewedSummary(dir, run); reviewed != "" {
+		msg += strings.ToUpper(reviewed[:1]) + reviewed[1:] + ".\n\n"
+	}
+	if run.Commit != "" {
+		msg += fmt.Sprintf("%s: %s\n", TriggeredByTrailer, run.Commit)
+	}
+	if run.Ref != "" {
+		msg += fmt.Sprintf("%s: %s\n", TriggeredRefTrailer, run.Ref)
+	}
+	return msg + fmt.Sprintf("%s: %s\n%s: %s", TriggeredBranchTrailer, watches, StationTrailer, name)
+}
+
+// CommitStation returns the station that made a commit, from its subject,
+// or false for commits not made by a station. Where the whole message is at
+// hand, the Line-Station trailer (StationTrailer) says the same.
+func CommitStation(subject string) (string, bool) {
+	rest, ok := strings.CutPrefix(subject, stationCommitPrefix)
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name, name != ""
+}
+
+// CommitOptions maps settings.commit_author and settings.commit_signing onto
+// the options for station commits and the rebases that replay them. An
+// unparseable author is ignored here; line validate reports it.
+func CommitOptions(s config.Settings) git.CommitOptions {
+	var opts git.CommitOptions
+	if s.CommitAuthor != "" {
+		if addr, err := mail.ParseAddress(s.CommitAuthor); err == nil {
+			opts.AuthorName = addr.Name
+			opts.AuthorEmail = addr.Address
+		}
+	}
+	if s.CommitSigning != nil {
+		opts.SignFormat = s.CommitSigning.Format
+		opts.SignKey = s.CommitSigning.Key
+	}
+	// With verify on, the gates run before committing instead of in the
+	// pre-commit hook, so failing output is kept and reported.
+	opts.NoVerify = s.Verify
+	return opts
+}
+
+// runAgent runs the station's agent in the worktree with the given prompt and
+// waits for it, tracking its PID and tmux session in the main repo while it
+// runs. It returns the agent's exit error separately from failures to start.
+// Agent starts are paced by settings.agent_rate, and an agent that hit its
+// provider's rate limit is run again (RUN-50).
+func runAgent(dir, wtPath string, resolved config.ResolvedStation, prompt string, ev EventSink) (agentErr, err error) {
+	wait := rateLimitBackoff
+	for retry := 0; ; retry++ {
+		paceAgent(dir, resolved, ev)
+		agentErr, err = runAgentOnce(dir, wtPath, resolved, prompt, ev)
+		if err != nil || !rateLimited(agentErr) || retry == rateLimitRetries {
+			return agentErr, err
+		}
+		emitf(ev, EventWarning, resolved.Name, "agent hit its provider's rate limit, running it again in %s (%d/%d)", shortDuration(wait), retry+1, rateLimitRetries)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// runAgentOnce is runAgent without the pacing and retries.
+func runAgentOnce(dir, wtPath string, resolved config.ResolvedStation, prompt string, ev EventSink) (agentErr, err error) {
+	span := trace.Start("agent "+resolved.Name, "line.station", resolved.Name, "process.command", resolved.Command)
+	defer func() { span.End(errors.Join(agentErr, err)) }()
+
+	agent, err := startAgent(agentDir(wtPath, resolved), resolved.Command, resolved.Args, resolved.Env, prompt, resolved.Name, dir, resolved.StripANSI, ev)
+	if err != nil {
+		return nil, err
+	}
+
 	// Write station PID file in main repo so status can detect the running agent
-	_ = state.WriteStationPID(dir, station.Name, agent.pid(), time.Now())
+	_ = state.WriteStationPID(dir, resolved.Name, agent.pid(), time.Now())
 
 	// Write tmux session name if running in tmux
 	if agent.session() != "" {
-		_ = state.WriteStationTmux(dir, station.Name, agent.session())
+		_ = state.WriteStationTmux(dir, resolved.Name, agent.session())
+	}
+
+	// Wait for agent to complete, recording that it is alive (RUN-42) and
+	// killing it if it stalls (RUN-43)
+	stopHeartbeat := heartbeat(dir, resolved.Name, agent, resolved.StallTimeout)
+	agentErr = agent.wait()
+	if stopHeartbeat() {
+		emitf(ev, EventWarning, resolved.Name, "agent wrote no output for %s (settings.stall_timeout), killed it", shortDuration(resolved.StallTimeout))
+		agentErr = &agentStalledError{timeout: resolved.StallTimeout}
 	}
 
-	// Wait for agent to complete
-	agentErr := agent.wait()
+	// Agents under tmux stream to their station log rather than the run's
+	// output; show the end of it when they fail (RUN-41).
+	var exitErr *AgentExitError
+	if errors.As(agentErr, &exitErr) && agent.session() != "" && len(exitErr.Output) > 0 {
+		ev.Emit(Event{Kind: EventInfo, Time: time.Now(), Station: resolved.Name,
+			Message: fmt.Sprintf("last %d lines of agent output:", len(exitErr.Output)),
+			Output:  []byte(strings.Join(exitErr.Output, "\n") + "\n")})
+	}
 
 	// Remove .claude/ from the worktree — ConfigureAgentDoneHook created
 	// settings.json there and it should not be committed to the station branch.
 	_ = os.RemoveAll(filepath.Join(wtPath, ".claude"))
+	_ = os.RemoveAll(filepath.Join(agentDir(wtPath, resolved), ".claude"))
 
 	// Claude Code syncs worktree settings to the main repo, so the agent
 	// done hook (touch .line-agent-done) can leak into the main repo's
@@ -89,22 +363,8 @@ func runStation(dir string, cfg *config.Config, station config.Station, predeces
 	}
 
 	// Clean up station state files
-	_ = state.RemoveStationPID(dir, station.Name)
-	_ = state.RemoveStationTmux(dir, station.Name)
+	_ = state.RemoveStationPID(dir, resolved.Name)
+	_ = state.RemoveStationTmux(dir, resolved.Name)
 
-	// RUN-14: A failed station blocks the line and is reported as 'failed'
-	if agentErr != nil {
-		fmt.Fprintf(os.Stderr, "station %s: agent exited with error: %v\n", station.Name, agentErr)
-		_ = state.WriteStationFailed(dir, station.Name)
-		return fmt.Errorf("agent failed: %w", agentErr)
-	}
-	_ = state.RemoveStationFailed(dir, station.Name)
-
-	// RUN-5: Commit any changes with skip marker (RUN-4, RUN-9)
-	commitMsg := fmt.Sprintf("assembly-line: station %s %s", station.Name, commitSkipMarker)
-	if err := git.CommitAll(wtPath, commitMsg); err != nil {
-		fmt.Fprintf(os.Stderr, "station %s: commit failed: %v\n", station.Name, err)
-	}
-
-	return nil
+	return agentErr, nil
 }
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/gate"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// verifyStation runs the gates against the uncommitted changes in the
+// station worktree. On failure the gate output is appended to the station
+// log and the station is marked failed with a verification reason.
+func verifyStation(dir, wtPath string, cfg *config.Config, name string, ev EventSink) error {
+	var out bytes.Buffer
+	gateErr := gate.RunGatesTo(gate.FromConfig(cfg.GatesFor(config.GateHookPreCommit)), wtPath, &out, &out)
+	if gateErr == nil {
+		return nil
+	}
+	ev.Emit(Event{Kind: EventWarning, Time: time.Now(), Station: name, Message: fmt.Sprintf("verification failed: %v", gateErr), Output: out.Bytes()})
+	_ = state.AppendStationLog(dir, name, fmt.Sprintf("\n--- verification failed: %v ---\n%s", gateErr, out.String()))
+	_ = state.WriteStationFailed(dir, name, fmt.Sprintf("%s: %v", state.FailedVerification, gateErr))
+	return fmt.Errorf("verification failed: %w", gateErr)
+}
+
+// verifyBeforeCommit runs the station's verify command against the agent's
+// uncommitted changes. With on_verify_failure: repair the agent is re-run to
+// fix the failure, up to the station's repair attempts, with the prompt it
+// first ran with.
+func verifyBeforeCommit(dir, wtPath string, station config.Station, resolved config.ResolvedStation, prompt string, ev EventSink) error {
+	maxAttempts := station.RepairAttempts()
+	for attempt := 0; ; attempt++ {
+		out, err := runVerify(wtPath, station.Verify)
+		if err == nil {
+			return nil
+		}
+		emitf(ev, EventWarning, station.Name, "verify failed: %v", err)
+		_ = state.AppendStationLog(dir, station.Name, fmt.Sprintf("\n--- verify %q failed: %v ---\n%s", station.Verify, err, out))
+		if attempt >= maxAttempts {
+			return fmt.Errorf("verify %q failed: %w", station.Verify, err)
+		}
+
+		emitf(ev, EventInfo, station.Name, "asking agent to repair (attempt %d/%d)", attempt+1, maxAttempts)
+		agentErr, err := runAgent(dir, wtPath, resolved, repairPrompt(prompt, station.Verify, out), ev)
+		if err != nil {
+			return err
+		}
+		if agentErr != nil {
+			return fmt.Errorf("repair agent failed: %w", agentErr)
+		}
+		if err := runAfter(dir, wtPath, station, ev); err != nil {
+			return err
+		}
+	}
+}
+
+// runVerify runs a verify command in the worktree and returns its combined
+// output.
+func runVerify(wtPath, command string) (string, error) {
+	out, err := runShell(wtPath, command)
+	return string(out), err
+}
+
+// maxRepairOutput caps how much verify output is fed back to the agent;
+// the tail is kept since that is where test runners summarise failures.
+const maxRepairOutput = 8 * 1024
+
+// repairPrompt asks the agent to fix the changes that failed verification
+// by command, adding its output to the station's prompt.
+func repairPrompt(prompt, command, output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxRepairOutput {
+		// Start the tail on a whole line, or at least a whole rune.
+		tail := output[len(output)-maxRepairOutput:]
+		if i := strings.IndexByte(tail, '\n'); i >= 0 {
+			tail = tail[i+1:]
+		}
+		for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+			tail = tail[1:]
+		}
+		output = "[...truncated...]\n" + tail
+	}
+	return fmt.Sprintf("%s\n\nYour changes failed verification: `%s` did not pass. Its output was:\n\n```\n%s\n```\n\nFix the problems so that it passes, keeping the intent of your changes.", prompt, command, output)
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// LockRun takes the exclusive lock that serialises runs taking over from one
+// another, waiting for it if another process holds it, and returns its
+// release.
+func LockRun(repoDir string) (func(), error) {
+	return lock(repoDir, runLockFile)
+}
+
+// lock takes an exclusive lock on the named file in .line, waiting for it
+// if another process holds it, and returns its release.
+func lock(repoDir, name string) (func(), error) {
+	if err := ensureDir(repoDir); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(repoDir, stateDir, name), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
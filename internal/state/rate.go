@@ -0,0 +1,52 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// agentRate is the token bucket of settings.agent_rate, shared by the runs
+// of a repository.
+type agentRate struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// TakeAgentStart takes a token for an agent start from the bucket of
+// settings.agent_rate, which holds up to n tokens and gains n every per. It
+// returns how long the agent has to wait: 0 if a token was there, otherwise
+// until the token taken in advance has accrued, so agents waiting in
+// several processes queue up rather than all starting at once.
+func TakeAgentStart(repoDir string, n int, per time.Duration, now time.Time) (time.Duration, error) {
+	unlock, err := lock(repoDir, agentRateLockFile)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	path := filepath.Join(repoDir, stateDir, agentRateFile)
+	b := agentRate{Tokens: float64(n), Updated: now}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &b)
+	}
+	perSecond := float64(n) / per.Seconds()
+	if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens += elapsed * perSecond
+	}
+	b.Tokens = min(b.Tokens, float64(n)) - 1
+	b.Updated = now
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return 0, err
+	}
+	if b.Tokens >= 0 {
+		return 0, nil
+	}
+	return time.Duration(-b.Tokens / perSecond * float64(time.Second)), nil
+}
@@ -1,21 +1,42 @@
 package state
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/re-cinq/assembly-line/internal/paths"
 )
 
 const (
 	stateDir            = ".line"
 	pidFile             = "run.pid"
+	runLockFile         = "run.lock"
+	settlingFile        = "run.settling"
 	rebasePromptedFile  = "rebase-prompted"
+	statuslineCacheFile = "statusline-cache"
+	disabledFile        = "disabled"
+	skippedFile         = "skipped"
+	lastCycleFile       = "last-cycle"
+	gateLastFile        = "gate-last.json"
+	agentRateFile       = "agent-rate"
+	agentRateLockFile   = "agent-rate.lock"
 	stationsDir         = "stations"
 )
 
+// Dir returns the repo's .line state directory.
+func Dir(repoDir string) string {
+	return filepath.Join(repoDir, stateDir)
+}
+
 // ensureDir creates the .line directory if it doesn't exist.
 func ensureDir(repoDir string) error {
 	dir := filepath.Join(repoDir, stateDir)
@@ -77,6 +98,28 @@ func RemovePID(repoDir string) error {
 	return removeFile(filepath.Join(repoDir, stateDir, pidFile))
 }
 
+// WriteSettling records that the runner with the given PID is waiting out
+// settings.debounce before it reads the watched branch, so later triggers can
+// leave the commits to it.
+func WriteSettling(repoDir string, pid int) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	path := filepath.Join(repoDir, stateDir, settlingFile)
+	return os.WriteFile(path, []byte(strconv.Itoa(pid)), 0o644)
+}
+
+// ReadSettling returns the PID of the runner that is settling, or 0.
+func ReadSettling(repoDir string) int {
+	pid, _ := strconv.Atoi(readStringFile(filepath.Join(repoDir, stateDir, settlingFile)))
+	return pid
+}
+
+// RemoveSettling removes the settling marker.
+func RemoveSettling(repoDir string) error {
+	return removeFile(filepath.Join(repoDir, stateDir, settlingFile))
+}
+
 // WriteRebasePrompted records the terminal ref that was last auto-rebased.
 func WriteRebasePrompted(repoDir, ref string) error {
 	if err := ensureDir(repoDir); err != nil {
@@ -96,6 +139,193 @@ func RemoveRebasePrompted(repoDir string) error {
 	return removeFile(filepath.Join(repoDir, stateDir, rebasePromptedFile))
 }
 
+// Disabled returns true if the pipeline kill switch is engaged, either by a
+// .line/disabled file or by LINE_DISABLED=1 in the environment.
+func Disabled(repoDir string) bool {
+	if os.Getenv("LINE_DISABLED") == "1" {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(repoDir, stateDir, disabledFile))
+	return err == nil
+}
+
+// StatuslineCache is a rendered statusline together with a key describing
+// the inputs it was rendered from.
+type StatuslineCache struct {
+	Key  string    `json:"key"`
+	Line string    `json:"line"`
+	At   time.Time `json:"at"`
+}
+
+// statuslineCachePath returns the cache file for a statusline variant
+// ("" for the default rendering), so consumers rendering different
+// variants (e.g. Claude Code and a shell prompt) don't evict each other.
+func statuslineCachePath(repoDir, variant string) string {
+	name := statuslineCacheFile
+	if variant != "" {
+		name += "-" + variant
+	}
+	return filepath.Join(repoDir, stateDir, name)
+}
+
+// WriteStatuslineCache stores a rendered statusline. Unlike other state it
+// does not create the .line directory, so rendering the statusline never
+// leaves state behind in a repo the line has not run in.
+func WriteStatuslineCache(repoDir, variant string, c StatuslineCache) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statuslineCachePath(repoDir, variant), data, 0o644)
+}
+
+// ReadStatuslineCache returns the stored statusline, or false if there is no
+// readable cache.
+func ReadStatuslineCache(repoDir, variant string) (StatuslineCache, bool) {
+	var c StatuslineCache
+	data, err := os.ReadFile(statuslineCachePath(repoDir, variant))
+	if err != nil {
+		return c, false
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, false
+	}
+	return c, true
+}
+
+// RemoveStatuslineCache removes the statusline caches of every variant.
+func RemoveStatuslineCache(repoDir string) error {
+	variants, _ := filepath.Glob(statuslineCachePath(repoDir, "*"))
+	for _, path := range append(variants, statuslineCachePath(repoDir, "")) {
+		if err := removeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SkippedCommit records a watched-branch commit that line run skipped, and
+// why: the skip marker it carried or "ignored paths".
+type SkippedCommit struct {
+	Commit string `json:"commit"`
+	Reason string `json:"reason"`
+}
+
+// AppendSkippedCommit records a skipped commit, once per commit.
+func AppendSkippedCommit(repoDir string, s SkippedCommit) error {
+	for _, prev := range ReadSkippedCommits(repoDir) {
+		if prev.Commit == s.Commit {
+			return nil
+		}
+	}
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(repoDir, stateDir, skippedFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// ReadSkippedCommits returns the commits skipped since the line last ran.
+func ReadSkippedCommits(repoDir string) []SkippedCommit {
+	var skipped []SkippedCommit
+	data, _ := os.ReadFile(filepath.Join(repoDir, stateDir, skippedFile))
+	for _, line := range strings.Split(string(data), "\n") {
+		var s SkippedCommit
+		if json.Unmarshal([]byte(line), &s) == nil && s.Commit != "" {
+			skipped = append(skipped, s)
+		}
+	}
+	return skipped
+}
+
+// RemoveSkippedCommits forgets the skipped commits.
+func RemoveSkippedCommits(repoDir string) error {
+	return removeFile(filepath.Join(repoDir, stateDir, skippedFile))
+}
+
+// Cycle is one pass of line run over the stations for a watched-branch
+// commit, whatever the stations did.
+type Cycle struct {
+	Commit   string    `json:"commit"`
+	Tree     string    `json:"tree,omitempty"` // the commit's tree
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	OK       bool      `json:"ok"`                // every station ran or was skipped, none failed or held for approval
+	Aborted  string    `json:"aborted,omitempty"` // why the cycle stopped short, e.g. a failing settings.hooks command
+}
+
+// WriteLastCycle records the most recent pass over the stations.
+func WriteLastCycle(repoDir string, c Cycle) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(repoDir, stateDir, lastCycleFile), data, 0o644)
+}
+
+// ReadLastCycle returns the most recent pass over the stations, or false if
+// there was none since the state was last cleared.
+func ReadLastCycle(repoDir string) (Cycle, bool) {
+	var c Cycle
+	data, err := os.ReadFile(filepath.Join(repoDir, stateDir, lastCycleFile))
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return c, false
+	}
+	return c, true
+}
+
+// RemoveLastCycle forgets the last pass over the stations.
+func RemoveLastCycle(repoDir string) error {
+	return removeFile(filepath.Join(repoDir, stateDir, lastCycleFile))
+}
+
+// GateResult is how one gate went.
+type GateResult struct {
+	Name     string  `json:"name"`
+	Run      string  `json:"run"`
+	Ref      string  `json:"ref,omitempty"` // the pushed ref it checked, for push gates
+	OK       bool    `json:"ok"`
+	ExitCode int     `json:"exit_code"` // -1 if it could not be started or was killed
+	Duration float64 `json:"duration_seconds"`
+	Output   string  `json:"output"` // stdout and stderr interleaved, cut to the last 64 KiB
+}
+
+// GateReport is the outcome of the last line gate, for editors and commit
+// tooling to show which gate blocked a commit or push and why.
+type GateReport struct {
+	Hook     string       `json:"hook"` // pre-commit or pre-push
+	Started  time.Time    `json:"started"`
+	Finished time.Time    `json:"finished"`
+	OK       bool         `json:"ok"`
+	Failed   string       `json:"failed,omitempty"` // the gate that failed
+	Gates    []GateResult `json:"gates"`          // the gates that ran, in order
+}
+
+// WriteGateReport records the outcome of the last line gate in
+// .line/gate-last.json.
+func WriteGateReport(repoDir string, r GateReport) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(repoDir, stateDir, gateLastFile), append(data, '\n'), 0o644)
+}
+
 // findProcess wraps os.FindProcess for use in platform-specific code.
 func findProcess(pid int) (*os.Process, error) {
 	return os.FindProcess(pid)
@@ -134,6 +364,46 @@ func ReadStationPID(repoDir, stationName string) (int, time.Time, error) {
 	return pid, startTime, nil
 }
 
+// StationHeartbeat is what the runner last saw of a station's running agent:
+// when it looked, how many bytes of output the agent had written by then,
+// and when that last grew.
+type StationHeartbeat struct {
+	At          time.Time `json:"heartbeat_at"`
+	OutputBytes int64     `json:"output_bytes"`
+	OutputAt    time.Time `json:"output_at"`
+}
+
+// WriteStationHeartbeat records a heartbeat of a station's running agent.
+func WriteStationHeartbeat(repoDir, stationName string, h StationHeartbeat) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".heartbeat"), data, 0o644)
+}
+
+// ReadStationHeartbeat returns the last heartbeat of a station's agent, or
+// false if none is recorded.
+func ReadStationHeartbeat(repoDir, stationName string) (StationHeartbeat, bool) {
+	var h StationHeartbeat
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".heartbeat"))
+	if err != nil {
+		return h, false
+	}
+	if err := json.Unmarshal(data, &h); err != nil || h.At.IsZero() {
+		return h, false
+	}
+	return h, true
+}
+
+// RemoveStationHeartbeat removes a station's heartbeat file.
+func RemoveStationHeartbeat(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".heartbeat"))
+}
+
 // RemoveStationPID removes a station's PID file.
 func RemoveStationPID(repoDir, stationName string) error {
 	return removeFile(stationFilePath(repoDir, stationName, ".pid"))
@@ -170,12 +440,73 @@ func KillAllStationAgents(repoDir string) {
 	}
 }
 
-// WriteStationFailed writes a marker indicating a station's agent failed.
-func WriteStationFailed(repoDir, stationName string) error {
+// stationSuffixes are the suffixes of a station's files in .line/stations.
+var stationSuffixes = []string{
+	".agent-exit", ".approval", ".backoff", ".conflict", ".exit-status", ".failed",
+	".heartbeat", ".history", ".in-progress", ".last-run", ".log", ".pid", ".refs", ".tmux",
+}
+
+// StationsWithState returns the names of the stations that have files in
+// .line/stations or a log, sorted.
+func StationsWithState(repoDir string) []string {
+	var files []string
+	for _, dir := range []string{filepath.Join(repoDir, stateDir, stationsDir), filepath.Dir(StationLogPath(repoDir, "_"))} {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			files = append(files, e.Name())
+		}
+	}
+	var names []string
+	for _, file := range files {
+		for _, suffix := range stationSuffixes {
+			if name, ok := strings.CutSuffix(file, suffix); ok && name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// MoveStation moves a station's files in .line/stations and its log to
+// another station name, replacing what the other name had.
+func MoveStation(repoDir, from, to string) error {
+	for _, suffix := range stationSuffixes {
+		if suffix == ".log" {
+			continue // moved below, wherever StationLogPath keeps it
+		}
+		if err := removeFile(stationFilePath(repoDir, to, suffix)); err != nil {
+			return err
+		}
+		if err := os.Rename(stationFilePath(repoDir, from, suffix), stationFilePath(repoDir, to, suffix)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := removeFile(StationLogPath(repoDir, to)); err != nil {
+		return err
+	}
+	if err := os.Rename(StationLogPath(repoDir, from), StationLogPath(repoDir, to)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FailedVerification prefixes the failure reason of a station whose
+// committed output failed the gates (settings.verify).
+const FailedVerification = "verification"
+
+// FailedStalled prefixes the failure reason of a station whose agent was
+// killed for writing no output for settings.stall_timeout.
+const FailedStalled = "stalled"
+
+// WriteStationFailed writes a marker indicating a station failed, with a
+// one-line reason.
+func WriteStationFailed(repoDir, stationName, reason string) error {
 	if err := ensureStationsDir(repoDir); err != nil {
 		return err
 	}
-	return os.WriteFile(stationFilePath(repoDir, stationName, ".failed"), []byte("1"), 0o644)
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".failed"), []byte(reason), 0o644)
 }
 
 // ReadStationFailed returns true if a station has a failure marker.
@@ -184,14 +515,448 @@ func ReadStationFailed(repoDir, stationName string) bool {
 	return err == nil
 }
 
-// RemoveStationFailed removes a station's failure marker.
+// ReadStationFailure returns the recorded failure reason for a station, or
+// "" if it has not failed (or failed before reasons were recorded).
+func ReadStationFailure(repoDir, stationName string) string {
+	return readStringFile(stationFilePath(repoDir, stationName, ".failed"))
+}
+
+// AppendStationLog appends text to a station's log file.
+func AppendStationLog(repoDir, stationName, text string) error {
+	if err := EnsureStationLogDir(repoDir, stationName); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(StationLogPath(repoDir, stationName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(text)
+	return err
+}
+
+// RemoveStationFailed removes a station's failure marker, and how its agent
+// exited.
 func RemoveStationFailed(repoDir, stationName string) error {
+	_ = removeFile(stationFilePath(repoDir, stationName, ".agent-exit"))
 	return removeFile(stationFilePath(repoDir, stationName, ".failed"))
 }
 
-// StationLogPath returns the path to a station's tmux pipe-pane log file.
+// AgentExit records how a station's agent failed: its exit code (128 plus
+// the signal number if a signal killed it, as shells report it), the
+// signal's name, and the last lines of its output.
+type AgentExit struct {
+	Code   int      `json:"code"`
+	Signal string   `json:"signal,omitempty"`
+	Output []string `json:"output,omitempty"`
+}
+
+// String describes the exit, e.g. "exited 137 (killed)".
+func (a AgentExit) String() string {
+	if a.Signal != "" {
+		return fmt.Sprintf("exited %d (%s)", a.Code, a.Signal)
+	}
+	return fmt.Sprintf("exited %d", a.Code)
+}
+
+// WriteStationAgentExit records how a failed station's agent exited, next
+// to its failure marker.
+func WriteStationAgentExit(repoDir, stationName string, a AgentExit) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".agent-exit"), data, 0o644)
+}
+
+// ReadStationAgentExit returns how a station's agent exited when it last
+// failed, or false if the station has not failed because of its agent.
+func ReadStationAgentExit(repoDir, stationName string) (AgentExit, bool) {
+	var a AgentExit
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".agent-exit"))
+	if err != nil {
+		return a, false
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return a, false
+	}
+	return a, true
+}
+
+// WriteStationConflict records that a station's branch could not be rebased
+// onto its predecessor, listing the conflicted files one per line.
+func WriteStationConflict(repoDir, stationName string, files []string) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".conflict"), []byte(strings.Join(files, "\n")), 0o644)
+}
+
+// ReadStationConflict returns the conflicted files recorded for a station and
+// whether the station is in the conflict state.
+func ReadStationConflict(repoDir, stationName string) ([]string, bool) {
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".conflict"))
+	if err != nil {
+		return nil, false
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return nil, true
+	}
+	return strings.Split(content, "\n"), true
+}
+
+// RemoveStationConflict clears a station's conflict marker.
+func RemoveStationConflict(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".conflict"))
+}
+
+// StationRun records the timing and outcome of a station's run.
+type StationRun struct {
+	// ID identifies the run to its agent (LINE_RUN_ID), e.g.
+	// "20260102T150405Z-1a2b3c".
+	ID       string    `json:"id,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Result   string    `json:"result"`           // "ok" or the error that stopped the station
+	Commit   string    `json:"commit,omitempty"` // the watched-branch commit the station ran for
+	// RangeFrom..RangeTo are the watched-branch commits the run reviewed:
+	// from the commit of the station's previous completed run (exclusive;
+	// empty on a first run) to Commit.
+	RangeFrom string `json:"range_from,omitempty"`
+	RangeTo   string `json:"range_to,omitempty"`
+	// Ref is the tag or branch a station with watches ran for, e.g.
+	// "refs/tags/v1.2.0".
+	Ref string `json:"ref,omitempty"`
+	// LogFrom..LogTo are the byte offsets of what the run wrote to the
+	// station's log, for line logs --run.
+	LogFrom int64 `json:"log_from,omitempty"`
+	LogTo   int64 `json:"log_to,omitempty"`
+}
+
+// WriteStationRefs records the commits of the refs a station with watches
+// has seen, by full ref name.
+func WriteStationRefs(repoDir, stationName string, refs map[string]string) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(refs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".refs"), data, 0o644)
+}
+
+// ReadStationRefs returns the refs a station with watches has seen, or
+// false if it has not looked at any since the state was last cleared.
+func ReadStationRefs(repoDir, stationName string) (map[string]string, bool) {
+	refs := map[string]string{}
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".refs"))
+	if err != nil || json.Unmarshal(data, &refs) != nil {
+		return map[string]string{}, false
+	}
+	return refs, true
+}
+
+// maxStationRuns bounds a station's run history.
+const maxStationRuns = 1000
+
+// WriteStationLastRun records a station's most recent run.
+func WriteStationLastRun(repoDir, stationName string, r StationRun) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".last-run"), data, 0o644)
+}
+
+// ReadStationLastRun returns a station's most recent run, or false if it has
+// not run since the state was last cleared.
+func ReadStationLastRun(repoDir, stationName string) (StationRun, bool) {
+	var r StationRun
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".last-run"))
+	if err != nil {
+		return r, false
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, false
+	}
+	return r, true
+}
+
+// AppendStationRun adds a run to the station's history, dropping the
+// oldest runs beyond maxStationRuns.
+func AppendStationRun(repoDir, stationName string, r StationRun) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	runs := append(ReadStationRuns(repoDir, stationName), r)
+	if len(runs) > maxStationRuns {
+		runs = runs[len(runs)-maxStationRuns:]
+	}
+	var b strings.Builder
+	for _, run := range runs {
+		data, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		b.Write(append(data, '\n'))
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".history"), []byte(b.String()), 0o644)
+}
+
+// ReadStationRuns returns the station's run history, oldest first.
+func ReadStationRuns(repoDir, stationName string) []StationRun {
+	var runs []StationRun
+	data, _ := os.ReadFile(stationFilePath(repoDir, stationName, ".history"))
+	for _, line := range strings.Split(string(data), "\n") {
+		var r StationRun
+		if json.Unmarshal([]byte(line), &r) == nil && !r.Started.IsZero() {
+			runs = append(runs, r)
+		}
+	}
+	return runs
+}
+
+// StationBackoff tracks a station's consecutive failures and, once it is
+// quarantined, the time until which the runner skips it.
+type StationBackoff struct {
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until,omitzero"`
+}
+
+// Quarantined reports whether the station is still quarantined at now.
+func (b StationBackoff) Quarantined(now time.Time) bool {
+	return now.Before(b.Until)
+}
+
+// WriteStationBackoff records a station's failure count and quarantine.
+func WriteStationBackoff(repoDir, stationName string, b StationBackoff) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".backoff"), data, 0o644)
+}
+
+// ReadStationBackoff returns a station's failure count and quarantine; the
+// zero value if it has none.
+func ReadStationBackoff(repoDir, stationName string) StationBackoff {
+	var b StationBackoff
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".backoff"))
+	if err != nil {
+		return b
+	}
+	_ = json.Unmarshal(data, &b)
+	return b
+}
+
+// RemoveStationBackoff resets a station's failure count and quarantine.
+func RemoveStationBackoff(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".backoff"))
+}
+
+// StationApproval describes a station commit waiting for line approve or
+// line reject: Commit is the station's output, Base the station branch head
+// it sits on, Summary a diffstat of the change.
+type StationApproval struct {
+	Commit  string `json:"commit"`
+	Base    string `json:"base"`
+	Summary string `json:"summary"`
+}
+
+// WriteStationApproval marks a station as awaiting approval.
+func WriteStationApproval(repoDir, stationName string, a StationApproval) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".approval"), data, 0o644)
+}
+
+// ReadStationApproval returns the commit a station is waiting on, or false
+// if it is not awaiting approval.
+func ReadStationApproval(repoDir, stationName string) (StationApproval, bool) {
+	var a StationApproval
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".approval"))
+	if err != nil {
+		return a, false
+	}
+	if err := json.Unmarshal(data, &a); err != nil || a.Commit == "" {
+		return a, false
+	}
+	return a, true
+}
+
+// RemoveStationApproval clears a station's awaiting-approval marker.
+func RemoveStationApproval(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".approval"))
+}
+
+// WriteStationInProgress marks a station's agent as working in its
+// worktree. The marker outlives a runner that is killed, so the next run
+// knows the worktree holds interrupted work.
+func WriteStationInProgress(repoDir, stationName string, started time.Time) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".in-progress"), []byte(started.Format(time.RFC3339)), 0o644)
+}
+
+// ReadStationInProgress reports whether a station's agent was left working,
+// and since when.
+func ReadStationInProgress(repoDir, stationName string) (time.Time, bool) {
+	data := readStringFile(stationFilePath(repoDir, stationName, ".in-progress"))
+	if data == "" {
+		return time.Time{}, false
+	}
+	started, _ := time.Parse(time.RFC3339, data)
+	return started, true
+}
+
+// RemoveStationInProgress clears a station's in-progress marker.
+func RemoveStationInProgress(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".in-progress"))
+}
+
+// StationLogPath returns the path to a station's log file, in the repo's
+// log directory (paths.Logs), falling back to .line/stations/ if that
+// cannot be determined.
 func StationLogPath(repoDir, stationName string) string {
-	return stationFilePath(repoDir, stationName, ".log")
+	logs, err := paths.Logs(repoDir)
+	if err != nil {
+		return stationFilePath(repoDir, stationName, ".log")
+	}
+	return filepath.Join(logs, stationName+".log")
+}
+
+// StationLogSize returns the size of a station's log, 0 if it has none.
+func StationLogSize(repoDir, stationName string) int64 {
+	fi, err := os.Stat(StationLogPath(repoDir, stationName))
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// ReadStationLogRange returns the bytes from..to of a station's log.
+func ReadStationLogRange(repoDir, stationName string, from, to int64) (string, error) {
+	f, err := os.Open(StationLogPath(repoDir, stationName))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err != nil {
+		return "", err
+	} else if fi.Size() < to {
+		return "", fmt.Errorf("the log of %s was cleared since", stationName)
+	}
+	buf := make([]byte, to-from)
+	if _, err := f.ReadAt(buf, from); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// ansiRE matches ANSI escape sequences (CSI, OSC, and single-char escapes).
+var ansiRE = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07]*\x07|\[[^\x1b]*|.)`)
+
+// StripANSI removes ANSI escape sequences and carriage returns from agent
+// output.
+func StripANSI(s string) string {
+	return strings.ReplaceAll(ansiRE.ReplaceAllString(s, ""), "\r", "")
+}
+
+// ansiCompleteRE matches an escape sequence at the start of a text that is
+// complete, i.e. not cut off at the end of what was read so far.
+var ansiCompleteRE = regexp.MustCompile(`^\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[^\[\]])`)
+
+// maxPendingEscape bounds how much of a seemingly unfinished escape sequence
+// CopyStripANSI holds back before giving up on it.
+const maxPendingEscape = 256
+
+// CopyStripANSI copies src to dst as StripANSI would have it, as it
+// arrives: an escape sequence split across reads is held back until it is
+// complete.
+func CopyStripANSI(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, 32<<10)
+	var pending []byte
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			data := append(pending, buf[:n]...)
+			keep := 0
+			if i := bytes.LastIndexByte(data, 0x1b); i >= 0 && len(data)-i < maxPendingEscape && !ansiCompleteRE.Match(data[i:]) {
+				keep = len(data) - i
+			}
+			if _, werr := io.WriteString(dst, StripANSI(string(data[:len(data)-keep]))); werr != nil {
+				return werr
+			}
+			pending = append([]byte(nil), data[len(data)-keep:]...)
+		}
+		if err == io.EOF {
+			_, werr := io.WriteString(dst, StripANSI(string(pending)))
+			return werr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// StationLogTail returns the last n lines of a station's log with ANSI
+// escapes and carriage returns stripped and surrounding blank lines dropped.
+func StationLogTail(repoDir, stationName string, n int) []string {
+	return stationLogTail(repoDir, stationName, n, StripANSI)
+}
+
+// StationLogTailRaw is StationLogTail with the lines as they are stored.
+func StationLogTailRaw(repoDir, stationName string, n int) []string {
+	return stationLogTail(repoDir, stationName, n, func(s string) string { return s })
+}
+
+func stationLogTail(repoDir, stationName string, n int, filter func(string) string) []string {
+	data, err := os.ReadFile(StationLogPath(repoDir, stationName))
+	if err != nil || n <= 0 {
+		return nil
+	}
+	lines := strings.Split(filter(string(data)), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// EnsureStationLogDir creates the directory holding StationLogPath.
+func EnsureStationLogDir(repoDir, stationName string) error {
+	return os.MkdirAll(filepath.Dir(StationLogPath(repoDir, stationName)), 0o755)
+}
+
+// RemoveStationLogs deletes every station log for the repo.
+func RemoveStationLogs(repoDir string) error {
+	logs, err := paths.Logs(repoDir)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(logs)
 }
 
 // WriteStationTmux writes the tmux session name for a running station.
@@ -212,3 +977,29 @@ func ReadStationTmux(repoDir, stationName string) string {
 func RemoveStationTmux(repoDir, stationName string) error {
 	return removeFile(stationFilePath(repoDir, stationName, ".tmux"))
 }
+
+// StationExitStatusPath returns the absolute path of the file the shell of
+// a station's tmux pane writes its agent's exit status to, removing any left
+// from an earlier run. tmux does not always record a dead pane's status.
+func StationExitStatusPath(repoDir, stationName string) (string, error) {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return "", err
+	}
+	path, err := filepath.Abs(stationFilePath(repoDir, stationName, ".exit-status"))
+	if err != nil {
+		return "", err
+	}
+	return path, removeFile(path)
+}
+
+// ReadStationExitStatus returns the exit status written to
+// StationExitStatusPath, or false if there is none.
+func ReadStationExitStatus(repoDir, stationName string) (int, bool) {
+	code, err := strconv.Atoi(readStringFile(stationFilePath(repoDir, stationName, ".exit-status")))
+	return code, err == nil
+}
+
+// RemoveStationExitStatus removes a station's exit status file.
+func RemoveStationExitStatus(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".exit-status"))
+}
@@ -0,0 +1,63 @@
+package statusdata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// CacheTTL bounds how long Cached reuses a rendering. The cache key already
+// covers every input of a Snapshot, so the TTL is only a safety net; it
+// also bounds how stale durations in a rendering, like how long an agent
+// has been silent, get.
+const CacheTTL = 10 * time.Second
+
+// Cached returns render's rendering of the line's state, reusing the one
+// stored for variant while nothing it was rendered from has changed: the
+// watched-branch and station heads, whether the runner is alive, the kill
+// switch, and each station's agent, stall, failure, quarantine, approval
+// and conflict state. extra describes the caller's own inputs, e.g. the
+// terminal width, and is part of the key.
+//
+// A hit costs one git process and a few file reads, so Cached suits
+// callers polled every few hundred milliseconds. Callers rendering
+// differently use different variants, so they don't evict each other.
+// Renderings are stored in .line only when it exists (see
+// state.WriteStatuslineCache), and line clear removes them.
+func Cached(dir string, cfg *config.Config, variant, extra string, render func(Snapshot) string) (string, error) {
+	heads, err := branchHeads(dir, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	key := cacheKey(dir, cfg, heads) + ";" + extra
+	if c, ok := state.ReadStatuslineCache(dir, variant); ok && c.Key == key && time.Since(c.At) < CacheTTL {
+		return c.Line, nil
+	}
+
+	out := render(gather(dir, cfg, heads))
+	_ = state.WriteStatuslineCache(dir, variant, state.StatuslineCache{Key: key, Line: out, At: time.Now()})
+	return out, nil
+}
+
+// cacheKey describes every input to gather: branch heads, runner liveness,
+// the kill switch, and per-station process, stall, failure, quarantine,
+// approval and conflict state.
+func cacheKey(dir string, cfg *config.Config, heads map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s=%s;run=%t;disabled=%t", cfg.Settings.Watches, heads[cfg.Settings.Watches], RunnerActive(dir), state.Disabled(dir))
+	for _, station := range cfg.Stations {
+		pid, _, _ := state.ReadStationPID(dir, station.Name)
+		conflicts, conflicted := state.ReadStationConflict(dir, station.Name)
+		_, awaiting := state.ReadStationApproval(dir, station.Name)
+		fmt.Fprintf(&b, ";%s=%s,%t,%t,%t,%t,%t,%t:%s", station.Name, heads[git.StationBranchName(station.Name)],
+			pid > 0 && state.IsProcessRunning(pid), AgentSilence(dir, cfg.Settings, station.Name) != "", state.ReadStationFailed(dir, station.Name),
+			state.ReadStationBackoff(dir, station.Name).Quarantined(time.Now()), awaiting,
+			conflicted, strings.Join(conflicts, ","))
+	}
+	return b.String()
+}
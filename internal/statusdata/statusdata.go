@@ -0,0 +1,228 @@
+// Package statusdata computes the state of the line that line status, line
+// show, line statusline and line rpc present: whether the runner is alive,
+// the kill switch, and the state of each station, from git and the runtime
+// files in .line (STAT-5: on demand, nothing is stored for it).
+//
+// Gather computes a Snapshot afresh. Cached is for callers polled often,
+// like a statusline: it reuses their last rendering of a snapshot for as
+// long as nothing the snapshot depends on has changed.
+package statusdata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// Station states, as line status prints them.
+const (
+	Pending            = "pending"
+	AgentRunning       = "agent running"
+	AwaitingApproval   = "awaiting approval"
+	Conflict           = "conflict"
+	Quarantined        = "quarantined"
+	Failed             = "failed"
+	FailedVerification = "failed verification"
+	UpToDate           = "up to date"
+	Done               = "done" // a station with watches that ran for its ref
+)
+
+// Station is the state of one station.
+type Station struct {
+	Name   string
+	Branch string
+	// Head is the full hash of the station branch, "" before it exists.
+	Head  string
+	State string
+	// Detail explains the state, e.g. the failing gate, how long a running
+	// agent has been silent (RUN-42), or the ref a station with watches
+	// last ran for.
+	Detail string
+	// Conflicts are the conflicted files in state Conflict.
+	Conflicts []string
+	// Since is when the running agent started, zero unless AgentRunning.
+	Since time.Time
+}
+
+// Running reports whether the station's agent is running.
+func (s Station) Running() bool {
+	return !s.Since.IsZero()
+}
+
+// Snapshot is the state of the line at one moment.
+type Snapshot struct {
+	// Active reports whether a line runner process is alive.
+	Active bool
+	// Disabled reports whether the kill switch is on.
+	Disabled bool
+	Watches  string
+	// Head is the full hash of the watched branch, "" if it doesn't exist.
+	Head string
+	// Stations are the config's stations without watches, in order.
+	Stations []Station
+	// Unpicked reports whether the last station has commits the watched
+	// branch doesn't (SL-2).
+	Unpicked bool
+}
+
+// Gather computes the state of the line in dir. The watched branch and
+// every station branch are resolved with a single git process.
+func Gather(dir string, cfg *config.Config) (Snapshot, error) {
+	heads, err := branchHeads(dir, cfg)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return gather(dir, cfg, heads), nil
+}
+
+// branchHeads resolves the watched branch and every station branch, keyed
+// by branch name.
+func branchHeads(dir string, cfg *config.Config) (map[string]string, error) {
+	return git.BranchHeads(dir, "refs/heads/"+cfg.Settings.Watches, "refs/heads/"+git.StationBranchName(""))
+}
+
+func gather(dir string, cfg *config.Config, heads map[string]string) Snapshot {
+	snap := Snapshot{
+		Active:   RunnerActive(dir),
+		Disabled: state.Disabled(dir),
+		Watches:  cfg.Settings.Watches,
+		Head:     heads[cfg.Settings.Watches],
+	}
+	for _, station := range cfg.Stations {
+		head, exists := heads[git.StationBranchName(station.Name)]
+		st := StationState(dir, cfg.Settings, station, snap.Head, exists)
+		st.Head = head
+		snap.Stations = append(snap.Stations, st)
+	}
+	if n := len(snap.Stations); n > 0 && snap.Stations[n-1].Head != "" {
+		snap.Unpicked, _ = git.HasCommitsBetween(dir, cfg.Settings.Watches, snap.Stations[n-1].Branch)
+	}
+	return snap
+}
+
+// StationState returns the state of a station without watches.
+// watchedFullRef is the watched branch's hash; branchExists reports whether
+// the station branch exists, letting callers batch that lookup. Head is
+// left for the caller to fill in.
+func StationState(dir string, settings config.Settings, station config.Station, watchedFullRef string, branchExists bool) Station {
+	st := Station{Name: station.Name, Branch: git.StationBranchName(station.Name), State: Pending}
+	if !branchExists {
+		return st
+	}
+
+	agentPID, startTime, _ := state.ReadStationPID(dir, station.Name)
+	if agentPID > 0 && state.IsProcessRunning(agentPID) {
+		st.State, st.Since, st.Detail = AgentRunning, startTime, AgentSilence(dir, settings, station.Name)
+		return st
+	}
+	if a, ok := state.ReadStationApproval(dir, station.Name); ok {
+		st.State = AwaitingApproval
+		st.Detail = fmt.Sprintf("%s — line approve %s / line reject %s", a.Summary, station.Name, station.Name)
+		return st
+	}
+	if files, ok := state.ReadStationConflict(dir, station.Name); ok {
+		st.State, st.Conflicts = Conflict, files
+		return st
+	}
+	if b := state.ReadStationBackoff(dir, station.Name); b.Quarantined(time.Now()) {
+		st.State = Quarantined
+		st.Detail = fmt.Sprintf("until %s after %d failures — line retry %s", b.Until.Format(time.TimeOnly), b.Failures, station.Name)
+		return st
+	}
+	if state.ReadStationFailed(dir, station.Name) {
+		st.State = Failed
+		if reason := state.ReadStationFailure(dir, station.Name); strings.HasPrefix(reason, state.FailedVerification+":") {
+			st.State, st.Detail = FailedVerification, strings.TrimSpace(strings.TrimPrefix(reason, state.FailedVerification+":"))
+		} else if strings.HasPrefix(reason, "agent exited") || strings.HasPrefix(reason, state.FailedStalled+":") {
+			// RUN-41, RUN-43: e.g. agent exited 137 (killed), stalled: no output for 20m
+			st.Detail = reason
+		}
+		return st
+	}
+	// STAT-8: If the only commits between station and watched branch are
+	// skip-marker commits, the station is still up to date.
+	if watchedFullRef != "" && (git.IsAncestor(dir, watchedFullRef, st.Branch) ||
+		git.OnlySkipCommitsBetween(dir, st.Branch, settings.Watches, runner.SkipMarkers) ||
+		rewrittenHead(dir, watchedFullRef, st.Branch)) {
+		st.State = UpToDate
+	}
+	return st
+}
+
+// rewrittenHead reports whether the watched branch's head is a rewrite,
+// with the same tree, of the commit the last clean cycle ran for and the
+// station has; line run skips such a commit (RUN-36).
+func rewrittenHead(dir, watchedFullRef, branch string) bool {
+	last, ok := state.ReadLastCycle(dir)
+	if !ok || !last.OK || last.Tree == "" || !git.IsAncestor(dir, last.Commit, branch) {
+		return false
+	}
+	tree, err := git.Run(dir, "rev-parse", watchedFullRef+"^{tree}")
+	return err == nil && tree == last.Tree
+}
+
+// RefStationState returns the state of a station with watches: the ref it
+// last ran for, rather than whether it is up to date with the watched
+// branch.
+func RefStationState(dir string, settings config.Settings, station config.Station) Station {
+	st := Station{Name: station.Name, Branch: git.StationBranchName(station.Name), State: Pending}
+	agentPID, startTime, _ := state.ReadStationPID(dir, station.Name)
+	if agentPID > 0 && state.IsProcessRunning(agentPID) {
+		st.State, st.Since, st.Detail = AgentRunning, startTime, AgentSilence(dir, settings, station.Name)
+		return st
+	}
+	if b := state.ReadStationBackoff(dir, station.Name); b.Quarantined(time.Now()) {
+		st.State = Quarantined
+		st.Detail = fmt.Sprintf("until %s after %d failures — line retry %s", b.Until.Format(time.TimeOnly), b.Failures, station.Name)
+		return st
+	}
+	run, ok := state.ReadStationLastRun(dir, station.Name)
+	if !ok || run.Ref == "" {
+		st.Detail = "watches " + station.Watches
+		return st
+	}
+	st.State, st.Detail = Done, runner.RefLabel(run.Ref)
+	if state.ReadStationFailed(dir, station.Name) {
+		st.State = Failed
+	}
+	return st
+}
+
+// AgentSilence flags a running agent that has written no output for
+// settings.stall_after, e.g. "no output for 12m 5s" (RUN-42), going by the
+// runner's heartbeats; "" if it has.
+func AgentSilence(dir string, settings config.Settings, name string) string {
+	after := settings.StallAfterDuration()
+	h, ok := state.ReadStationHeartbeat(dir, name)
+	if after == 0 || !ok || time.Since(h.OutputAt) < after {
+		return ""
+	}
+	return "no output for " + Uptime(h.OutputAt)
+}
+
+// RunnerActive reports whether a line runner process is alive.
+func RunnerActive(dir string) bool {
+	pid, _ := state.ReadPID(dir)
+	return pid > 0 && state.IsProcessRunning(pid)
+}
+
+// Uptime formats the time since start as e.g. "42s", "3m 5s" or "2h 10m".
+func Uptime(start time.Time) string {
+	s := int(time.Since(start).Seconds())
+	if s < 60 {
+		return fmt.Sprintf("%ds", s)
+	}
+	m := s / 60
+	s = s % 60
+	if m < 60 {
+		return fmt.Sprintf("%dm %ds", m, s)
+	}
+	h := m / 60
+	m = m % 60
+	return fmt.Sprintf("%dh %dm", h, m)
+}
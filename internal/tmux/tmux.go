@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -88,21 +89,42 @@ func PanePID(session string) (int, error) {
 	return pid, nil
 }
 
+// paneStatusWait bounds how long PaneStatus waits for tmux to learn the
+// exit status of a pane that is already dead.
+const paneStatusWait = time.Second
+
 // PaneStatus reads the pane dead status.
 // Returns dead=true if the pane's process has exited, along with its exit code.
+// A process killed by a signal gets 128 plus the signal number, as shells
+// report it.
 func PaneStatus(session string) (dead bool, exitCode int, err error) {
-	out, err := output("display-message", "-t", session+":0.0", "-p", "#{pane_dead} #{pane_dead_status}")
-	if err != nil {
-		return false, 0, fmt.Errorf("getting pane status: %w", err)
+	var parts []string
+	for deadline := time.Now().Add(paneStatusWait); ; {
+		out, err := output("display-message", "-t", session+":0.0", "-p", "#{pane_dead}:#{pane_dead_status}:#{pane_dead_signal}")
+		if err != nil {
+			return false, 0, fmt.Errorf("getting pane status: %w", err)
+		}
+		parts = strings.Split(strings.TrimSpace(out), ":")
+		// tmux marks the pane dead when its output ends, possibly before it
+		// has reaped the process; a process gone before tmux watched it
+		// leaves no status at all.
+		if parts[0] != "1" || len(parts) < 3 || parts[1] != "" || parts[2] != "" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
 	}
-	parts := strings.Fields(strings.TrimSpace(out))
-	if len(parts) == 0 {
+	if parts[0] == "" {
 		return false, 0, nil // no output — treat as alive
 	}
 	dead = parts[0] == "1"
 	if len(parts) >= 2 {
 		exitCode, _ = strconv.Atoi(parts[1])
 	}
+	if len(parts) >= 3 && parts[1] == "" {
+		if signal, err := strconv.Atoi(parts[2]); err == nil && signal > 0 {
+			exitCode = 128 + signal
+		}
+	}
 	return dead, exitCode, nil
 }
 
@@ -170,6 +170,35 @@ func TestPaneStatusNonZero(t *testing.T) {
 	t.Fatal("pane did not become dead within timeout")
 }
 
+func TestPaneStatusSignal(t *testing.T) {
+	skipIfNoTmux(t)
+
+	session := "line-test-panestatus-sig"
+	_ = tmux.KillSession(session)
+
+	err := tmux.NewSession(session, os.TempDir(), "kill -9 $$")
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer tmux.KillSession(session)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		dead, exitCode, err := tmux.PaneStatus(session)
+		if err != nil {
+			t.Fatalf("PaneStatus failed: %v", err)
+		}
+		if dead {
+			if exitCode != 137 {
+				t.Fatalf("expected exit code 137 for SIGKILL, got %d", exitCode)
+			}
+			return // success
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("pane did not become dead within timeout")
+}
+
 func TestPipePane(t *testing.T) {
 	skipIfNoTmux(t)
 
@@ -0,0 +1,328 @@
+// Package trace records OpenTelemetry spans for line runs (run → station →
+// agent → git) and exports them over OTLP/HTTP with the JSON encoding, as
+// configured by the standard OTEL_* environment variables. Without an OTLP
+// endpoint in the environment every call is a no-op.
+//
+// Spans nest by when they are started: a span's parent is the innermost
+// span still open. The runner runs one station at a time, so this follows
+// the call tree without threading a context through every function.
+package trace
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scopeName identifies line as the instrumentation scope of its spans.
+const scopeName = "github.com/re-cinq/assembly-line"
+
+// Span is one timed operation. A nil *Span is valid and does nothing, which
+// is what Start and Child return while tracing is off.
+type Span struct {
+	name     string
+	traceID  string
+	spanID   string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    [][2]string
+	err      error
+}
+
+var (
+	once     sync.Once
+	exporter *otlpExporter // nil: tracing is off
+
+	mu       sync.Mutex
+	open     []*Span // started and not yet ended, innermost last
+	finished []*Span // ended and not yet exported
+)
+
+// Start begins a span named name with the given key, value attribute
+// pairs, as a child of the innermost open span, or as the root of a new
+// trace if none is open.
+func Start(name string, attrs ...string) *Span {
+	once.Do(configure)
+	if exporter == nil {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	s := &Span{name: name, spanID: randomHex(8), start: time.Now()}
+	if n := len(open); n > 0 {
+		s.traceID, s.parentID = open[n-1].traceID, open[n-1].spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	s.setAttrs(attrs)
+	open = append(open, s)
+	return s
+}
+
+// Child is Start, except that it records nothing unless a span is open:
+// for operations, like git commands, that are only of interest as part of
+// a run.
+func Child(name string, attrs ...string) *Span {
+	mu.Lock()
+	inside := len(open) > 0
+	mu.Unlock()
+	if !inside {
+		return nil
+	}
+	return Start(name, attrs...)
+}
+
+// SetAttr adds key, value attribute pairs to the span.
+func (s *Span) SetAttr(attrs ...string) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	s.setAttrs(attrs)
+}
+
+func (s *Span) setAttrs(attrs []string) {
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs = append(s.attrs, [2]string{attrs[i], attrs[i+1]})
+	}
+}
+
+// End finishes the span, marking it failed if err is non-nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	s.end, s.err = time.Now(), err
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == s {
+			open = append(open[:i], open[i+1:]...)
+			break
+		}
+	}
+	finished = append(finished, s)
+}
+
+// Flush exports the finished spans. Spans that fail to export are dropped:
+// tracing never holds up or fails a run.
+func Flush() error {
+	mu.Lock()
+	spans := finished
+	finished = nil
+	mu.Unlock()
+	if exporter == nil || len(spans) == 0 {
+		return nil
+	}
+	return exporter.export(spans)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// otlpExporter posts spans to an OTLP/HTTP traces endpoint.
+type otlpExporter struct {
+	endpoint string
+	headers  map[string]string
+	timeout  time.Duration
+	resource [][2]string
+	err      error // a configuration problem, reported on every export
+}
+
+// configure sets up the exporter from the environment: tracing is on when
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT is set,
+// or OTEL_TRACES_EXPORTER is otlp, and off when OTEL_SDK_DISABLED is true
+// or OTEL_TRACES_EXPORTER is none.
+func configure() {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return
+	}
+	kind := os.Getenv("OTEL_TRACES_EXPORTER")
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	switch {
+	case kind == "none":
+		return
+	case endpoint == "" && kind == "otlp":
+		endpoint = "http://localhost:4318/v1/traces"
+	case endpoint == "":
+		return
+	}
+
+	e := &otlpExporter{endpoint: endpoint, headers: map[string]string{}, timeout: 10 * time.Second}
+	if kind != "" && kind != "otlp" {
+		e.err = fmt.Errorf("OTEL_TRACES_EXPORTER=%s is not supported, only otlp", kind)
+	}
+	if p := signalEnv("PROTOCOL"); p != "" && p != "http/json" {
+		e.err = fmt.Errorf("OTLP protocol %s is not supported, only http/json", p)
+	}
+	for k, v := range parseKeyValues(signalEnv("HEADERS")) {
+		e.headers[k] = v
+	}
+	if ms, err := strconv.Atoi(signalEnv("TIMEOUT")); err == nil && ms > 0 {
+		e.timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	attrs := parseKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if service == "" {
+		service = attrs["service.name"]
+	}
+	if service == "" {
+		service = "line"
+	}
+	e.resource = append(e.resource, [2]string{"service.name", service})
+	for k, v := range attrs {
+		if k != "service.name" {
+			e.resource = append(e.resource, [2]string{k, v})
+		}
+	}
+	exporter = e
+}
+
+// signalEnv reads OTEL_EXPORTER_OTLP_TRACES_<name>, falling back to
+// OTEL_EXPORTER_OTLP_<name>.
+func signalEnv(name string) string {
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_" + name); v != "" {
+		return v
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_" + name)
+}
+
+// parseKeyValues reads the k1=v1,k2=v2 lists of OTEL_RESOURCE_ATTRIBUTES
+// and OTEL_EXPORTER_OTLP_HEADERS; values may be percent-encoded.
+func parseKeyValues(s string) map[string]string {
+	kv := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(v)); err == nil {
+			v = decoded
+		}
+		kv[strings.TrimSpace(k)] = v
+	}
+	return kv
+}
+
+// OTLP/JSON encoding of ExportTraceServiceRequest: IDs are hex, times are
+// nanoseconds as decimal strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []otlpAttr `json:"attributes,omitempty"`
+		Status            otlpStatus `json:"status"`
+	}
+	otlpAttr struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 1 ok, 2 error
+		Message string `json:"message,omitempty"`
+	}
+)
+
+const spanKindInternal = 1
+
+func otlpAttrs(attrs [][2]string) []otlpAttr {
+	var out []otlpAttr
+	for _, a := range attrs {
+		out = append(out, otlpAttr{Key: a[0], Value: otlpValue{StringValue: a[1]}})
+	}
+	return out
+}
+
+// export posts the spans in one request.
+func (e *otlpExporter) export(spans []*Span) error {
+	if e.err != nil {
+		return e.err
+	}
+	scope := otlpScopeSpans{Scope: otlpScope{Name: scopeName}}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        otlpAttrs(s.attrs),
+		}
+		if s.err != nil {
+			span.Status = otlpStatus{Code: 2, Message: s.err.Error()}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttrs(e.resource)},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := (&http.Client{Timeout: e.timeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("exporting spans to %s: %s", e.endpoint, resp.Status)
+	}
+	return nil
+}
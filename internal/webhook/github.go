@@ -0,0 +1,91 @@
+// Package webhook receives GitHub push events, so a central line host can
+// review the pushes of a whole team instead of each laptop running its own
+// line.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxPayload is the largest payload GitHub delivers.
+const maxPayload = 25 << 20
+
+// Push is what line needs of a push event.
+type Push struct {
+	Ref     string `json:"ref"`   // e.g. refs/heads/main or refs/tags/v1.0
+	After   string `json:"after"` // the commit the ref points to now
+	Deleted bool   `json:"deleted"`
+}
+
+// Response is what a Handler answers GitHub with; GitHub shows it in the
+// webhook's recent deliveries.
+type Response struct {
+	Status  int
+	Message string
+}
+
+// Handler answers GitHub webhook deliveries: it rejects those not signed
+// with Secret (X-Hub-Signature-256), answers pings, and passes push events
+// to OnPush.
+type Handler struct {
+	Secret []byte
+	OnPush func(Push) Response
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !ValidSignature(h.Secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	resp := h.handle(r.Header.Get("X-GitHub-Event"), body)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(resp.Status)
+	fmt.Fprintln(w, resp.Message)
+}
+
+// handle dispatches a verified delivery by its event type.
+func (h *Handler) handle(event string, body []byte) Response {
+	switch event {
+	case "ping":
+		return Response{http.StatusOK, "pong"}
+	case "push":
+		var p Push
+		if err := json.Unmarshal(body, &p); err != nil || p.Ref == "" {
+			return Response{http.StatusBadRequest, "malformed push event"}
+		}
+		return h.OnPush(p)
+	}
+	return Response{http.StatusOK, fmt.Sprintf("ignored: %s event", event)}
+}
+
+// ValidSignature reports whether header, an X-Hub-Signature-256 value
+// ("sha256=<hex>"), is the HMAC-SHA256 of body with secret.
+func ValidSignature(secret, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}